@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/pkg/store"
 	"github.com/keel-hq/keel/types"
 
@@ -26,6 +27,10 @@ type Manager interface {
 	// SubscribeApproved - is used to get approved events by the manager
 	SubscribeApproved(ctx context.Context) (<-chan *types.Approval, error)
 
+	// SubscribeExpired - approvals that reached their deadline, used by
+	// extensions to close pending approval requests
+	SubscribeExpired(ctx context.Context) (<-chan *types.Approval, error)
+
 	// request approval for deployment/release/etc..
 	Create(r *types.Approval) error
 	// Update whole approval object
@@ -33,8 +38,8 @@ type Manager interface {
 
 	// Increases Approval votes by 1
 	Approve(identifier, voter string) (*types.Approval, error)
-	// Rejects Approval
-	Reject(identifier string) (*types.Approval, error)
+	// Rejects Approval, voter is recorded in the audit log
+	Reject(identifier, voter string) (*types.Approval, error)
 
 	Get(identifier string) (*types.Approval, error)
 	List() ([]*types.Approval, error)
@@ -54,6 +59,9 @@ const (
 	ApprovalsPrefix = "approvals"
 )
 
+// expiryCheckInterval - how often pending approvals are checked for deadlines
+const expiryCheckInterval = time.Minute
+
 // DefaultManager - default manager implementation
 type DefaultManager struct {
 	// cache is used to store approvals, key example:
@@ -62,6 +70,9 @@ type DefaultManager struct {
 
 	store store.Store
 
+	// optional, used to notify about approval progress
+	sender notification.Sender
+
 	// subscriber channels
 	channels map[uint32]chan *types.Approval
 	index    uint32
@@ -69,6 +80,9 @@ type DefaultManager struct {
 	// approved channels
 	approvedCh map[uint32]chan *types.Approval
 
+	// expired channels
+	expiredCh map[uint32]chan *types.Approval
+
 	mu    *sync.Mutex
 	subMu *sync.RWMutex
 }
@@ -76,6 +90,10 @@ type DefaultManager struct {
 type Opts struct {
 	Store store.Store
 	// Cache cache.Cache
+
+	// Sender - optional notification sender, when set a notification
+	// is sent for every vote
+	Sender notification.Sender
 }
 
 // New create new instance of default manager
@@ -83,8 +101,10 @@ func New(opts *Opts) *DefaultManager {
 	man := &DefaultManager{
 		// cache:      opts.Cache,
 		store:      opts.Store,
+		sender:     opts.Sender,
 		channels:   make(map[uint32]chan *types.Approval),
 		approvedCh: make(map[uint32]chan *types.Approval),
+		expiredCh:  make(map[uint32]chan *types.Approval),
 		index:      0,
 		mu:         &sync.Mutex{},
 		subMu:      &sync.RWMutex{},
@@ -96,7 +116,7 @@ func New(opts *Opts) *DefaultManager {
 // StartExpiryService - starts approval expiry service which deletes approvals
 // that already reached their deadline
 func (m *DefaultManager) StartExpiryService(ctx context.Context) error {
-	ticker := time.NewTicker(60 * time.Minute)
+	ticker := time.NewTicker(expiryCheckInterval)
 	defer ticker.Stop()
 	err := m.expireEntries()
 	if err != nil {
@@ -140,6 +160,8 @@ func (m *DefaultManager) expireEntries() error {
 			}
 
 			m.addAuditEntry(approval, types.AuditActionApprovalExpired, "")
+			m.notifyExpired(approval)
+			m.publishExpired(approval)
 		}
 	}
 
@@ -196,6 +218,24 @@ func (m *DefaultManager) SubscribeApproved(ctx context.Context) (<-chan *types.A
 	return approvedCh, nil
 }
 
+// SubscribeExpired - subscribe for expired approvals
+func (m *DefaultManager) SubscribeExpired(ctx context.Context) (<-chan *types.Approval, error) {
+	m.subMu.Lock()
+	index := atomic.AddUint32(&m.index, 1)
+	expiredCh := make(chan *types.Approval, 10)
+	m.expiredCh[index] = expiredCh
+	m.subMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.subMu.Lock()
+		delete(m.expiredCh, index)
+		m.subMu.Unlock()
+	}()
+
+	return expiredCh, nil
+}
+
 func (m *DefaultManager) publishRequest(approval *types.Approval) error {
 	m.subMu.RLock()
 	defer m.subMu.RUnlock()
@@ -216,6 +256,15 @@ func (m *DefaultManager) publishApproved(approval *types.Approval) error {
 	return nil
 }
 
+func (m *DefaultManager) publishExpired(approval *types.Approval) {
+	m.subMu.RLock()
+	defer m.subMu.RUnlock()
+
+	for _, subscriber := range m.expiredCh {
+		subscriber <- approval
+	}
+}
+
 // Update - update approval
 func (m *DefaultManager) Update(r *types.Approval) error {
 	_, err := m.Get(r.Identifier)
@@ -239,6 +288,23 @@ func (m *DefaultManager) Update(r *types.Approval) error {
 
 // Approve - increase VotesReceived by 1 and returns updated version
 func (m *DefaultManager) Approve(identifier, voter string) (*types.Approval, error) {
+	existing, vote, err := m.addVote(identifier, voter)
+	if err != nil {
+		return nil, err
+	}
+
+	// sent once the lock is released, a slow notifier (and its retries)
+	// must not block other approval operations
+	if vote != nil {
+		m.notifyVote(*vote)
+	}
+
+	return existing, nil
+}
+
+// addVote - records the vote, returned notification is nil when the voter
+// already voted or there is no sender
+func (m *DefaultManager) addVote(identifier, voter string) (*types.Approval, *types.EventNotification, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -248,13 +314,13 @@ func (m *DefaultManager) Approve(identifier, voter string) (*types.Approval, err
 			"identifier": identifier,
 			"error":      err,
 		}).Error("approvals.manager: failed to get")
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, v := range existing.GetVoters() {
 		if v == voter {
 			// nothing to do, same voter
-			return existing, nil
+			return existing, nil, nil
 		}
 	}
 
@@ -267,7 +333,7 @@ func (m *DefaultManager) Approve(identifier, voter string) (*types.Approval, err
 			"identifier": identifier,
 			"error":      err,
 		}).Error("approvals.manager: failed to update")
-		return nil, err
+		return nil, nil, err
 	}
 
 	m.addAuditEntry(existing, types.AuditActionApprovalApproved, voter)
@@ -276,7 +342,11 @@ func (m *DefaultManager) Approve(identifier, voter string) (*types.Approval, err
 		"identifier": identifier,
 	}).Info("approvals.manager: approved")
 
-	return existing, nil
+	if m.sender == nil {
+		return existing, nil, nil
+	}
+	vote := voteNotification(existing, voter)
+	return existing, &vote, nil
 }
 
 func (m *DefaultManager) addAuditEntry(approval *types.Approval, action string, voter string) {
@@ -295,7 +365,7 @@ func (m *DefaultManager) addAuditEntry(approval *types.Approval, action string,
 		"approval_id":     approval.ID,
 		"new_version":     approval.NewVersion,
 		"current_version": approval.CurrentVersion,
-		"votes_required":  strconv.Itoa(approval.VotesReceived),
+		"votes_required":  strconv.Itoa(approval.VotesRequired),
 		"votes_received":  strconv.Itoa(approval.VotesReceived),
 	})
 
@@ -308,9 +378,61 @@ func (m *DefaultManager) addAuditEntry(approval *types.Approval, action string,
 	}
 }
 
+// voteNotification - approval progress, i.e. "2/3 approvals"
+func voteNotification(approval *types.Approval, voter string) types.EventNotification {
+	return types.EventNotification{
+		Name:       "approval received",
+		Message:    fmt.Sprintf("Approval for %s received from %s (%d/%d approvals)", approval.Identifier, voter, approval.VotesReceived, approval.VotesRequired),
+		CreatedAt:  time.Now(),
+		Type:       types.NotificationUpdateApproved,
+		Level:      types.LevelInfo,
+		Identifier: approval.Identifier,
+		Metadata: map[string]string{
+			"provider":       approval.Provider.String(),
+			"votes_required": strconv.Itoa(approval.VotesRequired),
+			"votes_received": strconv.Itoa(approval.VotesReceived),
+		},
+	}
+}
+
+func (m *DefaultManager) notifyVote(vote types.EventNotification) {
+	err := m.sender.Send(vote)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"identifier": vote.Identifier,
+		}).Error("approvals.manager: failed to send vote notification")
+	}
+}
+
+// notifyExpired - lets approvers know that pending request is no longer actionable
+func (m *DefaultManager) notifyExpired(approval *types.Approval) {
+	if m.sender == nil {
+		return
+	}
+
+	err := m.sender.Send(types.EventNotification{
+		Name:       "approval expired",
+		Message:    fmt.Sprintf("Approval for %s expired (%d/%d approvals), update %s discarded", approval.Identifier, approval.VotesReceived, approval.VotesRequired, approval.Delta()),
+		CreatedAt:  time.Now(),
+		Type:       types.NotificationUpdateExpired,
+		Level:      types.LevelWarn,
+		Identifier: approval.Identifier,
+		Metadata: map[string]string{
+			"provider": approval.Provider.String(),
+		},
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"identifier": approval.Identifier,
+		}).Error("approvals.manager: failed to send expiry notification")
+	}
+}
+
 // Reject - rejects approval (marks rejected=true), approval will not be valid even if it
 // collects required votes
-func (m *DefaultManager) Reject(identifier string) (*types.Approval, error) {
+func (m *DefaultManager) Reject(identifier, voter string) (*types.Approval, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -326,7 +448,7 @@ func (m *DefaultManager) Reject(identifier string) (*types.Approval, error) {
 		return nil, err
 	}
 
-	m.addAuditEntry(existing, types.AuditActionApprovalRejected, "")
+	m.addAuditEntry(existing, types.AuditActionApprovalRejected, voter)
 
 	return existing, nil
 }
@@ -6,11 +6,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/pkg/store/sql"
 	"github.com/keel-hq/keel/types"
 )
@@ -358,6 +360,124 @@ func TestApproveTwoVoters(t *testing.T) {
 	}
 }
 
+func TestApprovalSurvivesRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "whstoretest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opts := sql.Opts{DatabaseType: "sqlite3", URI: filepath.Join(dir, "gorm.db")}
+
+	store, err := sql.New(opts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+
+	am := New(&Opts{
+		Store: store,
+	})
+
+	err = am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1:1.2.5",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(5 * time.Minute),
+		VotesRequired:  2,
+		VotesReceived:  0,
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	if _, err := am.Approve("xxx/app-1:1.2.5", "w"); err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+	store.Close()
+
+	// reopening the same database as keel does on startup
+	store, err = sql.New(opts)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %s", err)
+	}
+	defer store.Close()
+
+	am = New(&Opts{
+		Store: store,
+	})
+
+	pending, err := am.List()
+	if err != nil {
+		t.Fatalf("failed to list approvals: %s", err)
+	}
+	if len(pending) != 1 || pending[0].VotesReceived != 1 {
+		t.Fatalf("expected pending approval with 1 vote after restart, got: %+v", pending)
+	}
+
+	approved, err := am.Approve("xxx/app-1:1.2.5", "k")
+	if err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+	if approved.Status() != types.ApprovalStatusApproved {
+		t.Errorf("expected approval to be approved with votes from before restart, got: %s", approved.Status())
+	}
+}
+
+type fakeSender struct {
+	sent []types.EventNotification
+}
+
+func (s *fakeSender) Configure(cfg *notification.Config) (bool, error) {
+	return true, nil
+}
+
+func (s *fakeSender) Send(event types.EventNotification) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func TestApproveNotifiesProgress(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	fs := &fakeSender{}
+	am := New(&Opts{
+		Store:  store,
+		Sender: fs,
+	})
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1:1.2.5",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(5 * time.Minute),
+		VotesRequired:  3,
+		VotesReceived:  0,
+	})
+
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	am.Approve("xxx/app-1:1.2.5", "w")
+	am.Approve("xxx/app-1:1.2.5", "k")
+	// duplicate vote, shouldn't be announced
+	am.Approve("xxx/app-1:1.2.5", "k")
+
+	if len(fs.sent) != 2 {
+		t.Fatalf("expected 2 notifications, got: %d", len(fs.sent))
+	}
+
+	if !strings.Contains(fs.sent[1].Message, "(2/3 approvals)") {
+		t.Errorf("expected progress in message, got: %s", fs.sent[1].Message)
+	}
+
+	if fs.sent[1].Type != types.NotificationUpdateApproved {
+		t.Errorf("unexpected notification type: %s", fs.sent[1].Type)
+	}
+}
+
 func TestReject(t *testing.T) {
 	store, teardown := NewTestingUtils()
 	defer teardown()
@@ -380,7 +500,7 @@ func TestReject(t *testing.T) {
 		t.Fatalf("failed to create approval: %s", err)
 	}
 
-	am.Reject("xxx/app-1")
+	am.Reject("xxx/app-1", "user-a")
 
 	stored, err := am.Get("xxx/app-1")
 	if err != nil {
@@ -425,6 +545,90 @@ func TestExpire(t *testing.T) {
 	}
 }
 
+func TestExpireNotifies(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	fs := &fakeSender{}
+	am := New(&Opts{
+		Store:  store,
+		Sender: fs,
+	})
+
+	err := am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(-5 * time.Minute),
+		VotesRequired:  2,
+		VotesReceived:  1,
+	})
+
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	err = am.expireEntries()
+	if err != nil {
+		t.Errorf("got error while expiring entries: %s", err)
+	}
+
+	if len(fs.sent) != 1 {
+		t.Fatalf("expected 1 notification, got: %d", len(fs.sent))
+	}
+
+	if fs.sent[0].Type != types.NotificationUpdateExpired {
+		t.Errorf("unexpected notification type: %s", fs.sent[0].Type)
+	}
+
+	if fs.sent[0].Identifier != "xxx/app-1" {
+		t.Errorf("unexpected identifier: %s", fs.sent[0].Identifier)
+	}
+}
+
+func TestExpirePublishes(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := New(&Opts{
+		Store: store,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	expired, err := am.SubscribeExpired(ctx)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	err = am.Create(&types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "xxx/app-1",
+		CurrentVersion: "1.2.3",
+		NewVersion:     "1.2.5",
+		Deadline:       time.Now().Add(-5 * time.Minute),
+		VotesRequired:  2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	err = am.expireEntries()
+	if err != nil {
+		t.Errorf("got error while expiring entries: %s", err)
+	}
+
+	select {
+	case approval := <-expired:
+		if approval.Identifier != "xxx/app-1" {
+			t.Errorf("unexpected identifier: %s", approval.Identifier)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expired approval wasn't published")
+	}
+}
+
 func TestGetArchived(t *testing.T) {
 	store, teardown := NewTestingUtils()
 	defer teardown()
@@ -469,3 +673,63 @@ func TestGetArchived(t *testing.T) {
 		t.Errorf("didn't expect approval to be archived")
 	}
 }
+
+// blockingSender - blocks every send until released
+type blockingSender struct {
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSender) Configure(cfg *notification.Config) (bool, error) {
+	return true, nil
+}
+
+func (s *blockingSender) Send(event types.EventNotification) error {
+	s.sending <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestApproveDoesNotBlockWhileNotifying(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	fs := &blockingSender{sending: make(chan struct{}, 1), release: make(chan struct{})}
+	am := New(&Opts{
+		Store:  store,
+		Sender: fs,
+	})
+
+	for _, identifier := range []string{"xxx/app-1:1.2.5", "xxx/app-2:1.2.5"} {
+		err := am.Create(&types.Approval{
+			Provider:       types.ProviderTypeKubernetes,
+			Identifier:     identifier,
+			CurrentVersion: "1.2.3",
+			NewVersion:     "1.2.5",
+			Deadline:       time.Now().Add(5 * time.Minute),
+			VotesRequired:  2,
+		})
+		if err != nil {
+			t.Fatalf("failed to create approval: %s", err)
+		}
+	}
+
+	go am.Approve("xxx/app-1:1.2.5", "w")
+	<-fs.sending
+	defer close(fs.release)
+
+	rejected := make(chan error, 1)
+	go func() {
+		_, err := am.Reject("xxx/app-2:1.2.5", "user-a")
+		rejected <- err
+	}()
+
+	select {
+	case err := <-rejected:
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("reject blocked by a pending vote notification")
+	}
+}
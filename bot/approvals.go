@@ -39,12 +39,37 @@ func (bm *BotManager) SubscribeForApprovals(ctx context.Context, approval BotReq
 	}
 }
 
-func (bm *BotManager) ProcessApprovalResponses(ctx context.Context, reply BotReplyApproval) error {
+// SubscribeForExpiredApprovals - passes approvals that reached their deadline
+// to the bot
+func (bm *BotManager) SubscribeForExpiredApprovals(ctx context.Context, expired BotReplyApproval) error {
+	expiredCh, err := bm.approvalsManager.SubscribeExpired(ctx)
+	if err != nil {
+		log.Errorf("bot.SubscribeForExpiredApprovals(): %s", err.Error())
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case a := <-expiredCh:
+			err = expired(a)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":    err,
+					"approval": a.Identifier,
+				}).Error("bot.SubscribeForExpiredApprovals: failed to update expired approval request")
+			}
+		}
+	}
+}
+
+func (bm *BotManager) ProcessApprovalResponses(ctx context.Context, responses <-chan *ApprovalResponse, reply BotReplyApproval) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case resp := <-bm.approvalsRespCh:
+		case resp := <-responses:
 			switch resp.Status {
 			case types.ApprovalStatusApproved:
 				err := bm.processApprovedResponse(resp, reply)
@@ -104,7 +129,7 @@ func (bm *BotManager) processRejectedResponse(approvalResponse *ApprovalResponse
 	}
 
 	for _, identifier := range identifiers {
-		approval, err := bm.approvalsManager.Reject(identifier)
+		approval, err := bm.approvalsManager.Reject(identifier, approvalResponse.User)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error":      err,
@@ -150,19 +175,28 @@ func ApprovalsResponse(approvalsManager approvals.Manager) string {
 }
 
 func IsApproval(eventUser string, eventText string) (resp *ApprovalResponse, ok bool) {
-	if strings.HasPrefix(strings.ToLower(eventText), ApprovalResponseKeyword) {
+	return ParseApproval(eventUser, eventText, ApprovalResponseKeyword, RejectResponseKeyword)
+}
+
+// ParseApproval - same as IsApproval but with custom approve and reject
+// keywords, response text is normalized to the default keywords so responses
+// are processed the same way regardless of the keywords bot uses
+func ParseApproval(eventUser, eventText, approveKeyword, rejectKeyword string) (resp *ApprovalResponse, ok bool) {
+	lower := strings.ToLower(eventText)
+
+	if strings.HasPrefix(lower, strings.ToLower(approveKeyword)) {
 		return &ApprovalResponse{
 			User:   eventUser,
 			Status: types.ApprovalStatusApproved,
-			Text:   eventText,
+			Text:   ApprovalResponseKeyword + eventText[len(approveKeyword):],
 		}, true
 	}
 
-	if strings.HasPrefix(strings.ToLower(eventText), RejectResponseKeyword) {
+	if strings.HasPrefix(lower, strings.ToLower(rejectKeyword)) {
 		return &ApprovalResponse{
 			User:   eventUser,
 			Status: types.ApprovalStatusRejected,
-			Text:   eventText,
+			Text:   RejectResponseKeyword + eventText[len(rejectKeyword):],
 		}, true
 	}
 
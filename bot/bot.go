@@ -8,6 +8,7 @@ import (
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
 
 	log "github.com/sirupsen/logrus"
@@ -21,29 +22,42 @@ var (
 	BotEventTextToResponse = map[string][]string{
 		"help": {
 			`Here's a list of supported commands`,
-			`- "get deployments" -> get a list of all deployments`,
+			`- "get deployments" -> get a list of deployments tracked by keel`,
+			`- "get deployments all" -> get a list of all deployments`,
 			`- "get approvals" -> get a list of approvals`,
 			`- "rm approval <approval identifier>" -> remove approval`,
 			`- "approve <approval identifier>" -> approve update request`,
 			`- "reject <approval identifier>" -> reject update request`,
-			// `- "get deployments all" -> get a list of all deployments`,
+			`- "update <namespace>/<deployment>[/<container>] <tag> [--force]" -> update deployment image, --force ignores policy`,
+			`- "rollback <namespace>/<deployment>" -> roll deployment back to its previous revision`,
+			`- "pause <namespace>/<deployment>" -> stop updating deployment`,
+			`- "resume <namespace>/<deployment>" -> resume updating paused deployment`,
 			// `- "describe deployment <deployment>" -> get details for specified deployment`,
 		},
 	}
 
 	// static bot commands can be used straight away
 	staticBotCommands = map[string]bool{
-		"get deployments": true,
-		"get approvals":   true,
+		"get deployments":     true,
+		"get deployments all": true,
+		"get approvals":       true,
 	}
 
 	// dynamic bot command prefixes have to be matched
-	dynamicBotCommandPrefixes = []string{RemoveApprovalPrefix}
+	dynamicBotCommandPrefixes = []string{RemoveApprovalPrefix, UpdatePrefix, RollbackPrefix, PausePrefix, ResumePrefix}
 
 	ApprovalResponseKeyword = "approve"
 	RejectResponseKeyword   = "reject"
+
+	// ApprovalCallbackID - callback ID of interactive approval messages, button
+	// names are ApprovalResponseKeyword and RejectResponseKeyword and their
+	// value is the approval identifier
+	ApprovalCallbackID = "keel_approval"
 )
 
+// Bot - chat platform backend, every configured bot runs independently with
+// its own message and approval response channels while sharing the command
+// handlers and approvals manager
 type Bot interface {
 	Configure(approvalsRespCh chan *ApprovalResponse, botMessagesChannel chan *BotMessage) bool
 	Start(ctx context.Context) error
@@ -52,6 +66,19 @@ type Bot interface {
 	ReplyToApproval(approval *types.Approval) error
 }
 
+// ApprovalExpirer - implemented by bots that update approval requests they
+// posted once the approval expires, so expired requests can't be voted on
+type ApprovalExpirer interface {
+	ApprovalExpired(approval *types.Approval) error
+}
+
+// ApprovalActioner - implemented by bots that apply approval actions
+// themselves instead of sending them as approval responses, i.e. Slack
+// buttons clicked in Socket Mode
+type ApprovalActioner interface {
+	SetApprovalsManager(approvalsManager approvals.Manager)
+}
+
 type teardown func()
 type BotMessageResponder func(response string, channel string)
 
@@ -79,10 +106,9 @@ type ApprovalResponse struct {
 
 // BotManager holds approvalsManager and k8sImplementer for every bot
 type BotManager struct {
-	approvalsManager   approvals.Manager
-	k8sImplementer     kubernetes.Implementer
-	botMessagesChannel chan *BotMessage
-	approvalsRespCh    chan *ApprovalResponse
+	approvalsManager approvals.Manager
+	k8sImplementer   kubernetes.Implementer
+	registryClient   registry.Client
 }
 
 // RegisterBot makes a bot implementation available by the provided name.
@@ -112,45 +138,70 @@ func RegisterBot(name string, b Bot) {
 // Run all implemented bots
 func Run(k8sImplementer kubernetes.Implementer, approvalsManager approvals.Manager) {
 	bm := &BotManager{
-		approvalsManager:   approvalsManager,
-		k8sImplementer:     k8sImplementer,
-		approvalsRespCh:    make(chan *ApprovalResponse), // don't add buffer to make it blocking
-		botMessagesChannel: make(chan *BotMessage),
+		approvalsManager: approvalsManager,
+		k8sImplementer:   k8sImplementer,
+		registryClient:   registry.New(),
 	}
+
+	botsM.RLock()
+	registered := make(map[string]Bot, len(bots))
 	for botName, bot := range bots {
-		configured := bot.Configure(bm.approvalsRespCh, bm.botMessagesChannel)
+		registered[botName] = bot
+	}
+	botsM.RUnlock()
+
+	for botName, bot := range registered {
+		// channels aren't shared so responses are sent through the bot
+		// that received the message
+		approvalsRespCh := make(chan *ApprovalResponse) // don't add buffer to make it blocking
+		botMessagesChannel := make(chan *BotMessage)
+
+		configured := bot.Configure(approvalsRespCh, botMessagesChannel)
 		if configured {
-			bm.SetupBot(botName, bot)
+			bm.SetupBot(botName, bot, approvalsRespCh, botMessagesChannel)
 		} else {
 			log.Errorf("bot.Run(): can not get configuration for bot [%s]", botName)
 		}
 	}
 }
 
-func (bm *BotManager) SetupBot(botName string, bot Bot) {
+// SetupBot - starts the bot and its message and approval processing, a bot
+// failing to start doesn't stop the others
+func (bm *BotManager) SetupBot(botName string, bot Bot, approvalsRespCh chan *ApprovalResponse, botMessagesChannel chan *BotMessage) {
+	if actioner, ok := bot.(ApprovalActioner); ok {
+		actioner.SetApprovalsManager(bm.approvalsManager)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	err := bot.Start(ctx)
 	if err != nil {
 		cancel()
 		log.WithFields(log.Fields{
 			"error": err,
-		}).Fatalf("main: failed to setup %s bot\n", botName)
-	} else {
-		// store cancelling context for each bot
-		teardowns[botName] = func() { cancel() }
+			"bot":   botName,
+		}).Error("bot.SetupBot: failed to start bot")
+		return
+	}
 
-		go bm.ProcessBotMessages(ctx, bot.Respond)
-		go bm.ProcessApprovalResponses(ctx, bot.ReplyToApproval)
-		go bm.SubscribeForApprovals(ctx, bot.RequestApproval)
+	// store cancelling context for each bot
+	botsM.Lock()
+	teardowns[botName] = func() { cancel() }
+	botsM.Unlock()
+
+	go bm.ProcessBotMessages(ctx, botMessagesChannel, bot.Respond)
+	go bm.ProcessApprovalResponses(ctx, approvalsRespCh, bot.ReplyToApproval)
+	go bm.SubscribeForApprovals(ctx, bot.RequestApproval)
+	if expirer, ok := bot.(ApprovalExpirer); ok {
+		go bm.SubscribeForExpiredApprovals(ctx, expirer.ApprovalExpired)
 	}
 }
 
-func (bm *BotManager) ProcessBotMessages(ctx context.Context, respond BotMessageResponder) {
+func (bm *BotManager) ProcessBotMessages(ctx context.Context, messages <-chan *BotMessage, respond BotMessageResponder) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case message := <-bm.botMessagesChannel:
+		case message := <-messages:
 			response := bm.handleBotMessage(message)
 			if response != "" {
 				respond(response, message.Channel)
@@ -159,8 +210,14 @@ func (bm *BotManager) ProcessBotMessages(ctx context.Context, respond BotMessage
 	}
 }
 
+// Stop - tears down every running bot
 func Stop() {
-	for botName, teardown := range teardowns {
+	botsM.Lock()
+	running := teardowns
+	teardowns = make(map[string]teardown)
+	botsM.Unlock()
+
+	for botName, teardown := range running {
 		log.Infof("Teardown %s bot", botName)
 		teardown()
 		UnregisterBot(botName)
@@ -186,6 +243,9 @@ func (bm *BotManager) handleCommand(eventText string) string {
 	case "get deployments":
 		log.Info("HandleCommand: getting deployments")
 		return DeploymentsResponse(Filter{}, bm.k8sImplementer)
+	case "get deployments all":
+		log.Info("HandleCommand: getting all deployments")
+		return DeploymentsResponse(Filter{All: true}, bm.k8sImplementer)
 	case "get approvals":
 		log.Info("HandleCommand: getting approvals")
 		return ApprovalsResponse(bm.approvalsManager)
@@ -197,6 +257,26 @@ func (bm *BotManager) handleCommand(eventText string) string {
 		return RemoveApprovalHandler(id, bm.approvalsManager)
 	}
 
+	if strings.HasPrefix(eventText, UpdatePrefix) {
+		log.Info("HandleCommand: updating deployment")
+		return UpdateHandler(strings.TrimPrefix(eventText, UpdatePrefix), bm.k8sImplementer, bm.registryClient)
+	}
+
+	if strings.HasPrefix(eventText, RollbackPrefix) {
+		log.Info("HandleCommand: rolling back deployment")
+		return RollbackHandler(strings.TrimPrefix(eventText, RollbackPrefix), bm.k8sImplementer)
+	}
+
+	if strings.HasPrefix(eventText, PausePrefix) {
+		log.Info("HandleCommand: pausing deployment")
+		return PauseHandler(strings.TrimPrefix(eventText, PausePrefix), bm.k8sImplementer)
+	}
+
+	if strings.HasPrefix(eventText, ResumePrefix) {
+		log.Info("HandleCommand: resuming deployment")
+		return ResumeHandler(strings.TrimPrefix(eventText, ResumePrefix), bm.k8sImplementer)
+	}
+
 	log.Infof("bot.HandleCommand(): command [%s] not found", eventText)
 	return ""
 }
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeApprovalsManager struct {
+	approvals.Manager
+}
+
+func (m *fakeApprovalsManager) Subscribe(ctx context.Context) (<-chan *types.Approval, error) {
+	return make(chan *types.Approval), nil
+}
+
+type fakeBot struct {
+	startErr error
+
+	messages  chan *BotMessage
+	responses chan string
+	stopped   chan struct{}
+}
+
+func newFakeBot(startErr error) *fakeBot {
+	return &fakeBot{
+		startErr:  startErr,
+		responses: make(chan string, 1),
+		stopped:   make(chan struct{}),
+	}
+}
+
+func (b *fakeBot) Configure(approvalsRespCh chan *ApprovalResponse, botMessagesChannel chan *BotMessage) bool {
+	b.messages = botMessagesChannel
+	return true
+}
+
+func (b *fakeBot) Start(ctx context.Context) error {
+	if b.startErr != nil {
+		return b.startErr
+	}
+	go func() {
+		<-ctx.Done()
+		close(b.stopped)
+	}()
+	return nil
+}
+
+func (b *fakeBot) Respond(text string, channel string) {
+	b.responses <- text
+}
+
+func (b *fakeBot) RequestApproval(req *types.Approval) error      { return nil }
+func (b *fakeBot) ReplyToApproval(approval *types.Approval) error { return nil }
+
+func TestRunMultipleBots(t *testing.T) {
+	first, second, broken := newFakeBot(nil), newFakeBot(nil), newFakeBot(errors.New("connection refused"))
+	RegisterBot("first", first)
+	RegisterBot("second", second)
+	RegisterBot("broken", broken)
+	defer UnregisterBot("broken")
+
+	Run(&fakeImplementer{}, &fakeApprovalsManager{})
+
+	// responses go back through the bot that received the message
+	first.messages <- &BotMessage{Message: "help", Channel: "general"}
+	select {
+	case <-first.responses:
+	case <-time.After(time.Second):
+		t.Fatalf("expected response from the first bot")
+	}
+	select {
+	case resp := <-second.responses:
+		t.Errorf("didn't expect response from the second bot: %s", resp)
+	default:
+	}
+
+	second.messages <- &BotMessage{Message: "help", Channel: "general"}
+	select {
+	case <-second.responses:
+	case <-time.After(time.Second):
+		t.Fatalf("expected response from the second bot, bot failing to start shouldn't affect it")
+	}
+
+	Stop()
+	for name, b := range map[string]*fakeBot{"first": first, "second": second} {
+		select {
+		case <-b.stopped:
+		case <-time.After(time.Second):
+			t.Errorf("expected %s bot to be stopped", name)
+		}
+	}
+}
@@ -3,9 +3,12 @@ package bot
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/keel-hq/keel/bot/formatter"
+	"github.com/keel-hq/keel/internal/policy"
 	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/util/policies"
 
 	apps_v1 "k8s.io/api/apps/v1"
 
@@ -18,6 +21,9 @@ type Filter struct {
 	All       bool // keel or not
 }
 
+// maxListedDeployments - longer lists are cut off to keep bot responses readable
+const maxListedDeployments = 50
+
 // deployments - gets all deployments
 func deployments(k8sImplementer kubernetes.Implementer) ([]apps_v1.Deployment, error) {
 	deploymentLists := []*apps_v1.DeploymentList{}
@@ -50,27 +56,68 @@ func deployments(k8sImplementer kubernetes.Implementer) ([]apps_v1.Deployment, e
 	return impacted, nil
 }
 
+// DeploymentsResponse - lists deployments tracked by keel, or all of them when
+// filter.All is set
 func DeploymentsResponse(filter Filter, k8sImplementer kubernetes.Implementer) string {
 	deps, err := deployments(k8sImplementer)
 	if err != nil {
 		return fmt.Sprintf("got error while fetching deployments: %s", err)
 	}
 	log.Debugf("%d deployments fetched, formatting", len(deps))
+
+	formatted := filterDeployments(filter, convertToInternal(deps))
+	if len(formatted) == 0 {
+		if filter.All {
+			return "no deployments found"
+		}
+		return "no deployments tracked by keel found"
+	}
+
+	sort.Slice(formatted, func(i, j int) bool {
+		if formatted[i].Namespace != formatted[j].Namespace {
+			return formatted[i].Namespace < formatted[j].Namespace
+		}
+		return formatted[i].Name < formatted[j].Name
+	})
+
+	total := len(formatted)
+	if total > maxListedDeployments {
+		formatted = formatted[:maxListedDeployments]
+	}
+
 	buf := &bytes.Buffer{}
 
 	DeploymentCtx := formatter.Context{
 		Output: buf,
 		Format: formatter.NewDeploymentsFormat(formatter.TableFormatKey, false),
 	}
-	err = formatter.DeploymentWrite(DeploymentCtx, convertToInternal(deps))
+	err = formatter.DeploymentWrite(DeploymentCtx, formatted)
 
 	if err != nil {
 		return fmt.Sprintf(" got error while formatting deployments: %s", err)
 	}
 
+	if total > len(formatted) {
+		fmt.Fprintf(buf, "\nshowing %d of %d deployments", len(formatted), total)
+	}
+
 	return buf.String()
 }
 
+func filterDeployments(filter Filter, deployments []formatter.Deployment) []formatter.Deployment {
+	filtered := []formatter.Deployment{}
+	for _, d := range deployments {
+		if filter.Namespace != "" && d.Namespace != filter.Namespace {
+			continue
+		}
+		if !filter.All && d.Policy == "" {
+			continue
+		}
+		filtered = append(filtered, d)
+	}
+	return filtered
+}
+
 func convertToInternal(deployments []apps_v1.Deployment) []formatter.Deployment {
 	formatted := []formatter.Deployment{}
 	for _, d := range deployments {
@@ -81,11 +128,22 @@ func convertToInternal(deployments []apps_v1.Deployment) []formatter.Deployment
 			Replicas:          d.Status.Replicas,
 			AvailableReplicas: d.Status.AvailableReplicas,
 			Images:            getImages(&d),
+			Policy:            getPolicy(&d),
+			Paused:            policies.IsPaused(d.GetAnnotations()),
 		})
 	}
 	return formatted
 }
 
+// getPolicy - returns policy name, empty if deployment is not tracked by keel
+func getPolicy(deployment *apps_v1.Deployment) string {
+	plc := policy.GetPolicyFromLabelsOrAnnotations(deployment.GetLabels(), deployment.GetAnnotations())
+	if plc.Type() == policy.PolicyTypeNone {
+		return ""
+	}
+	return plc.Name()
+}
+
 func getImages(deployment *apps_v1.Deployment) []string {
 	var images []string
 	for _, c := range deployment.Spec.Template.Spec.Containers {
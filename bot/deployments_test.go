@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeImplementer struct {
+	kubernetes.Implementer
+
+	deployments map[string][]apps_v1.Deployment
+	updated     []*k8s.GenericResource
+}
+
+func (i *fakeImplementer) Namespaces() (*v1.NamespaceList, error) {
+	l := &v1.NamespaceList{}
+	for ns := range i.deployments {
+		l.Items = append(l.Items, v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: ns}})
+	}
+	return l, nil
+}
+
+func (i *fakeImplementer) Deployments(namespace string) (*apps_v1.DeploymentList, error) {
+	return &apps_v1.DeploymentList{Items: i.deployments[namespace]}, nil
+}
+
+func (i *fakeImplementer) Update(obj *k8s.GenericResource) error {
+	i.updated = append(i.updated, obj)
+	return nil
+}
+
+func newDeployment(namespace, name, image string, annotations map[string]string) apps_v1.Deployment {
+	d := apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+	d.Spec.Template.Spec.Containers = []v1.Container{{Name: name, Image: image}}
+	return d
+}
+
+func TestDeploymentsResponse(t *testing.T) {
+	fi := &fakeImplementer{
+		deployments: map[string][]apps_v1.Deployment{
+			"default": {
+				newDeployment("default", "tracked", "karolisr/keel:0.1.0", map[string]string{types.KeelPolicyLabel: "major"}),
+				newDeployment("default", "untracked", "nginx:1.19", nil),
+			},
+			"staging": {
+				newDeployment("staging", "api", "karolisr/api:1.2.0", map[string]string{types.KeelPolicyLabel: "glob:build-*"}),
+			},
+		},
+	}
+
+	resp := DeploymentsResponse(Filter{}, fi)
+	if !strings.Contains(resp, "POLICY") {
+		t.Errorf("expected policy column, got: %s", resp)
+	}
+	if !strings.Contains(resp, "karolisr/keel:0.1.0") || !strings.Contains(resp, "major") {
+		t.Errorf("expected tracked deployment, got: %s", resp)
+	}
+	if !strings.Contains(resp, "glob:build-*") {
+		t.Errorf("expected staging deployment, got: %s", resp)
+	}
+	if strings.Contains(resp, "untracked") {
+		t.Errorf("didn't expect untracked deployment, got: %s", resp)
+	}
+
+	resp = DeploymentsResponse(Filter{All: true}, fi)
+	if !strings.Contains(resp, "untracked") {
+		t.Errorf("expected all deployments, got: %s", resp)
+	}
+}
+
+func TestDeploymentsResponseTruncated(t *testing.T) {
+	deps := []apps_v1.Deployment{}
+	for i := 0; i < maxListedDeployments+5; i++ {
+		deps = append(deps, newDeployment("default", fmt.Sprintf("app-%03d", i), "karolisr/keel:0.1.0", map[string]string{types.KeelPolicyLabel: "all"}))
+	}
+	fi := &fakeImplementer{
+		deployments: map[string][]apps_v1.Deployment{"default": deps},
+	}
+
+	resp := DeploymentsResponse(Filter{}, fi)
+	if !strings.Contains(resp, fmt.Sprintf("showing %d of %d deployments", maxListedDeployments, maxListedDeployments+5)) {
+		t.Errorf("expected summary line, got: %s", resp)
+	}
+	if strings.Contains(resp, fmt.Sprintf("app-%03d", maxListedDeployments)) {
+		t.Errorf("didn't expect deployments past the limit")
+	}
+}
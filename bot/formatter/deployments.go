@@ -14,16 +14,19 @@ type Deployment struct {
 	Replicas          int32
 	AvailableReplicas int32
 	Images            []string `json:"images,omitempty"` // image:tag list
+	Policy            string   `json:"policy,omitempty"`
+	Paused            bool     `json:"paused,omitempty"`
 }
 
 // Formatter headers
 const (
 	defaultDeploymentQuietFormat = "{{.Name}}"
-	defaultDeploymentTableFormat = "table {{.Namespace}}\t{{.Name}}\t{{.Ready}}\t{{.Images}}"
+	defaultDeploymentTableFormat = "table {{.Namespace}}\t{{.Name}}\t{{.Ready}}\t{{.Policy}}\t{{.Images}}"
 
 	DeploymentNamespaceHeader = "NAMESPACE"
 	DeploymentNameHeader      = "NAME"
 	DeploymentReadyHeader     = "READY"
+	DeploymentPolicyHeader    = "POLICY"
 	DeploymentImagesHeader    = "IMAGES"
 )
 
@@ -86,6 +89,15 @@ func (c *DeploymentContext) Ready() string {
 	return fmt.Sprintf("%d/%d", c.v.AvailableReplicas, c.v.Replicas)
 }
 
+// Policy - print update policy
+func (c *DeploymentContext) Policy() string {
+	c.AddHeader(DeploymentPolicyHeader)
+	if c.v.Paused {
+		return c.v.Policy + " (paused)"
+	}
+	return c.v.Policy
+}
+
 // Images - print used images
 func (c *DeploymentContext) Images() string {
 	c.AddHeader(DeploymentImagesHeader)
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/policies"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Pause and resume commands: "pause <namespace>/<deployment>",
+// "resume <namespace>/<deployment>"
+const (
+	PausePrefix  = "pause "
+	ResumePrefix = "resume "
+)
+
+// PauseHandler - stops keel from updating deployment until it's resumed,
+// state is kept in deployment annotation so it survives restarts
+func PauseHandler(args string, k8sImplementer kubernetes.Implementer) string {
+	return setPaused(args, true, k8sImplementer)
+}
+
+// ResumeHandler - resumes updates of a paused deployment
+func ResumeHandler(args string, k8sImplementer kubernetes.Implementer) string {
+	return setPaused(args, false, k8sImplementer)
+}
+
+func setPaused(args string, paused bool, k8sImplementer kubernetes.Implementer) string {
+	command := strings.TrimSpace(ResumePrefix)
+	if paused {
+		command = strings.TrimSpace(PausePrefix)
+	}
+
+	parts := strings.Split(strings.TrimSpace(args), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Sprintf("usage: %s <namespace>/<deployment>", command)
+	}
+	namespace, name := parts[0], parts[1]
+
+	dep, err := findDeployment(k8sImplementer, namespace, name)
+	if err != nil {
+		return err.Error()
+	}
+
+	resource, err := k8s.NewGenericResource(dep)
+	if err != nil {
+		return err.Error()
+	}
+
+	annotations := resource.GetAnnotations()
+	if policies.IsPaused(annotations) == paused {
+		if paused {
+			return fmt.Sprintf("%s/%s is already paused", namespace, name)
+		}
+		return fmt.Sprintf("%s/%s is not paused", namespace, name)
+	}
+
+	if paused {
+		annotations[types.KeelPausedAnnotation] = "true"
+	} else {
+		delete(annotations, types.KeelPausedAnnotation)
+	}
+	resource.SetAnnotations(annotations)
+
+	if err := k8sImplementer.Update(resource); err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"namespace":  namespace,
+			"deployment": name,
+			"paused":     paused,
+		}).Error("bot.setPaused: failed to update deployment")
+		return fmt.Sprintf("failed to %s %s/%s: %s", command, namespace, name, err)
+	}
+
+	if paused {
+		return fmt.Sprintf("paused updates of %s/%s", namespace, name)
+	}
+	return fmt.Sprintf("resumed updates of %s/%s", namespace, name)
+}
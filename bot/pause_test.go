@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+)
+
+func TestPauseResume(t *testing.T) {
+	fi := &fakeImplementer{
+		deployments: map[string][]apps_v1.Deployment{
+			"default": {
+				newDeployment("default", "app", "karolisr/keel:0.1.0", map[string]string{types.KeelPolicyLabel: "patch"}),
+			},
+		},
+	}
+
+	reply := PauseHandler("default/app", fi)
+	if reply != "paused updates of default/app" {
+		t.Fatalf("unexpected reply: %s", reply)
+	}
+	if len(fi.updated) != 1 {
+		t.Fatalf("expected deployment to be updated")
+	}
+	if fi.updated[0].GetAnnotations()[types.KeelPausedAnnotation] != "true" {
+		t.Errorf("expected paused annotation, got: %v", fi.updated[0].GetAnnotations())
+	}
+
+	// fake doesn't persist updates
+	fi.deployments["default"][0] = *fi.updated[0].GetResource().(*apps_v1.Deployment)
+
+	if resp := DeploymentsResponse(Filter{}, fi); !strings.Contains(resp, "patch (paused)") {
+		t.Errorf("expected deployment to be listed as paused, got: %s", resp)
+	}
+
+	if reply := PauseHandler("default/app", fi); reply != "default/app is already paused" {
+		t.Errorf("unexpected reply: %s", reply)
+	}
+
+	reply = ResumeHandler("default/app", fi)
+	if reply != "resumed updates of default/app" {
+		t.Fatalf("unexpected reply: %s", reply)
+	}
+	if _, ok := fi.updated[1].GetAnnotations()[types.KeelPausedAnnotation]; ok {
+		t.Errorf("expected paused annotation to be removed")
+	}
+
+	if reply := ResumeHandler("app", fi); !strings.HasPrefix(reply, "usage:") {
+		t.Errorf("expected usage, got: %s", reply)
+	}
+}
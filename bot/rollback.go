@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/provider/kubernetes"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RollbackPrefix - rolls deployment back to previous revision:
+// "rollback <namespace>/<deployment>"
+const RollbackPrefix = "rollback "
+
+// RollbackHandler - rolls deployment back using its rollout history
+func RollbackHandler(args string, k8sImplementer kubernetes.Implementer) string {
+	parts := strings.Split(strings.TrimSpace(args), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "usage: rollback <namespace>/<deployment>"
+	}
+	namespace, name := parts[0], parts[1]
+
+	dep, err := findDeployment(k8sImplementer, namespace, name)
+	if err != nil {
+		return err.Error()
+	}
+
+	revision, err := kubernetes.Rollback(k8sImplementer, dep)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"namespace":  namespace,
+			"deployment": name,
+		}).Error("bot.RollbackHandler: failed to roll back deployment")
+		return fmt.Sprintf("failed to roll back %s/%s: %s", namespace, name, err)
+	}
+
+	return fmt.Sprintf("rolled back %s/%s to revision %d", namespace, name, revision)
+}
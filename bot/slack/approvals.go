@@ -3,46 +3,128 @@ package slack
 import (
 	"fmt"
 
+	"github.com/keel-hq/keel/bot"
 	"github.com/keel-hq/keel/types"
 	"github.com/nlopes/slack"
 )
 
+// approvalMessage - posted approval request
+type approvalMessage struct {
+	channel   string
+	timestamp string
+}
+
 // Request - request approval
 func (b *Bot) RequestApproval(req *types.Approval) error {
-	return b.postMessage(
+	channel, timestamp, err := b.postMessage(
 		"Approval required",
 		req.Message,
 		types.LevelSuccess.Color(),
-		[]slack.AttachmentField{
-			{
-				Title: "Approval required!",
-				Value: req.Message + "\n" + fmt.Sprintf("To vote for change type '%s approve %s' to reject it: '%s reject %s'.", b.name, req.Identifier, b.name, req.Identifier),
-				Short: false,
-			},
-			{
-				Title: "Votes",
-				Value: fmt.Sprintf("%d/%d", req.VotesReceived, req.VotesRequired),
-				Short: true,
-			},
-			{
-				Title: "Delta",
-				Value: req.Delta(),
-				Short: true,
-			},
-			{
-				Title: "Identifier",
-				Value: req.Identifier,
-				Short: true,
-			},
-			{
-				Title: "Provider",
-				Value: req.Provider.String(),
-				Short: true,
+		b.approvalRequestFields(req),
+		approvalActions(req.Identifier)...)
+	if err != nil {
+		return err
+	}
+
+	b.approvalMessagesMu.Lock()
+	if b.approvalMessages == nil {
+		b.approvalMessages = make(map[string]approvalMessage)
+	}
+	b.approvalMessages[req.Identifier] = approvalMessage{channel: channel, timestamp: timestamp}
+	b.approvalMessagesMu.Unlock()
+	return nil
+}
+
+// ApprovalExpired - replaces the approval request with a copy without
+// buttons saying that it expired
+func (b *Bot) ApprovalExpired(approval *types.Approval) error {
+	msg, ok := b.forgetApprovalMessage(approval.Identifier)
+	if !ok {
+		return nil
+	}
+
+	fields := append(b.approvalRequestFields(approval), slack.AttachmentField{
+		Title: "Expired",
+		Value: fmt.Sprintf("Approval expired, update %s was discarded.", approval.Delta()),
+		Short: false,
+	})
+	_, _, _, err := b.slackHTTPClient.UpdateMessage(msg.channel, msg.timestamp,
+		slack.MsgOptionAttachments(attachment(approval.Message, types.LevelWarn.Color(), fields)))
+	return err
+}
+
+// forgetApprovalMessage - removes tracked approval request once it's not
+// pending anymore
+func (b *Bot) forgetApprovalMessage(identifier string) (approvalMessage, bool) {
+	b.approvalMessagesMu.Lock()
+	defer b.approvalMessagesMu.Unlock()
+	msg, ok := b.approvalMessages[identifier]
+	delete(b.approvalMessages, identifier)
+	return msg, ok
+}
+
+func (b *Bot) approvalRequestFields(req *types.Approval) []slack.AttachmentField {
+	return []slack.AttachmentField{
+		{
+			Title: "Approval required!",
+			Value: req.Message + "\n" + fmt.Sprintf("To vote for change type '%s %s %s' to reject it: '%s %s %s'.", b.commandPrefix, b.approveCommand, req.Identifier, b.commandPrefix, b.rejectCommand, req.Identifier),
+			Short: false,
+		},
+		{
+			Title: "Votes",
+			Value: fmt.Sprintf("%d/%d", req.VotesReceived, req.VotesRequired),
+			Short: true,
+		},
+		{
+			Title: "Delta",
+			Value: req.Delta(),
+			Short: true,
+		},
+		{
+			Title: "Identifier",
+			Value: req.Identifier,
+			Short: true,
+		},
+		{
+			Title: "Provider",
+			Value: req.Provider.String(),
+			Short: true,
+		},
+	}
+}
+
+// approvalActions - approve and reject buttons, clicks are sent to
+// /v1/slack/interactions when Slack app interactivity is configured
+func approvalActions(identifier string) []slack.AttachmentAction {
+	return []slack.AttachmentAction{
+		{
+			Name:  bot.ApprovalResponseKeyword,
+			Text:  "Approve",
+			Type:  "button",
+			Style: "primary",
+			Value: identifier,
+		},
+		{
+			Name:  bot.RejectResponseKeyword,
+			Text:  "Reject",
+			Type:  "button",
+			Style: "danger",
+			Value: identifier,
+			Confirm: &slack.ConfirmationField{
+				Title:       "Reject update?",
+				Text:        fmt.Sprintf("Update %s will not be applied.", identifier),
+				OkText:      "Reject",
+				DismissText: "Cancel",
 			},
-		})
+		},
+	}
 }
 
 func (b *Bot) ReplyToApproval(approval *types.Approval) error {
+	if approval.Status() != types.ApprovalStatusPending {
+		b.forgetApprovalMessage(approval.Identifier)
+	}
+
 	switch approval.Status() {
 	case types.ApprovalStatusPending:
 		b.postMessage(
@@ -0,0 +1,135 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nlopes/slack"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IsApprovalInteraction - checks whether callback is an approve or reject
+// button click on an approval request
+func IsApprovalInteraction(callback *slack.InteractionCallback) bool {
+	return callback.Type == slack.InteractionTypeInteractionMessage &&
+		callback.CallbackID == bot.ApprovalCallbackID &&
+		len(callback.ActionCallback.AttachmentActions) > 0
+}
+
+// ApprovalActionMessage - applies button click to the approval, returned
+// message replaces the approval request to show who acted on it. Button
+// clicks are delivered to /v1/slack/interactions or, in Socket Mode, over
+// the websocket and both are handled here. Error is only returned for
+// unknown actions
+func ApprovalActionMessage(approvalsManager approvals.Manager, callback *slack.InteractionCallback) (slack.Message, error) {
+	action := callback.ActionCallback.AttachmentActions[0]
+	identifier := action.Value
+
+	var approval *types.Approval
+	var err error
+	switch action.Name {
+	case bot.ApprovalResponseKeyword:
+		approval, err = approvalsManager.Approve(identifier, callback.User.ID)
+	case bot.RejectResponseKeyword:
+		approval, err = approvalsManager.Reject(identifier, callback.User.ID)
+	default:
+		return slack.Message{}, fmt.Errorf("unknown action '%s'", action.Name)
+	}
+
+	if errors.Is(err, store.ErrRecordNotFound) {
+		// expired or removed while the request was still shown
+		return ApprovalGoneMessage(callback.OriginalMessage), nil
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"identifier": identifier,
+			"action":     action.Name,
+			"user":       callback.User.ID,
+		}).Error("bot.slack: failed to update approval")
+
+		// original message is kept so the user can try again
+		msg := slack.Message{}
+		msg.ResponseType = slack.ResponseTypeEphemeral
+		msg.Text = fmt.Sprintf("Failed to %s '%s': %s", action.Name, identifier, err)
+		return msg, nil
+	}
+
+	return ApprovalActedMessage(callback.OriginalMessage, approval, callback.User.ID), nil
+}
+
+// approvalActedFields - titles of the fields ApprovalActedMessage adds, only
+// the latest action is shown
+var approvalActedFields = map[string]bool{
+	"Vote received": true,
+	"Approved":      true,
+	"Rejected":      true,
+}
+
+// ApprovalActedMessage - copy of the approval request with the buttons removed
+// once the approval isn't pending anymore, shows who acted last and current votes
+func ApprovalActedMessage(original slack.Message, approval *types.Approval, user string) slack.Message {
+	msg := slack.Message{}
+	msg.ReplaceOriginal = true
+	msg.Text = original.Text
+
+	var verb string
+	switch approval.Status() {
+	case types.ApprovalStatusRejected:
+		verb = "Rejected"
+	case types.ApprovalStatusApproved:
+		verb = "Approved"
+	default:
+		verb = "Vote received"
+	}
+
+	for _, a := range original.Attachments {
+		if approval.Status() != types.ApprovalStatusPending {
+			a.Actions = nil
+		}
+		var fields []slack.AttachmentField
+		for _, f := range a.Fields {
+			if approvalActedFields[f.Title] {
+				continue
+			}
+			if f.Title == "Votes" {
+				f.Value = fmt.Sprintf("%d/%d", approval.VotesReceived, approval.VotesRequired)
+			}
+			fields = append(fields, f)
+		}
+		a.Fields = append(fields, slack.AttachmentField{
+			Title: verb,
+			Value: fmt.Sprintf("%s by <@%s>", verb, user),
+			Short: false,
+		})
+		msg.Attachments = append(msg.Attachments, a)
+	}
+
+	return msg
+}
+
+// ApprovalGoneMessage - copy of the approval request without buttons, the
+// approval doesn't exist anymore
+func ApprovalGoneMessage(original slack.Message) slack.Message {
+	msg := slack.Message{}
+	msg.ReplaceOriginal = true
+	msg.Text = original.Text
+
+	for _, a := range original.Attachments {
+		a.Actions = nil
+		a.Fields = append(a.Fields, slack.AttachmentField{
+			Title: "Expired",
+			Value: "Approval is no longer pending, it expired or was removed.",
+			Short: false,
+		})
+		msg.Attachments = append(msg.Attachments, a)
+	}
+
+	return msg
+}
@@ -0,0 +1,33 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestApprovalActedMessageReplacesVoteField(t *testing.T) {
+	original := slack.Message{}
+	original.Attachments = []slack.Attachment{{
+		Fields: []slack.AttachmentField{{Title: "Votes", Value: "0/3", Short: true}},
+	}}
+
+	approval := &types.Approval{VotesRequired: 3, VotesReceived: 1}
+	msg := ApprovalActedMessage(original, approval, "U1")
+
+	approval.VotesReceived = 2
+	msg = ApprovalActedMessage(msg, approval, "U2")
+
+	fields := msg.Attachments[0].Fields
+	if len(fields) != 2 {
+		t.Fatalf("expected votes and vote received fields, got: %v", fields)
+	}
+	if fields[0].Value != "2/3" {
+		t.Errorf("unexpected votes: %s", fields[0].Value)
+	}
+	if fields[1].Value != "Vote received by <@U2>" {
+		t.Errorf("unexpected vote field: %s", fields[1].Value)
+	}
+}
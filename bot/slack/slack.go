@@ -8,10 +8,12 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nlopes/slack"
 
+	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/bot"
 	"github.com/keel-hq/keel/constants"
 	"github.com/keel-hq/keel/version"
@@ -23,6 +25,7 @@ import (
 // send messages with attachments
 type SlackImplementer interface {
 	PostMessage(channelID string, options ...slack.MsgOption) (string, string, error)
+	UpdateMessage(channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error)
 }
 
 // Bot - main slack bot container
@@ -34,13 +37,31 @@ type Bot struct {
 
 	msgPrefix string
 
+	// commandPrefix - plain text prefix of bot commands, defaults to name
+	commandPrefix  string
+	approveCommand string
+	rejectCommand  string
+
 	slackClient *slack.Client
-	slackRTM    *slack.RTM
+	slackRTM    *slack.RTM // nil in Socket Mode
+
+	// appToken - app-level token, enables Socket Mode instead of RTM
+	appToken string
+	apiURL   string
 
 	slackHTTPClient SlackImplementer
 
 	approvalsChannel string // slack approvals channel name
 
+	// approvalMessages - posted approval requests, keyed by approval
+	// identifier, updated when the approval expires
+	approvalMessagesMu sync.Mutex
+	approvalMessages   map[string]approvalMessage
+
+	// approvalsManager - applies approval button clicks received in Socket
+	// Mode
+	approvalsManager approvals.Manager
+
 	ctx                context.Context
 	botMessagesChannel chan *bot.BotMessage
 	approvalsRespCh    chan *bot.ApprovalResponse
@@ -58,9 +79,25 @@ func (b *Bot) Configure(approvalsRespCh chan *bot.ApprovalResponse, botMessagesC
 			b.name = bootName
 		}
 
+		b.commandPrefix = b.name
+		if prefix := strings.TrimSpace(os.Getenv(constants.EnvSlackCommandPrefix)); prefix != "" {
+			b.commandPrefix = strings.ToLower(prefix)
+		}
+		b.approveCommand = bot.ApprovalResponseKeyword
+		if command := strings.TrimSpace(os.Getenv(constants.EnvSlackApproveCommand)); command != "" {
+			b.approveCommand = strings.ToLower(command)
+		}
+		b.rejectCommand = bot.RejectResponseKeyword
+		if command := strings.TrimSpace(os.Getenv(constants.EnvSlackRejectCommand)); command != "" {
+			b.rejectCommand = strings.ToLower(command)
+		}
+
 		token := os.Getenv(constants.EnvSlackToken)
 		client := slack.New(token)
 
+		b.appToken = os.Getenv(constants.EnvSlackAppToken)
+		b.apiURL = slack.APIURL
+
 		b.approvalsChannel = "general"
 		if channel := os.Getenv(constants.EnvSlackApprovalsChannel); channel != "" {
 			b.approvalsChannel = strings.TrimPrefix(channel, "#")
@@ -77,6 +114,12 @@ func (b *Bot) Configure(approvalsRespCh chan *bot.ApprovalResponse, botMessagesC
 	return false
 }
 
+// SetApprovalsManager - approvals manager used for button clicks received
+// in Socket Mode
+func (b *Bot) SetApprovalsManager(approvalsManager approvals.Manager) {
+	b.approvalsManager = approvalsManager
+}
+
 // Start - start bot
 func (b *Bot) Start(ctx context.Context) error {
 	// setting root context
@@ -111,6 +154,15 @@ func (b *Bot) Start(ctx context.Context) error {
 }
 
 func (b *Bot) startInternal() error {
+	if b.appToken != "" {
+		log.Info("bot.slack: using socket mode")
+		return b.startSocketMode()
+	}
+	return b.startRTM()
+}
+
+// startRTM - receives messages through the legacy RTM API
+func (b *Bot) startRTM() error {
 	b.slackRTM = b.slackClient.NewRTM()
 
 	go b.slackRTM.ManageConnection()
@@ -144,34 +196,41 @@ func (b *Bot) startInternal() error {
 	}
 }
 
-func (b *Bot) postMessage(title, message, color string, fields []slack.AttachmentField) error {
+// postMessage - posts message to the approvals channel, actions are added as
+// interactive message buttons. Returns channel ID and timestamp of the message
+func (b *Bot) postMessage(title, message, color string, fields []slack.AttachmentField, actions ...slack.AttachmentAction) (string, string, error) {
 	params := slack.NewPostMessageParameters()
 	params.Username = b.name
 	params.IconURL = b.getBotUserIconURL()
 
-	attachements := []slack.Attachment{
-		{
-			Fallback: message,
-			Color:    color,
-			Fields:   fields,
-			Footer:   fmt.Sprintf("https://keel.sh %s", version.GetKeelVersion().Version),
-			Ts:       json.Number(strconv.Itoa(int(time.Now().Unix()))),
-		},
-	}
-
 	var mgsOpts []slack.MsgOption
 
 	mgsOpts = append(mgsOpts, slack.MsgOptionPostMessageParameters(params))
-	mgsOpts = append(mgsOpts, slack.MsgOptionAttachments(attachements...))
+	mgsOpts = append(mgsOpts, slack.MsgOptionAttachments(attachment(message, color, fields, actions...)))
 
-	_, _, err := b.slackHTTPClient.PostMessage(b.approvalsChannel, mgsOpts...)
+	channel, timestamp, err := b.slackHTTPClient.PostMessage(b.approvalsChannel, mgsOpts...)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":             err,
 			"approvals_channel": b.approvalsChannel,
 		}).Error("bot.postMessage: failed to send message")
 	}
-	return err
+	return channel, timestamp, err
+}
+
+func attachment(message, color string, fields []slack.AttachmentField, actions ...slack.AttachmentAction) slack.Attachment {
+	a := slack.Attachment{
+		Fallback: message,
+		Color:    color,
+		Fields:   fields,
+		Footer:   fmt.Sprintf("https://keel.sh %s", version.GetKeelVersion().Version),
+		Ts:       json.Number(strconv.Itoa(int(time.Now().Unix()))),
+	}
+	if len(actions) > 0 {
+		a.CallbackID = bot.ApprovalCallbackID
+		a.Actions = actions
+	}
+	return a
 }
 
 // checking if message was received in approvals channel
@@ -180,7 +239,7 @@ func (b *Bot) isApprovalsChannel(event *slack.MessageEvent) bool {
 	channel, err := b.slackClient.GetChannelInfo(event.Channel)
 	if err != nil {
 		// looking for private channel
-		conv, err := b.slackRTM.GetConversationInfo(event.Channel, true)
+		conv, err := b.slackClient.GetConversationInfo(event.Channel, true)
 		if err != nil {
 			log.Errorf("couldn't find amongst private conversations: %s", err)
 		} else if conv.Name == b.approvalsChannel {
@@ -220,7 +279,7 @@ func (b *Bot) handleMessage(event *slack.MessageEvent) {
 
 	eventText = b.trimBot(eventText)
 
-	approval, ok := bot.IsApproval(event.User, eventText)
+	approval, ok := bot.ParseApproval(event.User, eventText, b.approveCommand, b.rejectCommand)
 	// only accepting approvals from approvals channel
 	if ok && b.isApprovalsChannel(event) {
 		b.approvalsRespCh <- approval
@@ -244,9 +303,19 @@ func (b *Bot) handleMessage(event *slack.MessageEvent) {
 
 func (b *Bot) Respond(text string, channel string) {
 
-	// if message is short, replying directly via slack RTM
+	// if message is short, replying directly via slack RTM or, in Socket
+	// Mode, Web API
 	if len(text) < 3000 {
-		b.slackRTM.SendMessage(b.slackRTM.NewOutgoingMessage(formatAsSnippet(text), channel))
+		if b.slackRTM != nil {
+			b.slackRTM.SendMessage(b.slackRTM.NewOutgoingMessage(formatAsSnippet(text), channel))
+			return
+		}
+		_, _, err := b.slackHTTPClient.PostMessage(channel, slack.MsgOptionText(formatAsSnippet(text), false), slack.MsgOptionAsUser(true))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("Respond: failed to send message")
+		}
 		return
 	}
 
@@ -270,7 +339,7 @@ func (b *Bot) Respond(text string, channel string) {
 func (b *Bot) isBotMessage(event *slack.MessageEvent, eventText string) bool {
 	prefixes := []string{
 		b.msgPrefix,
-		b.name,
+		b.commandPrefix,
 		// "kel",
 	}
 
@@ -286,7 +355,7 @@ func (b *Bot) isBotMessage(event *slack.MessageEvent, eventText string) bool {
 
 func (b *Bot) trimBot(msg string) string {
 	msg = strings.Replace(msg, strings.ToLower(b.msgPrefix), "", 1)
-	msg = strings.TrimPrefix(msg, b.name)
+	msg = strings.TrimPrefix(msg, b.commandPrefix)
 	msg = strings.Trim(msg, " :\n")
 
 	return msg
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/nlopes/slack"
@@ -74,7 +77,23 @@ type postedMessage struct {
 }
 
 type fakeSlackImplementer struct {
-	postedMessages []postedMessage
+	postedMessages  []postedMessage
+	updatedMessages []updatedMessage
+}
+
+type updatedMessage struct {
+	channel   string
+	timestamp string
+	msg       []slack.MsgOption
+}
+
+func (i *fakeSlackImplementer) UpdateMessage(channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error) {
+	i.updatedMessages = append(i.updatedMessages, updatedMessage{
+		channel:   channelID,
+		timestamp: timestamp,
+		msg:       options,
+	})
+	return channelID, timestamp, "", nil
 }
 
 // func (i *fakeSlackImplementer) PostMessage(channel, text string, params slack.PostMessageParameters) (string, string, error) {
@@ -85,7 +104,7 @@ func (i *fakeSlackImplementer) PostMessage(channelID string, options ...slack.Ms
 
 		msg: options,
 	})
-	return "", "", nil
+	return "C123", "1500000000.000100", nil
 }
 
 func newTestingUtils() (*sql.SQLStore, func()) {
@@ -363,3 +382,110 @@ func TestIsApproval(t *testing.T) {
 		t.Errorf("event expected to be an approval")
 	}
 }
+
+func TestCustomCommands(t *testing.T) {
+	os.Setenv(constants.EnvSlackToken, "token")
+	os.Setenv(constants.EnvSlackCommandPrefix, "deploybot")
+	os.Setenv(constants.EnvSlackApproveCommand, "lgtm")
+	os.Setenv(constants.EnvSlackRejectCommand, "nope")
+	defer func() {
+		os.Unsetenv(constants.EnvSlackToken)
+		os.Unsetenv(constants.EnvSlackCommandPrefix)
+		os.Unsetenv(constants.EnvSlackApproveCommand)
+		os.Unsetenv(constants.EnvSlackRejectCommand)
+	}()
+
+	approvalsRespCh := make(chan *b.ApprovalResponse, 1)
+	slackBot := &Bot{}
+	if !slackBot.Configure(approvalsRespCh, make(chan *b.BotMessage, 1)) {
+		t.Fatalf("expected bot to be configured")
+	}
+	// set by Start once bot user is found
+	slackBot.msgPrefix = "<@u123>"
+
+	event := &slack.MessageEvent{Msg: slack.Msg{Channel: "D123", User: "user-x"}}
+	if !slackBot.isBotMessage(event, "deploybot lgtm k8s/project/repo:1.2.3") {
+		t.Errorf("expected message with custom prefix to be a bot message")
+	}
+	if slackBot.isBotMessage(&slack.MessageEvent{Msg: slack.Msg{Channel: "C123"}}, "keel approve k8s/project/repo:1.2.3") {
+		t.Errorf("didn't expect default prefix to be used")
+	}
+
+	text := slackBot.trimBot("deploybot lgtm k8s/project/repo:1.2.3")
+	approval, ok := b.ParseApproval("user-x", text, slackBot.approveCommand, slackBot.rejectCommand)
+	if !ok || approval.Status != types.ApprovalStatusApproved {
+		t.Fatalf("expected approval, got: %v", approval)
+	}
+	if approval.Text != "approve k8s/project/repo:1.2.3" {
+		t.Errorf("unexpected normalized text: %s", approval.Text)
+	}
+
+	approval, ok = b.ParseApproval("user-x", "nope k8s/project/repo:1.2.3", slackBot.approveCommand, slackBot.rejectCommand)
+	if !ok || approval.Status != types.ApprovalStatusRejected || approval.Text != "reject k8s/project/repo:1.2.3" {
+		t.Errorf("expected rejection, got: %v", approval)
+	}
+
+	if _, ok := b.ParseApproval("user-x", "approve k8s/project/repo:1.2.3", slackBot.approveCommand, slackBot.rejectCommand); ok {
+		t.Errorf("didn't expect default approve keyword to be accepted")
+	}
+}
+
+func TestApprovalExpiredUpdatesRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true, "user": {"id": "U123", "profile": {"image_original": "https://example.com/keel.png"}}}`))
+	}))
+	defer srv.Close()
+
+	fi := &fakeSlackImplementer{}
+	bot := &Bot{
+		id:               "U123",
+		name:             "keel",
+		approvalsChannel: "approvals",
+		slackClient:      slack.New("token", slack.OptionAPIURL(srv.URL+"/")),
+		slackHTTPClient:  fi,
+	}
+
+	approval := &types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     "default/wd:1.1.2",
+		CurrentVersion: "1.1.1",
+		NewVersion:     "1.1.2",
+		VotesRequired:  2,
+	}
+	if err := bot.RequestApproval(approval); err != nil {
+		t.Fatalf("failed to request approval: %s", err)
+	}
+
+	// approval that wasn't requested through this bot
+	if err := bot.ApprovalExpired(&types.Approval{Identifier: "default/other:1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fi.updatedMessages) != 0 {
+		t.Fatalf("didn't expect messages to be updated, got: %d", len(fi.updatedMessages))
+	}
+
+	if err := bot.ApprovalExpired(approval); err != nil {
+		t.Fatalf("failed to update expired approval: %s", err)
+	}
+	if len(fi.updatedMessages) != 1 {
+		t.Fatalf("expected approval request to be updated, got: %d updates", len(fi.updatedMessages))
+	}
+	updated := fi.updatedMessages[0]
+	if updated.channel != "C123" || updated.timestamp != "1500000000.000100" {
+		t.Errorf("unexpected message updated: %s %s", updated.channel, updated.timestamp)
+	}
+
+	_, values, err := slack.UnsafeApplyMsgOptions("token", updated.channel, slack.APIURL, updated.msg...)
+	if err != nil {
+		t.Fatalf("failed to apply message options: %s", err)
+	}
+	if strings.Contains(values.Get("attachments"), "callback_id") || !strings.Contains(values.Get("attachments"), "Approval expired") {
+		t.Errorf("expected expired message without buttons, got: %s", values.Get("attachments"))
+	}
+
+	// request is only updated once
+	bot.ApprovalExpired(approval)
+	if len(fi.updatedMessages) != 1 {
+		t.Errorf("expected approval request to be updated once, got: %d updates", len(fi.updatedMessages))
+	}
+}
@@ -0,0 +1,241 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// socketModeMaxBackoff - longest wait between Socket Mode reconnects
+const socketModeMaxBackoff = time.Minute
+
+// socketModeEnvelope - message received over Socket Mode connection, every
+// envelope with an ID has to be acknowledged
+type socketModeEnvelope struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Reason     string          `json:"reason"`
+}
+
+// socketModeAck - acknowledges envelope so Slack doesn't retry it
+type socketModeAck struct {
+	EnvelopeID string `json:"envelope_id"`
+}
+
+// eventsAPIPayload - Events API callback delivered in "events_api" envelopes
+type eventsAPIPayload struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// startSocketMode - receives messages and approval button clicks through Slack
+// Socket Mode, replacement of the RTM API. Reconnects until context is done.
+func (b *Bot) startSocketMode() error {
+	backoff := time.Second
+	for {
+		wsURL, err := b.openSocketModeConnection()
+		if err == nil {
+			err = b.runSocketMode(wsURL)
+		}
+
+		select {
+		case <-b.ctx.Done():
+			return nil
+		default:
+		}
+
+		if err == nil {
+			// Slack asked to reconnect
+			backoff = time.Second
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"error":   err,
+			"backoff": backoff,
+		}).Error("bot.slack: socket mode connection failed, reconnecting")
+
+		select {
+		case <-b.ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > socketModeMaxBackoff {
+			backoff = socketModeMaxBackoff
+		}
+	}
+}
+
+// openSocketModeConnection - gets websocket URL using the app-level token
+func (b *Bot) openSocketModeConnection() (string, error) {
+	req, err := http.NewRequest(http.MethodPost, b.apiURL+"apps.connections.open", nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(b.ctx)
+	req.Header.Set("Authorization", "Bearer "+b.appToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		URL   string `json:"url"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode apps.connections.open response: %s", err)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("apps.connections.open failed: %s", result.Error)
+	}
+	return result.URL, nil
+}
+
+// runSocketMode - handles envelopes until connection is closed, returns nil
+// when Slack asks to reconnect or context is done
+func (b *Bot) runSocketMode(wsURL string) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(b.ctx, wsURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-b.ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var envelope socketModeEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			if b.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if envelope.EnvelopeID != "" {
+			if err := conn.WriteJSON(socketModeAck{EnvelopeID: envelope.EnvelopeID}); err != nil {
+				return err
+			}
+		}
+
+		switch envelope.Type {
+		case "hello":
+			log.Debug("bot.slack: socket mode connected")
+		case "disconnect":
+			log.WithField("reason", envelope.Reason).Debug("bot.slack: socket mode disconnect requested")
+			return nil
+		case "events_api":
+			b.handleEventsAPIPayload(envelope.Payload)
+		case "interactive":
+			b.handleInteraction(envelope.Payload)
+		}
+	}
+}
+
+// handleEventsAPIPayload - passes message events to the same handler RTM uses
+func (b *Bot) handleEventsAPIPayload(payload json.RawMessage) {
+	var callback eventsAPIPayload
+	if err := json.Unmarshal(payload, &callback); err != nil || callback.Type != "event_callback" {
+		return
+	}
+
+	var inner struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(callback.Event, &inner); err != nil || inner.Type != "message" {
+		return
+	}
+
+	var event slack.MessageEvent
+	if err := json.Unmarshal(callback.Event, &event); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("bot.slack: failed to decode message event")
+		return
+	}
+	b.handleMessage(&event)
+}
+
+// handleInteraction - approval button clicks are applied the same way as
+// clicks sent to /v1/slack/interactions, in Socket Mode they are delivered
+// over the websocket instead. The message replacing the approval request is
+// posted to the response URL
+func (b *Bot) handleInteraction(payload json.RawMessage) {
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("bot.slack: failed to decode interaction")
+		return
+	}
+
+	if !IsApprovalInteraction(&callback) || callback.User.ID == "" || b.approvalsManager == nil {
+		return
+	}
+
+	msg, err := ApprovalActionMessage(b.approvalsManager, &callback)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"user":  callback.User.ID,
+		}).Warn("bot.slack: ignoring interaction")
+		return
+	}
+
+	if err := b.respondToInteraction(callback.ResponseURL, msg); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("bot.slack: failed to update approval request")
+	}
+}
+
+// respondToInteraction - posts message to interaction response URL, Slack
+// replaces the original message or shows it only to the user
+func (b *Bot) respondToInteraction(responseURL string, msg slack.Message) error {
+	if responseURL == "" {
+		return fmt.Errorf("interaction has no response URL")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(b.ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+	}
+	return nil
+}
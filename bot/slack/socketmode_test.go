@@ -0,0 +1,169 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+
+	"github.com/keel-hq/keel/approvals"
+	b "github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/types"
+)
+
+func TestSocketMode(t *testing.T) {
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{Store: store})
+	err := am.Create(&types.Approval{
+		Identifier:     "default/wd:1.1.2",
+		VotesRequired:  1,
+		NewVersion:     "1.1.2",
+		CurrentVersion: "1.1.1",
+		Deadline:       time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	acks := make(chan string, 10)
+	responses := make(chan slack.Message, 1)
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/response", func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode response: %s", err)
+		}
+		responses <- msg
+	})
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xapp-token" {
+			w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true, "url": "ws` + strings.TrimPrefix(srv.URL, "http") + `/ws"}`))
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %s", err)
+			return
+		}
+		defer conn.Close()
+
+		envelopes := []string{
+			`{"type": "hello"}`,
+			`{"envelope_id": "1", "type": "events_api", "payload": {"type": "event_callback", "event": {"type": "message", "channel": "D123", "user": "U1", "text": "get deployments"}}}`,
+			`{"envelope_id": "2", "type": "interactive", "payload": {"type": "interactive_message", "callback_id": "keel_approval", "user": {"id": "U2"}, "actions": [{"name": "approve", "value": "default/wd:1.1.2"}], "response_url": "` + srv.URL + `/response", "original_message": {"attachments": [{"fields": [{"title": "Votes", "value": "0/1"}], "actions": [{"name": "approve", "value": "default/wd:1.1.2"}]}]}}}`,
+		}
+		for _, envelope := range envelopes {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(envelope)); err != nil {
+				return
+			}
+		}
+		for {
+			var ack socketModeAck
+			if err := conn.ReadJSON(&ack); err != nil {
+				return
+			}
+			acks <- ack.EnvelopeID
+		}
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bot := &Bot{
+		ctx:                ctx,
+		appToken:           "xapp-token",
+		apiURL:             srv.URL + "/",
+		commandPrefix:      "keel",
+		msgPrefix:          "<@u123>",
+		approveCommand:     b.ApprovalResponseKeyword,
+		rejectCommand:      b.RejectResponseKeyword,
+		approvalsRespCh:    make(chan *b.ApprovalResponse, 1),
+		botMessagesChannel: make(chan *b.BotMessage, 1),
+		approvalsManager:   am,
+	}
+	go bot.startInternal()
+
+	select {
+	case msg := <-bot.botMessagesChannel:
+		if msg.Message != "get deployments" || msg.User != "U1" || msg.Channel != "D123" {
+			t.Errorf("unexpected bot message: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("didn't receive bot message")
+	}
+
+	// approval request is replaced the same way as for clicks sent to
+	// /v1/slack/interactions
+	select {
+	case msg := <-responses:
+		if !msg.ReplaceOriginal || len(msg.Attachments) != 1 {
+			t.Fatalf("unexpected response: %+v", msg)
+		}
+		if len(msg.Attachments[0].Actions) != 0 {
+			t.Errorf("expected buttons to be removed")
+		}
+		fields := msg.Attachments[0].Fields
+		if len(fields) != 2 || fields[0].Value != "1/1" || fields[1].Value != "Approved by <@U2>" {
+			t.Errorf("unexpected fields: %+v", fields)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("approval request wasn't updated")
+	}
+
+	approval, err := am.Get("default/wd:1.1.2")
+	if err != nil {
+		t.Fatalf("failed to get approval: %s", err)
+	}
+	if approval.Status() != types.ApprovalStatusApproved {
+		t.Errorf("unexpected approval status: %s", approval.Status())
+	}
+
+	for _, want := range []string{"1", "2"} {
+		select {
+		case got := <-acks:
+			if got != want {
+				t.Errorf("expected envelope %s to be acknowledged, got: %s", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("envelope %s wasn't acknowledged", want)
+		}
+	}
+}
+
+func TestSocketModeIgnoresOtherEvents(t *testing.T) {
+	bot := &Bot{
+		ctx:                context.Background(),
+		approvalsRespCh:    make(chan *b.ApprovalResponse, 1),
+		botMessagesChannel: make(chan *b.BotMessage, 1),
+	}
+
+	payload, _ := json.Marshal(eventsAPIPayload{
+		Type:  "event_callback",
+		Event: json.RawMessage(`{"type": "reaction_added", "user": "U1"}`),
+	})
+	bot.handleEventsAPIPayload(payload)
+	bot.handleInteraction(json.RawMessage(`{"type": "interactive_message", "callback_id": "other", "actions": [{"name": "approve", "value": "x"}]}`))
+
+	select {
+	case msg := <-bot.botMessagesChannel:
+		t.Errorf("unexpected bot message: %+v", msg)
+	case resp := <-bot.approvalsRespCh:
+		t.Errorf("unexpected approval response: %+v", resp)
+	default:
+	}
+}
@@ -0,0 +1,38 @@
+package teams
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/types"
+)
+
+// RequestApproval - posts approval request to the incoming webhook
+func (b *Bot) RequestApproval(req *types.Approval) error {
+	return b.postMessage(strings.Join([]string{
+		"**Approval required!**",
+		req.Message,
+		fmt.Sprintf("To vote for change mention the bot with '%s %s', to reject it: '%s %s'.", bot.ApprovalResponseKeyword, req.Identifier, bot.RejectResponseKeyword, req.Identifier),
+		fmt.Sprintf("Votes: %d/%d, delta: %s, identifier: %s, provider: %s", req.VotesReceived, req.VotesRequired, req.Delta(), req.Identifier, req.Provider.String()),
+	}, "\n\n"))
+}
+
+func (b *Bot) ReplyToApproval(approval *types.Approval) error {
+	var title string
+	switch approval.Status() {
+	case types.ApprovalStatusPending:
+		title = "**Vote received!** Waiting for remaining votes."
+	case types.ApprovalStatusRejected:
+		title = "**Change rejected.**"
+	case types.ApprovalStatusApproved:
+		title = "**Update approved!** All approvals received, thanks for voting!"
+	default:
+		return nil
+	}
+
+	return b.postMessage(strings.Join([]string{
+		title,
+		fmt.Sprintf("Votes: %d/%d, delta: %s, identifier: %s", approval.VotesReceived, approval.VotesRequired, approval.Delta(), approval.Identifier),
+	}, "\n\n"))
+}
@@ -0,0 +1,297 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/constants"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultListenAddress = ":9302"
+
+	// replyTimeout - Teams expects outgoing webhook reply within 5 seconds,
+	// later responses are posted to the incoming webhook
+	replyTimeout = 4 * time.Second
+)
+
+var mentionRegexp = regexp.MustCompile(`<at>[^<]*</at>`)
+
+// Bot - Microsoft Teams bot, commands are received through a Teams outgoing
+// webhook (the bot is mentioned in a channel) and approval requests are
+// posted to an incoming webhook
+type Bot struct {
+	secret        []byte
+	listenAddress string
+	webhookURL    string
+	client        *http.Client
+
+	// pending - replies awaited by outgoing webhook requests, keyed by
+	// message channel
+	mu      sync.Mutex
+	pending map[string]chan string
+
+	listener net.Listener
+
+	ctx                context.Context
+	botMessagesChannel chan *bot.BotMessage
+	approvalsRespCh    chan *bot.ApprovalResponse
+}
+
+// activity - outgoing webhook message, see
+// https://learn.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/add-outgoing-webhook
+type activity struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	From struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"from"`
+	Conversation struct {
+		ID string `json:"id"`
+	} `json:"conversation"`
+}
+
+type reply struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func init() {
+	bot.RegisterBot("teams", &Bot{})
+}
+
+func (b *Bot) Configure(approvalsRespCh chan *bot.ApprovalResponse, botMessagesChannel chan *bot.BotMessage) bool {
+	if os.Getenv(constants.EnvTeamsBotSecret) == "" {
+		log.Info("bot.teams.Configure(): Teams approval bot is not configured")
+		return false
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(os.Getenv(constants.EnvTeamsBotSecret))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Errorf("bot.teams.Configure(): %s is not a valid security token", constants.EnvTeamsBotSecret)
+		return false
+	}
+	b.secret = secret
+
+	b.listenAddress = defaultListenAddress
+	if address := os.Getenv(constants.EnvTeamsBotListenAddress); address != "" {
+		b.listenAddress = address
+	}
+
+	b.webhookURL = os.Getenv(constants.EnvTeamsBotWebhookURL)
+	if b.webhookURL == "" {
+		b.webhookURL = os.Getenv(constants.EnvTeamsWebhookUrl)
+	}
+
+	b.client = &http.Client{Timeout: 5 * time.Second}
+	b.pending = make(map[string]chan string)
+	b.approvalsRespCh = approvalsRespCh
+	b.botMessagesChannel = botMessagesChannel
+
+	return true
+}
+
+// Start - starts serving the outgoing webhook, server is shut down once the
+// context is cancelled
+func (b *Bot) Start(ctx context.Context) error {
+	b.ctx = ctx
+
+	listener, err := net.Listen("tcp", b.listenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %s", b.listenAddress, err)
+	}
+	b.listener = listener
+
+	srv := &http.Server{Handler: http.HandlerFunc(b.handleActivity)}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("bot.teams: outgoing webhook server stopped")
+		}
+	}()
+
+	log.WithFields(log.Fields{
+		"address": listener.Addr().String(),
+	}).Info("bot.teams: serving outgoing webhook")
+	return nil
+}
+
+func (b *Bot) handleActivity(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !b.validSignature(req.Header.Get("Authorization"), body) {
+		log.Warn("bot.teams: outgoing webhook signature doesn't match")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var a activity
+	if err := json.Unmarshal(body, &a); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	text := trimMention(a.Text)
+	if approval, ok := bot.ParseApproval(a.From.Name, text, bot.ApprovalResponseKeyword, bot.RejectResponseKeyword); ok {
+		// result of the vote is posted by ReplyToApproval
+		select {
+		case b.approvalsRespCh <- approval:
+			writeReply(resp, "Processing: "+text)
+		case <-b.ctx.Done():
+			writeReply(resp, "Keel is shutting down, try again later")
+		}
+		return
+	}
+
+	// every request gets its own channel so the reply is returned to it
+	channel := a.Conversation.ID + "/" + a.ID
+	replies := make(chan string, 1)
+	b.mu.Lock()
+	b.pending[channel] = replies
+	b.mu.Unlock()
+
+	var response string
+	select {
+	case b.botMessagesChannel <- &bot.BotMessage{
+		Message: text,
+		User:    a.From.Name,
+		Channel: channel,
+		Name:    "teams",
+	}:
+		select {
+		case response = <-replies:
+		case <-time.After(replyTimeout):
+		}
+	case <-b.ctx.Done():
+	}
+
+	// responses sent after this are posted to the incoming webhook
+	b.mu.Lock()
+	delete(b.pending, channel)
+	b.mu.Unlock()
+	if response == "" {
+		select {
+		case response = <-replies:
+		default:
+		}
+	}
+
+	if response == "" {
+		writeReply(resp, "Processing: "+text)
+		return
+	}
+	writeReply(resp, formatAsCode(response))
+}
+
+// validSignature - Teams signs the body with HMAC-SHA256 using the security
+// token, header is "HMAC <base64 signature>"
+func (b *Bot) validSignature(header string, body []byte) bool {
+	if !strings.HasPrefix(header, "HMAC ") {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "HMAC "))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, b.secret)
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// Respond - returns response to the outgoing webhook request waiting for it,
+// posts it to the incoming webhook otherwise
+func (b *Bot) Respond(text string, channel string) {
+	b.mu.Lock()
+	replies, ok := b.pending[channel]
+	if ok {
+		select {
+		case replies <- text:
+		default:
+			ok = false
+		}
+	}
+	b.mu.Unlock()
+	if ok {
+		return
+	}
+
+	if err := b.postMessage(formatAsCode(text)); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("bot.teams.Respond: failed to send message")
+	}
+}
+
+// postMessage - posts markdown message to the incoming webhook
+func (b *Bot) postMessage(text string) error {
+	if b.webhookURL == "" {
+		return errors.New("incoming webhook URL is not set")
+	}
+
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(b.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("incoming webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func writeReply(resp http.ResponseWriter, text string) {
+	resp.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(resp).Encode(reply{Type: "message", Text: text})
+}
+
+// trimMention - removes bot mention and markup Teams adds to message text,
+// i.e. "<at>Keel</at>&nbsp;get deployments"
+func trimMention(text string) string {
+	text = mentionRegexp.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(html.UnescapeString(text), "\u00a0", " ")
+	return strings.ToLower(strings.Trim(text, " :\n\r"))
+}
+
+func formatAsCode(text string) string {
+	return "```\n" + text + "\n```"
+}
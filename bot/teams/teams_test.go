@@ -0,0 +1,177 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	b "github.com/keel-hq/keel/bot"
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/types"
+)
+
+var secret = base64.StdEncoding.EncodeToString([]byte("outgoing-webhook-token"))
+
+func sign(body []byte) string {
+	key, _ := base64.StdEncoding.DecodeString(secret)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "HMAC " + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func startBot(t *testing.T, webhookURL string) (*Bot, chan *b.BotMessage, chan *b.ApprovalResponse, func()) {
+	os.Setenv(constants.EnvTeamsBotSecret, secret)
+	os.Setenv(constants.EnvTeamsBotListenAddress, "127.0.0.1:0")
+	os.Setenv(constants.EnvTeamsBotWebhookURL, webhookURL)
+	defer os.Unsetenv(constants.EnvTeamsBotSecret)
+	defer os.Unsetenv(constants.EnvTeamsBotListenAddress)
+	defer os.Unsetenv(constants.EnvTeamsBotWebhookURL)
+
+	messages := make(chan *b.BotMessage)
+	responses := make(chan *b.ApprovalResponse, 1)
+	bot := &Bot{}
+	if !bot.Configure(responses, messages) {
+		t.Fatalf("expected bot to be configured")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := bot.Start(ctx); err != nil {
+		cancel()
+		t.Fatalf("failed to start bot: %s", err)
+	}
+	return bot, messages, responses, cancel
+}
+
+func post(t *testing.T, bot *Bot, text string, signed bool) (*http.Response, reply) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"type":         "message",
+		"id":           "1",
+		"text":         text,
+		"from":         map[string]string{"id": "29:1", "name": "Jane"},
+		"conversation": map[string]string{"id": "19:abc@thread.skype"},
+	})
+	req, _ := http.NewRequest("POST", "http://"+bot.listener.Addr().String(), bytes.NewReader(body))
+	if signed {
+		req.Header.Set("Authorization", sign(body))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var r reply
+	json.NewDecoder(resp.Body).Decode(&r)
+	return resp, r
+}
+
+func TestTeamsCommand(t *testing.T) {
+	bot, messages, _, stop := startBot(t, "")
+	defer stop()
+
+	go func() {
+		msg := <-messages
+		bot.Respond("deployments of "+msg.User+": "+msg.Message, msg.Channel)
+	}()
+
+	resp, r := post(t, bot, "<at>Keel</at>&nbsp;Get deployments", true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+	if !strings.Contains(r.Text, "deployments of Jane: get deployments") {
+		t.Errorf("unexpected reply: %s", r.Text)
+	}
+
+	resp, _ = post(t, bot, "<at>Keel</at> get deployments", false)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unsigned request to be rejected, got: %d", resp.StatusCode)
+	}
+}
+
+func TestTeamsApproval(t *testing.T) {
+	posted := make(chan string, 2)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		posted <- string(body)
+	}))
+	defer webhook.Close()
+
+	bot, _, responses, stop := startBot(t, webhook.URL)
+	defer stop()
+
+	err := bot.RequestApproval(&types.Approval{
+		Identifier:    "default/wd:1.1.2",
+		Message:       "New image is available for resource default/wd (1.1.1 -> 1.1.2).",
+		VotesRequired: 1,
+		Provider:      types.ProviderTypeKubernetes,
+	})
+	if err != nil {
+		t.Fatalf("failed to request approval: %s", err)
+	}
+	select {
+	case body := <-posted:
+		if !strings.Contains(body, "approve default/wd:1.1.2") {
+			t.Errorf("unexpected approval request: %s", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected approval request to be posted")
+	}
+
+	post(t, bot, "<at>Keel</at> approve default/wd:1.1.2", true)
+	select {
+	case resp := <-responses:
+		if resp.Status != types.ApprovalStatusApproved || resp.User != "Jane" || resp.Text != "approve default/wd:1.1.2" {
+			t.Errorf("unexpected approval response: %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected approval response")
+	}
+}
+
+func TestTeamsApprovalAfterStop(t *testing.T) {
+	key, _ := base64.StdEncoding.DecodeString(secret)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// nothing receives approval responses once bot manager stopped
+	bot := &Bot{
+		secret:          key,
+		ctx:             ctx,
+		approvalsRespCh: make(chan *b.ApprovalResponse),
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"type": "message",
+		"id":   "1",
+		"text": "<at>Keel</at> approve default/wd:1.1.2",
+		"from": map[string]string{"id": "29:1", "name": "Jane"},
+	})
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	req.Header.Set("Authorization", sign(body))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		bot.handleActivity(rec, req)
+		done <- rec
+	}()
+
+	select {
+	case rec := <-done:
+		if !strings.Contains(rec.Body.String(), "shutting down") {
+			t.Errorf("unexpected reply: %s", rec.Body.String())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("approval blocked after bot was stopped")
+	}
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	apps_v1 "k8s.io/api/apps/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// UpdatePrefix - manually updates deployment image:
+// "update <namespace>/<deployment>[/<container>] <tag> [--force]"
+const UpdatePrefix = "update "
+
+const forceFlag = "--force"
+
+type updateRequest struct {
+	namespace string
+	name      string
+	container string
+	tag       string
+	force     bool
+}
+
+func parseUpdateRequest(args string) (*updateRequest, error) {
+	req := &updateRequest{}
+	fields := []string{}
+	for _, f := range strings.Fields(args) {
+		if f == forceFlag {
+			req.force = true
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("usage: update <namespace>/<deployment>[/<container>] <tag> [%s]", forceFlag)
+	}
+
+	parts := strings.Split(fields[0], "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid deployment '%s', expected <namespace>/<deployment>[/<container>]", fields[0])
+	}
+	req.namespace, req.name = parts[0], parts[1]
+	if len(parts) == 3 {
+		req.container = parts[2]
+	}
+	req.tag = fields[1]
+
+	return req, nil
+}
+
+// UpdateHandler - validates requested tag against the registry and deployment
+// policy before setting the new image
+func UpdateHandler(args string, k8sImplementer kubernetes.Implementer, registryClient registry.Client) string {
+	req, err := parseUpdateRequest(args)
+	if err != nil {
+		return err.Error()
+	}
+
+	resource, err := getDeployment(k8sImplementer, req.namespace, req.name)
+	if err != nil {
+		return err.Error()
+	}
+
+	idx, err := containerIndex(resource, req.container)
+	if err != nil {
+		return err.Error()
+	}
+	container := resource.Containers()[idx]
+
+	ref, err := image.Parse(container.Image)
+	if err != nil {
+		return fmt.Sprintf("failed to parse image '%s': %s", container.Image, err)
+	}
+	if ref.Tag() == req.tag {
+		return fmt.Sprintf("%s/%s is already running %s", req.namespace, req.name, container.Image)
+	}
+
+	if !req.force {
+		plc := policy.GetPolicyFromLabelsOrAnnotations(resource.GetLabels(), resource.GetAnnotations())
+		ok, err := plc.ShouldUpdate(ref.Tag(), req.tag)
+		if err != nil {
+			return fmt.Sprintf("update rejected, policy '%s' check failed: %s", plc.Name(), err)
+		}
+		if !ok {
+			return fmt.Sprintf("update rejected, policy '%s' doesn't allow %s -> %s, use %s to override", plc.Name(), ref.Tag(), req.tag, forceFlag)
+		}
+	}
+
+	if err := tagExists(registryClient, ref, req.tag, resource); err != nil {
+		return fmt.Sprintf("update rejected, tag %s not found for %s: %s", req.tag, ref.Repository(), err)
+	}
+
+	newImage := fmt.Sprintf("%s:%s", ref.Repository(), req.tag)
+	resource.UpdateContainer(idx, newImage)
+
+	annotations := resource.GetAnnotations()
+	annotations["kubernetes.io/change-cause"] = fmt.Sprintf("keel manual update via bot, version %s -> %s [%s]", ref.Tag(), req.tag, time.Now().Format(time.RFC3339))
+	resource.SetAnnotations(annotations)
+
+	if err := k8sImplementer.Update(resource); err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"namespace":  req.namespace,
+			"deployment": req.name,
+			"image":      newImage,
+		}).Error("bot.UpdateHandler: failed to update deployment")
+		return fmt.Sprintf("failed to update %s/%s: %s", req.namespace, req.name, err)
+	}
+
+	log.WithFields(log.Fields{
+		"namespace":  req.namespace,
+		"deployment": req.name,
+		"image":      newImage,
+		"force":      req.force,
+	}).Info("bot.UpdateHandler: deployment updated")
+
+	return fmt.Sprintf("updated %s/%s %s -> %s", req.namespace, req.name, container.Image, newImage)
+}
+
+func getDeployment(k8sImplementer kubernetes.Implementer, namespace, name string) (*k8s.GenericResource, error) {
+	dep, err := findDeployment(k8sImplementer, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return k8s.NewGenericResource(dep)
+}
+
+func findDeployment(k8sImplementer kubernetes.Implementer, namespace, name string) (*apps_v1.Deployment, error) {
+	deps, err := k8sImplementer.Deployments(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployments in namespace %s: %s", namespace, err)
+	}
+	for i := range deps.Items {
+		if deps.Items[i].GetName() == name {
+			return deps.Items[i].DeepCopy(), nil
+		}
+	}
+	return nil, fmt.Errorf("deployment %s/%s not found", namespace, name)
+}
+
+// containerIndex - container has to be named unless deployment has only one
+func containerIndex(resource *k8s.GenericResource, name string) (int, error) {
+	containers := resource.Containers()
+	if name == "" {
+		if len(containers) != 1 {
+			return 0, fmt.Errorf("%s has %d containers, specify one with <namespace>/<deployment>/<container>", resource.GetName(), len(containers))
+		}
+		return 0, nil
+	}
+	for i, c := range containers {
+		if c.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("container %s not found in %s", name, resource.GetName())
+}
+
+func tagExists(registryClient registry.Client, ref *image.Reference, tag string, resource *k8s.GenericResource) error {
+	opts := registry.Opts{
+		Registry: ref.Scheme() + "://" + ref.Registry(),
+		Name:     ref.ShortName(),
+		Tag:      tag,
+	}
+
+	creds, err := credentialshelper.GetCredentials(&types.TrackedImage{
+		Image:     ref,
+		Namespace: resource.GetNamespace(),
+		Secrets:   resource.GetImagePullSecrets(),
+	})
+	if err == nil {
+		opts.Username = creds.Username
+		opts.Password = creds.Password
+	}
+
+	_, err = registryClient.Digest(opts)
+	return err
+}
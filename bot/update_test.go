@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+)
+
+type fakeRegistryClient struct {
+	tags map[string]bool
+}
+
+func (c *fakeRegistryClient) Get(opts registry.Opts) (*registry.Repository, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeRegistryClient) Digest(opts registry.Opts) (string, error) {
+	if c.tags[opts.Name+":"+opts.Tag] {
+		return "sha256:0000", nil
+	}
+	return "", errors.New("manifest unknown")
+}
+
+func TestParseUpdateRequest(t *testing.T) {
+	req, err := parseUpdateRequest("default/app/sidecar 1.2.3 --force")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if req.namespace != "default" || req.name != "app" || req.container != "sidecar" || req.tag != "1.2.3" || !req.force {
+		t.Errorf("unexpected request: %+v", req)
+	}
+
+	for _, args := range []string{"", "default/app", "app 1.2.3", "/app 1.2.3", "default/app 1.2.3 extra"} {
+		if _, err := parseUpdateRequest(args); err == nil {
+			t.Errorf("expected error for '%s'", args)
+		}
+	}
+}
+
+func TestUpdateHandler(t *testing.T) {
+	rc := &fakeRegistryClient{
+		tags: map[string]bool{
+			"karolisr/keel:0.1.1": true,
+			"karolisr/keel:1.0.0": true,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		args        string
+		wantUpdated string
+		wantReply   string
+	}{
+		{"patch allowed", "default/app 0.1.1", "index.docker.io/karolisr/keel:0.1.1", "updated default/app"},
+		{"major rejected by policy", "default/app 1.0.0", "", "policy 'patch' doesn't allow 0.1.0 -> 1.0.0"},
+		{"major forced", "default/app 1.0.0 --force", "index.docker.io/karolisr/keel:1.0.0", "updated default/app"},
+		{"missing tag", "default/app 0.1.2", "", "tag 0.1.2 not found"},
+		{"missing deployment", "default/other 0.1.1", "", "deployment default/other not found"},
+		{"missing container", "default/app/sidecar 0.1.1", "", "container sidecar not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fi := &fakeImplementer{
+				deployments: map[string][]apps_v1.Deployment{
+					"default": {
+						newDeployment("default", "app", "karolisr/keel:0.1.0", map[string]string{types.KeelPolicyLabel: "patch"}),
+					},
+				},
+			}
+
+			reply := UpdateHandler(tt.args, fi, rc)
+			if !strings.Contains(reply, tt.wantReply) {
+				t.Errorf("unexpected reply: %s", reply)
+			}
+
+			if tt.wantUpdated == "" {
+				if len(fi.updated) != 0 {
+					t.Errorf("didn't expect deployment to be updated")
+				}
+				return
+			}
+			if len(fi.updated) != 1 {
+				t.Fatalf("expected deployment to be updated")
+			}
+			if img := fi.updated[0].Containers()[0].Image; img != tt.wantUpdated {
+				t.Errorf("expected %s, got: %s", tt.wantUpdated, img)
+			}
+		})
+	}
+}
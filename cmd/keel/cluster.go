@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/internal/workgroup"
+	"github.com/keel-hq/keel/provider/kubernetes"
+
+	"k8s.io/client-go/tools/cache"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// cluster - kubernetes cluster Keel watches and updates resources in
+type cluster struct {
+	name        string // empty for the default cluster
+	implementer *kubernetes.KubernetesImplementer
+	grc         *k8s.GenericResourceCache
+	synced      []cache.InformerSynced
+}
+
+// clusterContext - additional cluster configured through KUBERNETES_CONTEXTS
+type clusterContext struct {
+	name    string
+	context string
+}
+
+// parseClusterContexts - parses comma separated "name=context" or "context"
+// entries, context is used as the cluster name when name is not set
+func parseClusterContexts(value string) ([]clusterContext, error) {
+	var contexts []clusterContext
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		c := clusterContext{name: entry, context: entry}
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			c.name, c.context = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+		if c.name == "" || c.context == "" {
+			return nil, fmt.Errorf("invalid cluster '%s', expected name=context or context", entry)
+		}
+		if seen[c.name] {
+			return nil, fmt.Errorf("duplicate cluster name '%s'", c.name)
+		}
+		seen[c.name] = true
+		contexts = append(contexts, c)
+	}
+	return contexts, nil
+}
+
+// watchCluster - starts informers for the resources cluster serves, each
+// cluster has its own resource cache
+func watchCluster(g *workgroup.Group, name string, implementer *kubernetes.KubernetesImplementer) *cluster {
+	t := &k8s.Translator{
+		FieldLogger: log.WithFields(log.Fields{"context": "translator", "cluster": name}),
+	}
+
+	buf := k8s.NewBuffer(g, t, log.StandardLogger(), 128)
+	wl := log.WithFields(log.Fields{"context": "watch", "cluster": name})
+
+	// only watching resources the cluster serves, older clusters might not
+	// have apps/v1 or batch/v1 cronjobs
+	capabilities := k8s.DetectCapabilities(implementer.Client().Discovery(), wl)
+	log.WithFields(log.Fields{
+		"cluster":           name,
+		"deployments":       capabilities.Deployments,
+		"statefulsets":      capabilities.StatefulSets,
+		"daemonsets":        capabilities.DaemonSets,
+		"cronjobs":          capabilities.CronJobs,
+		"deploymentconfigs": capabilities.DeploymentConfigs,
+	}).Info("main: detected supported kubernetes resources")
+
+	c := &cluster{
+		name:        name,
+		implementer: implementer,
+		grc:         &t.GenericResourceCache,
+	}
+	if capabilities.Deployments {
+		c.synced = append(c.synced, k8s.WatchDeployments(g, implementer.Client(), wl, buf))
+	}
+	if capabilities.StatefulSets {
+		c.synced = append(c.synced, k8s.WatchStatefulSets(g, implementer.Client(), wl, buf))
+	}
+	if capabilities.DaemonSets {
+		c.synced = append(c.synced, k8s.WatchDaemonSets(g, implementer.Client(), wl, buf))
+	}
+	if capabilities.CronJobs {
+		c.synced = append(c.synced, k8s.WatchCronJobs(g, implementer.Client(), wl, buf))
+	}
+	if capabilities.DeploymentConfigs {
+		c.synced = append(c.synced, k8s.WatchDeploymentConfigs(g, implementer.Dynamic(), wl, buf))
+	}
+	implementer.SetSecretLister(k8s.WatchSecrets(g, implementer.Client(), wl))
+	if !capabilities.Deployments {
+		log.WithField("cluster", name).Warn("main: apps/v1 deployments are not available, kubernetes version 1.9 or newer is required to update deployments")
+	}
+
+	return c
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logging configuration
+const (
+	EnvLogFormat = "LOG_FORMAT" // text (default) or json
+	EnvLogLevel  = "LOG_LEVEL"  // trace, debug, info (default), warn, error, fatal or panic
+)
+
+// setupLogging - configures logrus formatter and level, DEBUG=true is still
+// honoured when LOG_LEVEL is not set
+func setupLogging() error {
+	switch strings.ToLower(os.Getenv(EnvLogFormat)) {
+	case "", "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		// same keys and UTC timestamps for every entry, so aggregators can
+		// index them without parsing messages
+		log.SetFormatter(&utcFormatter{&log.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: log.FieldMap{
+				log.FieldKeyTime:  "time",
+				log.FieldKeyLevel: "level",
+				log.FieldKeyMsg:   "message",
+			},
+		}})
+	default:
+		return fmt.Errorf("unknown %s '%s', expected text or json", EnvLogFormat, os.Getenv(EnvLogFormat))
+	}
+
+	if os.Getenv(EnvLogLevel) != "" {
+		level, err := log.ParseLevel(os.Getenv(EnvLogLevel))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", EnvLogLevel, err)
+		}
+		log.SetLevel(level)
+		return nil
+	}
+
+	if os.Getenv(EnvDebug) == "true" {
+		log.SetLevel(log.DebugLevel)
+	}
+	return nil
+}
+
+// utcFormatter - formats entries with UTC timestamps
+type utcFormatter struct {
+	log.Formatter
+}
+
+func (f *utcFormatter) Format(entry *log.Entry) ([]byte, error) {
+	entry.Time = entry.Time.UTC()
+	return f.Formatter.Format(entry)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/internal/workgroup"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/trigger/poll"
+
+	"k8s.io/client-go/tools/cache"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// runOnce - checks images tracked by poll trigger once instead of starting
+// triggers, returns once resulting updates are applied (or only reported in
+// dry run mode). Returns exit code, 1 if registry checks failed
+func runOnce(ctx context.Context, g *workgroup.Group, providers provider.Providers, sender notification.Sender, clusters []*cluster) int {
+	code := 1
+	g.Add(func(stop <-chan struct{}) {
+		// informers are started by the group, tracked images are only
+		// known once caches are synced
+		for _, c := range clusters {
+			if !cache.WaitForCacheSync(stop, c.synced...) {
+				log.WithField("cluster", c.name).Error("main.runOnce: failed to sync kubernetes cache")
+				return
+			}
+		}
+
+		// cron isn't started, images are only checked while being added
+		watcher := newPollWatcher(providers, sender)
+		err := poll.NewPollManager(providers, watcher).RunOnce(ctx)
+
+		if d, ok := providers.(provider.Drainer); ok {
+			d.Drain()
+		}
+		providers.Stop()
+		if f, ok := sender.(notification.Flusher); ok {
+			f.Flush()
+		}
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("main.runOnce: poll finished with errors")
+			return
+		}
+		log.Info("main.runOnce: poll finished")
+		code = 0
+	})
+	g.Run()
+	return code
+}
@@ -9,12 +9,31 @@ const DefaultNamespace = "kube-system"
 // WebhookEndpointEnv if set - enables webhook notifications
 const WebhookEndpointEnv = "WEBHOOK_ENDPOINT"
 
+// webhook notification body template (Go text/template) and extra
+// headers in "Name: value" form, one per line
+const (
+	EnvWebhookTemplate = "WEBHOOK_TEMPLATE"
+	EnvWebhookHeaders  = "WEBHOOK_HEADERS"
+)
+
 // slack bot/token
 const (
 	EnvSlackToken            = "SLACK_TOKEN"
 	EnvSlackBotName          = "SLACK_BOT_NAME"
 	EnvSlackChannels         = "SLACK_CHANNELS"
 	EnvSlackApprovalsChannel = "SLACK_APPROVALS_CHANNEL"
+	// EnvSlackCommandPrefix - prefix of bot commands, defaults to bot name
+	EnvSlackCommandPrefix = "SLACK_COMMAND_PREFIX"
+	// EnvSlackApproveCommand, EnvSlackRejectCommand - approval command
+	// keywords, default to "approve" and "reject"
+	EnvSlackApproveCommand = "SLACK_APPROVE_COMMAND"
+	EnvSlackRejectCommand  = "SLACK_REJECT_COMMAND"
+	// EnvSlackSigningSecret - verifies interactive message callbacks (approval
+	// buttons), /v1/slack/interactions is only served when it's set
+	EnvSlackSigningSecret = "SLACK_SIGNING_SECRET"
+	// EnvSlackAppToken - app-level token (xapp-...) with connections:write
+	// scope, bot connects through Socket Mode instead of RTM when it's set
+	EnvSlackAppToken = "SLACK_APP_TOKEN"
 
 	EnvHipchatToken    = "HIPCHAT_TOKEN"
 	EnvHipchatBotName  = "HIPCHAT_BOT_NAME"
@@ -30,12 +49,41 @@ const (
 	// for documentation on setting it up
 	EnvMattermostEndpoint = "MATTERMOST_ENDPOINT"
 	EnvMattermostName     = "MATTERMOST_USERNAME"
+	EnvMattermostIconURL  = "MATTERMOST_ICON_URL"
 
 	// MS Teams webhook url, see https://docs.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using#setting-up-a-custom-incoming-webhook
 	EnvTeamsWebhookUrl = "TEAMS_WEBHOOK_URL"
+	// EnvTeamsBotSecret - security token of the Teams outgoing webhook the bot
+	// receives commands from, enables the Teams bot
+	EnvTeamsBotSecret = "TEAMS_BOT_SECRET"
+	// EnvTeamsBotListenAddress - address the Teams bot serves the outgoing
+	// webhook on, defaults to :9302
+	EnvTeamsBotListenAddress = "TEAMS_BOT_LISTEN_ADDRESS"
+	// EnvTeamsBotWebhookURL - incoming webhook approval requests and slow
+	// responses are posted to, defaults to TEAMS_WEBHOOK_URL
+	EnvTeamsBotWebhookURL = "TEAMS_BOT_WEBHOOK_URL"
+
+	// Discord webhook url, see https://support.discord.com/hc/en-us/articles/228383668-Intro-to-Webhooks
+	EnvDiscordWebhookUrl = "DISCORD_WEBHOOK_URL"
+
+	// Telegram bot token and target chat, see https://core.telegram.org/bots#how-do-i-create-a-bot
+	EnvTelegramBotToken = "TELEGRAM_BOT_TOKEN"
+	EnvTelegramChatID   = "TELEGRAM_CHAT_ID"
+
+	// PagerDuty Events API v2 integration key, see https://support.pagerduty.com/docs/services-and-integrations
+	EnvPagerDutyRoutingKey = "PAGERDUTY_ROUTING_KEY"
+
+	// OpsGenie API key, see https://support.atlassian.com/opsgenie/docs/api-key-management/
+	EnvOpsGenieAPIKey               = "OPSGENIE_API_KEY"
+	EnvOpsGenieAPIURL               = "OPSGENIE_API_URL"               // defaults to https://api.opsgenie.com, use https://api.eu.opsgenie.com for EU
+	EnvOpsGenieProductionNamespaces = "OPSGENIE_PRODUCTION_NAMESPACES" // comma separated, failures there are P1, defaults to production,prod
+
+	// NATS server URL, nats://[user:pass@|token@]host[:port], use tls:// for TLS connections
+	EnvNatsURL     = "NATS_URL"
+	EnvNatsSubject = "NATS_SUBJECT" // defaults to keel.events
 
 	// Mail notification settings
-	EnvMailTo         = "MAIL_TO"
+	EnvMailTo         = "MAIL_TO" // comma separated list of recipients
 	EnvMailFrom       = "MAIL_FROM"
 	EnvMailSmtpServer = "MAIL_SMTP_SERVER"
 	EnvMailSmtpPort   = "MAIL_SMTP_PORT"
@@ -46,14 +94,78 @@ const (
 // EnvNotificationLevel - minimum level for notifications, defaults to info
 const EnvNotificationLevel = "NOTIFICATION_LEVEL"
 
+// EnvNotificationSenderLevels - optional per sender levels, i.e. "webhook=error,slack=debug"
+const EnvNotificationSenderLevels = "NOTIFICATION_SENDER_LEVELS"
+
+// Notification retry backoff, durations such as "500ms" or "1m"
+const (
+	EnvNotificationBackoffBase = "NOTIFICATION_BACKOFF_BASE" // defaults to 1s
+	EnvNotificationBackoffMax  = "NOTIFICATION_BACKOFF_MAX"  // defaults to 2m
+)
+
+// EnvNotificationBatchWindow - successful updates completing within the window
+// (i.e. "30s") are sent as one notification, disabled by default
+const EnvNotificationBatchWindow = "NOTIFICATION_BATCH_WINDOW"
+
+// Notification message templates (Go templates) for title and body of each
+// event kind, built-in messages are used when not set
+const (
+	EnvNotificationTemplatePreUpdateTitle = "NOTIFICATION_TEMPLATE_PRE_UPDATE_TITLE"
+	EnvNotificationTemplatePreUpdateBody  = "NOTIFICATION_TEMPLATE_PRE_UPDATE_BODY"
+	EnvNotificationTemplateUpdateTitle    = "NOTIFICATION_TEMPLATE_UPDATE_TITLE"
+	EnvNotificationTemplateUpdateBody     = "NOTIFICATION_TEMPLATE_UPDATE_BODY"
+	EnvNotificationTemplateFailedTitle    = "NOTIFICATION_TEMPLATE_FAILED_TITLE"
+	EnvNotificationTemplateFailedBody     = "NOTIFICATION_TEMPLATE_FAILED_BODY"
+)
+
+// Notifier HTTP client settings for egress through TLS-intercepting proxies,
+// system CAs and proxy environment variables are used when not set
+const (
+	EnvNotificationCAFile = "NOTIFICATION_CA_FILE" // PEM bundle trusted in addition to system CAs
+	EnvNotificationProxy  = "NOTIFICATION_PROXY"   // proxy URL, i.e. http://proxy.corp:3128
+)
+
 // Basic Auth - User / Password
 const EnvBasicAuthUser = "BASIC_AUTH_USER"
 const EnvBasicAuthPassword = "BASIC_AUTH_PASSWORD"
 const EnvAuthenticatedWebhooks = "AUTHENTICATED_WEBHOOKS"
 const EnvTokenSecret = "TOKEN_SECRET"
 
+// EnvHarborWebhookSecret - optional secret Harbor sends in the Authorization header
+const EnvHarborWebhookSecret = "HARBOR_WEBHOOK_SECRET"
+
+// EnvGitlabWebhookToken - optional secret token GitLab sends in the X-Gitlab-Token header
+const EnvGitlabWebhookToken = "GITLAB_WEBHOOK_TOKEN"
+
+// EnvArtifactoryWebhookSecret - optional secret Artifactory sends in the X-JFrog-Event-Auth header
+const EnvArtifactoryWebhookSecret = "ARTIFACTORY_WEBHOOK_SECRET"
+
+// EnvNativeWebhookSecret - optional secret used to verify HMAC-SHA256 signature of
+// native webhook requests, header defaults to X-Keel-Signature
+const (
+	EnvNativeWebhookSecret          = "NATIVE_WEBHOOK_SECRET"
+	EnvNativeWebhookSignatureHeader = "NATIVE_WEBHOOK_SIGNATURE_HEADER"
+)
+
+// EnvWebhookDedupWindow - identical webhook events received within the window
+// (Go duration, defaults to 5s) are submitted once, set to 0 to disable
+const EnvWebhookDedupWindow = "WEBHOOK_DEDUP_WINDOW"
+
 // KeelLogoURL - is a logo URL for bot icon
 const KeelLogoURL = "https://keel.sh/img/logo.png"
 
 // Env var to define a namespace that keel will scan - avoid scan over all the cluster -
 const EnvRestrictedNamespace = "RESTRICTED_NAMESPACE"
+
+// EnvLabelSelector - optional label selector (e.g. keel=enabled) applied when listing
+// and watching resources, non-matching resources are never seen by keel. Matching
+// resources still need keel.sh/policy label or annotation to be updated
+const EnvLabelSelector = "LABEL_SELECTOR"
+
+// EnvNamespaceAllowlist - comma separated namespaces kubernetes provider acts on,
+// all namespaces when empty. EnvNamespaceDenylist - comma separated namespaces
+// that are always ignored, takes precedence over the allowlist
+const (
+	EnvNamespaceAllowlist = "NAMESPACE_ALLOWLIST"
+	EnvNamespaceDenylist  = "NAMESPACE_DENYLIST"
+)
@@ -25,6 +25,9 @@ import (
 // more info here: https://docs.aws.amazon.com/AmazonECR/latest/userguide/service_limits.html
 const AWSCredentialsExpiry = 2 * time.Hour
 
+// tokenExpiryMargin - cached tokens are dropped this long before ECR expires them
+const tokenExpiryMargin = 5 * time.Minute
+
 var registryRegxp *regexp.Regexp
 
 func init() {
@@ -128,7 +131,13 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 				Password: password,
 			}
 
-			h.cache.Put(registry, creds)
+			// tokens are valid for 12 hours, refreshing them a bit earlier so
+			// polls never run with an expired token
+			if ad.ExpiresAt != nil {
+				h.cache.PutWithExpiry(registry, creds, ad.ExpiresAt.Add(-tokenExpiryMargin))
+			} else {
+				h.cache.Put(registry, creds)
+			}
 
 			return creds, nil
 		}
@@ -137,6 +146,12 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 	return nil, fmt.Errorf("not found")
 }
 
+// InvalidateCredentials - drops cached token of the image registry, next
+// GetCredentials call requests a new one
+func (h *CredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.cache.Delete(image.Image.Registry())
+}
+
 func newAwsSession(region string) *session.Session {
 	sess := session.Must(session.NewSession(&aws.Config{
 		Region: aws.String(region),
@@ -10,7 +10,7 @@ import (
 
 type item struct {
 	credentials *types.Credentials
-	created     time.Time
+	expires     time.Time
 }
 
 // Cache - internal cache for aws
@@ -49,7 +49,7 @@ func (c *Cache) expire() {
 	defer c.mu.Unlock()
 	t := time.Now()
 	for k, v := range c.creds {
-		if t.Sub(v.created) > c.ttl {
+		if t.After(v.expires) {
 			delete(c.creds, k)
 		}
 	}
@@ -57,9 +57,21 @@ func (c *Cache) expire() {
 
 // Put - saves new creds
 func (c *Cache) Put(registry string, creds *types.Credentials) {
+	c.PutWithExpiry(registry, creds, time.Now().Add(c.ttl))
+}
+
+// PutWithExpiry - saves new creds that become invalid at the given time
+func (c *Cache) PutWithExpiry(registry string, creds *types.Credentials, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.creds[registry] = &item{credentials: creds, expires: expires}
+}
+
+// Delete - removes creds
+func (c *Cache) Delete(registry string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.creds[registry] = &item{credentials: creds, created: time.Now()}
+	delete(c.creds, registry)
 }
 
 // Get - retrieves creds
@@ -68,7 +80,7 @@ func (c *Cache) Get(registry string) (*types.Credentials, error) {
 	defer c.mu.RUnlock()
 
 	item, ok := c.creds[registry]
-	if !ok {
+	if !ok || time.Now().After(item.expires) {
 		return nil, fmt.Errorf("not found")
 	}
 
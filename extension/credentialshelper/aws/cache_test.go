@@ -57,3 +57,39 @@ func TestExpiry(t *testing.T) {
 	}
 
 }
+
+func TestPutWithExpiry(t *testing.T) {
+	c := &Cache{
+		creds: make(map[string]*item),
+		mu:    &sync.RWMutex{},
+		ttl:   time.Hour,
+		tick:  time.Hour,
+	}
+
+	creds := &types.Credentials{
+		Username: "user-1",
+		Password: "pass-1",
+	}
+
+	c.PutWithExpiry("reg1", creds, time.Now().Add(-time.Second))
+
+	_, err := c.Get("reg1")
+	if err == nil {
+		t.Errorf("expected expired token to be missing")
+	}
+
+	c.PutWithExpiry("reg2", creds, time.Now().Add(48*time.Hour))
+
+	if _, err := c.Get("reg2"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.creds["reg2"].expires.Before(time.Now().Add(47 * time.Hour)) {
+		t.Errorf("expected expiry to follow the token, got: %s", c.creds["reg2"].expires)
+	}
+
+	c.Delete("reg2")
+	if _, err := c.Get("reg2"); err == nil {
+		t.Errorf("expected deleted token to be missing")
+	}
+}
@@ -16,6 +16,12 @@ type CredentialsHelper interface {
 	IsEnabled() bool
 }
 
+// Invalidator - implemented by helpers that cache credentials, cached
+// credentials are dropped when the registry rejects them
+type Invalidator interface {
+	InvalidateCredentials(image *types.TrackedImage)
+}
+
 // Common errors
 var (
 	ErrCredentialsNotAvailable = errors.New("no credentials available for this registry")
@@ -106,3 +112,16 @@ func GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
 	}).Debug("extension.credentialshelper: credentials helper not found")
 	return nil, ErrCredentialsNotAvailable
 }
+
+// InvalidateCredentials - drops credentials cached for the image by helpers
+// that cache them, called when the registry rejects the credentials
+func InvalidateCredentials(image *types.TrackedImage) {
+	credHelpersM.RLock()
+	defer credHelpersM.RUnlock()
+
+	for _, credHelper := range credHelpers {
+		if invalidator, ok := credHelper.(Invalidator); ok && credHelper.IsEnabled() {
+			invalidator.InvalidateCredentials(image)
+		}
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/keel-hq/keel/extension/credentialshelper"
 	"github.com/keel-hq/keel/types"
 )
 
+// EnvRegistries - optional comma separated list of registry hosts that should
+// use the service account key, defaults to gcr.io and its regional hosts
+const EnvRegistries = "GCR_REGISTRIES"
+
 func init() {
 	credentialshelper.RegisterCredentialsHelper("gcr", New())
 }
@@ -16,10 +21,13 @@ func init() {
 type CredentialsHelper struct {
 	enabled     bool
 	credentials string
+	registries  []string
 }
 
 func New() *CredentialsHelper {
-	ch := &CredentialsHelper{}
+	ch := &CredentialsHelper{
+		registries: parseRegistries(os.Getenv(EnvRegistries)),
+	}
 
 	credentialsFile, ok := os.LookupEnv("GOOGLE_APPLICATION_CREDENTIALS")
 	if !ok {
@@ -45,7 +53,7 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 		return nil, errors.New("not initialised")
 	}
 
-	if image.Image.Registry() != "gcr.io" {
+	if !h.supported(image.Image.Registry()) {
 		return nil, credentialshelper.ErrUnsupportedRegistry
 	}
 
@@ -54,3 +62,27 @@ func (h *CredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Cr
 		Password: h.credentials,
 	}, nil
 }
+
+func (h *CredentialsHelper) supported(registry string) bool {
+	if len(h.registries) == 0 {
+		// gcr.io, eu.gcr.io, us.gcr.io, asia.gcr.io
+		return registry == "gcr.io" || strings.HasSuffix(registry, ".gcr.io")
+	}
+	for _, r := range h.registries {
+		if r == registry {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRegistries(value string) []string {
+	var registries []string
+	for _, r := range strings.Split(value, ",") {
+		r = strings.TrimSpace(r)
+		if r != "" {
+			registries = append(registries, r)
+		}
+	}
+	return registries
+}
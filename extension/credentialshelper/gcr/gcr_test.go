@@ -0,0 +1,57 @@
+package gcr
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+func mustParse(img string) *types.TrackedImage {
+	ref, err := image.Parse(img)
+	if err != nil {
+		panic(err)
+	}
+	return &types.TrackedImage{
+		Image: ref,
+	}
+}
+
+func TestGetCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		registries string
+		image      string
+		wantErr    error
+	}{
+		{"gcr", "", "gcr.io/v2-namespace/hello-world:1.1", nil},
+		{"regional gcr", "", "eu.gcr.io/v2-namespace/hello-world:1.1", nil},
+		{"docker hub", "", "karolisr/keel:0.2.0", credentialshelper.ErrUnsupportedRegistry},
+		{"configured artifact registry", "europe-docker.pkg.dev", "europe-docker.pkg.dev/project/repo/app:1.0.0", nil},
+		{"configured excludes gcr", "europe-docker.pkg.dev", "gcr.io/v2-namespace/hello-world:1.1", credentialshelper.ErrUnsupportedRegistry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &CredentialsHelper{
+				enabled:     true,
+				credentials: `{"type": "service_account"}`,
+				registries:  parseRegistries(tt.registries),
+			}
+
+			creds, err := h.GetCredentials(mustParse(tt.image))
+			if err != tt.wantErr {
+				t.Fatalf("GetCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if creds.Username != "_json_key" {
+				t.Errorf("expected _json_key username, got: %s", creds.Username)
+			}
+			if creds.Password != h.credentials {
+				t.Errorf("expected key contents as password, got: %s", creds.Password)
+			}
+		})
+	}
+}
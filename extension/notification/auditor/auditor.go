@@ -1,6 +1,11 @@
 package auditor
 
 import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/keel-hq/keel/extension/notification"
@@ -10,28 +15,41 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// Sink - audit log destination, every entry is written to all configured sinks
+type Sink interface {
+	Write(entry *types.AuditLog) error
+}
+
 type auditor struct {
-	store store.Store
+	sinks []Sink
 }
 
-func New(store store.Store) *auditor {
+// New - creates auditor that stores entries in the store and any additional sinks
+func New(store store.Store, sinks ...Sink) *auditor {
 	return &auditor{
-		store: store,
+		sinks: append([]Sink{NewStoreSink(store)}, sinks...),
 	}
 }
 
 func (a *auditor) Configure(config *notification.Config) (bool, error) {
 
 	log.WithFields(log.Fields{
-		"name": "auditor",
+		"name":  "auditor",
+		"sinks": len(a.sinks),
 	}).Info("extension.notification.auditor: audit logger configured")
 
 	return true, nil
 }
 
+// BatchExempt - every update is recorded, even when notifications are batched
+func (a *auditor) BatchExempt() bool {
+	return true
+}
+
 func (a *auditor) Send(event types.EventNotification) error {
 	al := &types.AuditLog{
 		ID:           uuid.New().String(),
+		CreatedAt:    event.CreatedAt,
 		AccountID:    "system",
 		Username:     "system",
 		Action:       event.Type.String(),
@@ -39,8 +57,49 @@ func (a *auditor) Send(event types.EventNotification) error {
 		Identifier:   event.Identifier,
 		Message:      event.Message,
 	}
+	if al.CreatedAt.IsZero() {
+		al.CreatedAt = time.Now()
+	}
 	al.SetMetadata(event.Metadata)
-	_, err := a.store.CreateAuditLog(al)
+
+	// writing to all sinks even if one of them fails, first error is returned
+	var err error
+	for _, sink := range a.sinks {
+		if sinkErr := sink.Write(al); sinkErr != nil && err == nil {
+			err = sinkErr
+		}
+	}
 
 	return err
 }
+
+type storeSink struct {
+	store store.Store
+}
+
+// NewStoreSink - stores audit logs in the database, entries are served by /v1/audit
+func NewStoreSink(store store.Store) Sink {
+	return &storeSink{store: store}
+}
+
+func (s *storeSink) Write(entry *types.AuditLog) error {
+	_, err := s.store.CreateAuditLog(entry)
+	return err
+}
+
+type jsonSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink - writes audit logs as JSON lines, usually to stdout so they
+// can be collected together with container logs
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonSink) Write(entry *types.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(entry)
+}
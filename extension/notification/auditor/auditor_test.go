@@ -0,0 +1,77 @@
+package auditor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type fakeSink struct {
+	entries []*types.AuditLog
+	err     error
+}
+
+func (s *fakeSink) Write(entry *types.AuditLog) error {
+	s.entries = append(s.entries, entry)
+	return s.err
+}
+
+func TestSendAllSinks(t *testing.T) {
+	failing := &fakeSink{err: errors.New("database is locked")}
+	ok := &fakeSink{}
+	a := &auditor{sinks: []Sink{failing, ok}}
+
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := a.Send(types.EventNotification{
+		ResourceKind: "deployment",
+		Identifier:   "deployment/default/app",
+		Message:      "Successfully updated deployment default/app 1.0.0->1.0.1",
+		CreatedAt:    createdAt,
+		Type:         types.NotificationDeploymentUpdate,
+		Metadata: map[string]string{
+			"previous_images": "karolisr/app:1.0.0",
+			"images":          "karolisr/app:1.0.1",
+			"trigger":         "poll",
+		},
+	})
+	if err == nil {
+		t.Errorf("expected sink error to be returned")
+	}
+
+	if len(ok.entries) != 1 {
+		t.Fatalf("expected entry to be written to remaining sinks, got: %d", len(ok.entries))
+	}
+	entry := ok.entries[0]
+	if !entry.CreatedAt.Equal(createdAt) {
+		t.Errorf("unexpected timestamp: %s", entry.CreatedAt)
+	}
+	if entry.Action != types.NotificationDeploymentUpdate.String() || entry.Identifier != "deployment/default/app" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Metadata["trigger"] != "poll" {
+		t.Errorf("expected metadata to be kept, got: %v", entry.Metadata)
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewJSONSink(buf)
+
+	entry := &types.AuditLog{ID: "1", Action: "deployment update", Identifier: "deployment/default/app"}
+	entry.SetMetadata(map[string]string{"images": "karolisr/app:1.0.1"})
+	if err := sink.Write(entry); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got types.AuditLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected JSON line, got: %s (%s)", buf.String(), err)
+	}
+	if got.Identifier != "deployment/default/app" || got.Metadata["images"] != "karolisr/app:1.0.1" {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BatchExempt - implemented by senders that record every notification, i.e.
+// the audit log. They get update notifications as they happen instead of
+// batch summaries
+type BatchExempt interface {
+	BatchExempt() bool
+}
+
+func isBatchExempt(s Sender) bool {
+	e, ok := s.(BatchExempt)
+	return ok && e.BatchExempt()
+}
+
+// Flusher - implemented by senders that queue notifications, Flush sends
+// queued notifications right away, i.e. before exiting
+type Flusher interface {
+	Flush()
+}
+
+// batch - queued notifications of the same channels, sent once timer fires
+type batch struct {
+	events []types.EventNotification
+	timer  *time.Timer
+}
+
+// isSuccessfulUpdate - notification sent once a resource or release was
+// updated, dry run, annotate-only and freeze notifications share the type
+// but nothing was updated
+func isSuccessfulUpdate(event types.EventNotification) bool {
+	switch event.Name {
+	case types.EventNameUpdateResource, types.EventNameUpdateRelease:
+		return event.Level == types.LevelSuccess
+	}
+	return false
+}
+
+// queue - adds successful update notification to the batch of its channels,
+// the first notification of a batch schedules the summary after BatchWindow.
+// Returns false when batching is disabled or the event isn't batched
+func (m *DefaultNotificationSender) queue(event types.EventNotification) bool {
+	if m.config == nil || m.config.BatchWindow <= 0 || !isSuccessfulUpdate(event) {
+		return false
+	}
+
+	// resources notifying different channels are summarized separately
+	key := strings.Join(event.Channels, ",")
+
+	m.batchMu.Lock()
+	defer m.batchMu.Unlock()
+	if m.batches == nil {
+		m.batches = make(map[string]*batch)
+	}
+	b, ok := m.batches[key]
+	if !ok {
+		b = &batch{}
+		b.timer = time.AfterFunc(m.config.BatchWindow, func() { m.flushBatch(key, b) })
+		m.batches[key] = b
+	}
+	b.events = append(b.events, event)
+	return true
+}
+
+// Flush - sends all queued batches without waiting for their window to end
+func (m *DefaultNotificationSender) Flush() {
+	m.batchMu.Lock()
+	batches := m.batches
+	m.batches = nil
+	m.batchMu.Unlock()
+
+	for _, b := range batches {
+		b.timer.Stop()
+		m.sendBatch(b.events)
+	}
+}
+
+// flushBatch - sends the batch once its window ends, unless it was already
+// flushed
+func (m *DefaultNotificationSender) flushBatch(key string, b *batch) {
+	m.batchMu.Lock()
+	if m.batches[key] != b {
+		m.batchMu.Unlock()
+		return
+	}
+	delete(m.batches, key)
+	m.batchMu.Unlock()
+
+	m.sendBatch(b.events)
+}
+
+// sendBatch - sends summary of the events to senders that aren't batch exempt
+func (m *DefaultNotificationSender) sendBatch(events []types.EventNotification) {
+	if len(events) == 0 {
+		return
+	}
+
+	// senders are a snapshot, the lock isn't held while sending and retrying
+	batched := make(map[string]Sender)
+	for name, s := range m.Senders() {
+		if !isBatchExempt(s) {
+			batched[name] = s
+		}
+	}
+
+	event := summarize(events)
+	if err := m.send(event, batched); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"resources": event.Metadata["resources"],
+		}).Error("extension.notification: failed to send batched notification")
+	}
+}
+
+// summarize - single notification listing messages of all batched events,
+// the only event of a batch is sent unchanged
+func summarize(events []types.EventNotification) types.EventNotification {
+	if len(events) == 1 {
+		return events[0]
+	}
+
+	identifiers := make([]string, 0, len(events))
+	lines := make([]string, 0, len(events)+1)
+	lines = append(lines, fmt.Sprintf("Updated %d resources:", len(events)))
+	for _, e := range events {
+		identifiers = append(identifiers, e.Identifier)
+		lines = append(lines, "- "+e.Message)
+	}
+
+	first := events[0]
+	return types.EventNotification{
+		Name:      "update resources",
+		Message:   strings.Join(lines, "\n"),
+		CreatedAt: time.Now(),
+		Type:      first.Type,
+		Level:     first.Level,
+		Channels:  first.Channels,
+		Metadata: map[string]string{
+			"resources": strings.Join(identifiers, ","),
+		},
+	}
+}
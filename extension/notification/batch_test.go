@@ -0,0 +1,166 @@
+package notification
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type recordingSender struct {
+	mu     sync.Mutex
+	sent   []types.EventNotification
+	exempt bool
+}
+
+func (s *recordingSender) Configure(*Config) (bool, error) {
+	return true, nil
+}
+
+func (s *recordingSender) Send(event types.EventNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func (s *recordingSender) BatchExempt() bool {
+	return s.exempt
+}
+
+func (s *recordingSender) events() []types.EventNotification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]types.EventNotification(nil), s.sent...)
+}
+
+func TestSendBatched(t *testing.T) {
+	sndr := New(context.Background())
+	sndr.Configure(&Config{
+		Level:       types.LevelDebug,
+		Attempts:    1,
+		BatchWindow: 50 * time.Millisecond,
+	})
+
+	chat := &recordingSender{}
+	audit := &recordingSender{exempt: true}
+	RegisterSender("chat", chat)
+	defer sndr.UnregisterSender("chat")
+	RegisterSender("audit", audit)
+	defer sndr.UnregisterSender("audit")
+
+	for _, id := range []string{"deployment/default/a", "deployment/default/b"} {
+		sndr.Send(types.EventNotification{
+			Identifier: id,
+			Name:       types.EventNameUpdateResource,
+			Message:    "updated " + id,
+			Type:       types.NotificationDeploymentUpdate,
+			Level:      types.LevelSuccess,
+		})
+	}
+	// other channels are summarized separately
+	sndr.Send(types.EventNotification{
+		Identifier: "deployment/default/c",
+		Name:       types.EventNameUpdateResource,
+		Message:    "updated deployment/default/c",
+		Type:       types.NotificationDeploymentUpdate,
+		Level:      types.LevelSuccess,
+		Channels:   []string{"ops"},
+	})
+	// dry runs and annotate-only resources weren't updated
+	sndr.Send(types.EventNotification{
+		Identifier: "deployment/default/d",
+		Name:       "dry run update resource",
+		Message:    "dry run deployment/default/d",
+		Type:       types.NotificationDeploymentUpdate,
+		Level:      types.LevelSuccess,
+	})
+	sndr.Send(types.EventNotification{
+		Identifier: "deployment/default/e",
+		Name:       "update available",
+		Message:    "available deployment/default/e",
+		Type:       types.NotificationDeploymentUpdate,
+		Level:      types.LevelSuccess,
+	})
+	// failures and pre-update notifications aren't batched
+	sndr.Send(types.EventNotification{
+		Name:    types.EventNameUpdateResource,
+		Message: "update failed",
+		Type:    types.NotificationDeploymentUpdate,
+		Level:   types.LevelError,
+	})
+
+	if got := chat.events(); len(got) != 3 || got[0].Message != "dry run deployment/default/d" || got[1].Message != "available deployment/default/e" || got[2].Message != "update failed" {
+		t.Fatalf("expected only dry run, annotate and failure notifications to be sent right away, got: %v", got)
+	}
+	if got := audit.events(); len(got) != 6 {
+		t.Errorf("expected batch exempt sender to get all 6 notifications, got: %d", len(got))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	got := chat.events()
+	if len(got) != 5 {
+		t.Fatalf("expected 3 immediate notifications and 2 batches, got: %v", got)
+	}
+	for _, e := range got[3:] {
+		switch {
+		case len(e.Channels) == 0:
+			if e.Metadata["resources"] != "deployment/default/a,deployment/default/b" {
+				t.Errorf("unexpected batched resources: %s", e.Metadata["resources"])
+			}
+			if !strings.HasPrefix(e.Message, "Updated 2 resources:") || !strings.Contains(e.Message, "- updated deployment/default/b") {
+				t.Errorf("unexpected batch message: %s", e.Message)
+			}
+		case e.Channels[0] == "ops":
+			// single event is sent unchanged
+			if e.Message != "updated deployment/default/c" {
+				t.Errorf("unexpected message: %s", e.Message)
+			}
+		}
+	}
+	if got := audit.events(); len(got) != 6 {
+		t.Errorf("didn't expect batch summaries for exempt sender, got: %d", len(got))
+	}
+}
+
+func TestFlushSendsQueuedBatches(t *testing.T) {
+	sndr := New(context.Background())
+	sndr.Configure(&Config{
+		Level:       types.LevelDebug,
+		Attempts:    1,
+		BatchWindow: 50 * time.Millisecond,
+	})
+
+	chat := &recordingSender{}
+	RegisterSender("chat", chat)
+	defer sndr.UnregisterSender("chat")
+
+	for _, id := range []string{"deployment/default/a", "deployment/default/b"} {
+		sndr.Send(types.EventNotification{
+			Identifier: id,
+			Name:       types.EventNameUpdateResource,
+			Message:    "updated " + id,
+			Type:       types.NotificationDeploymentUpdate,
+			Level:      types.LevelSuccess,
+		})
+	}
+	if got := chat.events(); len(got) != 0 {
+		t.Fatalf("expected notifications to be queued, got: %v", got)
+	}
+
+	sndr.Flush()
+	got := chat.events()
+	if len(got) != 1 || got[0].Metadata["resources"] != "deployment/default/a,deployment/default/b" {
+		t.Fatalf("expected batch to be sent on flush, got: %v", got)
+	}
+
+	// flushed batch isn't sent again once its window ends
+	time.Sleep(150 * time.Millisecond)
+	if got := chat.events(); len(got) != 1 {
+		t.Errorf("expected batch to be sent once, got: %d", len(got))
+	}
+}
@@ -0,0 +1,166 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+type sender struct {
+	endpoint string
+	client   *http.Client
+}
+
+// Config represents the configuration of a Discord Webhook Sender.
+type Config struct {
+	Endpoint string
+}
+
+func init() {
+	notification.RegisterSender("discord", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var httpConfig Config
+
+	if os.Getenv(constants.EnvDiscordWebhookUrl) != "" {
+		httpConfig.Endpoint = os.Getenv(constants.EnvDiscordWebhookUrl)
+	} else {
+		return false, nil
+	}
+
+	// Validate endpoint URL.
+	if httpConfig.Endpoint == "" {
+		return false, nil
+	}
+	if _, err := url.ParseRequestURI(httpConfig.Endpoint); err != nil {
+		return false, fmt.Errorf("could not parse endpoint URL: %s", err)
+	}
+	s.endpoint = httpConfig.Endpoint
+
+	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name": "discord",
+	}).Info("extension.notification.discord: sender configured")
+
+	return true, nil
+}
+
+type discordMessage struct {
+	Username  string         `json:"username"`
+	AvatarURL string         `json:"avatar_url"`
+	Embeds    []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	embed := discordEmbed{
+		Title:       event.Type.String(),
+		Description: event.Message,
+		Color:       levelColor(event.Level),
+	}
+	if !event.CreatedAt.IsZero() {
+		embed.Timestamp = event.CreatedAt.Format(time.RFC3339)
+	}
+	if images := event.Metadata["images"]; images != "" {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Image", Value: images, Inline: true})
+	}
+	if version := event.Metadata["version"]; version != "" {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "New tag", Value: version, Inline: true})
+	}
+
+	jsonNotification, err := json.Marshal(discordMessage{
+		Username:  "keel",
+		AvatarURL: constants.KeelLogoURL,
+		Embeds:    []discordEmbed{embed},
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	var resp *http.Response
+	err = notification.RetryRateLimited(func() (bool, time.Duration, error) {
+		var err error
+		resp, err = s.post(jsonNotification)
+		if err != nil {
+			return false, 0, err
+		}
+		return resp.StatusCode == http.StatusTooManyRequests, retryAfter(resp.Header.Get("Retry-After")), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("got status %d, expected 200/204", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *sender) post(body []byte) (*http.Response, error) {
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// retryAfter - parses Retry-After header, Discord sends seconds (possibly fractional)
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return time.Second
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func levelColor(level types.Level) int {
+	switch level {
+	case types.LevelError, types.LevelFatal:
+		return 0xE01E5A // red
+	case types.LevelWarn:
+		return 0xECB22E // yellow
+	case types.LevelSuccess:
+		return 0x2EB67D // green
+	default:
+		return 0x36C5F0 // blue
+	}
+}
@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestDiscordRequest(t *testing.T) {
+	var got discordMessage
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Errorf("failed to parse body: %s", err)
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Name:      "update deployment",
+		Message:   "message here",
+		CreatedAt: time.Now(),
+		Type:      types.NotificationDeploymentUpdate,
+		Level:     types.LevelSuccess,
+		Metadata: map[string]string{
+			"images":  "karolisr/webhook-demo:0.0.2",
+			"version": "0.0.2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(got.Embeds) != 1 {
+		t.Fatalf("expected 1 embed, got: %d", len(got.Embeds))
+	}
+	embed := got.Embeds[0]
+	if embed.Description != "message here" {
+		t.Errorf("unexpected description: %s", embed.Description)
+	}
+	if embed.Color != levelColor(types.LevelSuccess) {
+		t.Errorf("expected success color, got: %x", embed.Color)
+	}
+	if len(embed.Fields) != 2 || embed.Fields[0].Value != "karolisr/webhook-demo:0.0.2" || embed.Fields[1].Value != "0.0.2" {
+		t.Errorf("unexpected fields: %+v", embed.Fields)
+	}
+}
+
+func TestDiscordRateLimited(t *testing.T) {
+	calls := 0
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			resp.Header().Set("Retry-After", "0.1")
+			resp.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Message: "message here",
+		Type:    types.NotificationDeploymentUpdate,
+		Level:   types.LevelError,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected request to be retried once, got %d calls", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := retryAfter("1.5"); d != 1500*time.Millisecond {
+		t.Errorf("unexpected duration: %s", d)
+	}
+	if d := retryAfter(""); d != time.Second {
+		t.Errorf("expected default of 1s, got: %s", d)
+	}
+}
@@ -2,6 +2,7 @@ package hipchat
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -47,7 +48,12 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 		s.channels = []string{"general"}
 	}
 
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
 	s.hipchatClient = hipchat.NewClient(token)
+	s.hipchatClient.SetHTTPClient(&http.Client{Transport: transport})
 
 	if os.Getenv("HIPCHAT_SERVER") != "" {
 		server, _ := url.Parse(os.Getenv("HIPCHAT_SERVER"))
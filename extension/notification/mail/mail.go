@@ -1,9 +1,13 @@
 package mail
 
 import (
+	"bytes"
+	"fmt"
 	"net/smtp"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/keel-hq/keel/constants"
 	"github.com/keel-hq/keel/extension/notification"
@@ -70,13 +74,6 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 }
 
 func (s *sender) Send(event types.EventNotification) error {
-	body := event.CreatedAt.String() + "\n" + event.Level.String() + "-" +
-		event.Type.String() + "\n" + event.Message
-	msg := "From: " + s.from + "\n" +
-		"To: " + s.to + "\n" +
-		"Subject: Keel notification\n\n" +
-		body
-
 	// Support only plain auth
 	var auth smtp.Auth = nil
 	if s.smtpUser != "" {
@@ -88,12 +85,45 @@ func (s *sender) Send(event types.EventNotification) error {
 		)
 	}
 
-	err := smtp.SendMail(s.smtpServer+":"+strconv.Itoa(s.smtpPort), auth, s.from, []string{s.to}, []byte(msg))
+	err := smtp.SendMail(s.smtpServer+":"+strconv.Itoa(s.smtpPort), auth, s.from, s.recipients(), s.buildMessage(event))
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Error("extension.notification.mail: failed to send notification")
+		// returning error so the notification sender can retry
+		return err
 	}
 
 	return nil
 }
+
+// recipients - MAIL_TO can hold a comma separated list of addresses
+func (s *sender) recipients() []string {
+	var to []string
+	for _, addr := range strings.Split(s.to, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+func (s *sender) buildMessage(event types.EventNotification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.recipients(), ", "))
+	fmt.Fprintf(&b, "Subject: [Keel] %s: %s\r\n", event.Type.String(), event.Name)
+	fmt.Fprintf(&b, "Date: %s\r\n", event.CreatedAt.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "%s\r\n\r\n", event.Message)
+	fmt.Fprintf(&b, "Type: %s\r\n", event.Type.String())
+	fmt.Fprintf(&b, "Level: %s\r\n", event.Level.String())
+	if event.Identifier != "" {
+		fmt.Fprintf(&b, "Resource: %s\r\n", event.Identifier)
+	}
+	fmt.Fprintf(&b, "Time: %s\r\n", event.CreatedAt.String())
+
+	return b.Bytes()
+}
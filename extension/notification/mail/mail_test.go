@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestBuildMessage(t *testing.T) {
+	s := &sender{
+		from: "keel@example.com",
+		to:   "ops@example.com, dev@example.com",
+	}
+
+	msg := string(s.buildMessage(types.EventNotification{
+		Name:       "update deployment",
+		Message:    "Successfully updated deployment default/wd 0.0.1->0.0.2 (karolisr/webhook-demo:0.0.2)",
+		CreatedAt:  time.Now(),
+		Type:       types.NotificationDeploymentUpdate,
+		Level:      types.LevelSuccess,
+		Identifier: "deployment/default/wd",
+	}))
+
+	expected := []string{
+		"From: keel@example.com\r\n",
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [Keel] " + types.NotificationDeploymentUpdate.String() + ": update deployment\r\n",
+		"\r\n\r\nSuccessfully updated deployment default/wd 0.0.1->0.0.2 (karolisr/webhook-demo:0.0.2)\r\n",
+		"Level: success\r\n",
+		"Resource: deployment/default/wd\r\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(msg, e) {
+			t.Errorf("expected message to contain %q, got:\n%s", e, msg)
+		}
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	s := &sender{to: "ops@example.com, ,dev@example.com"}
+
+	to := s.recipients()
+	if len(to) != 2 {
+		t.Fatalf("expected 2 recipients, got: %v", to)
+	}
+	if to[0] != "ops@example.com" || to[1] != "dev@example.com" {
+		t.Errorf("unexpected recipients: %v", to)
+	}
+}
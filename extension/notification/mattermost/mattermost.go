@@ -7,11 +7,13 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/keel-hq/keel/constants"
 	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/version"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -21,6 +23,7 @@ const timeout = 5 * time.Second
 type sender struct {
 	endpoint string
 	name     string
+	iconURL  string
 	client   *http.Client
 }
 
@@ -28,6 +31,7 @@ type sender struct {
 type Config struct {
 	Endpoint string
 	Name     string
+	IconURL  string
 }
 
 func init() {
@@ -37,6 +41,7 @@ func init() {
 func (s *sender) Configure(config *notification.Config) (bool, error) {
 	// name in the notifications
 	s.name = "keel"
+	s.iconURL = constants.KeelLogoURL
 	// Get configuration
 	var httpConfig Config
 
@@ -50,6 +55,10 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 		httpConfig.Name = os.Getenv(constants.EnvMattermostName)
 	}
 
+	if os.Getenv(constants.EnvMattermostIconURL) != "" {
+		httpConfig.IconURL = os.Getenv(constants.EnvMattermostIconURL)
+	}
+
 	// Validate endpoint URL.
 	if httpConfig.Endpoint == "" {
 		return false, nil
@@ -58,6 +67,9 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	if httpConfig.Name != "" {
 		s.name = httpConfig.Name // setting default name
 	}
+	if httpConfig.IconURL != "" {
+		s.iconURL = httpConfig.IconURL
+	}
 	if _, err := url.ParseRequestURI(httpConfig.Endpoint); err != nil {
 		log.WithFields(log.Fields{
 			"endpoint": httpConfig.Endpoint,
@@ -68,8 +80,12 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	s.endpoint = httpConfig.Endpoint
 
 	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
 	s.client = &http.Client{
-		Transport: http.DefaultTransport,
+		Transport: transport,
 		Timeout:   timeout,
 	}
 
@@ -81,18 +97,56 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	return true, nil
 }
 
+// maxShortFieldLength - longer values are rendered full width, otherwise
+// Mattermost squeezes them into half of the attachment and wraps mid-word
+const maxShortFieldLength = 30
+
 type notificationEnvelope struct {
-	Username string `json:"username"`
-	IconURL  string `json:"icon_url"`
-	Text     string `json:"text"`
+	Username    string       `json:"username"`
+	IconURL     string       `json:"icon_url"`
+	Text        string       `json:"text,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+type attachment struct {
+	Fallback  string            `json:"fallback"`
+	Color     string            `json:"color"`
+	Title     string            `json:"title"`
+	Text      string            `json:"text"`
+	Fields    []attachmentField `json:"fields,omitempty"`
+	Footer    string            `json:"footer,omitempty"`
+	Timestamp int64             `json:"ts,omitempty"`
+}
+
+type attachmentField struct {
+	Short bool   `json:"short"`
+	Title string `json:"title"`
+	Value string `json:"value"`
 }
 
 func (s *sender) Send(event types.EventNotification) error {
+	att := attachment{
+		Fallback: fmt.Sprintf("%s: %s", event.Type.String(), event.Message),
+		Color:    event.Level.Color(),
+		Title:    event.Type.String(),
+		Text:     event.Message,
+		Footer:   fmt.Sprintf("https://keel.sh %s", version.GetKeelVersion().Version),
+	}
+	if !event.CreatedAt.IsZero() {
+		att.Timestamp = event.CreatedAt.Unix()
+	}
+	if images := event.Metadata["images"]; images != "" {
+		att.Fields = append(att.Fields, newField("Image", strings.Split(images, ", ")...))
+	}
+	if tag := event.Metadata["version"]; tag != "" {
+		att.Fields = append(att.Fields, newField("New tag", tag))
+	}
+
 	// Marshal notification.
 	jsonNotification, err := json.Marshal(notificationEnvelope{
-		IconURL:  constants.KeelLogoURL,
-		Username: s.name,
-		Text:     fmt.Sprintf("#### %s \n %s", event.Type.String(), event.Message),
+		IconURL:     s.iconURL,
+		Username:    s.name,
+		Attachments: []attachment{att},
 	})
 	if err != nil {
 		return fmt.Errorf("could not marshal: %s", err)
@@ -110,3 +164,21 @@ func (s *sender) Send(event types.EventNotification) error {
 
 	return nil
 }
+
+// newField - renders each value as inline code on its own line so long image
+// names are not broken up by markdown, only short single values share a row
+func newField(title string, values ...string) attachmentField {
+	lines := make([]string, 0, len(values))
+	short := len(values) == 1
+	for _, v := range values {
+		lines = append(lines, "`"+v+"`")
+		if len(v) > maxShortFieldLength {
+			short = false
+		}
+	}
+	return attachmentField{
+		Short: short,
+		Title: title,
+		Value: strings.Join(lines, "\n"),
+	}
+}
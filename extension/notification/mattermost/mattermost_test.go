@@ -0,0 +1,83 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestMattermostRequest(t *testing.T) {
+	var got notificationEnvelope
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Errorf("failed to parse body: %s", err)
+		}
+		resp.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		name:     "keel-bot",
+		iconURL:  "https://example.com/icon.png",
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Name:      "update deployment",
+		Message:   "message here",
+		CreatedAt: time.Now(),
+		Type:      types.NotificationDeploymentUpdate,
+		Level:     types.LevelSuccess,
+		Metadata: map[string]string{
+			"images":  "karolisr/webhook-demo:0.0.2, registry.example.com/some/very/long/group/path/sidecar:0.0.2",
+			"version": "0.0.2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got.Username != "keel-bot" || got.IconURL != "https://example.com/icon.png" {
+		t.Errorf("expected username and icon overrides, got: %s, %s", got.Username, got.IconURL)
+	}
+	if len(got.Attachments) != 1 {
+		t.Fatalf("expected 1 attachment, got: %d", len(got.Attachments))
+	}
+	att := got.Attachments[0]
+	if att.Text != "message here" {
+		t.Errorf("unexpected text: %s", att.Text)
+	}
+	if att.Color != types.LevelSuccess.Color() {
+		t.Errorf("expected success color, got: %s", att.Color)
+	}
+	if len(att.Fields) != 2 {
+		t.Fatalf("expected 2 fields, got: %+v", att.Fields)
+	}
+	if att.Fields[0].Short {
+		t.Errorf("expected multiple images to use full width")
+	}
+	if att.Fields[0].Value != "`karolisr/webhook-demo:0.0.2`\n`registry.example.com/some/very/long/group/path/sidecar:0.0.2`" {
+		t.Errorf("unexpected images value: %s", att.Fields[0].Value)
+	}
+	if !att.Fields[1].Short || att.Fields[1].Value != "`0.0.2`" {
+		t.Errorf("unexpected version field: %+v", att.Fields[1])
+	}
+}
+
+func TestNewFieldLongValue(t *testing.T) {
+	f := newField("Image", "registry.example.com/some/very/long/group/path/app:1.0.0")
+	if f.Short {
+		t.Errorf("expected long value to use full width")
+	}
+	f = newField("Image", "app:1.0.0")
+	if !f.Short {
+		t.Errorf("expected short value to share a row")
+	}
+}
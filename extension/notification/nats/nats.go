@@ -0,0 +1,133 @@
+package nats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+// DefaultSubject - subject events are published to when NATS_SUBJECT is not set
+const DefaultSubject = "keel.events"
+
+type sender struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// Config represents the configuration of a NATS Sender.
+type Config struct {
+	URL     string
+	Subject string
+}
+
+// Event - message published for every notification, correlation ID
+// is unique per message so consumers can deduplicate and trace events
+type Event struct {
+	types.EventNotification
+	CorrelationID string `json:"correlationId"`
+}
+
+func init() {
+	notification.RegisterSender("nats", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var natsConfig Config
+
+	if os.Getenv(constants.EnvNatsURL) != "" {
+		natsConfig.URL = os.Getenv(constants.EnvNatsURL)
+	} else {
+		return false, nil
+	}
+
+	natsConfig.Subject = DefaultSubject
+	if os.Getenv(constants.EnvNatsSubject) != "" {
+		natsConfig.Subject = os.Getenv(constants.EnvNatsSubject)
+	}
+	if strings.ContainsAny(natsConfig.Subject, " \t\r\n") {
+		return false, fmt.Errorf("invalid subject '%s', subjects cannot contain whitespace", natsConfig.Subject)
+	}
+	s.subject = natsConfig.Subject
+
+	conn, err := connect(natsConfig.URL)
+	if err != nil {
+		return false, fmt.Errorf("could not connect to %s: %s", constants.EnvNatsURL, err)
+	}
+	s.conn = conn
+
+	log.WithFields(log.Fields{
+		"name":    "nats",
+		"subject": s.subject,
+	}).Info("extension.notification.nats: sender configured")
+
+	return true, nil
+}
+
+// connect - opens the connection shared by all sends, url accepts
+// nats://[user:pass@|token@]host[:port], tls:// scheme enables TLS. The
+// client keeps reconnecting in the background, messages published
+// while disconnected are buffered until the connection is back
+func connect(url string) (*nats.Conn, error) {
+	return nats.Connect(url,
+		nats.Name("keel"),
+		nats.Timeout(timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			if err == nil {
+				return
+			}
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Warn("extension.notification.nats: disconnected from server")
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.WithFields(log.Fields{
+				"server": conn.ConnectedUrlRedacted(),
+			}).Info("extension.notification.nats: reconnected to server")
+		}),
+		nats.ErrorHandler(func(conn *nats.Conn, _ *nats.Subscription, err error) {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("extension.notification.nats: server error")
+		}),
+	)
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	payload, err := json.Marshal(Event{
+		EventNotification: event,
+		CorrelationID:     uuid.New().String(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	if err := s.conn.Publish(s.subject, payload); err != nil {
+		return fmt.Errorf("could not publish: %s", err)
+	}
+
+	// while reconnecting the message stays in the reconnect buffer, flushing
+	// would only time out and the retry would publish it twice
+	if !s.conn.IsConnected() {
+		return nil
+	}
+	if err := s.conn.FlushTimeout(timeout); err != nil {
+		return fmt.Errorf("could not flush: %s", err)
+	}
+	return nil
+}
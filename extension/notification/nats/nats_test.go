@@ -0,0 +1,174 @@
+package nats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type connectOptions struct {
+	User string `json:"user"`
+	Pass string `json:"pass"`
+	Name string `json:"name"`
+}
+
+type published struct {
+	subject string
+	payload []byte
+}
+
+type fakeServer struct {
+	addr      string
+	published chan published
+
+	mu          sync.Mutex
+	connections int
+	connect     connectOptions
+}
+
+// newFakeServer - speaks enough of the NATS protocol to accept clients and
+// receive publishes, every PING is answered with PONG
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &fakeServer{addr: ln.Addr().String(), published: make(chan published, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			srv.mu.Lock()
+			srv.connections++
+			srv.mu.Unlock()
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (srv *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			srv.mu.Lock()
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &srv.connect)
+			srv.mu.Unlock()
+		case strings.HasPrefix(line, "PUB "):
+			parts := strings.Fields(line)
+			size, _ := strconv.Atoi(parts[len(parts)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			srv.published <- published{subject: parts[1], payload: payload[:size]}
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		}
+	}
+}
+
+func (srv *fakeServer) next(t *testing.T) published {
+	select {
+	case p := <-srv.published:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatalf("message wasn't published")
+	}
+	return published{}
+}
+
+func TestNatsPublish(t *testing.T) {
+	srv := newFakeServer(t)
+
+	conn, err := connect("nats://user:secret@" + srv.addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer conn.Close()
+	s := &sender{conn: conn, subject: "deployments"}
+
+	event := types.EventNotification{
+		Name:     "update deployment",
+		Message:  "Successfully updated deployment default/wd (karolisr/webhook-demo:0.0.15)",
+		Type:     types.NotificationDeploymentUpdate,
+		Level:    types.LevelSuccess,
+		Metadata: map[string]string{"namespace": "default", "name": "wd"},
+	}
+	if err := s.Send(event); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p := srv.next(t)
+	if p.subject != "deployments" {
+		t.Errorf("unexpected subject: %s", p.subject)
+	}
+	srv.mu.Lock()
+	if srv.connect.User != "user" || srv.connect.Pass != "secret" || srv.connect.Name != "keel" {
+		t.Errorf("unexpected connect options: %+v", srv.connect)
+	}
+	srv.mu.Unlock()
+
+	var got Event
+	if err := json.Unmarshal(p.payload, &got); err != nil {
+		t.Fatalf("failed to decode payload %s: %s", p.payload, err)
+	}
+	if got.CorrelationID == "" {
+		t.Errorf("expected correlation ID to be set")
+	}
+	if got.Message != event.Message || got.Type != event.Type || got.Level != event.Level {
+		t.Errorf("unexpected event: %+v", got)
+	}
+	if got.Metadata["name"] != "wd" {
+		t.Errorf("unexpected metadata: %v", got.Metadata)
+	}
+}
+
+func TestNatsReusesConnection(t *testing.T) {
+	srv := newFakeServer(t)
+
+	conn, err := connect(srv.addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer conn.Close()
+	s := &sender{conn: conn, subject: DefaultSubject}
+
+	for i := 0; i < 3; i++ {
+		if err := s.Send(types.EventNotification{Message: "hi"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if p := srv.next(t); p.subject != DefaultSubject {
+			t.Errorf("unexpected subject: %s", p.subject)
+		}
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.connections != 1 {
+		t.Errorf("expected a single connection, got: %d", srv.connections)
+	}
+}
@@ -3,19 +3,19 @@ package notification
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/stopper"
-	"github.com/keel-hq/keel/util/timeutil"
 
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	notifierCheckInterval       = 5 * time.Minute
-	notifierMaxBackOff          = 15 * time.Minute
 	notifierLockRefreshDuration = time.Minute * 2
 	notifierLockDuration        = time.Minute*8 + notifierLockRefreshDuration
 
@@ -23,6 +23,13 @@ const (
 	logNotiName   = "notification name"
 )
 
+// Default retry backoff, delay doubles after every failed attempt
+// until it reaches max
+const (
+	DefaultBackoffBase = time.Second
+	DefaultBackoffMax  = 2 * time.Minute
+)
+
 var (
 	sendersM sync.RWMutex
 	senders  = make(map[string]Sender)
@@ -33,7 +40,20 @@ var (
 type Config struct {
 	Attempts int
 	Level    types.Level
-	Params   map[string]interface{} `yaml:",inline"`
+	// SenderLevels - optional per sender minimum level, overrides Level
+	// for the named sender
+	SenderLevels map[string]types.Level
+	// BackoffBase and BackoffMax - delay before the first retry and upper
+	// bound for the delay, defaults are used when not set
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Templates - optional message templates per event kind (TemplatePreUpdate,
+	// TemplateUpdate, TemplateFailed), built-in messages are used otherwise
+	Templates map[string]*MessageTemplate
+	// BatchWindow - successful update notifications sent within the window
+	// are summarized in one notification, disabled when not set
+	BatchWindow time.Duration
+	Params      map[string]interface{} `yaml:",inline"`
 }
 
 // Sender represents anything that can transmit notifications.
@@ -78,6 +98,10 @@ type DefaultNotificationSender struct {
 	config  *Config
 	stopper *stopper.Stopper
 	level   types.Level
+
+	// batches - queued update notifications, keyed by channels
+	batchMu sync.Mutex
+	batches map[string]*batch
 }
 
 // New - create new sender
@@ -120,59 +144,141 @@ func (m *DefaultNotificationSender) Senders() map[string]Sender {
 
 // Send - send notifications through all configured senders
 func (m *DefaultNotificationSender) Send(event types.EventNotification) error {
-	if event.Level < m.config.Level {
-		return nil
+	event = m.applyTemplate(event)
+
+	senders := m.Senders()
+	if m.queue(event) {
+		// batch exempt senders still get the notification right away
+		for name, s := range senders {
+			if !isBatchExempt(s) {
+				delete(senders, name)
+			}
+		}
 	}
+	return m.send(event, senders)
+}
 
-	sendersM.RLock()
-	defer sendersM.RUnlock()
+// send - sends the notification through the senders, retrying failed ones
+func (m *DefaultNotificationSender) send(event types.EventNotification, senders map[string]Sender) error {
+	var failed []string
+	for senderName, sender := range senders {
+		if event.Level < m.senderLevel(senderName) {
+			continue
+		}
 
-	for senderName, sender := range m.Senders() {
 		// TODO: move this into goroutine if we have enough senders
 		var attempts int
 		var backOff time.Duration
 		for {
-			// Max attempts exceeded.
-			if attempts >= m.config.Attempts {
-				log.WithFields(log.Fields{
-					logNotiName:    event.Name,
-					logSenderName:  senderName,
-					"max attempts": m.config.Attempts,
-				}).Info("giving up on sending notification : max attempts exceeded")
-				return fmt.Errorf("failed to send notification, max attempts (%d) reached", m.config.Attempts)
+			// Send using the current notifier.
+			err := sender.Send(event)
+			if err == nil {
+				// Send has been successful. Go to the next notifier.
+				break
 			}
+			attempts++
 
-			// Backoff
-			if backOff > 0 {
-				log.WithFields(log.Fields{
-					"duration":     backOff,
+			// Max attempts exceeded.
+			if attempts >= m.config.Attempts {
+				log.WithError(err).WithFields(log.Fields{
 					logNotiName:    event.Name,
 					logSenderName:  senderName,
-					"attempts":     attempts + 1,
 					"max attempts": m.config.Attempts,
-				}).Info("waiting before retrying to send notification")
-				if !m.stopper.Sleep(backOff) {
-					return nil
-				}
+					"type":         event.Type.String(),
+					"level":        event.Level.String(),
+					"message":      event.Message,
+					"identifier":   event.Identifier,
+					"metadata":     event.Metadata,
+				}).Error("giving up on sending notification: max attempts exceeded")
+				failed = append(failed, senderName)
+				break
 			}
 
-			// Send using the current notifier.
-			if err := sender.Send(event); err != nil {
-				// Send failed; increase attempts/backoff and retry.
-				log.WithError(err).WithFields(log.Fields{logSenderName: senderName, logNotiName: event.Name}).Error("could not send notification via notifier")
-				backOff = timeutil.ExpBackoff(backOff, notifierMaxBackOff)
-				attempts++
-				continue
+			// Send failed; increase backoff and retry.
+			backOff = m.nextBackOff(backOff)
+			delay := jitter(backOff)
+			log.WithError(err).WithFields(log.Fields{
+				"duration":     delay,
+				logNotiName:    event.Name,
+				logSenderName:  senderName,
+				"attempts":     attempts,
+				"max attempts": m.config.Attempts,
+			}).Warn("could not send notification via notifier, waiting before retrying")
+			if !m.stopper.Sleep(delay) {
+				return nil
 			}
-
-			// Send has been successful. Go to the next notifier.
-			break
 		}
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to send notification via %s, max attempts (%d) reached", strings.Join(failed, ", "), m.config.Attempts)
+	}
+
 	return nil
 }
 
+// nextBackOff - doubles previous delay, starting with the configured base
+// and capped at the configured max
+func (m *DefaultNotificationSender) nextBackOff(prev time.Duration) time.Duration {
+	base, max := m.config.BackoffBase, m.config.BackoffMax
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	if max <= 0 {
+		max = DefaultBackoffMax
+	}
+	if max < base {
+		max = base
+	}
+
+	if prev <= 0 {
+		return base
+	}
+	if prev > max/2 {
+		return max
+	}
+	return 2 * prev
+}
+
+// jitter - randomizes delay between half and full duration so
+// retries from multiple instances don't hit the endpoint at once
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (m *DefaultNotificationSender) senderLevel(name string) types.Level {
+	if level, ok := m.config.SenderLevels[name]; ok {
+		return level
+	}
+	return m.config.Level
+}
+
+// ParseSenderLevels - parses per sender levels in "sender=level" form separated
+// by commas, for example "webhook=error,slack=debug"
+func ParseSenderLevels(value string) (map[string]types.Level, error) {
+	levels := make(map[string]types.Level)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid sender level '%s', expected sender=level", pair)
+		}
+		level, err := types.ParseLevel(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(parts[0])] = level
+	}
+	return levels, nil
+}
+
 // UnregisterSender removes a Sender with a particular name from the list.
 func (m *DefaultNotificationSender) UnregisterSender(name string) {
 	sendersM.Lock()
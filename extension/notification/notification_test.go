@@ -3,13 +3,16 @@ package notification
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/keel-hq/keel/types"
 )
 
 type fakeSender struct {
-	sent *types.EventNotification
+	sent  *types.EventNotification
+	calls int
 
 	shouldConfigure bool
 	shouldError     error
@@ -21,6 +24,7 @@ func (s *fakeSender) Configure(*Config) (bool, error) {
 
 func (s *fakeSender) Send(event types.EventNotification) error {
 	s.sent = &event
+	s.calls++
 	fmt.Println("sending event")
 	return s.shouldError
 }
@@ -162,3 +166,160 @@ func TestSendLevelNotificationC(t *testing.T) {
 		t.Errorf("unexpected level: %s", fs.sent.Level)
 	}
 }
+
+func TestSendSenderLevels(t *testing.T) {
+	sndr := New(context.Background())
+
+	sndr.Configure(&Config{
+		Level:    types.LevelInfo,
+		Attempts: 1,
+		SenderLevels: map[string]types.Level{
+			"failuresOnly": types.LevelError,
+			"everything":   types.LevelDebug,
+		},
+	})
+
+	failuresOnly := &fakeSender{shouldConfigure: true}
+	everything := &fakeSender{shouldConfigure: true}
+	defaults := &fakeSender{shouldConfigure: true}
+
+	RegisterSender("failuresOnly", failuresOnly)
+	defer sndr.UnregisterSender("failuresOnly")
+	RegisterSender("everything", everything)
+	defer sndr.UnregisterSender("everything")
+	RegisterSender("defaults", defaults)
+	defer sndr.UnregisterSender("defaults")
+
+	err := sndr.Send(types.EventNotification{
+		Level:   types.LevelSuccess,
+		Type:    types.NotificationDeploymentUpdate,
+		Message: "foo",
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if failuresOnly.sent != nil {
+		t.Errorf("didn't expect success event to be sent to failures only sender")
+	}
+	if everything.sent == nil {
+		t.Errorf("expected event to be sent to everything sender")
+	}
+	if defaults.sent == nil {
+		t.Errorf("expected event to be sent to sender without override")
+	}
+
+	err = sndr.Send(types.EventNotification{
+		Level:   types.LevelDebug,
+		Type:    types.NotificationPreDeploymentUpdate,
+		Message: "bar",
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if everything.sent.Message != "bar" {
+		t.Errorf("expected debug event to be sent to everything sender")
+	}
+	if defaults.sent.Message != "foo" {
+		t.Errorf("didn't expect debug event to be sent to sender without override")
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	sndr := New(context.Background())
+
+	sndr.Configure(&Config{
+		Level:       types.LevelInfo,
+		Attempts:    3,
+		BackoffBase: time.Millisecond,
+		BackoffMax:  2 * time.Millisecond,
+	})
+
+	failing := &fakeSender{shouldConfigure: true, shouldError: fmt.Errorf("endpoint down")}
+	working := &fakeSender{shouldConfigure: true}
+
+	RegisterSender("failing", failing)
+	defer sndr.UnregisterSender("failing")
+	RegisterSender("working", working)
+	defer sndr.UnregisterSender("working")
+
+	err := sndr.Send(types.EventNotification{
+		Level:   types.LevelError,
+		Type:    types.NotificationDeploymentUpdate,
+		Message: "foo",
+	})
+	if err == nil {
+		t.Fatalf("expected error when sender keeps failing")
+	}
+
+	if failing.calls != 3 {
+		t.Errorf("expected 3 attempts, got: %d", failing.calls)
+	}
+	// other senders still get the event
+	if working.calls != 1 {
+		t.Errorf("expected working sender to be called once, got: %d", working.calls)
+	}
+}
+
+func TestNextBackOff(t *testing.T) {
+	m := &DefaultNotificationSender{config: &Config{
+		BackoffBase: 100 * time.Millisecond,
+		BackoffMax:  time.Second,
+	}}
+
+	var got []time.Duration
+	var backOff time.Duration
+	for i := 0; i < 6; i++ {
+		backOff = m.nextBackOff(backOff)
+		got = append(got, backOff)
+	}
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected backoff: %v, want: %v", got, want)
+	}
+
+	// defaults
+	m.config = &Config{}
+	if d := m.nextBackOff(0); d != DefaultBackoffBase {
+		t.Errorf("unexpected default base: %s", d)
+	}
+	if d := m.nextBackOff(time.Hour); d != DefaultBackoffMax {
+		t.Errorf("unexpected default max: %s", d)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second)
+		if d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("jittered delay out of bounds: %s", d)
+		}
+	}
+}
+
+func TestParseSenderLevels(t *testing.T) {
+	levels, err := ParseSenderLevels("webhook=error, slack=debug")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if levels["webhook"] != types.LevelError {
+		t.Errorf("unexpected webhook level: %s", levels["webhook"])
+	}
+	if levels["slack"] != types.LevelDebug {
+		t.Errorf("unexpected slack level: %s", levels["slack"])
+	}
+
+	_, err = ParseSenderLevels("webhook")
+	if err == nil {
+		t.Errorf("expected error for missing level")
+	}
+}
@@ -0,0 +1,209 @@
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+const defaultEndpoint = "https://api.opsgenie.com"
+
+// defaultProductionNamespaces - failures in these namespaces get the highest priority
+const defaultProductionNamespaces = "production,prod"
+
+// maxMessageLength - alert message limit, full message is sent as description
+const maxMessageLength = 130
+
+type sender struct {
+	endpoint             string
+	apiKey               string
+	productionNamespaces map[string]bool
+	client               *http.Client
+}
+
+// Config represents the configuration of an OpsGenie Sender.
+type Config struct {
+	APIKey               string
+	Endpoint             string
+	ProductionNamespaces string
+}
+
+func init() {
+	notification.RegisterSender("opsgenie", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var ogConfig Config
+
+	if os.Getenv(constants.EnvOpsGenieAPIKey) != "" {
+		ogConfig.APIKey = os.Getenv(constants.EnvOpsGenieAPIKey)
+	} else {
+		return false, nil
+	}
+
+	ogConfig.Endpoint = defaultEndpoint
+	if os.Getenv(constants.EnvOpsGenieAPIURL) != "" {
+		ogConfig.Endpoint = os.Getenv(constants.EnvOpsGenieAPIURL)
+	}
+
+	ogConfig.ProductionNamespaces = defaultProductionNamespaces
+	if os.Getenv(constants.EnvOpsGenieProductionNamespaces) != "" {
+		ogConfig.ProductionNamespaces = os.Getenv(constants.EnvOpsGenieProductionNamespaces)
+	}
+
+	s.endpoint = strings.TrimSuffix(ogConfig.Endpoint, "/")
+	s.apiKey = ogConfig.APIKey
+	s.productionNamespaces = make(map[string]bool)
+	for _, ns := range strings.Split(ogConfig.ProductionNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			s.productionNamespaces[ns] = true
+		}
+	}
+
+	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name":     "opsgenie",
+		"endpoint": s.endpoint,
+	}).Info("extension.notification.opsgenie: sender configured")
+
+	return true, nil
+}
+
+type createAlertRequest struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+type closeAlertRequest struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Send - creates an alert when an update fails and closes it once the same
+// resource is updated successfully, other events are ignored. Errors are
+// returned so notification manager can retry.
+func (s *sender) Send(event types.EventNotification) error {
+	if event.Type != types.NotificationDeploymentUpdate && event.Type != types.NotificationReleaseUpdate {
+		return nil
+	}
+
+	switch event.Level {
+	case types.LevelError, types.LevelFatal:
+		return s.createAlert(event)
+	case types.LevelSuccess:
+		return s.closeAlert(event)
+	}
+	return nil
+}
+
+func (s *sender) createAlert(event types.EventNotification) error {
+	namespace := event.Metadata["namespace"]
+
+	req := createAlertRequest{
+		Message:     truncate(event.Message, maxMessageLength),
+		Alias:       alias(event),
+		Description: event.Message,
+		Priority:    s.priority(event),
+		Source:      "keel",
+		Entity:      event.Identifier,
+		Tags:        []string{"keel", event.Type.String()},
+		Details:     event.Metadata,
+	}
+	if namespace != "" {
+		req.Tags = append(req.Tags, namespace)
+	}
+
+	return s.post("/v2/alerts", req)
+}
+
+func (s *sender) closeAlert(event types.EventNotification) error {
+	path := fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(alias(event)))
+	return s.post(path, closeAlertRequest{
+		Source: "keel",
+		Note:   event.Message,
+	})
+}
+
+func (s *sender) post(path string, body interface{}) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	req, err := http.NewRequest("POST", s.endpoint+path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// OpsGenie processes alert requests asynchronously
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("got status %d, expected 202", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// priority - failures in production namespaces are P1, fatal errors
+// elsewhere P2 and other failures P3
+func (s *sender) priority(event types.EventNotification) string {
+	if s.productionNamespaces[event.Metadata["namespace"]] {
+		return "P1"
+	}
+	if event.Level == types.LevelFatal {
+		return "P2"
+	}
+	return "P3"
+}
+
+// alias - ties alert creation and closing of the same resource together
+func alias(event types.EventNotification) string {
+	namespace, name := event.Metadata["namespace"], event.Metadata["name"]
+	if namespace == "" && name == "" {
+		return "keel/" + event.Identifier
+	}
+	return fmt.Sprintf("keel/%s/%s", namespace, name)
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
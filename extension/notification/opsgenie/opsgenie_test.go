@@ -0,0 +1,132 @@
+package opsgenie
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+type request struct {
+	path   string
+	auth   string
+	create createAlertRequest
+	close  closeAlertRequest
+}
+
+func TestOpsGenieAlerts(t *testing.T) {
+	var got []request
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		r := request{path: req.URL.RequestURI(), auth: req.Header.Get("Authorization")}
+		var err error
+		if req.URL.Path == "/v2/alerts" {
+			err = json.NewDecoder(req.Body).Decode(&r.create)
+		} else {
+			err = json.NewDecoder(req.Body).Decode(&r.close)
+		}
+		if err != nil {
+			t.Errorf("failed to parse body: %s", err)
+		}
+		got = append(got, r)
+		resp.WriteHeader(http.StatusAccepted)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint:             ts.URL,
+		apiKey:               "api-key",
+		productionNamespaces: map[string]bool{"production": true},
+		client:               &http.Client{},
+	}
+
+	metadata := map[string]string{
+		"namespace": "production",
+		"name":      "app",
+		"version":   "0.0.2",
+	}
+
+	events := []types.EventNotification{
+		{Message: "preparing", Type: types.NotificationPreDeploymentUpdate, Level: types.LevelDebug, Metadata: metadata},
+		{Message: "update failed", Type: types.NotificationDeploymentUpdate, Level: types.LevelError, Metadata: metadata},
+		{Message: "approved", Type: types.NotificationUpdateApproved, Level: types.LevelSuccess, Metadata: metadata},
+		{Message: "updated", Type: types.NotificationDeploymentUpdate, Level: types.LevelSuccess, Metadata: metadata},
+	}
+	for _, event := range events {
+		if err := s.Send(event); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected create and close requests only, got: %d", len(got))
+	}
+
+	create := got[0]
+	if create.path != "/v2/alerts" || create.auth != "GenieKey api-key" {
+		t.Errorf("unexpected create request: %+v", create)
+	}
+	if create.create.Alias != "keel/production/app" {
+		t.Errorf("unexpected alias: %s", create.create.Alias)
+	}
+	if create.create.Priority != "P1" {
+		t.Errorf("expected production failure to be P1, got: %s", create.create.Priority)
+	}
+	if create.create.Details["version"] != "0.0.2" {
+		t.Errorf("expected metadata in details, got: %v", create.create.Details)
+	}
+
+	closed := got[1]
+	if closed.path != "/v2/alerts/keel%2Fproduction%2Fapp/close?identifierType=alias" {
+		t.Errorf("unexpected close path: %s", closed.path)
+	}
+	if closed.close.Note != "updated" {
+		t.Errorf("unexpected close note: %s", closed.close.Note)
+	}
+}
+
+func TestOpsGenieErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	s := &sender{endpoint: ts.URL, client: &http.Client{}}
+
+	err := s.Send(types.EventNotification{Message: "update failed", Type: types.NotificationDeploymentUpdate, Level: types.LevelError})
+	if err == nil {
+		t.Errorf("expected error to be returned so notification is retried")
+	}
+}
+
+func TestPriority(t *testing.T) {
+	s := &sender{productionNamespaces: map[string]bool{"production": true}}
+
+	tests := []struct {
+		namespace string
+		level     types.Level
+		want      string
+	}{
+		{"production", types.LevelError, "P1"},
+		{"staging", types.LevelFatal, "P2"},
+		{"staging", types.LevelError, "P3"},
+	}
+	for _, tt := range tests {
+		event := types.EventNotification{Level: tt.level, Metadata: map[string]string{"namespace": tt.namespace}}
+		if got := s.priority(event); got != tt.want {
+			t.Errorf("priority(%s, %s) = %s, want %s", tt.namespace, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("unexpected: %s", got)
+	}
+	if got := truncate("a very long message", 10); got != "a very ..." {
+		t.Errorf("unexpected: %s", got)
+	}
+}
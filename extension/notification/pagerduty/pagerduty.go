@@ -0,0 +1,162 @@
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+const defaultEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+// Events API v2 actions
+const (
+	actionTrigger = "trigger"
+	actionResolve = "resolve"
+)
+
+type sender struct {
+	endpoint   string
+	routingKey string
+	client     *http.Client
+}
+
+// Config represents the configuration of a PagerDuty Sender.
+type Config struct {
+	RoutingKey string
+}
+
+func init() {
+	notification.RegisterSender("pagerduty", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var pdConfig Config
+
+	if os.Getenv(constants.EnvPagerDutyRoutingKey) != "" {
+		pdConfig.RoutingKey = os.Getenv(constants.EnvPagerDutyRoutingKey)
+	} else {
+		return false, nil
+	}
+
+	s.endpoint = defaultEndpoint
+	s.routingKey = pdConfig.RoutingKey
+
+	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name": "pagerduty",
+	}).Info("extension.notification.pagerduty: sender configured")
+
+	return true, nil
+}
+
+type pagerdutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerdutyPayload `json:"payload,omitempty"`
+}
+
+type pagerdutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// Send - triggers an incident when an update fails and resolves it once
+// the same resource is updated successfully, other events are ignored
+func (s *sender) Send(event types.EventNotification) error {
+	if event.Type != types.NotificationDeploymentUpdate && event.Type != types.NotificationReleaseUpdate {
+		return nil
+	}
+
+	var action string
+	switch event.Level {
+	case types.LevelError, types.LevelFatal:
+		action = actionTrigger
+	case types.LevelSuccess:
+		action = actionResolve
+	default:
+		return nil
+	}
+
+	pdEvent := pagerdutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: action,
+		DedupKey:    dedupKey(event),
+	}
+
+	if action == actionTrigger {
+		pdEvent.Payload = &pagerdutyPayload{
+			Summary:       event.Message,
+			Source:        "keel",
+			Severity:      severity(event.Level),
+			Component:     event.Metadata["name"],
+			Group:         event.Metadata["namespace"],
+			Class:         event.Type.String(),
+			CustomDetails: event.Metadata,
+		}
+		if !event.CreatedAt.IsZero() {
+			pdEvent.Payload.Timestamp = event.CreatedAt.Format(time.RFC3339)
+		}
+	}
+
+	jsonEvent, err := json.Marshal(pdEvent)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonEvent))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// PagerDuty accepts events asynchronously
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("got status %d, expected 202", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// dedupKey - ties trigger and resolve events of the same resource together
+func dedupKey(event types.EventNotification) string {
+	namespace, name := event.Metadata["namespace"], event.Metadata["name"]
+	if namespace == "" && name == "" {
+		return "keel/" + event.Identifier
+	}
+	return fmt.Sprintf("keel/%s/%s", namespace, name)
+}
+
+func severity(level types.Level) string {
+	if level == types.LevelFatal {
+		return "critical"
+	}
+	return "error"
+}
@@ -0,0 +1,98 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestPagerDutyEvents(t *testing.T) {
+	var got []pagerdutyEvent
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		var ev pagerdutyEvent
+		if err := json.NewDecoder(req.Body).Decode(&ev); err != nil {
+			t.Errorf("failed to parse body: %s", err)
+		}
+		got = append(got, ev)
+		resp.WriteHeader(http.StatusAccepted)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint:   ts.URL,
+		routingKey: "routing-key",
+		client:     &http.Client{},
+	}
+
+	metadata := map[string]string{
+		"namespace": "production",
+		"name":      "app",
+		"version":   "0.0.2",
+	}
+
+	events := []types.EventNotification{
+		{Message: "preparing", Type: types.NotificationPreDeploymentUpdate, Level: types.LevelDebug, Metadata: metadata},
+		{Message: "update failed", Type: types.NotificationDeploymentUpdate, Level: types.LevelError, Metadata: metadata, CreatedAt: time.Now()},
+		{Message: "approved", Type: types.NotificationUpdateApproved, Level: types.LevelSuccess, Metadata: metadata},
+		{Message: "updated", Type: types.NotificationDeploymentUpdate, Level: types.LevelSuccess, Metadata: metadata},
+	}
+	for _, event := range events {
+		if err := s.Send(event); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected trigger and resolve events only, got: %d", len(got))
+	}
+
+	trigger := got[0]
+	if trigger.EventAction != actionTrigger || trigger.RoutingKey != "routing-key" {
+		t.Errorf("unexpected trigger event: %+v", trigger)
+	}
+	if trigger.DedupKey != "keel/production/app" {
+		t.Errorf("unexpected dedup key: %s", trigger.DedupKey)
+	}
+	if trigger.Payload == nil || trigger.Payload.Summary != "update failed" || trigger.Payload.Severity != "error" {
+		t.Errorf("unexpected payload: %+v", trigger.Payload)
+	}
+
+	resolve := got[1]
+	if resolve.EventAction != actionResolve || resolve.DedupKey != trigger.DedupKey {
+		t.Errorf("unexpected resolve event: %+v", resolve)
+	}
+	if resolve.Payload != nil {
+		t.Errorf("didn't expect payload on resolve: %+v", resolve.Payload)
+	}
+}
+
+func TestPagerDutyUnexpectedStatus(t *testing.T) {
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint:   ts.URL,
+		routingKey: "routing-key",
+		client:     &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Message:    "update failed",
+		Type:       types.NotificationReleaseUpdate,
+		Level:      types.LevelError,
+		Identifier: "chart/default/release",
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,32 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxRetryAfter - longest wait a rate limited sender is willing to wait for
+// inside a single send, anything longer is left to the notification sender
+// retries
+const MaxRetryAfter = 30 * time.Second
+
+// RetryRateLimited - calls send, when the service reports rate limiting waits
+// as instructed and tries once more. send returns whether it was rate limited
+// and how long the service asked to wait, a second is used when it didn't say
+func RetryRateLimited(send func() (limited bool, wait time.Duration, err error)) error {
+	limited, wait, err := send()
+	if err != nil || !limited {
+		return err
+	}
+
+	if wait <= 0 {
+		wait = time.Second
+	}
+	if wait > MaxRetryAfter {
+		return fmt.Errorf("rate limited, retry after %s", wait)
+	}
+	time.Sleep(wait)
+
+	_, _, err = send()
+	return err
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryRateLimited(t *testing.T) {
+	calls := 0
+	err := RetryRateLimited(func() (bool, time.Duration, error) {
+		calls++
+		return calls == 1, 10 * time.Millisecond, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected send to be retried once, got %d calls", calls)
+	}
+}
+
+func TestRetryRateLimitedWaitTooLong(t *testing.T) {
+	calls := 0
+	err := RetryRateLimited(func() (bool, time.Duration, error) {
+		calls++
+		return true, 2 * MaxRetryAfter, nil
+	})
+	if err == nil {
+		t.Fatalf("expected error when wait exceeds the limit")
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry, got %d calls", calls)
+	}
+}
+
+func TestRetryRateLimitedError(t *testing.T) {
+	calls := 0
+	err := RetryRateLimited(func() (bool, time.Duration, error) {
+		calls++
+		return false, 0, errors.New("connection refused")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected error without retry, got %v after %d calls", err, calls)
+	}
+}
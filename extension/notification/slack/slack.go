@@ -3,6 +3,7 @@ package slack
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -51,7 +52,11 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 		s.channels = []string{"general"}
 	}
 
-	s.slackClient = slack.New(token)
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
+	s.slackClient = slack.New(token, slack.OptionHTTPClient(&http.Client{Transport: transport}))
 
 	log.WithFields(log.Fields{
 		"name":     "slack",
@@ -53,8 +53,12 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	s.endpoint = httpConfig.Endpoint
 
 	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
 	s.client = &http.Client{
-		Transport: http.DefaultTransport,
+		Transport: transport,
 		Timeout:   timeout,
 	}
 
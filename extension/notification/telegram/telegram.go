@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/constants"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const timeout = 5 * time.Second
+
+const defaultEndpoint = "https://api.telegram.org"
+
+type sender struct {
+	endpoint string
+	token    string
+	chatID   string
+	client   *http.Client
+}
+
+// Config represents the configuration of a Telegram Sender.
+type Config struct {
+	BotToken string
+	ChatID   string
+}
+
+func init() {
+	notification.RegisterSender("telegram", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	httpConfig := Config{
+		BotToken: os.Getenv(constants.EnvTelegramBotToken),
+		ChatID:   os.Getenv(constants.EnvTelegramChatID),
+	}
+
+	if httpConfig.BotToken == "" && httpConfig.ChatID == "" {
+		return false, nil
+	}
+	if httpConfig.BotToken == "" || httpConfig.ChatID == "" {
+		return false, fmt.Errorf("both %s and %s must be set", constants.EnvTelegramBotToken, constants.EnvTelegramChatID)
+	}
+
+	s.endpoint = defaultEndpoint
+	s.token = httpConfig.BotToken
+	s.chatID = httpConfig.ChatID
+
+	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	log.WithFields(log.Fields{
+		"name":    "telegram",
+		"chat_id": s.chatID,
+	}).Info("extension.notification.telegram: sender configured")
+
+	return true, nil
+}
+
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// telegramResponse - Bot API response, parameters are only set on some errors
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (s *sender) Send(event types.EventNotification) error {
+	jsonNotification, err := json.Marshal(telegramMessage{
+		ChatID:                s.chatID,
+		Text:                  formatMessage(event),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	var (
+		status int
+		tr     telegramResponse
+	)
+	err = notification.RetryRateLimited(func() (bool, time.Duration, error) {
+		var err error
+		status, tr, err = s.post(jsonNotification)
+		if err != nil {
+			return false, 0, err
+		}
+		return status == http.StatusTooManyRequests, time.Duration(tr.Parameters.RetryAfter) * time.Second, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("got status %d, expected 200: %s", status, tr.Description)
+	}
+
+	return nil
+}
+
+func (s *sender) post(body []byte) (int, telegramResponse, error) {
+	var tr telegramResponse
+
+	resp, err := s.client.Post(fmt.Sprintf("%s/bot%s/sendMessage", s.endpoint, s.token), "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		// error includes request URL, don't leak the bot token into logs
+		return 0, tr, fmt.Errorf("failed to send message: %s", strings.Replace(err.Error(), s.token, "<token>", -1))
+	}
+	defer resp.Body.Close()
+
+	// body is informational only, status code is what matters
+	json.NewDecoder(resp.Body).Decode(&tr)
+
+	return resp.StatusCode, tr, nil
+}
+
+func formatMessage(event types.EventNotification) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s <b>%s</b>\n%s", levelIcon(event.Level), html.EscapeString(event.Type.String()), html.EscapeString(event.Message))
+	if images := event.Metadata["images"]; images != "" {
+		fmt.Fprintf(&b, "\n\n<b>Image:</b> <code>%s</code>", html.EscapeString(images))
+	}
+	if version := event.Metadata["version"]; version != "" {
+		fmt.Fprintf(&b, "\n<b>New tag:</b> <code>%s</code>", html.EscapeString(version))
+	}
+
+	return b.String()
+}
+
+func levelIcon(level types.Level) string {
+	switch level {
+	case types.LevelError, types.LevelFatal:
+		return "❌"
+	case types.LevelWarn:
+		return "⚠️"
+	case types.LevelSuccess:
+		return "✅"
+	default:
+		return "ℹ️"
+	}
+}
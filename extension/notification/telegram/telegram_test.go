@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestTelegramRequest(t *testing.T) {
+	var got telegramMessage
+	var path string
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Errorf("failed to parse body: %s", err)
+		}
+		resp.Write([]byte(`{"ok":true,"result":{}}`))
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		token:    "123:abc",
+		chatID:   "-10042",
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Name:      "update deployment",
+		Message:   "updated <default/app>",
+		CreatedAt: time.Now(),
+		Type:      types.NotificationDeploymentUpdate,
+		Level:     types.LevelSuccess,
+		Metadata: map[string]string{
+			"images":  "karolisr/webhook-demo:0.0.2",
+			"version": "0.0.2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if got.ChatID != "-10042" {
+		t.Errorf("unexpected chat ID: %s", got.ChatID)
+	}
+	if got.ParseMode != "HTML" {
+		t.Errorf("unexpected parse mode: %s", got.ParseMode)
+	}
+	if !strings.Contains(got.Text, "updated &lt;default/app&gt;") {
+		t.Errorf("expected escaped message, got: %s", got.Text)
+	}
+	if !strings.Contains(got.Text, "<code>karolisr/webhook-demo:0.0.2</code>") {
+		t.Errorf("expected image in message, got: %s", got.Text)
+	}
+}
+
+func TestTelegramRateLimited(t *testing.T) {
+	calls := 0
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			resp.WriteHeader(http.StatusTooManyRequests)
+			resp.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`))
+			return
+		}
+		resp.Write([]byte(`{"ok":true,"result":{}}`))
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		token:    "123:abc",
+		chatID:   "-10042",
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Message: "message here",
+		Type:    types.NotificationDeploymentUpdate,
+		Level:   types.LevelError,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected request to be retried once, got %d calls", calls)
+	}
+}
+
+func TestTelegramRateLimitedTooLong(t *testing.T) {
+	calls := 0
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+		resp.WriteHeader(http.StatusTooManyRequests)
+		resp.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 120","parameters":{"retry_after":120}}`))
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	s := &sender{
+		endpoint: ts.URL,
+		token:    "123:abc",
+		chatID:   "-10042",
+		client:   &http.Client{},
+	}
+
+	err := s.Send(types.EventNotification{
+		Message: "message here",
+		Type:    types.NotificationDeploymentUpdate,
+	})
+	if err == nil {
+		t.Fatalf("expected error when retry_after exceeds limit")
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry, got %d calls", calls)
+	}
+}
@@ -0,0 +1,102 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Event kinds message templates can be set for
+const (
+	TemplatePreUpdate = "pre-update"
+	TemplateUpdate    = "update"
+	TemplateFailed    = "failed"
+)
+
+// MessageTemplate - Go templates overriding notification title (event name) and
+// body (event message) before the notification is passed to senders. Templates
+// are executed with the event, i.e. "{{ .Metadata.name }} is now {{ .Metadata.version }}".
+type MessageTemplate struct {
+	Title *template.Template
+	Body  *template.Template
+}
+
+// ParseMessageTemplate - parses title and body templates, built-in text is kept
+// for an empty template
+func ParseMessageTemplate(kind, title, body string) (*MessageTemplate, error) {
+	t := &MessageTemplate{}
+	var err error
+	if title != "" {
+		t.Title, err = template.New(kind + " title").Option("missingkey=zero").Parse(title)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s title template: %s", kind, err)
+		}
+	}
+	if body != "" {
+		t.Body, err = template.New(kind + " body").Option("missingkey=zero").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s body template: %s", kind, err)
+		}
+	}
+	return t, nil
+}
+
+// templateKind - gets event kind for the notification, empty for events
+// that can't be templated. Only notifications of the update itself are, dry
+// run, annotate-only, freeze and rollback notifications keep their messages
+func templateKind(event types.EventNotification) string {
+	switch event.Name {
+	case types.EventNamePreUpdateResource, types.EventNameUpdateResource, types.EventNameUpdateRelease:
+	default:
+		return ""
+	}
+	switch event.Type {
+	case types.NotificationPreDeploymentUpdate, types.NotificationPreReleaseUpdate:
+		return TemplatePreUpdate
+	case types.NotificationDeploymentUpdate, types.NotificationReleaseUpdate:
+		if event.Level >= types.LevelError {
+			return TemplateFailed
+		}
+		return TemplateUpdate
+	}
+	return ""
+}
+
+// applyTemplate - renders configured templates for the event, event is sent
+// unchanged if rendering fails
+func (m *DefaultNotificationSender) applyTemplate(event types.EventNotification) types.EventNotification {
+	if m.config == nil || len(m.config.Templates) == 0 {
+		return event
+	}
+	t, ok := m.config.Templates[templateKind(event)]
+	if !ok || t == nil {
+		return event
+	}
+
+	rendered := event
+	for _, field := range []struct {
+		tpl    *template.Template
+		target *string
+	}{
+		{t.Title, &rendered.Name},
+		{t.Body, &rendered.Message},
+	} {
+		if field.tpl == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := field.tpl.Execute(&buf, event); err != nil {
+			log.WithFields(log.Fields{
+				"error":    err,
+				"template": field.tpl.Name(),
+			}).Error("extension.notification: failed to render message template, using default message")
+			return event
+		}
+		*field.target = buf.String()
+	}
+	return rendered
+}
@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestSendTemplated(t *testing.T) {
+	update, err := ParseMessageTemplate(TemplateUpdate, "{{ .Metadata.name }} updated", "{{ .Metadata.name }} is now {{ .Metadata.version }}")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	failed, err := ParseMessageTemplate(TemplateFailed, "", "{{ .Metadata.name }} failed: {{ .Message }}")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sndr := New(context.Background())
+	sndr.Configure(&Config{
+		Level:    types.LevelDebug,
+		Attempts: 1,
+		Templates: map[string]*MessageTemplate{
+			TemplateUpdate: update,
+			TemplateFailed: failed,
+		},
+	})
+
+	fs := &fakeSender{shouldConfigure: true}
+	RegisterSender("fakeSender", fs)
+	defer sndr.UnregisterSender("fakeSender")
+
+	metadata := map[string]string{"name": "wd", "version": "1.1.2"}
+	tests := []struct {
+		event       types.EventNotification
+		wantName    string
+		wantMessage string
+	}{
+		{
+			event:       types.EventNotification{Name: "update resource", Message: "default", Type: types.NotificationDeploymentUpdate, Level: types.LevelSuccess, Metadata: metadata},
+			wantName:    "wd updated",
+			wantMessage: "wd is now 1.1.2",
+		},
+		{
+			event:       types.EventNotification{Name: "update resource", Message: "boom", Type: types.NotificationReleaseUpdate, Level: types.LevelError, Metadata: metadata},
+			wantName:    "update resource",
+			wantMessage: "wd failed: boom",
+		},
+		{
+			// not an update, dry run and rollback notifications keep their messages
+			event:       types.EventNotification{Name: "dry run update resource", Message: "default", Type: types.NotificationDeploymentUpdate, Level: types.LevelSuccess, Metadata: metadata},
+			wantName:    "dry run update resource",
+			wantMessage: "default",
+		},
+		{
+			event:       types.EventNotification{Name: "rollback resource", Message: "rolled back", Type: types.NotificationDeploymentUpdate, Level: types.LevelError, Metadata: metadata},
+			wantName:    "rollback resource",
+			wantMessage: "rolled back",
+		},
+		{
+			// no pre-update template, built-in message is kept
+			event:       types.EventNotification{Name: "preparing to update resource", Message: "default", Type: types.NotificationPreDeploymentUpdate, Level: types.LevelDebug, Metadata: metadata},
+			wantName:    "preparing to update resource",
+			wantMessage: "default",
+		},
+	}
+
+	for _, tt := range tests {
+		if err := sndr.Send(tt.event); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if fs.sent.Name != tt.wantName {
+			t.Errorf("expected name %q, got: %q", tt.wantName, fs.sent.Name)
+		}
+		if fs.sent.Message != tt.wantMessage {
+			t.Errorf("expected message %q, got: %q", tt.wantMessage, fs.sent.Message)
+		}
+	}
+}
+
+func TestParseMessageTemplateInvalid(t *testing.T) {
+	if _, err := ParseMessageTemplate(TemplateUpdate, "{{ .Name", ""); err == nil {
+		t.Errorf("expected error for invalid template")
+	}
+}
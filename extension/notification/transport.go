@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/keel-hq/keel/constants"
+)
+
+// Transport - HTTP transport for senders, trusts CAs from NOTIFICATION_CA_FILE
+// and sends requests through NOTIFICATION_PROXY when set. Default transport
+// is returned otherwise
+func Transport() (http.RoundTripper, error) {
+	caFile := os.Getenv(constants.EnvNotificationCAFile)
+	proxy := os.Getenv(constants.EnvNotificationProxy)
+	if caFile == "" && proxy == "" {
+		return http.DefaultTransport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid %s '%s'", constants.EnvNotificationProxy, proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", constants.EnvNotificationCAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keel-hq/keel/constants"
+)
+
+func TestTransportDefault(t *testing.T) {
+	os.Unsetenv(constants.EnvNotificationCAFile)
+	os.Unsetenv(constants.EnvNotificationProxy)
+
+	transport, err := Transport()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if transport != http.DefaultTransport {
+		t.Errorf("expected default transport when nothing is configured")
+	}
+}
+
+func TestTransportCAFile(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, cert, 0600); err != nil {
+		t.Fatalf("failed to write CA file: %s", err)
+	}
+
+	// server certificate isn't trusted by default
+	if _, err := (&http.Client{}).Get(ts.URL); err == nil {
+		t.Fatalf("expected certificate verification to fail")
+	}
+
+	os.Setenv(constants.EnvNotificationCAFile, caFile)
+	defer os.Unsetenv(constants.EnvNotificationCAFile)
+
+	transport, err := Transport()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	resp.Body.Close()
+
+	os.Setenv(constants.EnvNotificationCAFile, filepath.Join(t.TempDir(), "missing.pem"))
+	if _, err := Transport(); err == nil {
+		t.Errorf("expected error for missing CA file")
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	os.Setenv(constants.EnvNotificationProxy, "http://proxy.corp:3128")
+	defer os.Unsetenv(constants.EnvNotificationProxy)
+
+	transport, err := Transport()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req, _ := http.NewRequest("POST", "https://hooks.slack.com/services/x", nil)
+	proxy, err := transport.(*http.Transport).Proxy(req)
+	if err != nil || proxy == nil || proxy.String() != "http://proxy.corp:3128" {
+		t.Errorf("unexpected proxy: %v, %v", proxy, err)
+	}
+
+	os.Setenv(constants.EnvNotificationProxy, "proxy.corp")
+	if _, err := Transport(); err == nil {
+		t.Errorf("expected error for invalid proxy URL")
+	}
+}
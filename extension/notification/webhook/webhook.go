@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/keel-hq/keel/constants"
@@ -21,11 +23,18 @@ const timeout = 5 * time.Second
 type sender struct {
 	endpoint string
 	client   *http.Client
+
+	// optional body template and extra headers, default JSON envelope
+	// is sent when template is not set
+	template *template.Template
+	headers  map[string]string
 }
 
 // Config represents the configuration of a Webhook Sender.
 type Config struct {
 	Endpoint string
+	Template string
+	Headers  string
 }
 
 func init() {
@@ -51,15 +60,36 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	}
 	s.endpoint = httpConfig.Endpoint
 
+	httpConfig.Template = os.Getenv(constants.EnvWebhookTemplate)
+	if httpConfig.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(httpConfig.Template)
+		if err != nil {
+			return false, fmt.Errorf("could not parse %s: %s", constants.EnvWebhookTemplate, err)
+		}
+		s.template = tmpl
+	}
+
+	httpConfig.Headers = os.Getenv(constants.EnvWebhookHeaders)
+	headers, err := parseHeaders(httpConfig.Headers)
+	if err != nil {
+		return false, fmt.Errorf("could not parse %s: %s", constants.EnvWebhookHeaders, err)
+	}
+	s.headers = headers
+
 	// Setup HTTP client.
+	transport, err := notification.Transport()
+	if err != nil {
+		return false, err
+	}
 	s.client = &http.Client{
-		Transport: http.DefaultTransport,
+		Transport: transport,
 		Timeout:   timeout,
 	}
 
 	log.WithFields(log.Fields{
 		"name":     "webhook",
 		"endpoint": s.endpoint,
+		"template": s.template != nil,
 	}).Info("extension.notification.webhook: sender configured")
 
 	return true, nil
@@ -69,15 +99,106 @@ type notificationEnvelope struct {
 	types.EventNotification
 }
 
+// templateData - fields available in the body template
+type templateData struct {
+	Name         string
+	Message      string
+	Type         string
+	Level        string
+	ResourceKind string
+	Identifier   string
+	Provider     string
+	Namespace    string
+	Deployment   string
+	OldImage     string
+	NewImage     string
+	OldVersion   string
+	NewVersion   string
+	Timestamp    time.Time
+	Metadata     map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	// json - encodes value so it can be safely embedded into JSON body
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func newTemplateData(event types.EventNotification) templateData {
+	return templateData{
+		Name:         event.Name,
+		Message:      event.Message,
+		Type:         event.Type.String(),
+		Level:        event.Level.String(),
+		ResourceKind: event.ResourceKind,
+		Identifier:   event.Identifier,
+		Provider:     event.Metadata["provider"],
+		Namespace:    event.Metadata["namespace"],
+		Deployment:   event.Metadata["name"],
+		OldImage:     event.Metadata["previous_images"],
+		NewImage:     event.Metadata["images"],
+		OldVersion:   event.Metadata["previous_version"],
+		NewVersion:   event.Metadata["version"],
+		Timestamp:    event.CreatedAt,
+		Metadata:     event.Metadata,
+	}
+}
+
+// parseHeaders - parses "Name: value" pairs, one per line. Header values
+// can't contain new lines but commas are common (i.e. Accept), so only new
+// lines separate headers
+func parseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid header '%s', expected 'Name: value'", line)
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+func (s *sender) body(event types.EventNotification) ([]byte, error) {
+	if s.template == nil {
+		// Marshal notification.
+		jsonNotification, err := json.Marshal(notificationEnvelope{event})
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal: %s", err)
+		}
+		return jsonNotification, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := s.template.Execute(buf, newTemplateData(event)); err != nil {
+		return nil, fmt.Errorf("could not execute template: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
 func (s *sender) Send(event types.EventNotification) error {
-	// Marshal notification.
-	jsonNotification, err := json.Marshal(notificationEnvelope{event})
+	body, err := s.body(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("could not marshal: %s", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
 	}
 
 	// Send notification via HTTP POST.
-	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonNotification))
+	resp, err := s.client.Do(req)
 	if err != nil || resp == nil || (resp.StatusCode != 200 && resp.StatusCode != 201) {
 		if resp != nil {
 			return fmt.Errorf("got status %d, expected 200/201", resp.StatusCode)
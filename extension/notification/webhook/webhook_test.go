@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/keel-hq/keel/types"
@@ -57,3 +58,77 @@ func TestWebhookRequest(t *testing.T) {
 		Level:     types.LevelDebug,
 	})
 }
+
+func TestWebhookTemplate(t *testing.T) {
+	var body string
+	var header http.Header
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %s", err)
+		}
+		body = string(b)
+		header = req.Header
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(`{"summary": {{ json .Message }}, "target": "{{ .Namespace }}/{{ .Deployment }}", "from": "{{ .OldImage }}", "to": "{{ .NewImage }}", "type": "{{ .Type }}", "at": "{{ .Timestamp.Format "2006-01-02" }}"}`)
+	if err != nil {
+		t.Fatalf("failed to parse template: %s", err)
+	}
+	headers, err := parseHeaders("X-Api-Key: secret\nx-source: keel")
+	if err != nil {
+		t.Fatalf("failed to parse headers: %s", err)
+	}
+
+	s := &sender{
+		endpoint: ts.URL,
+		client:   &http.Client{},
+		template: tmpl,
+		headers:  headers,
+	}
+
+	err = s.Send(types.EventNotification{
+		Name:      "update deployment",
+		Message:   `updated "app"`,
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Type:      types.NotificationDeploymentUpdate,
+		Level:     types.LevelSuccess,
+		Metadata: map[string]string{
+			"namespace":       "default",
+			"name":            "app",
+			"previous_images": "karolisr/app:1.0.0",
+			"images":          "karolisr/app:1.0.1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"summary": "updated \"app\"", "target": "default/app", "from": "karolisr/app:1.0.0", "to": "karolisr/app:1.0.1", "type": "deployment update", "at": "2020-01-02"}`
+	if body != expected {
+		t.Errorf("unexpected body:\n%s\nexpected:\n%s", body, expected)
+	}
+	if header.Get("X-Api-Key") != "secret" || header.Get("X-Source") != "keel" {
+		t.Errorf("missing custom headers: %v", header)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders("Authorization: Bearer abc\r\nX-Team: ops\n\nAccept: application/json, text/plain\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if headers["Authorization"] != "Bearer abc" || headers["X-Team"] != "ops" {
+		t.Errorf("unexpected headers: %v", headers)
+	}
+	if headers["Accept"] != "application/json, text/plain" {
+		t.Errorf("expected comma to be kept in header value, got: %s", headers["Accept"])
+	}
+
+	if _, err := parseHeaders("no separator"); err == nil {
+		t.Errorf("expected error for invalid header")
+	}
+}
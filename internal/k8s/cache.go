@@ -28,6 +28,18 @@ func (cc *genericResourceCache) Values() []*GenericResource {
 	return r
 }
 
+// Get returns a copy of the entry with the given identifier.
+func (cc *genericResourceCache) Get(identifier string) (*GenericResource, bool) {
+	cc.Lock()
+	defer cc.Unlock()
+	for _, v := range cc.values {
+		if v.Identifier == identifier {
+			return v.DeepCopy(), true
+		}
+	}
+	return nil, false
+}
+
 // Add adds an entry to the cache. If a GenericResource with the same
 // name exists, it is replaced.
 func (cc *genericResourceCache) Add(grs ...*GenericResource) {
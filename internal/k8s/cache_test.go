@@ -50,4 +50,16 @@ func TestAddGet(t *testing.T) {
 	if stored2.Containers()[0].Image != "gcr.io/v2-namespace/hi-world:1.1.1" {
 		t.Errorf("cached entry got modified: %s", stored2.Containers()[0].Image)
 	}
+
+	fetched, ok := cc.Get(gr.Identifier)
+	if !ok {
+		t.Fatalf("cached entry not found: %s", gr.Identifier)
+	}
+	fetched.UpdateContainer(0, "gcr.io/v2-namespace/hi-world:2.2.2.")
+	if stored3, _ := cc.Get(gr.Identifier); stored3.Containers()[0].Image != "gcr.io/v2-namespace/hi-world:1.1.1" {
+		t.Errorf("cached entry got modified: %s", stored3.Containers()[0].Image)
+	}
+	if _, ok := cc.Get("deployment/xxxx/missing"); ok {
+		t.Errorf("expected missing entry not to be found")
+	}
 }
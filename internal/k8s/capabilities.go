@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+// Capabilities - workload resources served by the cluster in the API versions
+// keel supports (apps/v1, batch/v1 and OpenShift apps.openshift.io/v1)
+type Capabilities struct {
+	Deployments       bool
+	StatefulSets      bool
+	DaemonSets        bool
+	CronJobs          bool
+	DeploymentConfigs bool
+}
+
+// DetectCapabilities - checks which workload resources the cluster serves so
+// watchers aren't started for API groups that older clusters don't have. If
+// discovery fails for reasons other than a missing group version, resources
+// are assumed to be available. OpenShift resources are only enabled when the
+// cluster is known to serve them.
+func DetectCapabilities(client discovery.ServerResourcesInterface, log logrus.FieldLogger) Capabilities {
+	apps := serverResources(client, "apps/v1", true, log)
+	batch := serverResources(client, "batch/v1", true, log)
+	openshift := serverResources(client, DeploymentConfigGroupVersion, false, log)
+
+	return Capabilities{
+		Deployments:       apps("deployments"),
+		StatefulSets:      apps("statefulsets"),
+		DaemonSets:        apps("daemonsets"),
+		CronJobs:          batch("cronjobs"),
+		DeploymentConfigs: openshift(DeploymentConfigResource.Resource),
+	}
+}
+
+// serverResources - returns func reporting whether group version serves the
+// resource, required group versions are logged when they are missing and
+// assumed to be available when discovery fails
+func serverResources(client discovery.ServerResourcesInterface, groupVersion string, required bool, log logrus.FieldLogger) func(resource string) bool {
+	list, err := client.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if required {
+				log.WithField("group_version", groupVersion).Warn("k8s: group version not served by the cluster")
+			}
+			return func(string) bool { return false }
+		}
+		if !required {
+			log.WithFields(logrus.Fields{
+				"error":         err,
+				"group_version": groupVersion,
+			}).Warn("k8s: failed to discover optional resources, assuming they are not available")
+			return func(string) bool { return false }
+		}
+		log.WithFields(logrus.Fields{
+			"error":         err,
+			"group_version": groupVersion,
+		}).Warn("k8s: failed to discover resources, assuming they are available")
+		return func(string) bool { return true }
+	}
+
+	served := make(map[string]bool)
+	for _, r := range list.APIResources {
+		served[r.Name] = true
+	}
+	return func(resource string) bool { return served[resource] }
+}
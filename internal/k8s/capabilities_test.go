@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*meta_v1.APIResourceList
+		want      Capabilities
+	}{
+		{
+			name: "apps/v1 and batch/v1",
+			resources: []*meta_v1.APIResourceList{
+				{
+					GroupVersion: "apps/v1",
+					APIResources: []meta_v1.APIResource{{Name: "deployments"}, {Name: "statefulsets"}, {Name: "daemonsets"}, {Name: "replicasets"}},
+				},
+				{
+					GroupVersion: "batch/v1",
+					APIResources: []meta_v1.APIResource{{Name: "jobs"}, {Name: "cronjobs"}},
+				},
+			},
+			want: Capabilities{Deployments: true, StatefulSets: true, DaemonSets: true, CronJobs: true},
+		},
+		{
+			name: "openshift",
+			resources: []*meta_v1.APIResourceList{
+				{
+					GroupVersion: "apps/v1",
+					APIResources: []meta_v1.APIResource{{Name: "deployments"}, {Name: "statefulsets"}, {Name: "daemonsets"}},
+				},
+				{
+					GroupVersion: "batch/v1",
+					APIResources: []meta_v1.APIResource{{Name: "jobs"}, {Name: "cronjobs"}},
+				},
+				{
+					GroupVersion: "apps.openshift.io/v1",
+					APIResources: []meta_v1.APIResource{{Name: "deploymentconfigs"}},
+				},
+			},
+			want: Capabilities{Deployments: true, StatefulSets: true, DaemonSets: true, CronJobs: true, DeploymentConfigs: true},
+		},
+		{
+			name: "cronjobs only in batch/v1beta1",
+			resources: []*meta_v1.APIResourceList{
+				{
+					GroupVersion: "apps/v1",
+					APIResources: []meta_v1.APIResource{{Name: "deployments"}, {Name: "statefulsets"}, {Name: "daemonsets"}},
+				},
+				{
+					GroupVersion: "batch/v1",
+					APIResources: []meta_v1.APIResource{{Name: "jobs"}},
+				},
+				{
+					GroupVersion: "batch/v1beta1",
+					APIResources: []meta_v1.APIResource{{Name: "cronjobs"}},
+				},
+			},
+			want: Capabilities{Deployments: true, StatefulSets: true, DaemonSets: true},
+		},
+		{
+			name: "no apps/v1",
+			resources: []*meta_v1.APIResourceList{
+				{
+					GroupVersion: "extensions/v1beta1",
+					APIResources: []meta_v1.APIResource{{Name: "deployments"}},
+				},
+			},
+			want: Capabilities{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: tt.resources}}
+			if got := DetectCapabilities(client, logrus.New()); got != tt.want {
+				t.Errorf("DetectCapabilities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type failingDiscovery struct {
+	discovery.ServerResourcesInterface
+}
+
+func (d *failingDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*meta_v1.APIResourceList, error) {
+	return nil, errors.New("forbidden")
+}
+
+func TestDetectCapabilitiesDiscoveryError(t *testing.T) {
+	want := Capabilities{Deployments: true, StatefulSets: true, DaemonSets: true, CronJobs: true}
+	if got := DetectCapabilities(&failingDiscovery{}, logrus.New()); got != want {
+		t.Errorf("expected all resources to be assumed available, got: %+v", got)
+	}
+}
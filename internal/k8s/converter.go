@@ -33,6 +33,10 @@ func updateDeploymentContainer(d *apps_v1.Deployment, index int, image string) {
 	d.Spec.Template.Spec.Containers[index].Image = image
 }
 
+func updateDeploymentInitContainer(d *apps_v1.Deployment, index int, image string) {
+	d.Spec.Template.Spec.InitContainers[index].Image = image
+}
+
 // stateful sets https://kubernetes.io/docs/tutorials/stateful-application/basic-stateful-set/
 func getStatefulSetIdentifier(ss *apps_v1.StatefulSet) string {
 	return "statefulset/" + ss.Namespace + "/" + ss.Name
@@ -42,6 +46,10 @@ func updateStatefulSetContainer(ss *apps_v1.StatefulSet, index int, image string
 	ss.Spec.Template.Spec.Containers[index].Image = image
 }
 
+func updateStatefulSetInitContainer(ss *apps_v1.StatefulSet, index int, image string) {
+	ss.Spec.Template.Spec.InitContainers[index].Image = image
+}
+
 // daemonsets
 
 func getDaemonsetSetIdentifier(s *apps_v1.DaemonSet) string {
@@ -52,6 +60,10 @@ func updateDaemonsetSetContainer(s *apps_v1.DaemonSet, index int, image string)
 	s.Spec.Template.Spec.Containers[index].Image = image
 }
 
+func updateDaemonsetSetInitContainer(s *apps_v1.DaemonSet, index int, image string) {
+	s.Spec.Template.Spec.InitContainers[index].Image = image
+}
+
 // cron
 
 func getCronJobIdentifier(s *batch_v1.CronJob) string {
@@ -61,3 +73,7 @@ func getCronJobIdentifier(s *batch_v1.CronJob) string {
 func updateCronJobContainer(s *batch_v1.CronJob, index int, image string) {
 	s.Spec.JobTemplate.Spec.Template.Spec.Containers[index].Image = image
 }
+
+func updateCronJobInitContainer(s *batch_v1.CronJob, index int, image string) {
+	s.Spec.JobTemplate.Spec.Template.Spec.InitContainers[index].Image = image
+}
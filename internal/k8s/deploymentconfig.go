@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// OpenShift DeploymentConfigs are handled as unstructured objects, OpenShift
+// API types aren't vendored and updates must not drop fields keel doesn't know
+
+// DeploymentConfigGroupVersion - API group version serving DeploymentConfigs
+const DeploymentConfigGroupVersion = "apps.openshift.io/v1"
+
+// DeploymentConfigResource - OpenShift DeploymentConfig resource
+var DeploymentConfigResource = schema.GroupVersionResource{Group: "apps.openshift.io", Version: "v1", Resource: "deploymentconfigs"}
+
+const deploymentConfigKind = "DeploymentConfig"
+
+// isDeploymentConfig - checks whether unstructured object is a DeploymentConfig
+func isDeploymentConfig(u *unstructured.Unstructured) bool {
+	return u.GetAPIVersion() == DeploymentConfigGroupVersion && u.GetKind() == deploymentConfigKind
+}
+
+func getDeploymentConfigIdentifier(dc *unstructured.Unstructured) string {
+	return "deploymentconfig/" + dc.GetNamespace() + "/" + dc.GetName()
+}
+
+// deploymentConfigPodSpec - pod template spec of the DeploymentConfig, empty
+// when it can't be converted
+func deploymentConfigPodSpec(dc *unstructured.Unstructured) core_v1.PodSpec {
+	var spec core_v1.PodSpec
+	obj, found, err := unstructured.NestedMap(dc.Object, "spec", "template", "spec")
+	if err != nil || !found {
+		return spec
+	}
+	runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &spec)
+	return spec
+}
+
+func getDeploymentConfigSpecAnnotations(dc *unstructured.Unstructured) map[string]string {
+	annotations, _, _ := unstructured.NestedStringMap(dc.Object, "spec", "template", "metadata", "annotations")
+	return annotations
+}
+
+func setDeploymentConfigSpecAnnotations(dc *unstructured.Unstructured, annotations map[string]string) {
+	unstructured.SetNestedStringMap(dc.Object, annotations, "spec", "template", "metadata", "annotations")
+}
+
+// updateDeploymentConfigImage - sets image of the container at index, field
+// is either "containers" or "initContainers"
+func updateDeploymentConfigImage(dc *unstructured.Unstructured, field string, index int, image string) {
+	containers, found, err := unstructured.NestedSlice(dc.Object, "spec", "template", "spec", field)
+	if err != nil || !found || index >= len(containers) {
+		return
+	}
+	container, ok := containers[index].(map[string]interface{})
+	if !ok {
+		return
+	}
+	container["image"] = image
+	unstructured.SetNestedSlice(dc.Object, containers, "spec", "template", "spec", field)
+}
+
+func getDeploymentConfigStatus(dc *unstructured.Unstructured) Status {
+	field := func(name string) int32 {
+		value, _, _ := unstructured.NestedInt64(dc.Object, "status", name)
+		return int32(value)
+	}
+	return Status{
+		Replicas:            field("replicas"),
+		UpdatedReplicas:     field("updatedReplicas"),
+		ReadyReplicas:       field("readyReplicas"),
+		AvailableReplicas:   field("availableReplicas"),
+		UnavailableReplicas: field("unavailableReplicas"),
+	}
+}
+
+// deploymentConfigTriggers - triggers of the given type ("ConfigChange" or
+// "ImageChange")
+func deploymentConfigTriggers(dc *unstructured.Unstructured, triggerType string) (triggers []map[string]interface{}) {
+	all, _, _ := unstructured.NestedSlice(dc.Object, "spec", "triggers")
+	for _, t := range all {
+		trigger, ok := t.(map[string]interface{})
+		if !ok || trigger["type"] != triggerType {
+			continue
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers
+}
+
+// deploymentConfigImageTriggered - checks whether the container image is set
+// by an automatic ImageChange trigger. The trigger controller resolves the
+// image from its image stream tag and would revert any image keel sets
+func deploymentConfigImageTriggered(dc *unstructured.Unstructured, container string) bool {
+	for _, trigger := range deploymentConfigTriggers(dc, "ImageChange") {
+		automatic, _, _ := unstructured.NestedBool(trigger, "imageChangeParams", "automatic")
+		if !automatic {
+			continue
+		}
+		names, _, _ := unstructured.NestedStringSlice(trigger, "imageChangeParams", "containerNames")
+		for _, name := range names {
+			if name == container {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DeploymentConfigRolloutRequest - returns the DeploymentRequest to post to the
+// instantiate subresource when the DeploymentConfig has no ConfigChange trigger
+// and won't roll out template changes on its own, nil otherwise
+func DeploymentConfigRolloutRequest(dc *unstructured.Unstructured) *unstructured.Unstructured {
+	if len(deploymentConfigTriggers(dc, "ConfigChange")) > 0 {
+		return nil
+	}
+	req := &unstructured.Unstructured{Object: map[string]interface{}{
+		"name":   dc.GetName(),
+		"latest": true,
+		"force":  true,
+	}}
+	req.SetAPIVersion(DeploymentConfigGroupVersion)
+	req.SetKind("DeploymentRequest")
+	// dynamic client takes the subresource owner name from metadata
+	req.SetName(dc.GetName())
+	return req
+}
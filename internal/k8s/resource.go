@@ -8,6 +8,7 @@ import (
 	apps_v1 "k8s.io/api/apps/v1"
 	batch_v1 "k8s.io/api/batch/v1"
 	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // GenericResource - generic resource,
@@ -38,11 +39,15 @@ func (c genericResource) Less(i, j int) bool {
 // NewGenericResource - create new generic k8s resource
 func NewGenericResource(obj interface{}) (*GenericResource, error) {
 
-	switch obj.(type) {
+	switch obj := obj.(type) {
 	case *apps_v1.Deployment, *apps_v1.StatefulSet, *apps_v1.DaemonSet:
 		// ok
 	case *batch_v1.CronJob:
 		// ok
+	case *unstructured.Unstructured:
+		if !isDeploymentConfig(obj) {
+			return nil, fmt.Errorf("unsupported resource kind: %s", obj.GroupVersionKind())
+		}
 	default:
 		return nil, fmt.Errorf("unsupported resource type: %v", reflect.TypeOf(obj).Kind())
 	}
@@ -81,6 +86,8 @@ func (r *GenericResource) DeepCopy() *GenericResource {
 		gr.obj = obj.DeepCopy()
 	case *batch_v1.CronJob:
 		gr.obj = obj.DeepCopy()
+	case *unstructured.Unstructured:
+		gr.obj = obj.DeepCopy()
 	}
 
 	return gr
@@ -97,6 +104,8 @@ func (r *GenericResource) GetIdentifier() string {
 		return getDaemonsetSetIdentifier(obj)
 	case *batch_v1.CronJob:
 		return getCronJobIdentifier(obj)
+	case *unstructured.Unstructured:
+		return getDeploymentConfigIdentifier(obj)
 	}
 	return ""
 }
@@ -112,6 +121,8 @@ func (r *GenericResource) GetName() string {
 		return obj.GetName()
 	case *batch_v1.CronJob:
 		return obj.GetName()
+	case *unstructured.Unstructured:
+		return obj.GetName()
 	}
 	return ""
 }
@@ -127,6 +138,8 @@ func (r *GenericResource) GetNamespace() string {
 		return obj.GetNamespace()
 	case *batch_v1.CronJob:
 		return obj.GetNamespace()
+	case *unstructured.Unstructured:
+		return obj.GetNamespace()
 	}
 	return ""
 }
@@ -142,6 +155,8 @@ func (r *GenericResource) Kind() string {
 		return "daemonset"
 	case *batch_v1.CronJob:
 		return "cronjob"
+	case *unstructured.Unstructured:
+		return "deploymentconfig"
 	}
 	return ""
 }
@@ -162,6 +177,8 @@ func (r *GenericResource) GetLabels() (labels map[string]string) {
 		return getOrInitialise(obj.GetLabels())
 	case *batch_v1.CronJob:
 		return getOrInitialise(obj.GetLabels())
+	case *unstructured.Unstructured:
+		return getOrInitialise(obj.GetLabels())
 	}
 	return
 }
@@ -177,6 +194,8 @@ func (r *GenericResource) SetLabels(labels map[string]string) {
 		obj.SetLabels(labels)
 	case *batch_v1.CronJob:
 		obj.SetLabels(labels)
+	case *unstructured.Unstructured:
+		obj.SetLabels(labels)
 	}
 }
 
@@ -191,6 +210,8 @@ func (r *GenericResource) GetSpecAnnotations() (annotations map[string]string) {
 		return getOrInitialise(obj.Spec.Template.GetAnnotations())
 	case *batch_v1.CronJob:
 		return getOrInitialise(obj.Spec.JobTemplate.GetAnnotations())
+	case *unstructured.Unstructured:
+		return getOrInitialise(getDeploymentConfigSpecAnnotations(obj))
 	}
 	return
 }
@@ -206,6 +227,8 @@ func (r *GenericResource) SetSpecAnnotations(annotations map[string]string) {
 		obj.Spec.Template.SetAnnotations(annotations)
 	case *batch_v1.CronJob:
 		obj.Spec.JobTemplate.SetAnnotations(annotations)
+	case *unstructured.Unstructured:
+		setDeploymentConfigSpecAnnotations(obj, annotations)
 	}
 }
 
@@ -227,6 +250,8 @@ func (r *GenericResource) GetAnnotations() (annotations map[string]string) {
 		return getOrInitialise(obj.GetAnnotations())
 	case *batch_v1.CronJob:
 		return getOrInitialise(obj.GetAnnotations())
+	case *unstructured.Unstructured:
+		return getOrInitialise(obj.GetAnnotations())
 	}
 	return
 }
@@ -242,6 +267,8 @@ func (r *GenericResource) SetAnnotations(annotations map[string]string) {
 		obj.SetAnnotations(annotations)
 	case *batch_v1.CronJob:
 		obj.SetAnnotations(annotations)
+	case *unstructured.Unstructured:
+		obj.SetAnnotations(annotations)
 	}
 }
 
@@ -256,6 +283,8 @@ func (r *GenericResource) GetImagePullSecrets() (secrets []string) {
 		return getImagePullSecrets(obj.Spec.Template.Spec.ImagePullSecrets)
 	case *batch_v1.CronJob:
 		return getImagePullSecrets(obj.Spec.JobTemplate.Spec.Template.Spec.ImagePullSecrets)
+	case *unstructured.Unstructured:
+		return getImagePullSecrets(deploymentConfigPodSpec(obj).ImagePullSecrets)
 	}
 	return
 }
@@ -271,10 +300,39 @@ func (r *GenericResource) GetImages() (images []string) {
 		return getContainerImages(obj.Spec.Template.Spec.Containers)
 	case *batch_v1.CronJob:
 		return getContainerImages(obj.Spec.JobTemplate.Spec.Template.Spec.Containers)
+	case *unstructured.Unstructured:
+		return getContainerImages(deploymentConfigPodSpec(obj).Containers)
 	}
 	return
 }
 
+// GetInitImages - returns images used by init containers of this resource
+func (r *GenericResource) GetInitImages() (images []string) {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		return getContainerImages(obj.Spec.Template.Spec.InitContainers)
+	case *apps_v1.StatefulSet:
+		return getContainerImages(obj.Spec.Template.Spec.InitContainers)
+	case *apps_v1.DaemonSet:
+		return getContainerImages(obj.Spec.Template.Spec.InitContainers)
+	case *batch_v1.CronJob:
+		return getContainerImages(obj.Spec.JobTemplate.Spec.Template.Spec.InitContainers)
+	case *unstructured.Unstructured:
+		return getContainerImages(deploymentConfigPodSpec(obj).InitContainers)
+	}
+	return
+}
+
+// ImageTriggered - checks whether the container image is managed outside of
+// the pod template, only OpenShift DeploymentConfigs with automatic
+// ImageChange triggers do that
+func (r *GenericResource) ImageTriggered(container string) bool {
+	if obj, ok := r.obj.(*unstructured.Unstructured); ok {
+		return deploymentConfigImageTriggered(obj, container)
+	}
+	return false
+}
+
 // Containers - returns containers managed by this resource
 func (r *GenericResource) Containers() (containers []core_v1.Container) {
 	switch obj := r.obj.(type) {
@@ -286,6 +344,8 @@ func (r *GenericResource) Containers() (containers []core_v1.Container) {
 		return obj.Spec.Template.Spec.Containers
 	case *batch_v1.CronJob:
 		return obj.Spec.JobTemplate.Spec.Template.Spec.Containers
+	case *unstructured.Unstructured:
+		return deploymentConfigPodSpec(obj).Containers
 	}
 	return
 }
@@ -301,6 +361,41 @@ func (r *GenericResource) UpdateContainer(index int, image string) {
 		updateDaemonsetSetContainer(obj, index, image)
 	case *batch_v1.CronJob:
 		updateCronJobContainer(obj, index, image)
+	case *unstructured.Unstructured:
+		updateDeploymentConfigImage(obj, "containers", index, image)
+	}
+}
+
+// InitContainers - returns init containers managed by this resource
+func (r *GenericResource) InitContainers() (containers []core_v1.Container) {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		return obj.Spec.Template.Spec.InitContainers
+	case *apps_v1.StatefulSet:
+		return obj.Spec.Template.Spec.InitContainers
+	case *apps_v1.DaemonSet:
+		return obj.Spec.Template.Spec.InitContainers
+	case *batch_v1.CronJob:
+		return obj.Spec.JobTemplate.Spec.Template.Spec.InitContainers
+	case *unstructured.Unstructured:
+		return deploymentConfigPodSpec(obj).InitContainers
+	}
+	return
+}
+
+// UpdateInitContainer - updates init container image
+func (r *GenericResource) UpdateInitContainer(index int, image string) {
+	switch obj := r.obj.(type) {
+	case *apps_v1.Deployment:
+		updateDeploymentInitContainer(obj, index, image)
+	case *apps_v1.StatefulSet:
+		updateStatefulSetInitContainer(obj, index, image)
+	case *apps_v1.DaemonSet:
+		updateDaemonsetSetInitContainer(obj, index, image)
+	case *batch_v1.CronJob:
+		updateCronJobInitContainer(obj, index, image)
+	case *unstructured.Unstructured:
+		updateDeploymentConfigImage(obj, "initContainers", index, image)
 	}
 }
 
@@ -362,6 +457,8 @@ func (r *GenericResource) GetStatus() Status {
 			AvailableReplicas:   0,
 			UnavailableReplicas: 0,
 		}
+	case *unstructured.Unstructured:
+		return getDeploymentConfigStatus(obj)
 	}
 	return Status{}
 }
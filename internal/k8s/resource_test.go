@@ -6,6 +6,7 @@ import (
 	apps_v1 "k8s.io/api/apps/v1"
 	core_v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestDeployment(t *testing.T) {
@@ -185,3 +186,131 @@ func TestDaemonsetlSetMultipleContainers(t *testing.T) {
 		t.Errorf("unexpected image: %s", updated.Spec.Template.Spec.Containers[0].Image)
 	}
 }
+
+func TestDeploymentConfig(t *testing.T) {
+	dc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata": map[string]interface{}{
+			"name":      "dc-1",
+			"namespace": "xxxx",
+		},
+		"spec": map[string]interface{}{
+			"triggers": []interface{}{
+				map[string]interface{}{"type": "ConfigChange"},
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "hello", "image": "gcr.io/v2-namespace/hello-world:1.1.1"},
+						map[string]interface{}{"name": "sidecar", "image": "gcr.io/v2-namespace/sidecar:1.0.0"},
+					},
+				},
+			},
+		},
+	}}
+
+	gr, err := NewGenericResource(dc)
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+
+	if gr.Kind() != "deploymentconfig" {
+		t.Errorf("unexpected kind: %s", gr.Kind())
+	}
+	if gr.GetIdentifier() != "deploymentconfig/xxxx/dc-1" {
+		t.Errorf("unexpected identifier: %s", gr.GetIdentifier())
+	}
+	if len(gr.Containers()) != 2 {
+		t.Fatalf("expected 2 containers, got: %d", len(gr.Containers()))
+	}
+
+	gr.UpdateContainer(1, "hey/there")
+	gr.SetSpecAnnotations(map[string]string{"keel.sh/update-time": "now"})
+
+	updated, ok := gr.GetResource().(*unstructured.Unstructured)
+	if !ok {
+		t.Fatalf("conversion failed")
+	}
+
+	containers := deploymentConfigPodSpec(updated).Containers
+	if containers[0].Image != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("unexpected image: %s", containers[0].Image)
+	}
+	if containers[1].Image != "hey/there" {
+		t.Errorf("unexpected image: %s", containers[1].Image)
+	}
+	if gr.GetSpecAnnotations()["keel.sh/update-time"] != "now" {
+		t.Errorf("spec annotations not set: %v", gr.GetSpecAnnotations())
+	}
+
+	// fields keel doesn't know about must be preserved
+	triggers, found, _ := unstructured.NestedSlice(updated.Object, "spec", "triggers")
+	if !found || len(triggers) != 1 {
+		t.Errorf("triggers were not preserved: %v", triggers)
+	}
+	if req := DeploymentConfigRolloutRequest(updated); req != nil {
+		t.Errorf("ConfigChange trigger rolls out, unexpected request: %v", req.Object)
+	}
+}
+
+func TestDeploymentConfigImageTrigger(t *testing.T) {
+	dc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata": map[string]interface{}{
+			"name":      "dc-1",
+			"namespace": "xxxx",
+		},
+		"spec": map[string]interface{}{
+			"triggers": []interface{}{
+				map[string]interface{}{
+					"type": "ImageChange",
+					"imageChangeParams": map[string]interface{}{
+						"automatic":      true,
+						"containerNames": []interface{}{"hello"},
+						"from":           map[string]interface{}{"kind": "ImageStreamTag", "name": "hello:latest"},
+					},
+				},
+				map[string]interface{}{
+					"type": "ImageChange",
+					"imageChangeParams": map[string]interface{}{
+						"automatic":      false,
+						"containerNames": []interface{}{"sidecar"},
+						"from":           map[string]interface{}{"kind": "ImageStreamTag", "name": "sidecar:latest"},
+					},
+				},
+			},
+		},
+	}}
+
+	gr, err := NewGenericResource(dc)
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+	if !gr.ImageTriggered("hello") {
+		t.Errorf("expected hello to be managed by the image trigger")
+	}
+	if gr.ImageTriggered("sidecar") {
+		t.Errorf("manual image trigger shouldn't manage sidecar")
+	}
+
+	// no ConfigChange trigger, rollout has to be requested
+	req := DeploymentConfigRolloutRequest(dc)
+	if req == nil {
+		t.Fatalf("expected rollout request")
+	}
+	if req.GetKind() != "DeploymentRequest" || req.Object["name"] != "dc-1" {
+		t.Errorf("unexpected rollout request: %v", req.Object)
+	}
+}
+
+func TestNewGenericResourceUnknownUnstructured(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+	}}
+	if _, err := NewGenericResource(u); err == nil {
+		t.Errorf("expected error for unsupported unstructured object")
+	}
+}
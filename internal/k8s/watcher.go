@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -12,46 +13,79 @@ import (
 	batch_v1 "k8s.io/api/batch/v1"
 
 	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
 // WatchDeployments creates a SharedInformer for apps/v1.Deployments and registers it with g.
-func WatchDeployments(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.AppsV1().RESTClient(), log, "deployments", new(apps_v1.Deployment), rs...)
+// Returned func reports whether the informer has completed its initial list.
+func WatchDeployments(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) cache.InformerSynced {
+	return watchResource(g, newListWatch(client.AppsV1().RESTClient(), "deployments"), log, "deployments", new(apps_v1.Deployment), rs...)
 }
 
 // WatchStatefulSets creates a SharedInformer for apps/v1.StatefulSet and registers it with g.
-func WatchStatefulSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.AppsV1().RESTClient(), log, "statefulsets", new(apps_v1.StatefulSet), rs...)
+// Returned func reports whether the informer has completed its initial list.
+func WatchStatefulSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) cache.InformerSynced {
+	return watchResource(g, newListWatch(client.AppsV1().RESTClient(), "statefulsets"), log, "statefulsets", new(apps_v1.StatefulSet), rs...)
 }
 
 // WatchDaemonSets creates a SharedInformer for apps/v1.DaemonSet and registers it with g.
-func WatchDaemonSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.AppsV1().RESTClient(), log, "daemonsets", new(apps_v1.DaemonSet), rs...)
+// Returned func reports whether the informer has completed its initial list.
+func WatchDaemonSets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) cache.InformerSynced {
+	return watchResource(g, newListWatch(client.AppsV1().RESTClient(), "daemonsets"), log, "daemonsets", new(apps_v1.DaemonSet), rs...)
 }
 
 // WatchCronJobs creates a SharedInformer for batch_v1.CronJob and registers it with g.
-func WatchCronJobs(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) {
-	watch(g, client.BatchV1().RESTClient(), log, "cronjobs", new(batch_v1.CronJob), rs...)
+// Returned func reports whether the informer has completed its initial list.
+func WatchCronJobs(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) cache.InformerSynced {
+	return watchResource(g, newListWatch(client.BatchV1().RESTClient(), "cronjobs"), log, "cronjobs", new(batch_v1.CronJob), rs...)
 }
 
-func watch(g *workgroup.Group, c cache.Getter, log logrus.FieldLogger, resource string, objType runtime.Object, rs ...cache.ResourceEventHandler) {
-	//Check if the env var RESTRICTED_NAMESPACE is empty or equal to keel
-	// If equal to keel or empty, the scan will be over all the cluster
-	// If RESTRICTED_NAMESPACE is different than keel or empty, keel will scan in the defined namespace
-	namespaceScan := "keel"
-	if os.Getenv(constants.EnvRestrictedNamespace) == "keel" {
-		namespaceScan = v1.NamespaceAll
-	} else if os.Getenv(constants.EnvRestrictedNamespace) == "" {
-		namespaceScan = v1.NamespaceAll
-	} else {
-		namespaceScan = os.Getenv(constants.EnvRestrictedNamespace)
+// WatchDeploymentConfigs creates a SharedInformer for OpenShift DeploymentConfigs and registers
+// it with g, objects are unstructured. Returned func reports whether the informer has completed
+// its initial list.
+func WatchDeploymentConfigs(g *workgroup.Group, client dynamic.Interface, log logrus.FieldLogger, rs ...cache.ResourceEventHandler) cache.InformerSynced {
+	namespace, labelSelector := watchScope()
+	resource := client.Resource(DeploymentConfigResource).Namespace(namespace)
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = labelSelector
+			return resource.List(context.TODO(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = labelSelector
+			return resource.Watch(context.TODO(), options)
+		},
 	}
+	return watchResource(g, lw, log, DeploymentConfigResource.Resource, new(unstructured.Unstructured), rs...)
+}
+
+// WatchSecrets creates a SharedIndexInformer for secrets and registers it with g. Returned
+// lister serves secrets from the informer cache, so webhook and registry secret lookups
+// don't hit the API server. Secrets are limited to RESTRICTED_NAMESPACE only, resources
+// reference secrets by name. Returned func reports whether the informer has completed
+// its initial list.
+func WatchSecrets(g *workgroup.Group, client *kubernetes.Clientset, log logrus.FieldLogger) (corelisters.SecretLister, cache.InformerSynced) {
+	namespace, _ := watchScope()
+	lw := cache.NewListWatchFromClient(client.CoreV1().RESTClient(), "secrets", namespace, fields.Everything())
+	sw := cache.NewSharedIndexInformer(lw, new(v1.Secret), 30*time.Minute, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	g.Add(func(stop <-chan struct{}) {
+		log := log.WithField("resource", "secrets")
+		log.Println("started")
+		defer log.Println("stopped")
+		sw.Run(stop)
+	})
+	return corelisters.NewSecretLister(sw.GetIndexer()), sw.HasSynced
+}
 
-	lw := cache.NewListWatchFromClient(c, resource, namespaceScan, fields.Everything())
+func watchResource(g *workgroup.Group, lw cache.ListerWatcher, log logrus.FieldLogger, resource string, objType runtime.Object, rs ...cache.ResourceEventHandler) cache.InformerSynced {
 	sw := cache.NewSharedInformer(lw, objType, 30*time.Minute)
 	for _, r := range rs {
 		sw.AddEventHandler(r)
@@ -62,6 +96,35 @@ func watch(g *workgroup.Group, c cache.Getter, log logrus.FieldLogger, resource
 		defer log.Println("stopped")
 		sw.Run(stop)
 	})
+	return sw.HasSynced
+}
+
+// newListWatch - list watch limited to RESTRICTED_NAMESPACE and LABEL_SELECTOR
+// when they are set
+func newListWatch(c cache.Getter, resource string) *cache.ListWatch {
+	namespace, labelSelector := watchScope()
+	return cache.NewFilteredListWatchFromClient(c, resource, namespace, func(options *meta_v1.ListOptions) {
+		options.FieldSelector = fields.Everything().String()
+		options.LabelSelector = labelSelector
+	})
+}
+
+// watchScope - namespace and label selector resources are listed with
+func watchScope() (namespace, labelSelector string) {
+	//Check if the env var RESTRICTED_NAMESPACE is empty or equal to keel
+	// If equal to keel or empty, the scan will be over all the cluster
+	// If RESTRICTED_NAMESPACE is different than keel or empty, keel will scan in the defined namespace
+	namespaceScan := "keel"
+	if os.Getenv(constants.EnvRestrictedNamespace) == "keel" {
+		namespaceScan = v1.NamespaceAll
+	} else if os.Getenv(constants.EnvRestrictedNamespace) == "" {
+		namespaceScan = v1.NamespaceAll
+	} else {
+		namespaceScan = os.Getenv(constants.EnvRestrictedNamespace)
+	}
+
+	// optional label selector, resources that don't match are never listed
+	return namespaceScan, os.Getenv(constants.EnvLabelSelector)
 }
 
 type buffer struct {
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/keel-hq/keel/constants"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestListWatchLabelSelector(t *testing.T) {
+	var query map[string][]string
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		query = req.URL.Query()
+		resp.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(resp).Encode(apps_v1.DeploymentList{
+			TypeMeta: meta_v1.TypeMeta{APIVersion: "apps/v1", Kind: "DeploymentList"},
+		})
+	}))
+	defer ts.Close()
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	for _, selector := range []string{"", "keel=enabled"} {
+		os.Setenv(constants.EnvLabelSelector, selector)
+
+		lw := newListWatch(client.AppsV1().RESTClient(), "deployments")
+		if _, err := lw.List(meta_v1.ListOptions{}); err != nil {
+			t.Fatalf("failed to list: %s", err)
+		}
+
+		got := ""
+		if v, ok := query["labelSelector"]; ok {
+			got = v[0]
+		}
+		if got != selector {
+			t.Errorf("expected label selector '%s', got: '%s'", selector, got)
+		}
+	}
+	os.Unsetenv(constants.EnvLabelSelector)
+}
@@ -46,6 +46,32 @@ func GetPolicyFromLabelsOrAnnotations(labels map[string]string, annotations map[
 	return GetPolicy(policyNameL, &Options{MatchTag: getMatchTag(labels), MatchPreRelease: getMatchPreRelease(labels)})
 }
 
+// GetContainerPolicy - gets container specific policy from k8s labels or
+// annotations, returns false when container doesn't have its own policy
+func GetContainerPolicy(container string, labels map[string]string, annotations map[string]string) (Policy, bool) {
+	key := types.KeelContainerPolicyPrefix + container
+
+	if policyName, ok := annotations[key]; ok {
+		return GetPolicy(policyName, &Options{MatchTag: getMatchTag(annotations), MatchPreRelease: getMatchPreRelease(annotations)}), true
+	}
+	if policyName, ok := labels[key]; ok {
+		return GetPolicy(policyName, &Options{MatchTag: getMatchTag(labels), MatchPreRelease: getMatchPreRelease(labels)}), true
+	}
+	return nil, false
+}
+
+// HasContainerPolicies - checks whether any container specific policies are set
+func HasContainerPolicies(labels map[string]string, annotations map[string]string) bool {
+	for _, m := range []map[string]string{annotations, labels} {
+		for k := range m {
+			if strings.HasPrefix(k, types.KeelContainerPolicyPrefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Options - additional options when parsing policy
 type Options struct {
 	MatchTag        bool
@@ -146,3 +146,50 @@ func TestGetPolicyFromLabelsOrAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestGetContainerPolicy(t *testing.T) {
+	labels := map[string]string{
+		types.KeelPolicyLabel:                     "all",
+		types.KeelContainerPolicyPrefix + "web":   "minor",
+		types.KeelContainerPolicyPrefix + "cache": "force",
+	}
+	annotations := map[string]string{
+		types.KeelContainerPolicyPrefix + "worker": "patch",
+		types.KeelContainerPolicyPrefix + "cache":  "major",
+	}
+
+	tests := []struct {
+		container string
+		want      Policy
+		wantOk    bool
+	}{
+		{"web", NewSemverPolicy(SemverPolicyTypeMinor, true), true},
+		{"worker", NewSemverPolicy(SemverPolicyTypePatch, true), true},
+		// annotations take precedence over labels
+		{"cache", NewSemverPolicy(SemverPolicyTypeMajor, true), true},
+		{"sidecar", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.container, func(t *testing.T) {
+			got, ok := GetContainerPolicy(tt.container, labels, annotations)
+			if ok != tt.wantOk {
+				t.Fatalf("GetContainerPolicy() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetContainerPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasContainerPolicies(t *testing.T) {
+	if HasContainerPolicies(map[string]string{types.KeelPolicyLabel: "all"}, nil) {
+		t.Errorf("didn't expect container policies")
+	}
+	if !HasContainerPolicies(nil, map[string]string{types.KeelContainerPolicyPrefix + "web": "minor"}) {
+		t.Errorf("expected container policies in annotations")
+	}
+	if !HasContainerPolicies(map[string]string{types.KeelContainerPolicyPrefix + "web": "minor"}, nil) {
+		t.Errorf("expected container policies in labels")
+	}
+}
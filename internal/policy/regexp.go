@@ -3,10 +3,15 @@ package policy
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
-// RegexpPolicy - regular expression based pattern
+// RegexpPolicy - regular expression based pattern. When the pattern has a
+// capture group, the first group is treated as a sortable component and only
+// tags with a greater component than the current one are accepted, i.e.
+// regexp:^release-(\d{4}\.\d{2})$
 type RegexpPolicy struct {
 	policy string
 	regexp *regexp.Regexp
@@ -33,8 +38,61 @@ func NewRegexpPolicy(policy string) (*RegexpPolicy, error) {
 }
 
 func (p *RegexpPolicy) ShouldUpdate(current, new string) (bool, error) {
-	return p.regexp.MatchString(new), nil
+	if !p.Sortable() {
+		return p.regexp.MatchString(new), nil
+	}
+
+	newMatch := p.regexp.FindStringSubmatch(new)
+	if newMatch == nil {
+		return false, nil
+	}
+
+	currentMatch := p.regexp.FindStringSubmatch(current)
+	if currentMatch == nil {
+		// current tag doesn't follow the pattern, any matching tag will do
+		return true, nil
+	}
+
+	return naturalLess(currentMatch[1], newMatch[1]), nil
+}
+
+// Sortable - returns true when the pattern has a capture group that can be
+// used to order tags
+func (p *RegexpPolicy) Sortable() bool {
+	return p.regexp.NumSubexp() > 0
 }
 
 func (p *RegexpPolicy) Name() string     { return p.policy }
 func (p *RegexpPolicy) Type() PolicyType { return PolicyTypeRegexp }
+
+// naturalLess - compares strings treating digit sequences as numbers so
+// "2024.9" < "2024.10" and "build-9" < "build-10"
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aChunk, aNum := nextChunk(a)
+		bChunk, bNum := nextChunk(b)
+		a, b = a[len(aChunk):], b[len(bChunk):]
+
+		if aNum && bNum {
+			ai, _ := strconv.ParseUint(aChunk, 10, 64)
+			bi, _ := strconv.ParseUint(bChunk, 10, 64)
+			if ai != bi {
+				return ai < bi
+			}
+			continue
+		}
+		if aChunk != bChunk {
+			return aChunk < bChunk
+		}
+	}
+	return len(a) < len(b)
+}
+
+func nextChunk(s string) (string, bool) {
+	digit := unicode.IsDigit(rune(s[0]))
+	i := 1
+	for i < len(s) && unicode.IsDigit(rune(s[i])) == digit {
+		i++
+	}
+	return s[:i], digit
+}
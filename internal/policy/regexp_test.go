@@ -0,0 +1,56 @@
+package policy
+
+import "testing"
+
+func TestRegexpPolicy_ShouldUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		current string
+		new     string
+		want    bool
+	}{
+		{"match without group", "regexp:^release-", "release-1", "release-0", true},
+		{"no match without group", "regexp:^release-", "release-1", "feature-2", false},
+		{"greater date", `regexp:^release-(\d{4}\.\d+)$`, "release-2024.01", "release-2024.02", true},
+		{"older date", `regexp:^release-(\d{4}\.\d+)$`, "release-2024.02", "release-2024.01", false},
+		{"same tag", `regexp:^release-(\d{4}\.\d+)$`, "release-2024.02", "release-2024.02", false},
+		{"natural order", `regexp:^release-(\d{4}\.\d+)$`, "release-2024.9", "release-2024.10", true},
+		{"build number", `regexp:^build-(\d+)$`, "build-99", "build-100", true},
+		{"new doesn't match", `regexp:^build-(\d+)$`, "build-99", "latest", false},
+		{"current doesn't match", `regexp:^build-(\d+)$`, "latest", "build-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewRegexpPolicy(tt.pattern)
+			if err != nil {
+				t.Fatalf("failed to create policy: %s", err)
+			}
+			got, err := p.ShouldUpdate(tt.current, tt.new)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("RegexpPolicy.ShouldUpdate(%s, %s) = %v, want %v", tt.current, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2024.9", "2024.10", true},
+		{"2024.10", "2024.9", false},
+		{"a1", "b1", true},
+		{"1", "1a", true},
+		{"abc", "abc", false},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
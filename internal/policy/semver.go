@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/Masterminds/semver"
+
+	"github.com/keel-hq/keel/util/version"
 )
 
 // SemverPolicyType - policy type
@@ -86,7 +88,9 @@ func shouldUpdate(spt SemverPolicyType, matchPreRelease bool, current, new strin
 	// Do not enforce pre-release match when either:
 	// - All policy
 	// - matchPreRelease set to false
-	if currentVersion.Prerelease() != newVersion.Prerelease() && spt != SemverPolicyTypeAll && matchPreRelease {
+	// Pre-releases are matched by channel so 1.2.0-rc.1 can move to 1.2.0-rc.2
+	// but never to 1.2.0-beta.1 or 1.2.0
+	if version.PreReleaseChannel(currentVersion.Prerelease()) != version.PreReleaseChannel(newVersion.Prerelease()) && spt != SemverPolicyTypeAll && matchPreRelease {
 		return false, nil
 	}
 
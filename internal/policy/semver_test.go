@@ -2,6 +2,8 @@ package policy
 
 import (
 	"testing"
+
+	"github.com/keel-hq/keel/types"
 )
 
 func Test_shouldUpdate(t *testing.T) {
@@ -242,6 +244,83 @@ func Test_shouldUpdate(t *testing.T) {
 			want:    false,
 			wantErr: true,
 		},
+		{
+			name: "same channel increase, policy minor",
+			args: args{
+				current:         "1.2.0-rc.1",
+				new:             "1.2.0-rc.2",
+				spt:             SemverPolicyTypeMinor,
+				preReleaseMatch: true,
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "same channel decrease, policy minor",
+			args: args{
+				current:         "1.2.0-rc.2",
+				new:             "1.2.0-rc.1",
+				spt:             SemverPolicyTypeMinor,
+				preReleaseMatch: true,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "channel to stable, policy minor",
+			args: args{
+				current:         "1.2.0-rc.2",
+				new:             "1.2.0",
+				spt:             SemverPolicyTypeMinor,
+				preReleaseMatch: true,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "different channel, policy minor",
+			args: args{
+				current:         "1.2.0-canary.3",
+				new:             "1.2.0-beta.1",
+				spt:             SemverPolicyTypeMinor,
+				preReleaseMatch: true,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "stable to numeric pre-release, policy minor",
+			args: args{
+				current:         "1.2.0",
+				new:             "1.3.0-20240101",
+				spt:             SemverPolicyTypeMinor,
+				preReleaseMatch: true,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "stable to numeric pre-release patch, policy patch",
+			args: args{
+				current:         "1.2.0",
+				new:             "1.2.1-1",
+				spt:             SemverPolicyTypePatch,
+				preReleaseMatch: true,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "numeric pre-release increase, policy minor",
+			args: args{
+				current:         "1.2.0-20240101",
+				new:             "1.3.0-20240201",
+				spt:             SemverPolicyTypeMinor,
+				preReleaseMatch: true,
+			},
+			want:    true,
+			wantErr: false,
+		},
 		{
 			name: "pre-release increase, policy All",
 			args: args{
@@ -277,3 +356,35 @@ func Test_shouldUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestAllPolicyAllowsMajorUpgrade(t *testing.T) {
+	all := GetPolicyFromLabelsOrAnnotations(map[string]string{types.KeelPolicyLabel: "all"}, nil)
+	minor := GetPolicyFromLabelsOrAnnotations(map[string]string{types.KeelPolicyLabel: "minor"}, nil)
+
+	tests := []struct {
+		name    string
+		plc     Policy
+		current string
+		new     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "major bump, policy all", plc: all, current: "1.5.0", new: "2.0.0", want: true},
+		{name: "major bump, policy minor", plc: minor, current: "1.5.0", new: "2.0.0", want: false},
+		{name: "downgrade, policy all", plc: all, current: "2.0.0", new: "1.5.0", want: false},
+		{name: "same version, policy all", plc: all, current: "2.0.0", new: "2.0.0", want: false},
+		{name: "non semver, policy all", plc: all, current: "1.5.0", new: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.plc.ShouldUpdate(tt.current, tt.new)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ShouldUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ShouldUpdate(%s, %s) = %v, want %v", tt.current, tt.new, got, tt.want)
+			}
+		})
+	}
+}
@@ -133,7 +133,7 @@ func (s *TriggerServer) approvalApproveHandler(resp http.ResponseWriter, req *ht
 	// checking action
 	switch ar.Action {
 	case actionReject:
-		approval, err = s.approvalsManager.Reject(ar.Identifier)
+		approval, err = s.approvalsManager.Reject(ar.Identifier, ar.Voter)
 		if err != nil {
 			if err == store.ErrRecordNotFound {
 				http.Error(resp, fmt.Sprintf("approval '%s' not found", ar.Identifier), http.StatusNotFound)
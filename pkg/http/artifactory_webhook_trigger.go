@@ -0,0 +1,117 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var newArtifactoryWebhooksCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "artifactory_webhook_requests_total",
+		Help: "How many /v1/webhooks/artifactory requests processed, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(newArtifactoryWebhooksCounter)
+}
+
+// Example of Artifactory trigger, deprecated schema
+// {
+//     "event": "docker.tag.pushed",
+//     "data": {
+//         "repoKey": "docker-local",
+//         "imageName": "team/app",
+//         "tag": "1.2.3",
+//         "sha256": "35c4a2c15539c6c1e4e5fa4e554dac323ad0107d8eb5c582d6ff386b383b7dce"
+//     }
+// }
+//
+// current schema is the same as the one sent to /v1/webhooks/jfrog, with
+// "domain": "docker", "event_type": "pushed" and snake_case data fields
+
+type artifactoryWebhook struct {
+	// deprecated schema
+	Event string `json:"event"`
+
+	// current schema
+	Domain    string `json:"domain"`
+	EventType string `json:"event_type"`
+
+	Data struct {
+		RepoKey          string `json:"repoKey"`
+		ImageName        string `json:"imageName"`
+		RepoKeyCurrent   string `json:"repo_key"`
+		ImageNameCurrent string `json:"image_name"`
+		Tag              string `json:"tag"`
+		Sha256           string `json:"sha256"`
+	} `json:"data"`
+}
+
+// pushed - returns image name and tag if the webhook is a docker tag push
+func (w *artifactoryWebhook) pushed() (imageName, tag string, ok bool) {
+	switch {
+	case w.Event == "docker.tag.pushed":
+		return w.Data.ImageName, w.Data.Tag, true
+	case w.Domain == "docker" && w.EventType == "pushed":
+		return w.Data.ImageNameCurrent, w.Data.Tag, true
+	}
+	return "", "", false
+}
+
+// artifactoryHandler - used to react to Artifactory docker tag push webhooks
+func (s *TriggerServer) artifactoryHandler(resp http.ResponseWriter, req *http.Request) {
+	// Artifactory sends configured webhook secret in X-JFrog-Event-Auth header
+	if s.artifactoryWebhookSecret != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get("X-JFrog-Event-Auth")), []byte(s.artifactoryWebhookSecret)) != 1 {
+		log.Warn("trigger.artifactoryHandler: invalid or missing webhook secret")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	aw := artifactoryWebhook{}
+	if err := json.NewDecoder(req.Body).Decode(&aw); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.artifactoryHandler: failed to decode request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	imageName, tag, ok := aw.pushed()
+	if !ok {
+		log.WithFields(log.Fields{
+			"event":      aw.Event,
+			"domain":     aw.Domain,
+			"event_type": aw.EventType,
+		}).Debug("trigger.artifactoryHandler: not a docker tag push event, ignoring")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if imageName == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "image name cannot be empty")
+		return
+	}
+
+	if tag == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "tag cannot be empty")
+		return
+	}
+
+	event := newJfrogEvent("artifactory", imageName, tag)
+
+	s.trigger(event)
+
+	resp.WriteHeader(http.StatusOK)
+
+	newArtifactoryWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+
+	"net/http/httptest"
+	"testing"
+)
+
+var fakeArtifactoryWebhookDeprecated = `{
+    "event": "docker.tag.pushed",
+    "data": {
+        "repoKey": "docker-local",
+        "imageName": "team/app",
+        "tag": "1.2.3",
+        "sha256": "35c4a2c15539c6c1e4e5fa4e554dac323ad0107d8eb5c582d6ff386b383b7dce"
+    }
+}
+`
+
+var fakeArtifactoryWebhook = `{
+    "domain": "docker",
+    "event_type": "pushed",
+    "data": {
+        "repo_key": "docker-local",
+        "event_type": "pushed",
+        "path": "team/app/1.2.4/manifest.json",
+        "name": "manifest.json",
+        "sha256": "35c4a2c15539c6c1e4e5fa4e554dac323ad0107d8eb5c582d6ff386b383b7dce",
+        "size": 1206,
+        "image_name": "team/app",
+        "tag": "1.2.4"
+    },
+    "subscription_key": "keel",
+    "jpd_origin": "https://example.jfrog.io",
+    "source": "jfrog/user@example.com"
+}
+`
+
+var fakeArtifactoryWebhookDeleted = `{
+    "domain": "docker",
+    "event_type": "deleted",
+    "data": {
+        "repo_key": "docker-local",
+        "image_name": "team/app",
+        "tag": "1.2.4"
+    }
+}
+`
+
+func TestArtifactoryWebhookHandler(t *testing.T) {
+	os.Setenv(EnvPrivateRegistry, "example.jfrog.io")
+	defer os.Unsetenv(EnvPrivateRegistry)
+
+	tests := []struct {
+		name          string
+		body          string
+		wantCode      int
+		wantSubmitted int
+		wantTag       string
+	}{
+		{"deprecated schema", fakeArtifactoryWebhookDeprecated, 200, 1, "1.2.3"},
+		{"current schema", fakeArtifactoryWebhook, 200, 1, "1.2.4"},
+		{"deleted", fakeArtifactoryWebhookDeleted, 200, 0, ""},
+		{"missing tag", `{"event": "docker.tag.pushed", "data": {"imageName": "team/app"}}`, 400, 0, ""},
+		{"malformed", `{"event": `, 400, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/artifactory", bytes.NewBuffer([]byte(tt.body)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+
+			rec := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("unexpected status code: %d", rec.Code)
+				t.Log(rec.Body.String())
+			}
+
+			if len(fp.submitted) != tt.wantSubmitted {
+				t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+			if tt.wantSubmitted == 0 {
+				return
+			}
+
+			if fp.submitted[0].Repository.Name != "example.jfrog.io/team/app" {
+				t.Errorf("expected example.jfrog.io/team/app but got %s", fp.submitted[0].Repository.Name)
+			}
+
+			if fp.submitted[0].Repository.Tag != tt.wantTag {
+				t.Errorf("expected %s but got %s", tt.wantTag, fp.submitted[0].Repository.Tag)
+			}
+		})
+	}
+}
+
+func TestArtifactoryWebhookHandlerSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		wantCode      int
+		wantSubmitted int
+	}{
+		{"missing", "", 401, 0},
+		{"wrong", "not-the-secret", 401, 0},
+		{"valid", "artifactory-secret", 200, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+			srv.artifactoryWebhookSecret = "artifactory-secret"
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/artifactory", bytes.NewBuffer([]byte(fakeArtifactoryWebhook)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.secret != "" {
+				req.Header.Set("X-JFrog-Event-Auth", tt.secret)
+			}
+
+			rec := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("unexpected status code: %d", rec.Code)
+			}
+
+			if len(fp.submitted) != tt.wantSubmitted {
+				t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+		})
+	}
+}
@@ -21,7 +21,22 @@ func authHeadersMiddleware(rw http.ResponseWriter, r *http.Request, next http.Ha
 	next(rw, r)
 }
 
+// basicAuthChallenge - sent with 401 responses on webhook routes so clients
+// know to retry with basic auth credentials
+const basicAuthChallenge = `Basic realm="keel", charset="UTF-8"`
+
 func (s *TriggerServer) requireAdminAuthorization(next http.HandlerFunc) http.HandlerFunc {
+	return s.authorize(next, false)
+}
+
+// requireWebhookAuthorization - same as admin authorization, but failed requests
+// get WWW-Authenticate challenge. It's not set for admin routes as browsers would
+// show basic auth dialog instead of the UI login page.
+func (s *TriggerServer) requireWebhookAuthorization(next http.HandlerFunc) http.HandlerFunc {
+	return s.authorize(next, true)
+}
+
+func (s *TriggerServer) authorize(next http.HandlerFunc, challenge bool) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 
 		// rw.Header().Set("Access-Control-Expose-Headers", "Authorization")
@@ -32,6 +47,13 @@ func (s *TriggerServer) requireAdminAuthorization(next http.HandlerFunc) http.Ha
 			return
 		}
 
+		unauthorized := func() {
+			if challenge {
+				rw.Header().Set("WWW-Authenticate", basicAuthChallenge)
+			}
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		}
+
 		username, password, ok := r.BasicAuth()
 		if ok {
 			resp, err := s.authenticator.Authenticate(&auth.AuthRequest{
@@ -44,10 +66,9 @@ func (s *TriggerServer) requireAdminAuthorization(next http.HandlerFunc) http.Ha
 				log.WithFields(log.Fields{
 					"error": err,
 					"user":  username,
-					"pas":   password,
-				}).Error("failed uath")
-				// rw.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+					"path":  r.URL.Path,
+				}).Warn("http: basic authentication failed")
+				unauthorized()
 				return
 			}
 
@@ -65,10 +86,11 @@ func (s *TriggerServer) requireAdminAuthorization(next http.HandlerFunc) http.Ha
 		})
 
 		if err != nil {
-			// rw.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-
-			log.Warnf("authentication by token failed, token: %s, err: %s", extractToken(r), err)
-			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  r.URL.Path,
+			}).Warn("http: token authentication failed")
+			unauthorized()
 			return
 		}
 		r = auth.SetAuthenticationDetails(r, &resp.User)
@@ -45,7 +45,10 @@ func init() {
 //  }
 //}
 
+const azureActionPush = "push"
+
 type azureWebhook struct {
+	Action string `json:"action"`
 	Target struct {
 		Repository string `json:"repository"`
 		Tag        string `json:"tag"`
@@ -66,6 +69,16 @@ func (s *TriggerServer) azureHandler(resp http.ResponseWriter, req *http.Request
 		return
 	}
 
+	// only pushes are relevant, ACR also sends delete, quarantine and chart events
+	if aw.Action != "" && aw.Action != azureActionPush {
+		log.WithFields(log.Fields{
+			"action":     aw.Action,
+			"repository": aw.Target.Repository,
+		}).Debug("trigger.azureHandler: ignoring non-push event")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
 	if aw.Target.Tag == "" {
 		resp.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(resp, "tag cannot be empty")
@@ -66,3 +66,73 @@ func TestAzureWebhookHandler(t *testing.T) {
 		t.Errorf("expected sha256:80f0d5c8786bb9e621a45ece0db56d11cdc624ad20da9fe62e9d25490f331d7d but got %s", fp.submitted[0].Repository.Digest)
 	}
 }
+
+func TestAzureWebhookHandlerACRRoute(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/acr", bytes.NewBuffer([]byte(fakeAzureWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+
+		t.Log(rec.Body.String())
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+
+	if fp.submitted[0].Repository.Name != "myregistry.azurecr.io/hello-world" {
+		t.Errorf("myregistry.azurecr.io/hello-world but got %s", fp.submitted[0].Repository.Name)
+	}
+}
+
+var fakeAzureDeleteWebhook = `{
+  "id": "cb8c3971-9adc-488b-bdd8-43cbb4974ff6",
+  "timestamp": "2017-11-17T16:52:01.343145347Z",
+  "action": "delete",
+  "target": {
+    "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+    "digest": "sha256:80f0d5c8786bb9e621a45ece0db56d11cdc624ad20da9fe62e9d25490f331d7d",
+    "repository": "hello-world",
+    "tag": "v1"
+  },
+  "request": {
+    "id": "3cbb6949-7549-4fa1-86cd-a6d5451dffc8",
+    "host": "myregistry.azurecr.io",
+    "method": "DELETE"
+  }
+}
+`
+
+func TestAzureWebhookHandlerIgnoresDelete(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/acr", bytes.NewBuffer([]byte(fakeAzureDeleteWebhook)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected no events to be submitted, got: %d", len(fp.submitted))
+	}
+}
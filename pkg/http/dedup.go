@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/keel-hq/keel/types"
+)
+
+// DefaultWebhookDedupWindow - identical webhook events received within this
+// window are submitted to providers only once
+const DefaultWebhookDedupWindow = 5 * time.Second
+
+// errDuplicateEvent - returned by trigger when the event was dropped as a duplicate
+var errDuplicateEvent = errors.New("duplicate event")
+
+var webhookDuplicatesCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "keel_webhook_duplicates_total",
+		Help: "How many duplicate webhook events were dropped, partitioned by webhook type.",
+	},
+	[]string{"type"},
+)
+
+func init() {
+	prometheus.MustRegister(webhookDuplicatesCounter)
+}
+
+// eventDeduplicator - collapses identical (image, tag, digest and signature)
+// events, CI pipelines and registries tend to deliver the same push several
+// times
+type eventDeduplicator struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newEventDeduplicator(window time.Duration) *eventDeduplicator {
+	return &eventDeduplicator{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// duplicate - checks whether identical event was already recorded within
+// the window. Always false when window is not set.
+func (d *eventDeduplicator) duplicate(event types.Event) bool {
+	if d == nil || d.window <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, seenAt := range d.seen {
+		if now.Sub(seenAt) >= d.window {
+			delete(d.seen, k)
+		}
+	}
+
+	_, ok := d.seen[dedupKey(event)]
+	return ok
+}
+
+// record - remembers event so identical events within the window are
+// dropped, called once the event was submitted so failed submissions can be
+// retried
+func (d *eventDeduplicator) record(event types.Event) {
+	if d == nil || d.window <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.seen[dedupKey(event)] = time.Now()
+}
+
+func dedupKey(event types.Event) string {
+	return event.Repository.String() + "@" + event.Repository.Digest + "#" + event.Signature
+}
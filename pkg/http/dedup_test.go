@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+)
+
+func TestWebhookDeduplication(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+	srv.dedup = newEventDeduplicator(time.Minute)
+
+	payloads := []string{
+		`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1", "digest": "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`,
+		`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1", "digest": "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`,
+		// different digest
+		`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1", "digest": "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"}`,
+		// different tag
+		`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.2", "digest": "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`,
+		`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.2", "digest": "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`,
+	}
+
+	for _, payload := range payloads {
+		req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBuffer([]byte(payload)))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		if rec.Code != 200 {
+			t.Errorf("unexpected status code: %d", rec.Code)
+		}
+	}
+
+	if len(fp.submitted) != 3 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
+
+func TestEventDeduplicatorWindow(t *testing.T) {
+	d := newEventDeduplicator(10 * time.Millisecond)
+	event := types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.1"},
+	}
+
+	if d.duplicate(event) {
+		t.Fatalf("first event shouldn't be a duplicate")
+	}
+	if d.duplicate(event) {
+		t.Fatalf("event that wasn't recorded shouldn't be a duplicate")
+	}
+	d.record(event)
+	if !d.duplicate(event) {
+		t.Fatalf("expected event within window to be a duplicate")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if d.duplicate(event) {
+		t.Errorf("event after window shouldn't be a duplicate")
+	}
+
+	disabled := newEventDeduplicator(0)
+	disabled.record(event)
+	if disabled.duplicate(event) {
+		t.Errorf("deduplication should be disabled without window")
+	}
+}
+
+func TestWebhookDeduplicationFailedSubmit(t *testing.T) {
+	fp := &fakeProvider{err: errors.New("provider unavailable")}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+	srv.providers = fp
+	srv.dedup = newEventDeduplicator(time.Minute)
+
+	payload := `{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBuffer([]byte(payload)))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		if rec.Code != 200 {
+			t.Errorf("unexpected status code: %d", rec.Code)
+		}
+		fp.err = nil
+	}
+
+	// failed submission is retried, the retry suppresses the third delivery
+	if len(fp.submitted) != 2 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+}
+
+func TestWebhookDeduplicationSignature(t *testing.T) {
+	body := []byte(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`)
+	mac := hmac.New(sha256.New, []byte("team-a"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+	srv.dedup = newEventDeduplicator(time.Minute)
+
+	// unsigned copy only reaches resources without a secret, it mustn't
+	// suppress the signed event
+	for _, sig := range []string{"", signature, "sha256=" + signature} {
+		req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("failed to create req: %s", err)
+		}
+		if sig != "" {
+			req.Header.Set(DefaultNativeWebhookSignatureHeader, sig)
+		}
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		if rec.Code != 200 {
+			t.Errorf("unexpected status code: %d", rec.Code)
+		}
+	}
+
+	if len(fp.submitted) != 2 {
+		t.Fatalf("expected unsigned and signed events to be submitted, got: %d", len(fp.submitted))
+	}
+}
@@ -1,9 +1,12 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/keel-hq/keel/types"
@@ -98,8 +101,10 @@ func (s *TriggerServer) dockerHubHandler(resp http.ResponseWriter, req *http.Req
 	}
 
 	if dw.PushData.Tag == "" {
-		resp.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(resp, "repository tag cannot be empty")
+		log.WithFields(log.Fields{
+			"repository": dw.Repository.RepoName,
+		}).Debug("trigger.dockerHubHandler: event without tag, ignoring")
+		resp.WriteHeader(http.StatusOK)
 		return
 	}
 
@@ -111,7 +116,56 @@ func (s *TriggerServer) dockerHubHandler(resp http.ResponseWriter, req *http.Req
 
 	s.trigger(event)
 
+	if isDockerHubCallback(dw.CallbackURL) {
+		go dockerHubCallback(dw.CallbackURL, event)
+	}
+
 	resp.WriteHeader(http.StatusOK)
 
 	newDockerhubWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
 }
+
+var dockerHubCallbackClient = &http.Client{Timeout: 10 * time.Second}
+
+type dockerHubCallbackRequest struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+}
+
+// isDockerHubCallback - callback URL comes from the payload, only acknowledge
+// it when it points back to docker hub
+func isDockerHubCallback(callbackURL string) bool {
+	if callbackURL == "" {
+		return false
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return u.Scheme == "https" && (host == "docker.com" || strings.HasSuffix(host, ".docker.com"))
+}
+
+// dockerHubCallback - acknowledges webhook so docker hub can mark it (and any
+// webhook chain that follows) as successful
+func dockerHubCallback(callbackURL string, event types.Event) {
+	body, err := json.Marshal(&dockerHubCallbackRequest{
+		State:       "success",
+		Description: fmt.Sprintf("event for %s received", event.Repository.String()),
+		Context:     "keel",
+	})
+	if err != nil {
+		return
+	}
+
+	cbResp, err := dockerHubCallbackClient.Post(callbackURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":        err,
+			"callback_url": callbackURL,
+		}).Warn("trigger.dockerHubHandler: failed to acknowledge webhook callback")
+		return
+	}
+	cbResp.Body.Close()
+}
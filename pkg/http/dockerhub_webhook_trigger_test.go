@@ -68,3 +68,49 @@ func TestDockerhubWebhookHandler(t *testing.T) {
 		t.Errorf("expected 0.1.7 but got %s", fp.submitted[0].Repository.Tag)
 	}
 }
+
+func TestDockerhubWebhookHandlerNoTag(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	body := `{"push_data": {"tag": ""}, "repository": {"repo_name": "karolisr/keel"}}`
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/dockerhub", bytes.NewBuffer([]byte(body)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+
+		t.Log(rec.Body.String())
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected no events to be submitted, got: %d", len(fp.submitted))
+	}
+}
+
+func TestIsDockerHubCallback(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://registry.hub.docker.com/u/karolisr/keel/hook/22hagb51h1gfb4eefc5f1g4j3abi0beg4/", true},
+		{"https://hub.docker.com/callback", true},
+		{"http://registry.hub.docker.com/u/karolisr/keel/hook/", false},
+		{"https://docker.com.example.org/hook/", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isDockerHubCallback(tt.url); got != tt.want {
+			t.Errorf("isDockerHubCallback(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
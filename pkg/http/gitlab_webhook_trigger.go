@@ -0,0 +1,129 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var newGitlabWebhooksCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gitlab_webhook_requests_total",
+		Help: "How many /v1/webhooks/gitlab requests processed, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(newGitlabWebhooksCounter)
+}
+
+// Example of GitLab container registry event
+// {
+//     "object_kind": "container_registry",
+//     "event_name": "push",
+//     "project": {
+//         "id": 15,
+//         "path_with_namespace": "mygroup/myproject"
+//     },
+//     "repository": {
+//         "name": "app",
+//         "path": "mygroup/myproject/app",
+//         "location": "registry.gitlab.com/mygroup/myproject/app"
+//     },
+//     "tag": {
+//         "name": "1.2.3",
+//         "path": "mygroup/myproject/app:1.2.3",
+//         "location": "registry.gitlab.com/mygroup/myproject/app:1.2.3",
+//         "digest": "sha256:c3d4a6b4d1cbd5e4b2f0a4f1e0c3b2a1d4c5b6a7e8f9a0b1c2d3e4f5a6b7c8d9"
+//     }
+// }
+
+type gitlabWebhook struct {
+	ObjectKind string `json:"object_kind"`
+	EventName  string `json:"event_name"`
+	Project    struct {
+		ID                int    `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	Repository struct {
+		Name     string `json:"name"`
+		Path     string `json:"path"`
+		Location string `json:"location"`
+	} `json:"repository"`
+	Tag struct {
+		Name     string `json:"name"`
+		Path     string `json:"path"`
+		Location string `json:"location"`
+		Digest   string `json:"digest"`
+	} `json:"tag"`
+}
+
+// gitlabHandler - used to react to GitLab container registry push events
+func (s *TriggerServer) gitlabHandler(resp http.ResponseWriter, req *http.Request) {
+	if s.gitlabWebhookToken != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Gitlab-Token")), []byte(s.gitlabWebhookToken)) != 1 {
+		log.Warn("trigger.gitlabHandler: invalid or missing webhook token")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	gw := gitlabWebhook{}
+	if err := json.NewDecoder(req.Body).Decode(&gw); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("trigger.gitlabHandler: failed to decode request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if gw.EventName != "push" {
+		log.WithFields(log.Fields{
+			"event_name": gw.EventName,
+		}).Debug("trigger.gitlabHandler: not a push event, ignoring")
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+
+	location := gw.Tag.Location
+	if location == "" && gw.Repository.Location != "" && gw.Tag.Name != "" {
+		location = gw.Repository.Location + ":" + gw.Tag.Name
+	}
+	if location == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "image location cannot be empty")
+		return
+	}
+
+	imageRef, err := image.Parse(location)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"location": location,
+		}).Error("trigger.gitlabHandler: failed to parse image location")
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "failed to parse image location %s, error: %s", location, err)
+		return
+	}
+
+	event := types.Event{}
+	event.CreatedAt = time.Now()
+	event.TriggerName = "gitlab"
+	event.Repository.Name = imageRef.Repository()
+	event.Repository.Tag = imageRef.Tag()
+	event.Repository.Digest = gw.Tag.Digest
+
+	s.trigger(event)
+
+	resp.WriteHeader(http.StatusOK)
+
+	newGitlabWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
+}
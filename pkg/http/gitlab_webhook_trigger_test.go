@@ -0,0 +1,145 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+
+	"net/http/httptest"
+	"testing"
+)
+
+var fakeGitlabWebhook = `{
+    "object_kind": "container_registry",
+    "event_name": "push",
+    "project": {
+        "id": 15,
+        "path_with_namespace": "mygroup/myproject"
+    },
+    "repository": {
+        "name": "app",
+        "path": "mygroup/myproject/app",
+        "location": "registry.gitlab.com/mygroup/myproject/app"
+    },
+    "tag": {
+        "name": "1.2.3",
+        "path": "mygroup/myproject/app:1.2.3",
+        "location": "registry.gitlab.com/mygroup/myproject/app:1.2.3",
+        "digest": "sha256:c3d4a6b4d1cbd5e4b2f0a4f1e0c3b2a1d4c5b6a7e8f9a0b1c2d3e4f5a6b7c8d9"
+    }
+}
+`
+
+var fakeGitlabWebhookNoTagLocation = `{
+    "event_name": "push",
+    "repository": {
+        "location": "registry.gitlab.com/mygroup/myproject/app"
+    },
+    "tag": {
+        "name": "1.2.4"
+    }
+}
+`
+
+var fakeGitlabWebhookDelete = `{
+    "object_kind": "container_registry",
+    "event_name": "delete",
+    "repository": {
+        "location": "registry.gitlab.com/mygroup/myproject/app"
+    },
+    "tag": {
+        "name": "1.2.3",
+        "location": "registry.gitlab.com/mygroup/myproject/app:1.2.3"
+    }
+}
+`
+
+func TestGitlabWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantCode      int
+		wantSubmitted int
+		wantTag       string
+	}{
+		{"push", fakeGitlabWebhook, 200, 1, "1.2.3"},
+		{"push without tag location", fakeGitlabWebhookNoTagLocation, 200, 1, "1.2.4"},
+		{"delete", fakeGitlabWebhookDelete, 200, 0, ""},
+		{"no location", `{"event_name": "push"}`, 400, 0, ""},
+		{"malformed", `{"event_name": `, 400, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/gitlab", bytes.NewBuffer([]byte(tt.body)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+
+			rec := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("unexpected status code: %d", rec.Code)
+				t.Log(rec.Body.String())
+			}
+
+			if len(fp.submitted) != tt.wantSubmitted {
+				t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+			if tt.wantSubmitted == 0 {
+				return
+			}
+
+			if fp.submitted[0].Repository.Name != "registry.gitlab.com/mygroup/myproject/app" {
+				t.Errorf("expected registry.gitlab.com/mygroup/myproject/app but got %s", fp.submitted[0].Repository.Name)
+			}
+
+			if fp.submitted[0].Repository.Tag != tt.wantTag {
+				t.Errorf("expected %s but got %s", tt.wantTag, fp.submitted[0].Repository.Tag)
+			}
+		})
+	}
+}
+
+func TestGitlabWebhookHandlerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		wantCode      int
+		wantSubmitted int
+	}{
+		{"missing", "", 401, 0},
+		{"wrong", "not-the-token", 401, 0},
+		{"valid", "gitlab-token", 200, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+			srv.gitlabWebhookToken = "gitlab-token"
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/gitlab", bytes.NewBuffer([]byte(fakeGitlabWebhook)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.token != "" {
+				req.Header.Set("X-Gitlab-Token", tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("unexpected status code: %d", rec.Code)
+			}
+
+			if len(fp.submitted) != tt.wantSubmitted {
+				t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+		})
+	}
+}
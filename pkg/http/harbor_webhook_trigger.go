@@ -1,9 +1,11 @@
 package http
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/keel-hq/keel/types"
@@ -68,7 +70,20 @@ type harborWebhook struct {
 	} `json:"event_data"`
 }
 
+// validHarborSecret - Harbor sends the configured auth header value as is,
+// accepting both raw secret and "Bearer <secret>" forms
+func validHarborSecret(header, secret string) bool {
+	header = strings.TrimPrefix(header, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(header), []byte(secret)) == 1
+}
+
 func (s *TriggerServer) harborHandler(resp http.ResponseWriter, req *http.Request) {
+	if s.harborWebhookSecret != "" && !validHarborSecret(req.Header.Get("Authorization"), s.harborWebhookSecret) {
+		log.Warn("trigger.harborHandler: invalid or missing webhook secret")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	hn := harborWebhook{}
 	if err := json.NewDecoder(req.Body).Decode(&hn); err != nil {
 		log.WithFields(log.Fields{
@@ -81,7 +96,7 @@ func (s *TriggerServer) harborHandler(resp http.ResponseWriter, req *http.Reques
 		"event": hn,
 	}).Debug("harborHandler: received event, looking for a pushImage tag")
 
-	if hn.Type == "pushImage" || hn.Type == "PUSH_ARTIFACT" { 
+	if hn.Type == "pushImage" || hn.Type == "PUSH_ARTIFACT" {
 		// go trough all the ressource items
 		for _, e := range hn.EventData.Resources {
 			imageRepo, err := image.Parse(e.ResourceURL)
@@ -179,3 +179,44 @@ func TestHarborWebhookHandlerMalformed(t *testing.T) {
 		t.Errorf("expected latest but got %s", fp.submitted[0].Repository.Tag)
 	}
 }
+
+func TestHarborWebhookHandlerSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+		wantSubmitted int
+	}{
+		{"missing", "", 401, 0},
+		{"wrong", "not-the-secret", 401, 0},
+		{"raw", "harbor-secret", 200, 1},
+		{"bearer", "Bearer harbor-secret", 200, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+			srv.harborWebhookSecret = "harbor-secret"
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/harbor", bytes.NewBuffer([]byte(fakeHarborWebhook2)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("unexpected status code: %d", rec.Code)
+			}
+
+			if len(fp.submitted) != tt.wantSubmitted {
+				t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+		})
+	}
+}
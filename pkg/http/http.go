@@ -2,16 +2,20 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/negroni"
 
@@ -21,16 +25,37 @@ import (
 	"github.com/keel-hq/keel/pkg/store"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/provider/kubernetes"
+	"github.com/keel-hq/keel/trigger/poll"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/version"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var webhookRequestsCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "keel_webhook_requests_total",
+		Help: "How many webhook events were submitted to providers, partitioned by webhook type.",
+	},
+	[]string{"type"},
+)
+
+func init() {
+	prometheus.MustRegister(webhookRequestsCounter)
+}
+
 // Opts - http server options
 type Opts struct {
 	Port int
 
+	// PathPrefix - optional base path all routes are served under, e.g. /keel
+	PathPrefix string
+
+	// TLSCertFile, TLSKeyFile - optional, when both are set server serves
+	// HTTPS, certificate is reloaded when files change
+	TLSCertFile string
+	TLSKeyFile  string
+
 	// available providers
 	Providers provider.Providers
 
@@ -47,8 +72,59 @@ type Opts struct {
 	UIDir string
 
 	AuthenticatedWebhooks bool
+
+	// HarborWebhookSecret - optional, when set Harbor webhooks must
+	// carry it in the Authorization header
+	HarborWebhookSecret string
+
+	// GitlabWebhookToken - optional, when set GitLab webhooks must
+	// carry it in the X-Gitlab-Token header
+	GitlabWebhookToken string
+
+	// ArtifactoryWebhookSecret - optional, when set Artifactory webhooks must
+	// carry it in the X-JFrog-Event-Auth header
+	ArtifactoryWebhookSecret string
+
+	// NativeWebhookSecret - optional, when set native webhooks must be signed
+	// with HMAC-SHA256 of the request body in NativeWebhookSignatureHeader
+	NativeWebhookSecret          string
+	NativeWebhookSignatureHeader string
+
+	// SlackSigningSecret - optional, when set Slack approval button clicks are
+	// accepted on /v1/slack/interactions
+	SlackSigningSecret string
+
+	// WebhookDedupWindow - identical webhook events (same image, tag and
+	// digest) received within the window are submitted only once, 0 disables
+	WebhookDedupWindow time.Duration
+
+	// DefaultPollSchedule - schedule set by /v1/tracked requests without one,
+	// defaults to types.KeelPollDefaultSchedule
+	DefaultPollSchedule string
+
+	// PollStatus - optional, last registry check results included in
+	// /v1/tracked
+	PollStatus PollStatusGetter
+
+	// ReadinessChecks - named checks served on /readyz
+	ReadinessChecks map[string]ReadinessCheck
+
+	// LivenessChecks - named checks served on /healthz
+	LivenessChecks map[string]LivenessCheck
 }
 
+// PollStatusGetter - returns result of the last registry check of an image
+// tracked by poll trigger
+type PollStatusGetter interface {
+	Status(image *types.TrackedImage) (poll.ImageStatus, bool)
+}
+
+// ReadinessCheck - reports whether a component is ready to handle events
+type ReadinessCheck func() bool
+
+// LivenessCheck - returns an error if a component is stuck or has died
+type LivenessCheck func() error
+
 // TriggerServer - webhook trigger & healthcheck server
 type TriggerServer struct {
 	grc              *k8s.GenericResourceCache
@@ -57,6 +133,9 @@ type TriggerServer struct {
 	providers        provider.Providers
 	approvalsManager approvals.Manager
 	port             int
+	pathPrefix       string
+	tlsCertFile      string
+	tlsKeyFile       string
 	server           *http.Server
 	router           *mux.Router
 
@@ -66,21 +145,63 @@ type TriggerServer struct {
 	uiDir string
 
 	authenticatedWebhooks bool
+
+	harborWebhookSecret      string
+	gitlabWebhookToken       string
+	artifactoryWebhookSecret string
+
+	nativeWebhookSecret          string
+	nativeWebhookSignatureHeader string
+
+	slackSigningSecret string
+
+	dedup *eventDeduplicator
+
+	pollStatus PollStatusGetter
+
+	defaultPollSchedule string
+
+	readinessChecks map[string]ReadinessCheck
+	livenessChecks  map[string]LivenessCheck
 }
 
 // NewTriggerServer - create new HTTP trigger based server
 func NewTriggerServer(opts *Opts) *TriggerServer {
+	signatureHeader := opts.NativeWebhookSignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = DefaultNativeWebhookSignatureHeader
+	}
+
+	pollSchedule := opts.DefaultPollSchedule
+	if pollSchedule == "" {
+		pollSchedule = types.KeelPollDefaultSchedule
+	}
+
 	return &TriggerServer{
-		port:                  opts.Port,
-		grc:                   opts.GRC,
-		kubernetesClient:      opts.KubernetesClient,
-		providers:             opts.Providers,
-		approvalsManager:      opts.ApprovalManager,
-		router:                mux.NewRouter(),
-		authenticator:         opts.Authenticator,
-		store:                 opts.Store,
-		uiDir:                 opts.UIDir,
-		authenticatedWebhooks: opts.AuthenticatedWebhooks,
+		port:                         opts.Port,
+		pathPrefix:                   normalizePathPrefix(opts.PathPrefix),
+		tlsCertFile:                  opts.TLSCertFile,
+		tlsKeyFile:                   opts.TLSKeyFile,
+		grc:                          opts.GRC,
+		kubernetesClient:             opts.KubernetesClient,
+		providers:                    opts.Providers,
+		approvalsManager:             opts.ApprovalManager,
+		router:                       mux.NewRouter(),
+		authenticator:                opts.Authenticator,
+		store:                        opts.Store,
+		uiDir:                        opts.UIDir,
+		authenticatedWebhooks:        opts.AuthenticatedWebhooks,
+		harborWebhookSecret:          opts.HarborWebhookSecret,
+		gitlabWebhookToken:           opts.GitlabWebhookToken,
+		artifactoryWebhookSecret:     opts.ArtifactoryWebhookSecret,
+		nativeWebhookSecret:          opts.NativeWebhookSecret,
+		nativeWebhookSignatureHeader: signatureHeader,
+		slackSigningSecret:           opts.SlackSigningSecret,
+		dedup:                        newEventDeduplicator(opts.WebhookDedupWindow),
+		pollStatus:                   opts.PollStatus,
+		defaultPollSchedule:          pollSchedule,
+		readinessChecks:              opts.ReadinessChecks,
+		livenessChecks:               opts.LivenessChecks,
 	}
 }
 
@@ -91,15 +212,39 @@ func (s *TriggerServer) Start() error {
 
 	n := negroni.New(negroni.NewRecovery())
 	n.Use(negroni.HandlerFunc(corsHeadersMiddleware))
-	n.UseHandler(s.router)
+	n.UseHandler(s.handler())
 
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
 		Handler: n,
 	}
 
+	if s.tlsCertFile != "" && s.tlsKeyFile != "" {
+		reloader, err := newCertReloader(s.tlsCertFile, s.tlsKeyFile)
+		if err != nil {
+			return err
+		}
+		s.server.TLSConfig = &tls.Config{
+			GetCertificate: reloader.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+
+		log.WithFields(log.Fields{
+			"port":        s.port,
+			"path_prefix": s.pathPrefix,
+			"cert_file":   s.tlsCertFile,
+		}).Info("webhook trigger server starting with TLS...")
+
+		return s.server.ListenAndServeTLS("", "")
+	}
+
+	if s.tlsCertFile != "" || s.tlsKeyFile != "" {
+		log.Warn("http: both TLS certificate and key files are required, serving plain HTTP")
+	}
+
 	log.WithFields(log.Fields{
-		"port": s.port,
+		"port":        s.port,
+		"path_prefix": s.pathPrefix,
 	}).Info("webhook trigger server starting...")
 
 	return s.server.ListenAndServe()
@@ -112,6 +257,41 @@ func (s *TriggerServer) Stop() {
 	s.server.Shutdown(ctx)
 }
 
+// handler - routes are registered without the path prefix, it's stripped
+// from requests before routing so UI assets are served from the same paths
+func (s *TriggerServer) handler() http.Handler {
+	if s.pathPrefix == "" {
+		return s.router
+	}
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		path := strings.TrimPrefix(req.URL.Path, s.pathPrefix)
+		if len(path) == len(req.URL.Path) || (path != "" && path[0] != '/') {
+			http.NotFound(resp, req)
+			return
+		}
+		if path == "" {
+			path = "/"
+		}
+
+		r := new(http.Request)
+		*r = *req
+		r.URL = new(url.URL)
+		*r.URL = *req.URL
+		r.URL.Path = path
+		r.URL.RawPath = ""
+		s.router.ServeHTTP(resp, r)
+	})
+}
+
+// normalizePathPrefix - returns prefix with a leading slash and without a trailing one
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
 func getID(req *http.Request) string {
 	return mux.Vars(req)["id"]
 }
@@ -124,8 +304,15 @@ func (s *TriggerServer) registerRoutes(mux *mux.Router) {
 
 	s.registerWebhookRoutes(mux)
 
+	// Slack verifies requests with its own signature so the endpoint doesn't
+	// need admin authentication
+	if s.slackSigningSecret != "" {
+		mux.HandleFunc("/v1/slack/interactions", s.slackInteractionsHandler).Methods("POST", "OPTIONS")
+	}
+
 	// health endpoint for k8s to be happy
 	mux.HandleFunc("/healthz", s.healthHandler).Methods("GET", "OPTIONS")
+	mux.HandleFunc("/readyz", s.readyHandler).Methods("GET", "OPTIONS")
 	// version handler
 	mux.HandleFunc("/version", s.versionHandler).Methods("GET", "OPTIONS")
 
@@ -179,26 +366,32 @@ func (s *TriggerServer) registerRoutes(mux *mux.Router) {
 func (s *TriggerServer) registerWebhookRoutes(mux *mux.Router) {
 
 	if s.authenticatedWebhooks {
-		mux.HandleFunc("/v1/webhooks/native", s.requireAdminAuthorization(s.nativeHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/dockerhub", s.requireAdminAuthorization(s.dockerHubHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/jfrog", s.requireAdminAuthorization(s.jfrogHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/quay", s.requireAdminAuthorization(s.quayHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/azure", s.requireAdminAuthorization(s.azureHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/github", s.requireAdminAuthorization(s.githubHandler)).Methods("POST", "OPTIONS")
-		mux.HandleFunc("/v1/webhooks/harbor", s.requireAdminAuthorization(s.harborHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/native", s.requireWebhookAuthorization(s.nativeHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/dockerhub", s.requireWebhookAuthorization(s.dockerHubHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/jfrog", s.requireWebhookAuthorization(s.jfrogHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/quay", s.requireWebhookAuthorization(s.quayHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/azure", s.requireWebhookAuthorization(s.azureHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/acr", s.requireWebhookAuthorization(s.azureHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/github", s.requireWebhookAuthorization(s.githubHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/harbor", s.requireWebhookAuthorization(s.harborHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/gitlab", s.requireWebhookAuthorization(s.gitlabHandler)).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/artifactory", s.requireWebhookAuthorization(s.artifactoryHandler)).Methods("POST", "OPTIONS")
 
 		// Docker registry notifications, used by Docker, Gitlab, Harbor
 		// https://docs.docker.com/registry/notifications/
 		//https://docs.gitlab.com/ee/administration/container_registry.html#configure-container-registry-notifications
-		mux.HandleFunc("/v1/webhooks/registry", s.registryNotificationHandler).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/registry", s.requireWebhookAuthorization(s.registryNotificationHandler)).Methods("POST", "OPTIONS")
 	} else {
 		mux.HandleFunc("/v1/webhooks/native", s.nativeHandler).Methods("POST", "OPTIONS")
 		mux.HandleFunc("/v1/webhooks/dockerhub", s.dockerHubHandler).Methods("POST", "OPTIONS")
 		mux.HandleFunc("/v1/webhooks/jfrog", s.jfrogHandler).Methods("POST", "OPTIONS")
 		mux.HandleFunc("/v1/webhooks/quay", s.quayHandler).Methods("POST", "OPTIONS")
 		mux.HandleFunc("/v1/webhooks/azure", s.azureHandler).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/acr", s.azureHandler).Methods("POST", "OPTIONS")
 		mux.HandleFunc("/v1/webhooks/github", s.githubHandler).Methods("POST", "OPTIONS")
 		mux.HandleFunc("/v1/webhooks/harbor", s.harborHandler).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/gitlab", s.gitlabHandler).Methods("POST", "OPTIONS")
+		mux.HandleFunc("/v1/webhooks/artifactory", s.artifactoryHandler).Methods("POST", "OPTIONS")
 
 		// Docker registry notifications, used by Docker, Gitlab, Harbor
 		// https://docs.docker.com/registry/notifications/
@@ -207,10 +400,48 @@ func (s *TriggerServer) registerWebhookRoutes(mux *mux.Router) {
 	}
 }
 
+// healthHandler - liveness probe, responds with 200 OK while all liveness
+// checks pass and 503 Service Unavailable (listing failed checks) otherwise
 func (s *TriggerServer) healthHandler(resp http.ResponseWriter, req *http.Request) {
+	var failed []string
+	for _, name := range sortedKeys(s.livenessChecks) {
+		if err := s.livenessChecks[name](); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	writeProbeResponse(resp, failed)
+}
+
+// readyHandler - readiness probe, responds with 200 OK once all readiness
+// checks pass and 503 Service Unavailable (listing pending checks) until then
+func (s *TriggerServer) readyHandler(resp http.ResponseWriter, req *http.Request) {
+	var failed []string
+	for _, name := range sortedKeys(s.readinessChecks) {
+		if !s.readinessChecks[name]() {
+			failed = append(failed, fmt.Sprintf("%s: not ready", name))
+		}
+	}
+	writeProbeResponse(resp, failed)
+}
+
+func writeProbeResponse(resp http.ResponseWriter, failed []string) {
+	if len(failed) > 0 {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(resp, strings.Join(failed, "\n"))
+		return
+	}
 	resp.WriteHeader(http.StatusOK)
 }
 
+func sortedKeys[T any](checks map[string]T) []string {
+	keys := make([]string, 0, len(checks))
+	for k := range checks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (s *TriggerServer) versionHandler(resp http.ResponseWriter, req *http.Request) {
 	v := version.GetKeelVersion()
 
@@ -226,7 +457,21 @@ func (s *TriggerServer) versionHandler(resp http.ResponseWriter, req *http.Reque
 }
 
 func (s *TriggerServer) trigger(event types.Event) error {
-	return s.providers.Submit(event)
+	if s.dedup.duplicate(event) {
+		webhookDuplicatesCounter.With(prometheus.Labels{"type": event.TriggerName}).Inc()
+		log.WithFields(log.Fields{
+			"image":   event.Repository.String(),
+			"digest":  event.Repository.Digest,
+			"trigger": event.TriggerName,
+		}).Debug("trigger: ignoring duplicate webhook event")
+		return errDuplicateEvent
+	}
+	webhookRequestsCounter.With(prometheus.Labels{"type": event.TriggerName}).Inc()
+	if err := s.providers.Submit(event); err != nil {
+		return err
+	}
+	s.dedup.record(event)
+	return nil
 }
 
 func response(obj interface{}, statusCode int, err error, resp http.ResponseWriter, req *http.Request) {
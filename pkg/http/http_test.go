@@ -0,0 +1,174 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestReadyHandler(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	ready := false
+	srv.readinessChecks = map[string]ReadinessCheck{
+		"kubernetes cache": func() bool { return ready },
+		"poll":             func() bool { return true },
+	}
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got: %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "kubernetes cache: not ready") {
+		t.Errorf("expected pending check in response, got: %s", rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "poll") {
+		t.Errorf("didn't expect ready check in response, got: %s", rec.Body.String())
+	}
+
+	ready = true
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got: %d", rec.Code)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 without checks, got: %d", rec.Code)
+	}
+
+	srv.livenessChecks = map[string]LivenessCheck{
+		"kubernetes provider": func() error { return errors.New("event loop panicked: boom") },
+	}
+
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got: %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "kubernetes provider: event loop panicked: boom") {
+		t.Errorf("unexpected response: %s", rec.Body.String())
+	}
+}
+
+func TestNormalizePathPrefix(t *testing.T) {
+	for in, want := range map[string]string{
+		"":        "",
+		"/":       "",
+		"keel":    "/keel",
+		"/keel/":  "/keel",
+		"/a/keel": "/a/keel",
+	} {
+		if got := normalizePathPrefix(in); got != want {
+			t.Errorf("normalizePathPrefix(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	srv.pathPrefix = normalizePathPrefix("/keel/")
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{"GET", "/keel/healthz", http.StatusOK},
+		{"GET", "/keel/version", http.StatusOK},
+		{"POST", "/keel/v1/webhooks/native", http.StatusOK},
+		{"GET", "/healthz", http.StatusNotFound},
+		{"GET", "/keelhealthz", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`))
+		rec := httptest.NewRecorder()
+		srv.handler().ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got: %d", tt.method, tt.path, tt.want, rec.Code)
+		}
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Errorf("expected webhook to be submitted through prefixed path, got: %d", len(fp.submitted))
+	}
+}
+
+func TestAuthenticatedWebhooks(t *testing.T) {
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	srv.authenticatedWebhooks = true
+	srv.router = mux.NewRouter()
+	srv.registerRoutes(srv.router)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		username string
+		password string
+		want     int
+	}{
+		{"webhook without credentials", "POST", "/v1/webhooks/native", "", "", http.StatusUnauthorized},
+		{"webhook with wrong password", "POST", "/v1/webhooks/native", "user-1", "wrong", http.StatusUnauthorized},
+		{"webhook with credentials", "POST", "/v1/webhooks/native", "user-1", "secret", http.StatusOK},
+		{"registry notifications without credentials", "POST", "/v1/webhooks/registry", "", "", http.StatusUnauthorized},
+		{"approvals without credentials", "POST", "/v1/approvals", "", "", http.StatusUnauthorized},
+		{"health is exempt", "GET", "/healthz", "", "", http.StatusOK},
+		{"metrics are exempt", "GET", "/metrics", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`))
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got: %d", tt.want, rec.Code)
+			}
+
+			challenge := rec.Header().Get("WWW-Authenticate")
+			isWebhook := strings.HasPrefix(tt.path, "/v1/webhooks/")
+			if tt.want == http.StatusUnauthorized && isWebhook && !strings.HasPrefix(challenge, "Basic ") {
+				t.Errorf("expected basic auth challenge, got: '%s'", challenge)
+			}
+			if !isWebhook && challenge != "" {
+				t.Errorf("didn't expect challenge on admin routes, got: '%s'", challenge)
+			}
+		})
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Errorf("expected only authenticated webhook to be submitted, got: %d", len(fp.submitted))
+	}
+}
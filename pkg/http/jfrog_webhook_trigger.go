@@ -114,18 +114,7 @@ func (s *TriggerServer) jfrogHandler(resp http.ResponseWriter, req *http.Request
 		return
 	}
 
-	// for every updated tag generating event
-	event := types.Event{}
-	event.CreatedAt = time.Now()
-	event.TriggerName = "jfrog"
-	event.Repository.Tag = jw.Data.Tag
-	event.Repository.Name = jw.Data.ImageName
-	if privReg, ok := os.LookupEnv(EnvPrivateRegistry); ok {
-		if len(privReg) >= 3 {
-			event.Repository.Name = fmt.Sprintf("%s/%s", privReg, jw.Data.ImageName)
-		}
-	}
-
+	event := newJfrogEvent("jfrog", jw.Data.ImageName, jw.Data.Tag)
 	log.Infof("Received jfrog webhook for image: %s:%s", jw.Data.ImageName, jw.Data.Tag)
 	log.Debug("jfrogWebhook data: ", jw)
 	s.trigger(event)
@@ -134,3 +123,19 @@ func (s *TriggerServer) jfrogHandler(resp http.ResponseWriter, req *http.Request
 	resp.WriteHeader(http.StatusOK)
 	return
 }
+
+// newJfrogEvent - image names in JFrog payloads don't include registry
+// host, it's taken from PRIVATE_REGISTRY env variable when set
+func newJfrogEvent(triggerName, imageName, tag string) types.Event {
+	event := types.Event{}
+	event.CreatedAt = time.Now()
+	event.TriggerName = triggerName
+	event.Repository.Tag = tag
+	event.Repository.Name = imageName
+	if privReg, ok := os.LookupEnv(EnvPrivateRegistry); ok {
+		if len(privReg) >= 3 {
+			event.Repository.Name = fmt.Sprintf("%s/%s", privReg, imageName)
+		}
+	}
+	return event
+}
@@ -1,12 +1,19 @@
 package http
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/keel-hq/keel/provider/kubernetes"
 	"github.com/keel-hq/keel/types"
+	"github.com/opencontainers/go-digest"
 	"github.com/prometheus/client_golang/prometheus"
 
 	log "github.com/sirupsen/logrus"
@@ -24,10 +31,103 @@ func init() {
 	prometheus.MustRegister(newNativeWebhooksCounter)
 }
 
-// nativeHandler - used to trigger event directly
+// DefaultNativeWebhookSignatureHeader - header carrying native webhook signature,
+// "sha256=<hex encoded HMAC-SHA256 of the body>" or just the hex digest
+const DefaultNativeWebhookSignatureHeader = "X-Keel-Signature"
+
+// DefaultWebhookWaitTimeout - how long native webhook waits for the outcome
+// of the event with ?wait=true
+const DefaultWebhookWaitTimeout = 30 * time.Second
+
+// MaxWebhookWaitTimeout - longest wait accepted in the wait parameter
+const MaxWebhookWaitTimeout = 5 * time.Minute
+
+// webhookStatusTimeout - status returned when providers didn't finish
+// processing the event in time
+const webhookStatusTimeout = "timeout"
+
+// webhookResult - native webhook response when caller waits for the outcome
+type webhookResult struct {
+	Status    string                 `json:"status"`
+	Message   string                 `json:"message,omitempty"`
+	Resources []types.ResourceUpdate `json:"resources"`
+}
+
+// validSignature - checks HMAC-SHA256 signature of the body
+func validSignature(secret string, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// resourceSecretMatches - reports whether request is signed with the webhook
+// secret of any resource, providers then only update resources it's signed for
+func (s *TriggerServer) resourceSecretMatches(authorize func(secret string) bool) bool {
+	if s.grc == nil || s.kubernetesClient == nil {
+		return false
+	}
+	for _, resource := range s.grc.Values() {
+		secret, err := kubernetes.WebhookSecret(s.kubernetesClient, resource)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+			}).Warn("trigger.nativeHandler: failed to get resource webhook secret")
+			continue
+		}
+		if secret != "" && authorize(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeHandler - used to trigger event directly, accepts
+//
+//	{"name": "repo/image", "tag": "1.2.3", "digest": "sha256:..."}
+//
+// digest is optional, when set it's passed to providers so resources tracking
+// the same tag with the force policy are only restarted when the digest changes.
+// With ?wait=true (or a duration, i.e. ?wait=2m) the response is sent once
+// providers processed the event and describes the outcome
 func (s *TriggerServer) nativeHandler(resp http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("failed to read request")
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// resources with their own secret only accept requests signed with it,
+	// the rest fall back to the global secret
+	signature := req.Header.Get(s.nativeWebhookSignatureHeader)
+	globalValid := s.nativeWebhookSecret == "" || validSignature(s.nativeWebhookSecret, body, signature)
+	authorize := func(secret string) bool {
+		if secret == "" {
+			return globalValid
+		}
+		return validSignature(secret, body, signature)
+	}
+
+	if !globalValid && !s.resourceSecretMatches(authorize) {
+		log.WithFields(log.Fields{
+			"header": s.nativeWebhookSignatureHeader,
+		}).Warn("trigger.nativeHandler: invalid or missing webhook signature")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	repo := types.Repository{}
-	if err := json.NewDecoder(req.Body).Decode(&repo); err != nil {
+	if err := json.Unmarshal(body, &repo); err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Error("failed to decode request")
@@ -49,13 +149,86 @@ func (s *TriggerServer) nativeHandler(resp http.ResponseWriter, req *http.Reques
 		return
 	}
 
+	if repo.Digest != "" {
+		if _, err := digest.Parse(repo.Digest); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "invalid repository digest: %s", err)
+			return
+		}
+	}
+
+	wait, err := waitTimeout(req.URL.Query().Get("wait"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "invalid wait parameter: %s", err)
+		return
+	}
+
 	event.Repository = repo
 	event.CreatedAt = time.Now()
 	event.TriggerName = "native"
-	s.trigger(event)
-
-	resp.WriteHeader(http.StatusOK)
+	event.Authorize = authorize
+	event.Signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	if wait > 0 {
+		event.Results = types.NewEventResults()
+	}
+	err = s.trigger(event)
 
 	newNativeWebhooksCounter.With(prometheus.Labels{"image": event.Repository.Name}).Inc()
-	return
+
+	if wait == 0 {
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+	writeWebhookResult(resp, event.Results, wait, err)
+}
+
+// waitTimeout - parses the wait query parameter, "true" waits for the
+// default timeout, durations are capped at MaxWebhookWaitTimeout. 0 when
+// caller doesn't wait
+func waitTimeout(value string) (time.Duration, error) {
+	switch value {
+	case "", "false", "0":
+		return 0, nil
+	case "true", "1":
+		return DefaultWebhookWaitTimeout, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+	if d > MaxWebhookWaitTimeout {
+		d = MaxWebhookWaitTimeout
+	}
+	return d, nil
+}
+
+// writeWebhookResult - waits for providers to process the event and writes
+// the outcome, 202 is returned when they don't finish in time and 500 when
+// any resource failed to update
+func writeWebhookResult(resp http.ResponseWriter, results *types.EventResults, timeout time.Duration, triggerErr error) {
+	result := webhookResult{}
+	code := http.StatusOK
+	switch {
+	case triggerErr == errDuplicateEvent:
+		result.Status = types.UpdateStatusNoop
+		result.Message = "duplicate event, identical event was already submitted"
+	case !results.Wait(timeout):
+		result.Status = webhookStatusTimeout
+		result.Message = fmt.Sprintf("event is still being processed after %s", timeout)
+		code = http.StatusAccepted
+	default:
+		result.Status = results.Status()
+		if result.Status == types.UpdateStatusError {
+			code = http.StatusInternalServerError
+		}
+	}
+	result.Resources = results.Resources()
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	json.NewEncoder(resp).Encode(result)
 }
@@ -2,14 +2,22 @@ package http
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/internal/k8s"
 	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/provider/kubernetes"
 	"github.com/keel-hq/keel/types"
 
 	"github.com/keel-hq/keel/pkg/auth"
@@ -19,6 +27,9 @@ import (
 	"testing"
 
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func NewTestingUtils() (*sql.SQLStore, func()) {
@@ -69,11 +80,12 @@ func NewTestingServer(fp provider.Provider) (*TriggerServer, func()) {
 type fakeProvider struct {
 	submitted []types.Event
 	images    []*types.TrackedImage
+	err       error
 }
 
 func (p *fakeProvider) Submit(event types.Event) error {
 	p.submitted = append(p.submitted, event)
-	return nil
+	return p.err
 }
 
 func (p *fakeProvider) TrackedImages() ([]*types.TrackedImage, error) {
@@ -112,6 +124,35 @@ func TestNativeWebhookHandler(t *testing.T) {
 	}
 }
 
+func TestNativeWebhookHandlerMetrics(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBuffer([]byte(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	srv.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, err = http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if !strings.Contains(rec.Body.String(), `keel_webhook_requests_total{type="native"}`) {
+		t.Errorf("expected webhook requests counter in metrics output")
+	}
+}
+
 func TestNativeWebhookHandlerNoRepoName(t *testing.T) {
 
 	fp := &fakeProvider{}
@@ -136,3 +177,278 @@ func TestNativeWebhookHandlerNoRepoName(t *testing.T) {
 	}
 
 }
+
+func TestNativeWebhookHandlerDigest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantDigest string
+	}{
+		{
+			name:       "valid digest",
+			body:       `{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1", "digest": "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"}`,
+			wantStatus: 200,
+			wantDigest: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+		{
+			name:       "invalid digest",
+			body:       `{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1", "digest": "sha256:xxx"}`,
+			wantStatus: 400,
+		},
+		{
+			name:       "no tag",
+			body:       `{"name": "gcr.io/v2-namespace/hello-world", "digest": "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"}`,
+			wantStatus: 400,
+		},
+		{
+			name:       "malformed body",
+			body:       `{"name": `,
+			wantStatus: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code: %d", rec.Code)
+			}
+			if tt.wantStatus != 200 {
+				if len(fp.submitted) != 0 {
+					t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+				}
+				return
+			}
+			if len(fp.submitted) != 1 {
+				t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+			if fp.submitted[0].Repository.Digest != tt.wantDigest {
+				t.Errorf("unexpected digest: %s", fp.submitted[0].Repository.Digest)
+			}
+		})
+	}
+}
+
+func TestNativeWebhookHandlerSignature(t *testing.T) {
+	body := []byte(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`)
+	mac := hmac.New(sha256.New, []byte("very-secret"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name       string
+		secret     string
+		header     string
+		sentHeader string
+		signature  string
+		wantCode   int
+	}{
+		{"no secret configured", "", "", "", "", 200},
+		{"missing signature", "very-secret", "", "", "", 401},
+		{"invalid signature", "very-secret", "", DefaultNativeWebhookSignatureHeader, "sha256=deadbeef", 401},
+		{"valid prefixed signature", "very-secret", "", DefaultNativeWebhookSignatureHeader, "sha256=" + signature, 200},
+		{"valid bare signature", "very-secret", "", DefaultNativeWebhookSignatureHeader, signature, 200},
+		{"custom header", "very-secret", "X-Hub-Signature-256", "X-Hub-Signature-256", "sha256=" + signature, 200},
+		{"default header ignored with custom header", "very-secret", "X-Hub-Signature-256", DefaultNativeWebhookSignatureHeader, signature, 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			srv.nativeWebhookSecret = tt.secret
+			if tt.header != "" {
+				srv.nativeWebhookSignatureHeader = tt.header
+			}
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			if tt.sentHeader != "" {
+				req.Header.Set(tt.sentHeader, tt.signature)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status code %d, got: %d", tt.wantCode, rec.Code)
+			}
+
+			submitted := 0
+			if tt.wantCode == 200 {
+				submitted = 1
+			}
+			if len(fp.submitted) != submitted {
+				t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+		})
+	}
+}
+
+// secretImplementer - returns the same secret for every secret ref
+type secretImplementer struct {
+	kubernetes.Implementer
+	secret *v1.Secret
+}
+
+func (i *secretImplementer) Secret(namespace, name string) (*v1.Secret, error) {
+	return i.secret, nil
+}
+
+func TestNativeWebhookHandlerResourceSecret(t *testing.T) {
+	body := []byte(`{"name": "gcr.io/v2-namespace/hello-world", "tag": "1.1.1"}`)
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name          string
+		globalSecret  string
+		signedWith    string
+		wantCode      int
+		wantAuthorize map[string]bool
+	}{
+		{name: "resource secret", globalSecret: "global", signedWith: "team-a", wantCode: 200, wantAuthorize: map[string]bool{"": false, "team-a": true, "team-b": false}},
+		{name: "resource secret ref", globalSecret: "global", signedWith: "team-b", wantCode: 200, wantAuthorize: map[string]bool{"": false, "team-a": false, "team-b": true}},
+		{name: "global secret", globalSecret: "global", signedWith: "global", wantCode: 200, wantAuthorize: map[string]bool{"": true, "team-a": false}},
+		{name: "no global secret", signedWith: "other", wantCode: 200, wantAuthorize: map[string]bool{"": true, "team-a": false}},
+		{name: "unknown secret", globalSecret: "global", signedWith: "other", wantCode: 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			srv.nativeWebhookSecret = tt.globalSecret
+			srv.grc = &k8s.GenericResourceCache{}
+			for name, annotations := range map[string]map[string]string{
+				"team-a": {types.KeelWebhookSecretAnnotation: "team-a"},
+				"team-b": {types.KeelWebhookSecretRefAnnotation: "webhook/secret"},
+			} {
+				gr, err := k8s.NewGenericResource(&apps_v1.Deployment{
+					ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+				})
+				if err != nil {
+					t.Fatalf("failed to create resource: %s", err)
+				}
+				srv.grc.Add(gr)
+			}
+			srv.kubernetesClient = &secretImplementer{secret: &v1.Secret{Data: map[string][]byte{"secret": []byte("team-b")}}}
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/native", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+			req.Header.Set(DefaultNativeWebhookSignatureHeader, sign(tt.signedWith))
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status code %d, got: %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantCode != 200 {
+				if len(fp.submitted) != 0 {
+					t.Errorf("unexpected number of events submitted: %d", len(fp.submitted))
+				}
+				return
+			}
+
+			if len(fp.submitted) != 1 {
+				t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+			}
+			for secret, want := range tt.wantAuthorize {
+				if got := fp.submitted[0].Authorize(secret); got != want {
+					t.Errorf("Authorize(%q) = %t, want %t", secret, got, want)
+				}
+			}
+		})
+	}
+}
+
+// reportingProvider - processes events in the background, reporting status
+type reportingProvider struct {
+	fakeProvider
+	status string
+	delay  time.Duration
+}
+
+func (p *reportingProvider) Submit(event types.Event) error {
+	event.Results.Add(1)
+	go func() {
+		defer event.Results.Done()
+		time.Sleep(p.delay)
+		event.Results.Report(types.ResourceUpdate{
+			Provider:   "fp",
+			Identifier: "deployment/default/wd",
+			NewVersion: event.Repository.Tag,
+			Status:     p.status,
+		})
+	}()
+	return nil
+}
+
+func TestNativeWebhookHandlerWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		status     string
+		delay      time.Duration
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "fire and forget", query: "", status: types.UpdateStatusUpdated, wantCode: 200},
+		{name: "updated", query: "?wait=true", status: types.UpdateStatusUpdated, wantCode: 200, wantStatus: types.UpdateStatusUpdated},
+		{name: "pending approval", query: "?wait=5s", status: types.UpdateStatusPending, wantCode: 200, wantStatus: types.UpdateStatusPending},
+		{name: "failed", query: "?wait=5s", status: types.UpdateStatusError, wantCode: 500, wantStatus: types.UpdateStatusError},
+		{name: "timeout", query: "?wait=10ms", status: types.UpdateStatusUpdated, delay: time.Second, wantCode: 202, wantStatus: webhookStatusTimeout},
+		{name: "invalid wait", query: "?wait=soon", wantCode: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &reportingProvider{status: tt.status, delay: tt.delay}
+			srv, teardown := NewTestingServer(fp)
+			defer teardown()
+
+			req, err := http.NewRequest("POST", "/v1/webhooks/native"+tt.query, bytes.NewBuffer([]byte(`{"name": "karolisr/keel", "tag": "0.2.0"}`)))
+			if err != nil {
+				t.Fatalf("failed to create req: %s", err)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status code %d, got: %d (%s)", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if tt.wantStatus == "" {
+				return
+			}
+
+			var result webhookResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got: %s", tt.wantStatus, result.Status)
+			}
+			if tt.wantStatus != webhookStatusTimeout && (len(result.Resources) != 1 || result.Resources[0].NewVersion != "0.2.0") {
+				t.Errorf("unexpected resources: %+v", result.Resources)
+			}
+		})
+	}
+}
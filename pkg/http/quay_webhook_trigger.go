@@ -45,6 +45,24 @@ type quayWebhook struct {
 	UpdatedTags []string `json:"updated_tags"`
 }
 
+// quayRegistry - used to build image name when docker_url is not supplied
+const quayRegistry = "quay.io"
+
+// imageName - returns docker_url or, if it's missing, builds image name from
+// namespace and repository name
+func (qw *quayWebhook) imageName() string {
+	if qw.DockerURL != "" {
+		return qw.DockerURL
+	}
+	if qw.Namespace != "" && qw.Name != "" {
+		return quayRegistry + "/" + qw.Namespace + "/" + qw.Name
+	}
+	if qw.Repository != "" {
+		return quayRegistry + "/" + qw.Repository
+	}
+	return ""
+}
+
 func (s *TriggerServer) quayHandler(resp http.ResponseWriter, req *http.Request) {
 	qw := quayWebhook{}
 	if err := json.NewDecoder(req.Body).Decode(&qw); err != nil {
@@ -55,7 +73,8 @@ func (s *TriggerServer) quayHandler(resp http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	if qw.DockerURL == "" {
+	imageName := qw.imageName()
+	if imageName == "" {
 		resp.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(resp, "docker_url cannot be empty")
 		return
@@ -69,10 +88,13 @@ func (s *TriggerServer) quayHandler(resp http.ResponseWriter, req *http.Request)
 
 	// for every updated tag generating event
 	for _, tag := range qw.UpdatedTags {
+		if tag == "" {
+			continue
+		}
 		event := types.Event{}
 		event.CreatedAt = time.Now()
 		event.TriggerName = "quay"
-		event.Repository.Name = qw.DockerURL
+		event.Repository.Name = imageName
 		event.Repository.Tag = tag
 
 		s.trigger(event)
@@ -53,3 +53,72 @@ func TestQuayWebhookHandler(t *testing.T) {
 		t.Errorf("expected 1.2.3 but got %s", fp.submitted[0].Repository.Tag)
 	}
 }
+
+var fakeQuayWebhookMultipleTags = `{
+  "name": "repository",
+  "repository": "mynamespace/repository",
+  "namespace": "mynamespace",
+  "homepage": "https://quay.io/repository/mynamespace/repository",
+  "updated_tags": [
+    "1.2.3",
+    "latest"
+  ]
+}
+`
+
+func TestQuayWebhookHandlerMultipleTags(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/quay", bytes.NewBuffer([]byte(fakeQuayWebhookMultipleTags)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+
+		t.Log(rec.Body.String())
+	}
+
+	if len(fp.submitted) != 2 {
+		t.Fatalf("unexpected number of events submitted: %d", len(fp.submitted))
+	}
+
+	for idx, tag := range []string{"1.2.3", "latest"} {
+		if fp.submitted[idx].Repository.Name != "quay.io/mynamespace/repository" {
+			t.Errorf("expected quay.io/mynamespace/repository but got %s", fp.submitted[idx].Repository.Name)
+		}
+		if fp.submitted[idx].Repository.Tag != tag {
+			t.Errorf("expected %s but got %s", tag, fp.submitted[idx].Repository.Tag)
+		}
+	}
+}
+
+func TestQuayWebhookHandlerMalformed(t *testing.T) {
+
+	fp := &fakeProvider{}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("POST", "/v1/webhooks/quay", bytes.NewBuffer([]byte(`{"docker_url": `)))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != 400 {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected no events to be submitted, got: %d", len(fp.submitted))
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	slackbot "github.com/keel-hq/keel/bot/slack"
+	"github.com/nlopes/slack"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// slackInteractionsHandler - receives Slack interactive message callbacks, approve
+// and reject button clicks are applied to the approval and the original message
+// is replaced to show who acted on it
+func (s *TriggerServer) slackInteractionsHandler(resp http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	verifier, err := slack.NewSecretsVerifier(req.Header, s.slackSigningSecret)
+	if err == nil {
+		verifier.Write(body)
+		err = verifier.Ensure()
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("http.slackInteractionsHandler: invalid request signature")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(resp, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(values.Get("payload")), &callback); err != nil {
+		http.Error(resp, fmt.Sprintf("invalid payload: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if !slackbot.IsApprovalInteraction(&callback) {
+		http.Error(resp, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+
+	// votes and rejections are both recorded against the user
+	if callback.User.ID == "" {
+		http.Error(resp, "missing user", http.StatusForbidden)
+		return
+	}
+
+	msg, err := slackbot.ApprovalActionMessage(s.approvalsManager, &callback)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(resp).Encode(msg)
+}
@@ -0,0 +1,236 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+	"github.com/nlopes/slack"
+)
+
+const testSlackSigningSecret = "slack-secret"
+
+func newSlackInteractionRequest(t *testing.T, secret, payload string) *http.Request {
+	body := url.Values{"payload": {payload}}.Encode()
+	req, err := http.NewRequest("POST", "/v1/slack/interactions", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func slackApprovalPayload(action, identifier string) string {
+	return fmt.Sprintf(`{
+		"type": "interactive_message",
+		"callback_id": "keel_approval",
+		"user": {"id": "U123", "name": "jane"},
+		"actions": [{"name": %q, "type": "button", "value": %q}],
+		"original_message": {
+			"text": "",
+			"attachments": [{
+				"callback_id": "keel_approval",
+				"fields": [{"title": "Votes", "value": "0/1", "short": true}],
+				"actions": [
+					{"name": "approve", "text": "Approve", "type": "button", "value": %q},
+					{"name": "reject", "text": "Reject", "type": "button", "value": %q}
+				]
+			}]
+		}
+	}`, action, identifier, identifier, identifier)
+}
+
+func TestSlackInteractions(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		action     string
+		wantStatus int
+		wantField  string
+		wantResult types.ApprovalStatus
+	}{
+		{name: "approve", secret: testSlackSigningSecret, action: "approve", wantStatus: 200, wantField: "Approved by <@U123>", wantResult: types.ApprovalStatusApproved},
+		{name: "reject", secret: testSlackSigningSecret, action: "reject", wantStatus: 200, wantField: "Rejected by <@U123>", wantResult: types.ApprovalStatusRejected},
+		{name: "unknown action", secret: testSlackSigningSecret, action: "delete", wantStatus: 400, wantResult: types.ApprovalStatusPending},
+		{name: "invalid signature", secret: "wrong", action: "approve", wantStatus: 401, wantResult: types.ApprovalStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, teardown := NewTestingUtils()
+			defer teardown()
+
+			am := approvals.New(&approvals.Opts{Store: store})
+			srv := NewTriggerServer(&Opts{
+				Providers:          provider.New([]provider.Provider{&fakeProvider{}}, am),
+				ApprovalManager:    am,
+				Authenticator:      auth.New(&auth.Opts{}),
+				Store:              store,
+				SlackSigningSecret: testSlackSigningSecret,
+			})
+			srv.registerRoutes(srv.router)
+
+			err := am.Create(&types.Approval{
+				Identifier:     "dev/whd-dev:0.0.15",
+				VotesRequired:  1,
+				NewVersion:     "0.0.15",
+				CurrentVersion: "0.0.14",
+				Deadline:       time.Now().Add(time.Hour),
+			})
+			if err != nil {
+				t.Fatalf("failed to create approval: %s", err)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, newSlackInteractionRequest(t, tt.secret, slackApprovalPayload(tt.action, "dev/whd-dev:0.0.15")))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			approval, err := am.Get("dev/whd-dev:0.0.15")
+			if err != nil {
+				t.Fatalf("failed to get approval: %s", err)
+			}
+			if approval.Status() != tt.wantResult {
+				t.Errorf("unexpected approval status: %s", approval.Status())
+			}
+
+			if tt.wantStatus != 200 {
+				return
+			}
+
+			var msg slack.Message
+			if err := json.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !msg.ReplaceOriginal {
+				t.Errorf("expected original message to be replaced")
+			}
+			if len(msg.Attachments) != 1 {
+				t.Fatalf("unexpected attachments: %d", len(msg.Attachments))
+			}
+			if len(msg.Attachments[0].Actions) != 0 {
+				t.Errorf("expected buttons to be removed")
+			}
+			var found bool
+			for _, f := range msg.Attachments[0].Fields {
+				if strings.Contains(f.Value, tt.wantField) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected field %q, got: %v", tt.wantField, msg.Attachments[0].Fields)
+			}
+		})
+	}
+}
+
+func TestSlackInteractionsExpiredApproval(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{Store: store})
+	srv := NewTriggerServer(&Opts{
+		Providers:          provider.New([]provider.Provider{&fakeProvider{}}, am),
+		ApprovalManager:    am,
+		Authenticator:      auth.New(&auth.Opts{}),
+		Store:              store,
+		SlackSigningSecret: testSlackSigningSecret,
+	})
+	srv.registerRoutes(srv.router)
+
+	// approval expired and was deleted, message still has buttons
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, newSlackInteractionRequest(t, testSlackSigningSecret, slackApprovalPayload("approve", "dev/whd-dev:0.0.15")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var msg slack.Message
+	if err := json.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if !msg.ReplaceOriginal || len(msg.Attachments) != 1 {
+		t.Fatalf("expected original message to be replaced, got: %+v", msg)
+	}
+	if len(msg.Attachments[0].Actions) != 0 {
+		t.Errorf("expected buttons to be removed")
+	}
+	fields := msg.Attachments[0].Fields
+	if len(fields) == 0 || !strings.Contains(fields[len(fields)-1].Value, "no longer pending") {
+		t.Errorf("expected expired field, got: %v", fields)
+	}
+}
+
+func TestSlackInteractionsMissingUser(t *testing.T) {
+	store, teardown := NewTestingUtils()
+	defer teardown()
+
+	am := approvals.New(&approvals.Opts{Store: store})
+	srv := NewTriggerServer(&Opts{
+		Providers:          provider.New([]provider.Provider{&fakeProvider{}}, am),
+		ApprovalManager:    am,
+		Authenticator:      auth.New(&auth.Opts{}),
+		Store:              store,
+		SlackSigningSecret: testSlackSigningSecret,
+	})
+	srv.registerRoutes(srv.router)
+
+	err := am.Create(&types.Approval{
+		Identifier:     "dev/whd-dev:0.0.15",
+		VotesRequired:  1,
+		NewVersion:     "0.0.15",
+		CurrentVersion: "0.0.14",
+		Deadline:       time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	payload := strings.Replace(slackApprovalPayload("reject", "dev/whd-dev:0.0.15"), `"id": "U123"`, `"id": ""`, 1)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, newSlackInteractionRequest(t, testSlackSigningSecret, payload))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	approval, err := am.Get("dev/whd-dev:0.0.15")
+	if err != nil {
+		t.Fatalf("failed to get approval: %s", err)
+	}
+	if approval.Status() != types.ApprovalStatusPending {
+		t.Errorf("unexpected approval status: %s", approval.Status())
+	}
+}
+
+func TestSlackInteractionsNotConfigured(t *testing.T) {
+	srv, teardown := NewTestingServer(&fakeProvider{})
+	defer teardown()
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, newSlackInteractionRequest(t, testSlackSigningSecret, slackApprovalPayload("approve", "dev/whd-dev:0.0.15")))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// certReloadInterval - how often certificate files are checked for changes
+const certReloadInterval = 30 * time.Second
+
+// certReloader - serves certificate loaded from files, reloading it when
+// files change so renewed certificates are picked up without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate - used as tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, checked := r.cert, r.checked
+	r.mu.RUnlock()
+
+	if time.Since(checked) < certReloadInterval {
+		return cert, nil
+	}
+
+	r.mu.Lock()
+	r.checked = time.Now()
+	r.mu.Unlock()
+
+	modTime, err := r.filesModTime()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("http.certReloader: failed to check certificate files, using current certificate")
+		return cert, nil
+	}
+
+	r.mu.RLock()
+	changed := modTime.After(r.modTime)
+	r.mu.RUnlock()
+
+	if changed {
+		if err := r.load(modTime); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("http.certReloader: failed to reload certificate, using current certificate")
+			return cert, nil
+		}
+		log.WithFields(log.Fields{
+			"cert_file": r.certFile,
+		}).Info("http.certReloader: certificate reloaded")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %s", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.checked = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// filesModTime - latest modification time of certificate and key files
+func (r *certReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
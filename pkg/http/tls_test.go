@@ -0,0 +1,114 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %s", err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("failed to write key: %s", err)
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, modTime, modTime); err != nil {
+			t.Fatalf("failed to set modification time: %s", err)
+		}
+	}
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keeltls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeTestCert(t, certFile, keyFile, "first", time.Now().Add(-time.Minute))
+
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %s", err)
+	}
+
+	cert, _ := r.GetCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Errorf("unexpected certificate: %s", name)
+	}
+
+	writeTestCert(t, certFile, keyFile, "second", time.Now())
+
+	// files are not checked again until reload interval passes
+	cert, _ = r.GetCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Errorf("didn't expect certificate to be reloaded yet, got: %s", name)
+	}
+
+	r.checked = time.Time{}
+	cert, _ = r.GetCertificate(nil)
+	if name := commonName(t, cert); name != "second" {
+		t.Errorf("expected renewed certificate, got: %s", name)
+	}
+
+	// broken files keep current certificate
+	if err := ioutil.WriteFile(certFile, []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(certFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	r.checked = time.Time{}
+	cert, err = r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name := commonName(t, cert); name != "second" {
+		t.Errorf("expected current certificate to be kept, got: %s", name)
+	}
+}
+
+func TestCertReloaderMissingFiles(t *testing.T) {
+	if _, err := newCertReloader("/nonexistent/tls.crt", "/nonexistent/tls.key"); err == nil {
+		t.Errorf("expected error for missing certificate files")
+	}
+}
@@ -10,13 +10,24 @@ import (
 )
 
 type trackedImage struct {
-	Image        string `json:"image"`
-	Trigger      string `json:"trigger"`
-	PollSchedule string `json:"pollSchedule"`
-	Provider     string `json:"provider"`
-	Namespace    string `json:"namespace"`
-	Policy       string `json:"policy"`
-	Registry     string `json:"registry"`
+	Image        string     `json:"image"`
+	Trigger      string     `json:"trigger"`
+	PollSchedule string     `json:"pollSchedule"`
+	Provider     string     `json:"provider"`
+	Namespace    string     `json:"namespace"`
+	Policy       string     `json:"policy"`
+	Registry     string     `json:"registry"`
+	Kind         string     `json:"kind"`
+	Name         string     `json:"name"`
+	Identifier   string     `json:"identifier"`
+	Paused       bool       `json:"paused"`
+	LastUpdated  *time.Time `json:"lastUpdated,omitempty"`
+
+	// last registry check, only set for images tracked by poll trigger
+	LastSeenTag    string     `json:"lastSeenTag,omitempty"`
+	LastSeenDigest string     `json:"lastSeenDigest,omitempty"`
+	LastCheckedAt  *time.Time `json:"lastCheckedAt,omitempty"`
+	LastCheckError string     `json:"lastCheckError,omitempty"`
 }
 
 func (s *TriggerServer) trackedHandler(resp http.ResponseWriter, req *http.Request) {
@@ -25,7 +36,7 @@ func (s *TriggerServer) trackedHandler(resp http.ResponseWriter, req *http.Reque
 	var imgs []trackedImage
 
 	for _, img := range trackedImages {
-		imgs = append(imgs, trackedImage{
+		ti := trackedImage{
 			Image:        img.Image.Name(),
 			Trigger:      img.Trigger.String(),
 			PollSchedule: img.PollSchedule,
@@ -33,7 +44,29 @@ func (s *TriggerServer) trackedHandler(resp http.ResponseWriter, req *http.Reque
 			Namespace:    img.Namespace,
 			Policy:       img.Policy.Name(),
 			Registry:     img.Image.Registry(),
-		})
+			Kind:         img.Kind,
+			Name:         img.Name,
+			Identifier:   img.Identifier,
+			Paused:       img.Paused,
+		}
+		if !img.LastUpdated.IsZero() {
+			lastUpdated := img.LastUpdated
+			ti.LastUpdated = &lastUpdated
+		}
+		if s.pollStatus != nil {
+			if status, ok := s.pollStatus.Status(img); ok {
+				ti.LastSeenTag = status.Tag
+				ti.LastSeenDigest = status.Digest
+				if !status.CheckedAt.IsZero() {
+					checkedAt := status.CheckedAt
+					ti.LastCheckedAt = &checkedAt
+				}
+				if status.Error != nil {
+					ti.LastCheckError = status.Error.Error()
+				}
+			}
+		}
+		imgs = append(imgs, ti)
 	}
 
 	response(&imgs, 200, err, resp, req)
@@ -68,10 +101,10 @@ func (s *TriggerServer) trackSetHandler(resp http.ResponseWriter, req *http.Requ
 	}
 
 	switch trackReq.Trigger {
-	case "default", "poll":
+	case "default", "poll", "push", "both":
 		// ok
 	default:
-		http.Error(resp, "unknown trigger type, supported: 'default', 'poll'", http.StatusBadRequest)
+		http.Error(resp, "unknown trigger type, supported: 'default', 'poll', 'push', 'both'", http.StatusBadRequest)
 		return
 	}
 
@@ -83,7 +116,7 @@ func (s *TriggerServer) trackSetHandler(resp http.ResponseWriter, req *http.Requ
 			return
 		}
 	} else {
-		trackReq.Schedule = types.KeelPollDefaultSchedule
+		trackReq.Schedule = s.defaultPollSchedule
 	}
 
 	for _, v := range s.grc.Values() {
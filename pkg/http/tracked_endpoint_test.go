@@ -0,0 +1,177 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/trigger/poll"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+func TestTrackedHandler(t *testing.T) {
+	updatedAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	wdRef, _ := image.Parse("karolisr/webhook-demo:0.0.15")
+	nginxRef, _ := image.Parse("nginx:1.19.0")
+
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{
+				Image:       wdRef,
+				Trigger:     types.TriggerTypePoll,
+				Provider:    "kubernetes",
+				Namespace:   "default",
+				Policy:      policy.NewSemverPolicy(policy.SemverPolicyTypeMajor, true),
+				Kind:        "deployment",
+				Name:        "wd",
+				Identifier:  "deployment/default/wd",
+				Paused:      true,
+				LastUpdated: updatedAt,
+			},
+			{
+				Image:      nginxRef,
+				Trigger:    types.TriggerTypeDefault,
+				Provider:   "kubernetes",
+				Namespace:  "web",
+				Policy:     policy.NewForcePolicy(false),
+				Kind:       "statefulset",
+				Name:       "nginx",
+				Identifier: "statefulset/web/nginx",
+			},
+		},
+	}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+
+	req, err := http.NewRequest("GET", "/v1/tracked", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("user-1", "secret")
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var tracked []trackedImage
+	if err := json.Unmarshal(rec.Body.Bytes(), &tracked); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+
+	if len(tracked) != 2 {
+		t.Fatalf("expected 2 tracked images, got: %d", len(tracked))
+	}
+
+	wd := tracked[0]
+	if wd.Kind != "deployment" || wd.Namespace != "default" || wd.Name != "wd" {
+		t.Errorf("unexpected resource: %s %s/%s", wd.Kind, wd.Namespace, wd.Name)
+	}
+	if wd.Policy != "major" {
+		t.Errorf("unexpected policy: %s", wd.Policy)
+	}
+	if !wd.Paused {
+		t.Errorf("expected resource to be paused")
+	}
+	if wd.LastUpdated == nil || !wd.LastUpdated.Equal(updatedAt) {
+		t.Errorf("unexpected last update time: %v", wd.LastUpdated)
+	}
+
+	if tracked[1].LastUpdated != nil {
+		t.Errorf("expected last update time to be omitted, got: %v", tracked[1].LastUpdated)
+	}
+	if tracked[1].Paused {
+		t.Errorf("didn't expect resource to be paused")
+	}
+}
+
+type fakePollStatus struct {
+	statuses map[string]poll.ImageStatus
+}
+
+func (f *fakePollStatus) Status(image *types.TrackedImage) (poll.ImageStatus, bool) {
+	status, ok := f.statuses[image.Image.Remote()]
+	return status, ok
+}
+
+func TestTrackedHandlerPollStatus(t *testing.T) {
+	checkedAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	wdRef, _ := image.Parse("karolisr/webhook-demo:0.0.15")
+	nginxRef, _ := image.Parse("nginx:1.19.0")
+
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{Image: wdRef, Trigger: types.TriggerTypePoll, Policy: policy.NewSemverPolicy(policy.SemverPolicyTypeMajor, true)},
+			{Image: nginxRef, Trigger: types.TriggerTypeDefault, Policy: policy.NewForcePolicy(false)},
+		},
+	}
+	srv, teardown := NewTestingServer(fp)
+	defer teardown()
+	srv.pollStatus = &fakePollStatus{statuses: map[string]poll.ImageStatus{
+		wdRef.Remote(): {
+			Tag:       "0.0.16",
+			Digest:    "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+			CheckedAt: checkedAt,
+			Error:     errors.New("registry unavailable"),
+		},
+	}}
+
+	req, err := http.NewRequest("GET", "/v1/tracked", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+	req.SetBasicAuth("user-1", "secret")
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("unexpected status code: %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var tracked []trackedImage
+	if err := json.Unmarshal(rec.Body.Bytes(), &tracked); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+
+	wd := tracked[0]
+	if wd.LastSeenTag != "0.0.16" || wd.LastSeenDigest != "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb" {
+		t.Errorf("unexpected last seen tag/digest: %s %s", wd.LastSeenTag, wd.LastSeenDigest)
+	}
+	if wd.LastCheckedAt == nil || !wd.LastCheckedAt.Equal(checkedAt) {
+		t.Errorf("unexpected last check time: %v", wd.LastCheckedAt)
+	}
+	if wd.LastCheckError != "registry unavailable" {
+		t.Errorf("unexpected last check error: %s", wd.LastCheckError)
+	}
+
+	if tracked[1].LastSeenTag != "" || tracked[1].LastCheckedAt != nil {
+		t.Errorf("expected poll status to be omitted for image that isn't polled, got: %+v", tracked[1])
+	}
+}
+
+func TestTrackedHandlerRequiresAuth(t *testing.T) {
+	srv, teardown := NewTestingServer(&fakeProvider{})
+	defer teardown()
+
+	req, err := http.NewRequest("GET", "/v1/tracked", nil)
+	if err != nil {
+		t.Fatalf("failed to create req: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
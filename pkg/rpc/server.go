@@ -0,0 +1,306 @@
+// Package rpc - gRPC API for submitting events and managing tracked images and
+// approvals, alternative to the HTTP endpoints
+package rpc
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative ../../pkg/rpc/keelpb/keel.proto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/pkg/rpc/keelpb"
+	"github.com/keel-hq/keel/pkg/store"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	"github.com/opencontainers/go-digest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultPort - default gRPC server port
+const DefaultPort = 9301
+
+// Opts - gRPC server options
+type Opts struct {
+	Port int
+
+	// available providers
+	Providers provider.Providers
+
+	ApprovalManager approvals.Manager
+
+	// Store - used to list archived approvals
+	Store store.Store
+
+	// Authenticator - when enabled all calls need basic auth or token
+	// credentials in the authorization metadata, the same as for HTTP API
+	Authenticator auth.Authenticator
+}
+
+// Server - gRPC API server
+type Server struct {
+	keelpb.UnimplementedKeelServer
+
+	port             int
+	providers        provider.Providers
+	approvalsManager approvals.Manager
+	store            store.Store
+	authenticator    auth.Authenticator
+
+	server *grpc.Server
+}
+
+// NewServer - create new gRPC server
+func NewServer(opts *Opts) *Server {
+	port := opts.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	s := &Server{
+		port:             port,
+		providers:        opts.Providers,
+		approvalsManager: opts.ApprovalManager,
+		store:            opts.Store,
+		authenticator:    opts.Authenticator,
+	}
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(s.authorize))
+	keelpb.RegisterKeelServer(s.server, s)
+	return s
+}
+
+// Start - start server, blocks until server is stopped
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+	return s.serve(lis)
+}
+
+func (s *Server) serve(lis net.Listener) error {
+	log.WithFields(log.Fields{
+		"port": s.port,
+	}).Info("rpc: gRPC server started")
+
+	return s.server.Serve(lis)
+}
+
+// Stop - stops server, waits for in-flight calls
+func (s *Server) Stop() {
+	s.server.GracefulStop()
+}
+
+// authorize - checks "authorization" metadata ("Basic <base64 user:pass>" or
+// "Bearer <token>") when authentication is enabled. Without it only events can
+// be submitted, same as HTTP API only serves webhooks.
+func (s *Server) authorize(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.authenticator == nil || !s.authenticator.Enabled() {
+		if info.FullMethod != "/keel.v1.Keel/SubmitEvent" {
+			return nil, status.Error(codes.FailedPrecondition, "authentication has to be enabled to use this method")
+		}
+		return handler(ctx, req)
+	}
+
+	authReq, err := authRequest(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if _, err := s.authenticator.Authenticate(authReq); err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"method": info.FullMethod,
+		}).Warn("rpc: authentication failed")
+		return nil, status.Error(codes.Unauthenticated, "authentication failed")
+	}
+	return handler(ctx, req)
+}
+
+func authRequest(ctx context.Context) (*auth.AuthRequest, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, fmt.Errorf("missing authorization metadata")
+	}
+
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid authorization metadata")
+	}
+	switch strings.ToLower(parts[0]) {
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid basic auth credentials")
+		}
+		credentials := strings.SplitN(string(decoded), ":", 2)
+		if len(credentials) != 2 {
+			return nil, fmt.Errorf("invalid basic auth credentials")
+		}
+		return &auth.AuthRequest{Username: credentials[0], Password: credentials[1], AuthType: auth.AuthTypeBasic}, nil
+	case "bearer":
+		return &auth.AuthRequest{Token: parts[1], AuthType: auth.AuthTypeToken}, nil
+	}
+	return nil, fmt.Errorf("unsupported authorization type '%s'", parts[0])
+}
+
+// SubmitEvent - submits event to providers, same as the native webhook
+func (s *Server) SubmitEvent(ctx context.Context, req *keelpb.SubmitEventRequest) (*keelpb.SubmitEventResponse, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "repository name cannot be empty")
+	}
+	if req.Tag == "" {
+		return nil, status.Error(codes.InvalidArgument, "repository tag cannot be empty")
+	}
+	if req.Digest != "" {
+		if _, err := digest.Parse(req.Digest); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid repository digest: %s", err)
+		}
+	}
+
+	err := s.providers.Submit(types.Event{
+		Repository: types.Repository{
+			Name:   req.Name,
+			Tag:    req.Tag,
+			Digest: req.Digest,
+		},
+		CreatedAt:   time.Now(),
+		TriggerName: "grpc",
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &keelpb.SubmitEventResponse{}, nil
+}
+
+// ListTracked - lists tracked images
+func (s *Server) ListTracked(ctx context.Context, req *keelpb.ListTrackedRequest) (*keelpb.ListTrackedResponse, error) {
+	trackedImages, err := s.providers.TrackedImages()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &keelpb.ListTrackedResponse{}
+	for _, img := range trackedImages {
+		ti := &keelpb.TrackedImage{
+			Image:        img.Image.Name(),
+			Trigger:      img.Trigger.String(),
+			PollSchedule: img.PollSchedule,
+			Provider:     img.Provider,
+			Namespace:    img.Namespace,
+			Registry:     img.Image.Registry(),
+			Kind:         img.Kind,
+			Name:         img.Name,
+			Identifier:   img.Identifier,
+			Paused:       img.Paused,
+		}
+		if img.Policy != nil {
+			ti.Policy = img.Policy.Name()
+		}
+		if !img.LastUpdated.IsZero() {
+			ti.LastUpdated = timestamppb.New(img.LastUpdated)
+		}
+		resp.Images = append(resp.Images, ti)
+	}
+	return resp, nil
+}
+
+// ListApprovals - lists pending approvals, archived ones too when requested
+func (s *Server) ListApprovals(ctx context.Context, req *keelpb.ListApprovalsRequest) (*keelpb.ListApprovalsResponse, error) {
+	var (
+		list []*types.Approval
+		err  error
+	)
+	if req.IncludeArchived {
+		list, err = s.store.ListApprovals(&types.GetApprovalQuery{})
+	} else {
+		list, err = s.approvalsManager.List()
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &keelpb.ListApprovalsResponse{}
+	for _, a := range list {
+		resp.Approvals = append(resp.Approvals, toProtoApproval(a))
+	}
+	return resp, nil
+}
+
+// ApprovalAction - approves, rejects, archives or deletes approval
+func (s *Server) ApprovalAction(ctx context.Context, req *keelpb.ApprovalActionRequest) (*keelpb.ApprovalActionResponse, error) {
+	if req.Identifier == "" {
+		return nil, status.Error(codes.InvalidArgument, "identifier cannot be empty")
+	}
+
+	var (
+		approval *types.Approval
+		err      error
+	)
+	switch req.Action {
+	case keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_APPROVE:
+		approval, err = s.approvalsManager.Approve(req.Identifier, req.Voter)
+	case keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_REJECT:
+		approval, err = s.approvalsManager.Reject(req.Identifier, req.Voter)
+	case keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_ARCHIVE:
+		approval, err = s.approvalsManager.Get(req.Identifier)
+		if err == nil {
+			err = s.approvalsManager.Archive(req.Identifier)
+			approval.Archived = true
+		}
+	case keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_DELETE:
+		approval, err = s.approvalsManager.Get(req.Identifier)
+		if err == nil {
+			err = s.approvalsManager.Delete(approval)
+			approval = nil
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported action '%s'", req.Action)
+	}
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			return nil, status.Errorf(codes.NotFound, "approval '%s' not found", req.Identifier)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &keelpb.ApprovalActionResponse{}
+	if approval != nil {
+		resp.Approval = toProtoApproval(approval)
+	}
+	return resp, nil
+}
+
+func toProtoApproval(a *types.Approval) *keelpb.Approval {
+	pa := &keelpb.Approval{
+		Id:             a.ID,
+		Archived:       a.Archived,
+		Provider:       a.Provider.String(),
+		Identifier:     a.Identifier,
+		Message:        a.Message,
+		CurrentVersion: a.CurrentVersion,
+		NewVersion:     a.NewVersion,
+		Digest:         a.Digest,
+		VotesRequired:  int32(a.VotesRequired),
+		VotesReceived:  int32(a.VotesReceived),
+		Voters:         a.GetVoters(),
+		Rejected:       a.Rejected,
+		CreatedAt:      timestamppb.New(a.CreatedAt),
+		UpdatedAt:      timestamppb.New(a.UpdatedAt),
+	}
+	if !a.Deadline.IsZero() {
+		pa.Deadline = timestamppb.New(a.Deadline)
+	}
+	return pa
+}
@@ -0,0 +1,188 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/pkg/auth"
+	"github.com/keel-hq/keel/pkg/rpc/keelpb"
+	"github.com/keel-hq/keel/pkg/store/sql"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+type fakeProvider struct {
+	submitted []types.Event
+}
+
+func (p *fakeProvider) Submit(event types.Event) error {
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+func (p *fakeProvider) TrackedImages() ([]*types.TrackedImage, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) List() []string {
+	return []string{"fakeprovider"}
+}
+
+func (p *fakeProvider) Stop() {}
+
+func (p *fakeProvider) GetName() string {
+	return "fp"
+}
+
+func newTestingClient(t *testing.T, fp *fakeProvider, authenticator auth.Authenticator) (keelpb.KeelClient, approvals.Manager, func()) {
+	dir, err := ioutil.TempDir("", "rpcstoretest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := sql.New(sql.Opts{DatabaseType: "sqlite3", URI: filepath.Join(dir, "gorm.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	srv := NewServer(&Opts{
+		Providers:       provider.New([]provider.Provider{fp}, am),
+		ApprovalManager: am,
+		Store:           store,
+		Authenticator:   authenticator,
+	})
+	lis := bufconn.Listen(1024 * 1024)
+	go srv.serve(lis)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teardown := func() {
+		conn.Close()
+		srv.Stop()
+		os.RemoveAll(dir)
+	}
+	return keelpb.NewKeelClient(conn), am, teardown
+}
+
+func basicAuth(user, password string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+}
+
+func TestSubmitEvent(t *testing.T) {
+	fp := &fakeProvider{}
+	client, _, teardown := newTestingClient(t, fp, auth.New(&auth.Opts{}))
+	defer teardown()
+
+	_, err := client.SubmitEvent(context.Background(), &keelpb.SubmitEventRequest{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected 1 event, got: %d", len(fp.submitted))
+	}
+	if fp.submitted[0].Repository.Tag != "1.1.1" || fp.submitted[0].TriggerName != "grpc" {
+		t.Errorf("unexpected event: %+v", fp.submitted[0])
+	}
+
+	_, err = client.SubmitEvent(context.Background(), &keelpb.SubmitEventRequest{Name: "gcr.io/v2-namespace/hello-world"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument for missing tag, got: %v", err)
+	}
+
+	// without authentication only events can be submitted
+	_, err = client.ListApprovals(context.Background(), &keelpb.ListApprovalsRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected failed precondition, got: %v", err)
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	fp := &fakeProvider{}
+	client, am, teardown := newTestingClient(t, fp, auth.New(&auth.Opts{Username: "user-1", Password: "secret"}))
+	defer teardown()
+
+	err := am.Create(&types.Approval{
+		Identifier:     "deployment/default/wd:1.2.3",
+		CurrentVersion: "1.2.2",
+		NewVersion:     "1.2.3",
+		VotesRequired:  2,
+		Deadline:       time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create approval: %s", err)
+	}
+
+	_, err = client.ListApprovals(basicAuth("user-1", "wrong"), &keelpb.ListApprovalsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected unauthenticated, got: %v", err)
+	}
+
+	ctx := basicAuth("user-1", "secret")
+	list, err := client.ListApprovals(ctx, &keelpb.ListApprovalsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(list.Approvals) != 1 || list.Approvals[0].NewVersion != "1.2.3" {
+		t.Fatalf("unexpected approvals: %v", list.Approvals)
+	}
+
+	_, err = client.ApprovalAction(ctx, &keelpb.ApprovalActionRequest{
+		Identifier: "deployment/default/wd:1.2.3",
+		Voter:      "user-1",
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument for unspecified action, got: %v", err)
+	}
+
+	resp, err := client.ApprovalAction(ctx, &keelpb.ApprovalActionRequest{
+		Identifier: "deployment/default/wd:1.2.3",
+		Action:     keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_APPROVE,
+		Voter:      "user-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.Approval.VotesReceived != 1 {
+		t.Errorf("expected 1 vote, got: %d", resp.Approval.VotesReceived)
+	}
+
+	_, err = client.ApprovalAction(ctx, &keelpb.ApprovalActionRequest{
+		Identifier: "deployment/default/wd:1.2.3",
+		Action:     keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_REJECT,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = client.ApprovalAction(ctx, &keelpb.ApprovalActionRequest{
+		Identifier: "deployment/default/missing:1.0.0",
+		Action:     keelpb.ApprovalActionType_APPROVAL_ACTION_TYPE_APPROVE,
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected not found, got: %v", err)
+	}
+}
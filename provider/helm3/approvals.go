@@ -26,11 +26,14 @@ func (p *Provider) checkForApprovals(event *types.Event, plans []*UpdatePlan) (a
 				"namespace":    plan.Namespace,
 				"version":      plan.NewVersion,
 			}).Error("provider.helm3: failed to check approval status for deployment")
+			p.report(event, plan, types.UpdateStatusError, err.Error())
 			continue
 		}
 		if approved {
 			approvedPlans = append(approvedPlans, plan)
+			continue
 		}
+		p.reportUnapproved(event, plan)
 	}
 	return approvedPlans
 }
@@ -49,44 +52,58 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 
 	// checking for existing approval
 	existing, err := p.approvalManager.Get(identifier)
-	if err != nil {
-		if err == store.ErrRecordNotFound {
-
-			// if approval doesn't exist and trigger wasn't existing approval fulfillment -
-			// create a new one, otherwise if several deployments rely on the same image, it would just be
-			// requesting approvals in a loop
-			if event.TriggerName == types.TriggerTypeApproval.String() {
-				return false, nil
-			}
-
-			if plan.Config.ApprovalDeadline == 0 {
-				plan.Config.ApprovalDeadline = types.KeelApprovalDeadlineDefault
-			}
-
-			// creating new one
-			approval := &types.Approval{
-				Provider:       types.ProviderTypeHelm,
-				Identifier:     identifier,
-				Event:          event,
-				CurrentVersion: plan.CurrentVersion,
-				NewVersion:     plan.NewVersion,
-				VotesRequired:  plan.Config.Approvals,
-				VotesReceived:  0,
-				Rejected:       false,
-				Deadline:       time.Now().Add(time.Duration(plan.Config.ApprovalDeadline) * time.Hour),
-			}
-
-			approval.Message = fmt.Sprintf("New image is available for release %s/%s (%s).",
-				plan.Namespace,
-				plan.Name,
-				approval.Delta(),
-			)
-
-			return false, p.approvalManager.Create(approval)
+	switch {
+	case err == store.ErrRecordNotFound:
+		// if approval doesn't exist and trigger wasn't existing approval fulfillment -
+		// create a new one, otherwise if several deployments rely on the same image, it would just be
+		// requesting approvals in a loop
+		if event.TriggerName == types.TriggerTypeApproval.String() {
+			return false, nil
 		}
-
+		return false, p.requestApproval(event, plan, identifier)
+	case err != nil:
 		return false, err
+	case !existing.SameTarget(event):
+		// same version was pushed again with a different image, votes were
+		// given for the previous build
+		log.WithFields(log.Fields{
+			"identifier": identifier,
+			"previous":   existing.Digest,
+			"new":        event.Repository.Digest,
+		}).Info("provider.helm3: image digest changed, requesting new approval")
+		if err := p.approvalManager.Archive(identifier); err != nil {
+			return false, err
+		}
+		return false, p.requestApproval(event, plan, identifier)
 	}
 
+	// re-triggers for the same target reuse pending approval and votes it received
 	return existing.Status() == types.ApprovalStatusApproved, nil
 }
+
+func (p *Provider) requestApproval(event *types.Event, plan *UpdatePlan, identifier string) error {
+	if plan.Config.ApprovalDeadline == 0 {
+		plan.Config.ApprovalDeadline = types.KeelApprovalDeadlineDefault
+	}
+
+	approval := &types.Approval{
+		Provider:       types.ProviderTypeHelm,
+		Identifier:     identifier,
+		Event:          event,
+		CurrentVersion: plan.CurrentVersion,
+		NewVersion:     plan.NewVersion,
+		Digest:         event.Repository.Digest,
+		VotesRequired:  plan.Config.Approvals,
+		VotesReceived:  0,
+		Rejected:       false,
+		Deadline:       time.Now().Add(time.Duration(plan.Config.ApprovalDeadline) * time.Hour),
+	}
+
+	approval.Message = fmt.Sprintf("New image is available for release %s/%s (%s).",
+		plan.Namespace,
+		plan.Name,
+		approval.Delta(),
+	)
+
+	return p.approvalManager.Create(approval)
+}
@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
 
@@ -117,6 +119,7 @@ type KeelChartConfig struct {
 	ApprovalDeadline     int               `json:"approvalDeadline"` // Deadline in hours
 	Images               []ImageDetails    `json:"images"`
 	NotificationChannels []string          `json:"notificationChannels"` // optional notification channels
+	MatchMode            string            `json:"matchMode"`            // strict or loose, defaults to provider's match mode
 
 	Plc policy.Policy `json:"-"`
 }
@@ -140,6 +143,21 @@ type Provider struct {
 
 	events chan *types.Event
 	stop   chan struct{}
+
+	// stopping - set once Stop is called, new events are rejected and
+	// inflight tracks the event that is currently being processed
+	mu       sync.Mutex
+	stopping bool
+	inflight sync.WaitGroup
+
+	// pending - submitted events that are not processed yet, see Drain
+	pending sync.WaitGroup
+
+	// matchMode - default for releases without keel.matchMode
+	matchMode string
+
+	// defaultSchedule - poll schedule of releases without keel.pollSchedule
+	defaultSchedule string
 }
 
 // NewProvider - create new Helm provider
@@ -150,9 +168,23 @@ func NewProvider(implementer Implementer, sender notification.Sender, approvalMa
 		sender:          sender,
 		events:          make(chan *types.Event, 100),
 		stop:            make(chan struct{}),
+		matchMode:       types.MatchModeStrict,
+		defaultSchedule: types.KeelPollDefaultSchedule,
 	}
 }
 
+// SetMatchMode - how event images are matched against release images unless
+// the release sets keel.matchMode, see types.KeelMatchModeAnnotation
+func (p *Provider) SetMatchMode(mode string) {
+	p.matchMode = mode
+}
+
+// SetDefaultPollSchedule - poll schedule of releases that don't set
+// keel.pollSchedule, defaults to types.KeelPollDefaultSchedule
+func (p *Provider) SetDefaultPollSchedule(schedule string) {
+	p.defaultSchedule = schedule
+}
+
 // GetName - get provider name
 func (p *Provider) GetName() string {
 	return ProviderName
@@ -160,8 +192,26 @@ func (p *Provider) GetName() string {
 
 // Submit - submit event to provider
 func (p *Provider) Submit(event types.Event) error {
-	p.events <- &event
-	return nil
+	if p.isStopping() {
+		return provider.ErrStopping
+	}
+	p.pending.Add(1)
+	event.Results.Add(1)
+	select {
+	case p.events <- &event:
+		return nil
+	case <-p.stop:
+		p.pending.Done()
+		event.Results.Done()
+		return provider.ErrStopping
+	}
+}
+
+// Drain - waits until events submitted so far are processed, events that
+// are discarded by Stop are never processed so it must not be called after
+// Stop
+func (p *Provider) Drain() {
+	p.pending.Wait()
 }
 
 // Start - starts kubernetes provider, waits for events
@@ -169,14 +219,58 @@ func (p *Provider) Start() error {
 	return p.startInternal()
 }
 
-// Stop - stops kubernetes provider
+// Stop - stops accepting new events and waits for the update that is
+// currently in progress to finish, queued events are discarded
 func (p *Provider) Stop() {
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return
+	}
+	p.stopping = true
+	p.mu.Unlock()
+
 	close(p.stop)
+	p.inflight.Wait()
+}
+
+// process - processes event, marking it as done so Stop can return
+func (p *Provider) process(event *types.Event) error {
+	defer p.inflight.Done()
+	defer p.pending.Done()
+	defer event.Results.Done()
+	err := p.processEvent(event)
+	if err != nil {
+		event.Results.Report(types.ResourceUpdate{
+			Provider: p.GetName(),
+			Status:   types.UpdateStatusError,
+			Reason:   err.Error(),
+		})
+	}
+	return err
+}
+
+func (p *Provider) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+// begin - registers event as in-flight, returns false once provider is stopping
+func (p *Provider) begin() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopping {
+		return false
+	}
+	p.inflight.Add(1)
+	return true
 }
 
 // TrackedImages - returns tracked images from all releases that have keel configuration
 func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 	var trackedImages []*types.TrackedImage
+	tracked := 0
 
 	releases, err := p.implementer.ListReleases()
 	if err != nil {
@@ -206,7 +300,7 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 		}
 
 		if cfg.PollSchedule == "" {
-			cfg.PollSchedule = types.KeelPollDefaultSchedule
+			cfg.PollSchedule = p.defaultSchedule
 		}
 		// used to check pod secrets
 		selector := fmt.Sprintf("app=%s,release=%s", release.Chart.Metadata.Name, release.Name)
@@ -227,12 +321,16 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 				"helm.sh/chart": fmt.Sprintf("%s-%s", release.Chart.Metadata.Name, release.Chart.Metadata.Version),
 			}
 			img.Namespace = release.Namespace
+			img.Kind = "Release"
+			img.Name = release.Name
 			img.Provider = ProviderName
 			trackedImages = append(trackedImages, img)
 		}
-
+		tracked++
 	}
 
+	provider.TrackedResourcesGauge.With(prometheus.Labels{"provider": ProviderName}).Set(float64(tracked))
+
 	return trackedImages, nil
 }
 
@@ -240,7 +338,11 @@ func (p *Provider) startInternal() error {
 	for {
 		select {
 		case event := <-p.events:
-			err := p.processEvent(event)
+			if !p.begin() {
+				log.WithField("queued", len(p.events)).Info("provider.helm3: shutting down, discarding queued events")
+				return nil
+			}
+			err := p.process(event)
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error": err,
@@ -256,6 +358,16 @@ func (p *Provider) startInternal() error {
 }
 
 func (p *Provider) processEvent(event *types.Event) (err error) {
+	// releases can't set their own webhook secret, only the global one
+	// authorizes them
+	if event.Authorize != nil && !event.Authorize("") {
+		log.WithFields(log.Fields{
+			"image":   event.Repository.Name,
+			"trigger": event.TriggerName,
+		}).Debug("provider.helm3: event isn't signed with the global webhook secret, skipping")
+		return nil
+	}
+
 	plans, err := p.createUpdatePlans(event)
 	if err != nil {
 		return err
@@ -263,7 +375,7 @@ func (p *Provider) processEvent(event *types.Event) (err error) {
 
 	approved := p.checkForApprovals(event, plans)
 
-	return p.applyPlans(approved)
+	return p.applyPlans(event, approved)
 }
 
 func (p *Provider) createUpdatePlans(event *types.Event) ([]*UpdatePlan, error) {
@@ -276,7 +388,7 @@ func (p *Provider) createUpdatePlans(event *types.Event) ([]*UpdatePlan, error)
 
 	for _, release := range releases {
 
-		plan, update, err := checkRelease(&event.Repository, release.Namespace, release.Name, release.Chart, release.Config)
+		plan, update, err := checkRelease(&event.Repository, release.Namespace, release.Name, release.Chart, release.Config, p.matchMode)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error":     err,
@@ -286,6 +398,16 @@ func (p *Provider) createUpdatePlans(event *types.Event) ([]*UpdatePlan, error)
 			continue
 		}
 
+		if update && !plan.Config.Trigger.Accepts(event.TriggerName) {
+			log.WithFields(log.Fields{
+				"name":      release.Name,
+				"namespace": release.Namespace,
+				"trigger":   plan.Config.Trigger.String(),
+				"event":     event.TriggerName,
+			}).Debug("provider.helm3: release doesn't accept events from this trigger, skipping")
+			continue
+		}
+
 		if update {
 			helm3VersionedUpdatesCounter.With(prometheus.Labels{"chart": fmt.Sprintf("%s/%s", release.Namespace, release.Name)}).Inc()
 			plans = append(plans, plan)
@@ -295,13 +417,14 @@ func (p *Provider) createUpdatePlans(event *types.Event) ([]*UpdatePlan, error)
 	return plans, nil
 }
 
-func (p *Provider) applyPlans(plans []*UpdatePlan) error {
+func (p *Provider) applyPlans(event *types.Event, plans []*UpdatePlan) error {
+	trigger := event.TriggerName
 	for _, plan := range plans {
 
 		p.sender.Send(types.EventNotification{
 			ResourceKind: "chart",
 			Identifier:   fmt.Sprintf("%s/%s/%s", "chart", plan.Namespace, plan.Name),
-			Name:         "update release",
+			Name:         types.EventNameUpdateRelease,
 			Message:      fmt.Sprintf("Preparing to update release %s/%s %s->%s (%s)", plan.Namespace, plan.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(mapToSlice(plan.Values), ", ")),
 			CreatedAt:    time.Now(),
 			Type:         types.NotificationPreReleaseUpdate,
@@ -322,11 +445,12 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 				"name":      plan.Name,
 				"namespace": plan.Namespace,
 			}).Error("provider.helm3: failed to apply plan")
+			p.report(event, plan, types.UpdateStatusError, err.Error())
 
 			p.sender.Send(types.EventNotification{
 				ResourceKind: "chart",
 				Identifier:   fmt.Sprintf("%s/%s/%s", "chart", plan.Namespace, plan.Name),
-				Name:         "update release",
+				Name:         types.EventNameUpdateRelease,
 				Message:      fmt.Sprintf("Release update failed %s/%s %s->%s (%s), error: %s", plan.Namespace, plan.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(mapToSlice(plan.Values), ", "), err),
 				CreatedAt:    time.Now(),
 				Type:         types.NotificationReleaseUpdate,
@@ -341,6 +465,9 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 			continue
 		}
 
+		provider.UpdatesCounter.With(prometheus.Labels{"provider": ProviderName, "trigger": trigger}).Inc()
+		p.report(event, plan, types.UpdateStatusUpdated, "")
+
 		err = p.updateComplete(plan)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -360,16 +487,20 @@ func (p *Provider) applyPlans(plans []*UpdatePlan) error {
 		p.sender.Send(types.EventNotification{
 			ResourceKind: "chart",
 			Identifier:   fmt.Sprintf("%s/%s/%s", "chart", plan.Namespace, plan.Name),
-			Name:         "update release",
+			Name:         types.EventNameUpdateRelease,
 			Message:      msg,
 			CreatedAt:    time.Now(),
 			Type:         types.NotificationReleaseUpdate,
 			Level:        types.LevelSuccess,
 			Channels:     plan.Config.NotificationChannels,
 			Metadata: map[string]string{
-				"provider":  p.GetName(),
-				"namespace": plan.Namespace,
-				"name":      plan.Name,
+				"provider":         p.GetName(),
+				"namespace":        plan.Namespace,
+				"name":             plan.Name,
+				"version":          plan.NewVersion,
+				"previous_version": plan.CurrentVersion,
+				"values":           strings.Join(mapToSlice(plan.Values), ", "),
+				"trigger":          trigger,
 			},
 		})
 
@@ -0,0 +1,39 @@
+package helm3
+
+import (
+	"fmt"
+
+	"github.com/keel-hq/keel/types"
+)
+
+// report - records outcome of the plan for triggers waiting for the event
+func (p *Provider) report(event *types.Event, plan *UpdatePlan, status, reason string) {
+	if event.Results == nil {
+		return
+	}
+	event.Results.Report(types.ResourceUpdate{
+		Provider:       p.GetName(),
+		Identifier:     fmt.Sprintf("%s/%s/%s", "chart", plan.Namespace, plan.Name),
+		Kind:           "chart",
+		Namespace:      plan.Namespace,
+		Name:           plan.Name,
+		CurrentVersion: plan.CurrentVersion,
+		NewVersion:     plan.NewVersion,
+		Status:         status,
+		Reason:         reason,
+	})
+}
+
+// reportUnapproved - plan is either waiting for approvals or its approval
+// was rejected
+func (p *Provider) reportUnapproved(event *types.Event, plan *UpdatePlan) {
+	if event.Results == nil {
+		return
+	}
+	approval, err := p.approvalManager.Get(getIdentifier(plan))
+	if err == nil && approval.Status() == types.ApprovalStatusRejected {
+		p.report(event, plan, types.UpdateStatusRejected, "approval rejected")
+		return
+	}
+	p.report(event, plan, types.UpdateStatusPending, "waiting for approvals")
+}
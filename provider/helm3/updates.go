@@ -4,13 +4,14 @@ import (
 	"github.com/keel-hq/keel/internal/policy"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
+	"github.com/keel-hq/keel/util/policies"
 
 	hapi_chart "helm.sh/helm/v3/pkg/chart"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func checkRelease(repo *types.Repository, namespace, name string, chart *hapi_chart.Chart, config map[string]interface{}) (plan *UpdatePlan, shouldUpdateRelease bool, err error) {
+func checkRelease(repo *types.Repository, namespace, name string, chart *hapi_chart.Chart, config map[string]interface{}, matchMode string) (plan *UpdatePlan, shouldUpdateRelease bool, err error) {
 
 	plan = &UpdatePlan{
 		Chart:       chart,
@@ -57,6 +58,17 @@ func checkRelease(repo *types.Repository, namespace, name string, chart *hapi_ch
 		return plan, false, nil
 	}
 
+	if keelCfg.MatchMode != "" {
+		mode, err := policies.ParseMatchMode(keelCfg.MatchMode)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Warnf("provider.helm3: invalid match mode, using %s", matchMode)
+		} else {
+			matchMode = mode
+		}
+	}
+
 	// checking for impacted images
 	for _, imageDetails := range keelCfg.Images {
 		imageRef, err := parseImage(vals, &imageDetails)
@@ -69,7 +81,7 @@ func checkRelease(repo *types.Repository, namespace, name string, chart *hapi_ch
 			continue
 		}
 
-		if imageRef.Repository() != eventRepoRef.Repository() {
+		if !image.SameRepository(imageRef, eventRepoRef, matchMode != types.MatchModeLoose) {
 			log.WithFields(log.Fields{
 				"parsed_image_name": imageRef.Remote(),
 				"target_image_name": repo.Name,
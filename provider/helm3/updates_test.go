@@ -197,7 +197,7 @@ keel:
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotPlan, gotShouldUpdateRelease, err := checkRelease(tt.args.repo, tt.args.namespace, tt.args.name, tt.args.chart, tt.args.config)
+			gotPlan, gotShouldUpdateRelease, err := checkRelease(tt.args.repo, tt.args.namespace, tt.args.name, tt.args.chart, tt.args.config, types.MatchModeStrict)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("checkRelease() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -497,7 +497,7 @@ image:
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotPlan, gotShouldUpdateRelease, err := checkRelease(tt.args.repo, tt.args.namespace, tt.args.name, tt.args.chart, tt.args.config)
+			gotPlan, gotShouldUpdateRelease, err := checkRelease(tt.args.repo, tt.args.namespace, tt.args.name, tt.args.chart, tt.args.config, types.MatchModeStrict)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("checkRelease() error = %v, wantErr %v", err, tt.wantErr)
 				return
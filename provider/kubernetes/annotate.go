@@ -0,0 +1,126 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var availableWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// availableUpdate - payload sent to the available update webhook, i.e. for a
+// GitOps pipeline to open a pull request
+type availableUpdate struct {
+	Provider       string   `json:"provider"`
+	Kind           string   `json:"kind"`
+	Namespace      string   `json:"namespace"`
+	Name           string   `json:"name"`
+	Identifier     string   `json:"identifier"`
+	CurrentVersion string   `json:"currentVersion"`
+	Version        string   `json:"version"`
+	PreviousImages []string `json:"previousImages"`
+	Images         []string `json:"images"`
+	CreatedAt      string   `json:"createdAt"`
+}
+
+// annotateUpdate - records available version in keel.sh/available annotation
+// of annotate-only resource, images are left untouched
+func (p *Provider) annotateUpdate(plan *UpdatePlan, channels []string) {
+	resource := plan.Original
+	annotations := resource.GetAnnotations()
+	annotations[types.KeelAvailableAnnotation] = plan.NewVersion
+	resource.SetAnnotations(annotations)
+
+	if err := p.implementer.Update(resource); err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"version":   plan.NewVersion,
+		}).Error("provider.kubernetes: got error while annotating resource with available version")
+		return
+	}
+	p.cacheUpdated(resource)
+
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"namespace": resource.Namespace,
+		"previous":  plan.CurrentVersion,
+		"available": plan.NewVersion,
+	}).Info("provider.kubernetes: resource annotated with available version")
+
+	if err := p.updateComplete(plan); err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Warn("provider.kubernetes: got error while archiving approvals counter after annotating resource")
+	}
+
+	metadata := updateMetadata(p.GetName(), plan)
+	metadata["annotate_only"] = "true"
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "update available",
+		Message:      fmt.Sprintf("Update available for %s %s/%s %s->%s (%s), resource annotated", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(plan.Resource.GetImages(), ", ")),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelInfo,
+		Channels:     channels,
+		Metadata:     metadata,
+	})
+
+	if p.availableWebhook == "" {
+		return
+	}
+	if err := p.callAvailableWebhook(plan); err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"webhook":   p.availableWebhook,
+		}).Error("provider.kubernetes: got error while calling available update webhook")
+	}
+}
+
+func (p *Provider) callAvailableWebhook(plan *UpdatePlan) error {
+	resource := plan.Original
+	body, err := json.Marshal(availableUpdate{
+		Provider:       p.GetName(),
+		Kind:           resource.Kind(),
+		Namespace:      resource.Namespace,
+		Name:           resource.Name,
+		Identifier:     resource.Identifier,
+		CurrentVersion: plan.CurrentVersion,
+		Version:        plan.NewVersion,
+		PreviousImages: plan.PreviousImages,
+		Images:         plan.Resource.GetImages(),
+		CreatedAt:      time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := availableWebhookClient.Post(p.availableWebhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessEventAnnotateOnly(t *testing.T) {
+	var received []availableUpdate
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload availableUpdate
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode webhook payload: %s", err)
+		}
+		received = append(received, payload)
+	}))
+	defer ts.Close()
+
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "gitops",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{types.KeelAnnotateOnlyAnnotation: "true"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	fi := &fakeImplementer{}
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fi, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.SetAnnotateOnly(false, ts.URL)
+
+	event := &types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+	}
+	updated, err := provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("didn't expect updated resources, got: %d", len(updated))
+	}
+
+	if fi.updated == nil {
+		t.Fatalf("expected resource to be annotated")
+	}
+	if fi.updated.GetAnnotations()[types.KeelAvailableAnnotation] != "1.1.2" {
+		t.Errorf("unexpected available annotation: %s", fi.updated.GetAnnotations()[types.KeelAvailableAnnotation])
+	}
+	if fi.updated.Containers()[0].Image != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("image shouldn't be changed, got: %s", fi.updated.Containers()[0].Image)
+	}
+	if fs.sentEvent.Name != "update available" {
+		t.Errorf("unexpected notification: %s", fs.sentEvent.Name)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 webhook call, got: %d", len(received))
+	}
+	if received[0].Name != "gitops" || received[0].Version != "1.1.2" || received[0].Images[0] != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("unexpected webhook payload: %+v", received[0])
+	}
+
+	// already annotated version is not annotated again
+	grc.Add(fi.updated)
+	fi.updated = nil
+	if _, err := provider.processEvent(event); err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if fi.updated != nil || len(received) != 1 {
+		t.Errorf("didn't expect resource to be annotated again")
+	}
+}
@@ -26,18 +26,21 @@ func (p *Provider) checkForApprovals(event *types.Event, plans []*UpdatePlan) (a
 				"name":      plan.Resource.Name,
 				"namespace": plan.Resource.Namespace,
 			}).Error("provider.kubernetes: failed to check approval status for deployment")
+			p.report(event, plan, types.UpdateStatusError, err.Error())
 			continue
 		}
 		if approved {
 			approvedPlans = append(approvedPlans, plan)
+			continue
 		}
+		p.reportUnapproved(event, plan)
 	}
 	return approvedPlans
 }
 
 // updateComplete is called after we successfully update resource
 func (p *Provider) updateComplete(plan *UpdatePlan) error {
-	return p.approvalManager.Archive(getApprovalIdentifier(plan.Resource.Identifier, plan.NewVersion))
+	return p.approvalManager.Archive(p.approvalIdentifier(plan))
 }
 
 func getInt(key string, labels map[string]string, annotations map[string]string) (int, error) {
@@ -68,6 +71,32 @@ func getInt(key string, labels map[string]string, annotations map[string]string)
 	return 0, nil
 }
 
+// getDeadline - approval deadline is either a number of hours or a duration
+// such as "30m", returns 0 if not set
+func getDeadline(labels map[string]string, annotations map[string]string) (time.Duration, error) {
+	valStr, ok := labels[types.KeelApprovalDeadlineLabel]
+	if !ok {
+		valStr, ok = annotations[types.KeelApprovalDeadlineLabel]
+	}
+	if !ok {
+		return 0, nil
+	}
+
+	hours, err := strconv.Atoi(valStr)
+	if err == nil {
+		return time.Duration(hours) * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(valStr)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("deadline cannot be negative: %s", valStr)
+	}
+	return d, nil
+}
+
 func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error) {
 
 	minApprovals, err := getInt(types.KeelMinimumApprovalsLabel, plan.Resource.GetLabels(), plan.Resource.GetAnnotations())
@@ -80,8 +109,8 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 	}
 
 	// deadline
-	deadline := types.KeelApprovalDeadlineDefault
-	d, err := getInt(types.KeelApprovalDeadlineLabel, plan.Resource.GetLabels(), plan.Resource.GetAnnotations())
+	deadline := time.Duration(types.KeelApprovalDeadlineDefault) * time.Hour
+	d, err := getDeadline(plan.Resource.GetLabels(), plan.Resource.GetAnnotations())
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":    err,
@@ -91,56 +120,63 @@ func (p *Provider) isApproved(event *types.Event, plan *UpdatePlan) (bool, error
 		deadline = d
 	}
 
-	identifier := getApprovalIdentifier(plan.Resource.Identifier, plan.NewVersion)
+	identifier := p.approvalIdentifier(plan)
 
 	// checking for existing approval
 	existing, err := p.approvalManager.Get(identifier)
-	if err != nil {
-
-		if err == store.ErrRecordNotFound {
-			// if approval doesn't exist and trigger wasn't existing approval fulfillment -
-			// create a new one, otherwise if several deployments rely on the same image, it would just be
-			// requesting approvals in a loop
-			if event.TriggerName == types.TriggerTypeApproval.String() {
-				return false, nil
-			}
-
-			// creating new one
-			approval := &types.Approval{
-				Provider:       types.ProviderTypeKubernetes,
-				Identifier:     identifier,
-				Event:          event,
-				CurrentVersion: plan.CurrentVersion,
-				NewVersion:     plan.NewVersion,
-				VotesRequired:  minApprovals,
-				VotesReceived:  0,
-				Rejected:       false,
-				Deadline:       time.Now().Add(time.Duration(deadline) * time.Hour),
-			}
-
-			approval.Message = fmt.Sprintf("New image is available for resource %s/%s (%s).",
-				plan.Resource.Namespace,
-				plan.Resource.Name,
-				approval.Delta(),
-			)
-
-			return false, p.approvalManager.Create(approval)
+	switch {
+	case err == store.ErrRecordNotFound:
+		// if approval doesn't exist and trigger wasn't existing approval fulfillment -
+		// create a new one, otherwise if several deployments rely on the same image, it would just be
+		// requesting approvals in a loop
+		if event.TriggerName == types.TriggerTypeApproval.String() {
+			return false, nil
 		}
-
+		return false, p.requestApproval(event, plan, identifier, minApprovals, deadline)
+	case err != nil:
 		return false, err
+	case !existing.SameTarget(event):
+		// same version was pushed again with a different image, votes were
+		// given for the previous build
+		log.WithFields(log.Fields{
+			"identifier": identifier,
+			"previous":   existing.Digest,
+			"new":        event.Repository.Digest,
+		}).Info("provider.kubernetes: image digest changed, requesting new approval")
+		if err := p.approvalManager.Archive(identifier); err != nil {
+			return false, err
+		}
+		return false, p.requestApproval(event, plan, identifier, minApprovals, deadline)
+	}
+
+	// re-triggers for the same target reuse pending approval and votes it received
+	if existing.Status() != types.ApprovalStatusApproved {
+		return false, nil
 	}
+	plan.Approval = existing
+
+	return true, nil
+}
+
+func (p *Provider) requestApproval(event *types.Event, plan *UpdatePlan, identifier string, minApprovals int, deadline time.Duration) error {
+	approval := &types.Approval{
+		Provider:       types.ProviderTypeKubernetes,
+		Identifier:     identifier,
+		Event:          event,
+		CurrentVersion: plan.CurrentVersion,
+		NewVersion:     plan.NewVersion,
+		Digest:         event.Repository.Digest,
+		VotesRequired:  minApprovals,
+		VotesReceived:  0,
+		Rejected:       false,
+		Deadline:       time.Now().Add(deadline),
+	}
+
+	approval.Message = fmt.Sprintf("New image is available for resource %s/%s (%s).",
+		plan.Resource.Namespace,
+		plan.Resource.Name,
+		approval.Delta(),
+	)
 
-	// if event.Repository.Digest != "" && event.Repository.Digest != existing.Digest {
-	// 	err = p.approvalManager.Reset(existing)
-	// 	if err != nil {
-	// 		return false, fmt.Errorf("failed to reset approval after changed digest, error %s", err)
-	// 	}
-	// 	return false, nil
-	// }
-	// log.WithFields(log.Fields{
-	// 	"previous": existing.Digest,
-	// 	"new":      event.Repository.Digest,
-	// }).Info("digests match")
-
-	return existing.Status() == types.ApprovalStatusApproved, nil
+	return p.approvalManager.Create(approval)
 }
@@ -340,3 +340,109 @@ func TestApprovalsCleanup(t *testing.T) {
 		t.Logf("approval status: %v, identifier: %s", approvals[0].Archived, approvals[0].Identifier)
 	}
 }
+
+func TestGetDeadline(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{"not set", nil, nil, 0, false},
+		{"hours label", map[string]string{types.KeelApprovalDeadlineLabel: "5"}, nil, 5 * time.Hour, false},
+		{"duration annotation", nil, map[string]string{types.KeelApprovalDeadlineLabel: "30m"}, 30 * time.Minute, false},
+		{"invalid", nil, map[string]string{types.KeelApprovalDeadlineLabel: "soon"}, 0, true},
+		{"negative", nil, map[string]string{types.KeelApprovalDeadlineLabel: "-5m"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDeadline(tt.labels, tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getDeadline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getDeadline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApprovalReusedForSameTarget(t *testing.T) {
+	fp := &fakeImplementer{}
+	deployments := []*apps_v1.Deployment{
+		{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "dep-1",
+				Namespace:   "xxxx",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all", types.KeelMinimumApprovalsLabel: "2"},
+				Annotations: map[string]string{},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deployments)...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	const identifier = "deployment/xxxx/dep-1:1.1.2"
+	submit := func(digest string) *types.Approval {
+		_, err := provider.processEvent(&types.Event{Repository: types.Repository{
+			Name:   "gcr.io/v2-namespace/hello-world",
+			Tag:    "1.1.2",
+			Digest: digest,
+		}})
+		if err != nil {
+			t.Fatalf("failed to process event: %s", err)
+		}
+		approval, err := provider.approvalManager.Get(identifier)
+		if err != nil {
+			t.Fatalf("failed to find approval, err: %s", err)
+		}
+		return approval
+	}
+
+	first := submit("sha256:aaaa")
+	if first.Digest != "sha256:aaaa" {
+		t.Errorf("expected digest to be recorded, got: %s", first.Digest)
+	}
+	if _, err := provider.approvalManager.Approve(identifier, "user-1"); err != nil {
+		t.Fatalf("failed to approve: %s", err)
+	}
+
+	// CI re-triggering the same build
+	for _, digest := range []string{"sha256:aaaa", ""} {
+		again := submit(digest)
+		if again.ID != first.ID {
+			t.Errorf("expected approval %s to be reused, got %s", first.ID, again.ID)
+		}
+		if again.VotesReceived != 1 {
+			t.Errorf("expected votes to be kept, got: %d", again.VotesReceived)
+		}
+	}
+
+	// same tag pushed with a different image
+	rebuilt := submit("sha256:bbbb")
+	if rebuilt.ID == first.ID {
+		t.Errorf("expected new approval for a different digest")
+	}
+	if rebuilt.VotesReceived != 0 || rebuilt.Digest != "sha256:bbbb" {
+		t.Errorf("unexpected approval: votes %d, digest %s", rebuilt.VotesReceived, rebuilt.Digest)
+	}
+}
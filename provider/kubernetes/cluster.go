@@ -0,0 +1,51 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+)
+
+// SetCluster - names the cluster provider manages when Keel updates multiple
+// clusters. Provider name, tracked images, approvals and notifications include
+// the cluster name so updates in different clusters can be told apart. Should
+// be called before the provider is started.
+func (p *Provider) SetCluster(name string) {
+	p.cluster = name
+	if name != "" {
+		p.sender = &clusterSender{Sender: p.sender, cluster: name}
+	}
+}
+
+// Cluster - cluster name, empty for the default cluster
+func (p *Provider) Cluster() string {
+	return p.cluster
+}
+
+// approvalIdentifier - approvals are keyed by resource so resources with the
+// same name in different clusters need separate approvals
+func (p *Provider) approvalIdentifier(plan *UpdatePlan) string {
+	if p.cluster == "" {
+		return getApprovalIdentifier(plan.Resource.Identifier, plan.NewVersion)
+	}
+	return getApprovalIdentifier(p.cluster+"/"+plan.Resource.Identifier, plan.NewVersion)
+}
+
+// clusterSender - adds cluster name to notifications sent by the provider
+type clusterSender struct {
+	notification.Sender
+	cluster string
+}
+
+func (s *clusterSender) Send(event types.EventNotification) error {
+	metadata := make(map[string]string, len(event.Metadata)+1)
+	for k, v := range event.Metadata {
+		metadata[k] = v
+	}
+	metadata["cluster"] = s.cluster
+	event.Metadata = metadata
+	event.Message = fmt.Sprintf("[%s] %s", s.cluster, event.Message)
+
+	return s.Sender.Send(event)
+}
@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProviderCluster(t *testing.T) {
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "wd",
+			Namespace:   "default",
+			Annotations: map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "wd", Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	fi := &fakeImplementer{}
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	p.SetCluster("prod")
+
+	if p.GetName() != "kubernetes/prod" {
+		t.Errorf("unexpected provider name: %s", p.GetName())
+	}
+
+	tracked, err := p.TrackedImages()
+	if err != nil {
+		t.Fatalf("failed to get tracked images: %s", err)
+	}
+	if len(tracked) != 1 || tracked[0].Cluster != "prod" || tracked[0].Provider != "kubernetes/prod" {
+		t.Fatalf("unexpected tracked images: %v", tracked)
+	}
+
+	_, err = p.processEvent(&types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+	})
+	if err != nil {
+		t.Fatalf("failed to process event: %s", err)
+	}
+	if fi.updated == nil {
+		t.Fatalf("expected resource to be updated")
+	}
+
+	if fs.sentEvent.Metadata["cluster"] != "prod" {
+		t.Errorf("expected cluster in notification metadata, got: %v", fs.sentEvent.Metadata)
+	}
+	if fs.sentEvent.Metadata["provider"] != "kubernetes/prod" {
+		t.Errorf("unexpected provider in notification metadata: %s", fs.sentEvent.Metadata["provider"])
+	}
+	want := "[prod] Successfully updated deployment default/wd 1.1.1->1.1.2 (gcr.io/v2-namespace/hello-world:1.1.2)"
+	if fs.sentEvent.Message != want {
+		t.Errorf("unexpected message: %s", fs.sentEvent.Message)
+	}
+
+	plan := &UpdatePlan{Resource: fi.updated, NewVersion: "1.1.2"}
+	if id := p.approvalIdentifier(plan); id != "prod/"+fi.updated.Identifier+":1.1.2" {
+		t.Errorf("unexpected approval identifier: %s", id)
+	}
+}
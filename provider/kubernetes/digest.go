@@ -0,0 +1,76 @@
+package kubernetes
+
+import (
+	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/registry"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+	"github.com/keel-hq/keel/util/policies"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// hasPinnedContainer - checks whether resource runs the event image pinned to
+// a digest, i.e. "name:tag@sha256:..."
+func hasPinnedContainer(repo *types.Repository, resource *k8s.GenericResource, matchMode string) bool {
+	eventRef, err := image.Parse(repo.String())
+	if err != nil {
+		return false
+	}
+
+	containers := resource.Containers()
+	if policies.ShouldTrackInitContainers(resource.GetLabels(), resource.GetAnnotations()) {
+		containers = append(containers, resource.InitContainers()...)
+	}
+	for _, c := range containers {
+		ref, err := image.Parse(c.Image)
+		if err != nil {
+			continue
+		}
+		if ref.Digest() != "" && image.SameRepository(ref, eventRef, matchMode != types.MatchModeLoose) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDigest - gets digest of the event tag from the registry, events from
+// most webhooks and from semver polling don't carry one. Returns a copy of the
+// repository, digest stays empty if it can't be resolved.
+func (p *Provider) resolveDigest(repo *types.Repository, resource *k8s.GenericResource) *types.Repository {
+	resolved := *repo
+
+	ref, err := image.Parse(repo.String())
+	if err != nil {
+		return &resolved
+	}
+
+	opts := registry.Opts{
+		Registry: ref.Scheme() + "://" + ref.Registry(),
+		Name:     ref.ShortName(),
+		Tag:      repo.Tag,
+	}
+
+	creds, err := credentialshelper.GetCredentials(&types.TrackedImage{
+		Image:     ref,
+		Namespace: resource.Namespace,
+		Secrets:   resource.GetImagePullSecrets(),
+	})
+	if err == nil {
+		opts.Username = creds.Username
+		opts.Password = creds.Password
+	}
+
+	digest, err := p.registryClient.Digest(opts)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": repo.String(),
+		}).Error("provider.kubernetes: failed to resolve digest for pinned image")
+		return &resolved
+	}
+
+	resolved.Digest = digest
+	return &resolved
+}
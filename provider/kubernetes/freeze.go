@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/policies"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// isFrozen - checks whether keel.sh/freeze blocks the update. Skipped update is
+// logged every time, notification (debug level, so only sent when notification
+// level allows it) once per version to not repeat it on every poll.
+func (p *Provider) isFrozen(plan *UpdatePlan) bool {
+	resource := plan.Resource
+	frozen, tag := policies.GetFreeze(resource.GetAnnotations())
+	if !frozen || (tag != "" && tag == plan.NewVersion) {
+		return false
+	}
+
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"namespace": resource.Namespace,
+		"version":   plan.NewVersion,
+		"freeze":    resource.GetAnnotations()[types.KeelFreezeAnnotation],
+	}).Info("provider.kubernetes: resource is frozen, skipping update")
+
+	p.mu.Lock()
+	notified := p.frozenNotified[resource.Identifier] == plan.NewVersion
+	p.frozenNotified[resource.Identifier] = plan.NewVersion
+	p.mu.Unlock()
+	if notified {
+		return true
+	}
+
+	msg := fmt.Sprintf("%s %s/%s is frozen, skipped update %s->%s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion)
+	if tag != "" {
+		msg = fmt.Sprintf("%s %s/%s is pinned to %s, skipped update %s->%s", resource.Kind(), resource.Namespace, resource.Name, tag, plan.CurrentVersion, plan.NewVersion)
+	}
+
+	metadata := updateMetadata(p.GetName(), plan)
+	metadata["frozen"] = "true"
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "update skipped",
+		Message:      msg,
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelDebug,
+		Channels:     types.ParseEventNotificationChannels(resource.GetAnnotations()),
+		Metadata:     metadata,
+	})
+	return true
+}
@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessEventFrozen(t *testing.T) {
+	tests := []struct {
+		name        string
+		freeze      string
+		tag         string
+		wantUpdated bool
+		wantNotify  bool
+	}{
+		{name: "not frozen", freeze: "false", tag: "1.1.2", wantUpdated: true},
+		{name: "frozen", freeze: "true", tag: "1.1.2", wantNotify: true},
+		{name: "pinned to other tag", freeze: "1.1.3", tag: "1.1.2", wantNotify: true},
+		{name: "pinned tag", freeze: "1.1.3", tag: "1.1.3", wantUpdated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dep := &apps_v1.Deployment{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:        "frozen",
+					Namespace:   "xxxx",
+					Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+					Annotations: map[string]string{types.KeelFreezeAnnotation: tt.freeze},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{{Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+						},
+					},
+				},
+			}
+
+			grc := &k8s.GenericResourceCache{}
+			grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+			fi := &fakeImplementer{}
+			fs := &fakeSender{}
+			approver, teardown := approver()
+			defer teardown()
+			provider, err := NewProvider(fi, fs, approver, grc)
+			if err != nil {
+				t.Fatalf("failed to get provider: %s", err)
+			}
+
+			event := &types.Event{
+				Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: tt.tag},
+			}
+			updated, err := provider.processEvent(event)
+			if err != nil {
+				t.Fatalf("got error while processing event: %s", err)
+			}
+			if (len(updated) == 1) != tt.wantUpdated {
+				t.Fatalf("unexpected number of updated resources: %d", len(updated))
+			}
+
+			if !tt.wantNotify {
+				return
+			}
+			if fs.sentEvent.Name != "update skipped" || fs.sentEvent.Metadata["frozen"] != "true" {
+				t.Fatalf("expected skipped update notification, got: %+v", fs.sentEvent)
+			}
+
+			// same version is only notified once
+			fs.sentEvent = types.EventNotification{}
+			if _, err := provider.processEvent(event); err != nil {
+				t.Fatalf("got error while processing event: %s", err)
+			}
+			if fs.sentEvent.Name != "" {
+				t.Errorf("didn't expect repeated notification, got: %s", fs.sentEvent.Message)
+			}
+		})
+	}
+}
@@ -10,8 +10,12 @@ import (
 	batch_v1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -22,7 +26,9 @@ import (
 // Implementer - thing wrapper around currently used k8s APIs
 type Implementer interface {
 	Namespaces() (*v1.NamespaceList, error)
+	Deployment(namespace, name string) (*apps_v1.Deployment, error)
 	Deployments(namespace string) (*apps_v1.DeploymentList, error)
+	ReplicaSets(namespace, labelSelector string) (*apps_v1.ReplicaSetList, error)
 	Update(obj *k8s.GenericResource) error
 	Secret(namespace, name string) (*v1.Secret, error)
 	Pods(namespace, labelSelector string) (*v1.PodList, error)
@@ -36,6 +42,14 @@ type Implementer interface {
 type KubernetesImplementer struct {
 	cfg    *rest.Config
 	client *kubernetes.Clientset
+	// dynamic - client for resources without typed clients (OpenShift DeploymentConfigs)
+	dynamic dynamic.Interface
+	// labelSelector - optional, listed deployments are limited to matching ones
+	labelSelector string
+	// secrets - optional, secrets are read from the informer cache once
+	// it has synced
+	secrets       corelisters.SecretLister
+	secretsSynced cache.InformerSynced
 }
 
 // Opts - implementer options, usually for k8s deployments
@@ -45,6 +59,11 @@ type Opts struct {
 	InCluster  bool
 	ConfigPath string
 	Master     string
+	// Context - optional kubeconfig context, current context is used when empty
+	Context string
+	// LabelSelector - optional, limits listed deployments the same way
+	// LABEL_SELECTOR limits watched resources
+	LabelSelector string
 }
 
 // NewKubernetesImplementer - create new k8s implementer
@@ -61,6 +80,19 @@ func NewKubernetesImplementer(opts *Opts) (*KubernetesImplementer, error) {
 			return nil, err
 		}
 		log.Info("provider.kubernetes: using in-cluster configuration")
+	} else if opts.ConfigPath != "" && opts.Context != "" {
+		var err error
+		cfg, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: opts.ConfigPath},
+			&clientcmd.ConfigOverrides{CurrentContext: opts.Context},
+		).ClientConfig()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"context": opts.Context,
+			}).Error("provider.kubernetes: failed to get kubernetes config for context")
+			return nil, err
+		}
 	} else if opts.ConfigPath != "" {
 		var err error
 		cfg, err = clientcmd.BuildConfigFromFlags("", opts.ConfigPath)
@@ -82,7 +114,15 @@ func NewKubernetesImplementer(opts *Opts) (*KubernetesImplementer, error) {
 		return nil, err
 	}
 
-	return &KubernetesImplementer{client: client, cfg: cfg}, nil
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("provider.kubernetes: failed to create dynamic kubernetes client")
+		return nil, err
+	}
+
+	return &KubernetesImplementer{client: client, cfg: cfg, dynamic: dynamicClient, labelSelector: opts.LabelSelector}, nil
 }
 
 func (i *KubernetesImplementer) Client() *kubernetes.Clientset {
@@ -93,6 +133,10 @@ func (i *KubernetesImplementer) Config() *rest.Config {
 	return i.cfg
 }
 
+func (i *KubernetesImplementer) Dynamic() dynamic.Interface {
+	return i.dynamic
+}
+
 // Namespaces - get all namespaces
 func (i *KubernetesImplementer) Namespaces() (*v1.NamespaceList, error) {
 	namespaces := i.client.CoreV1().Namespaces()
@@ -105,14 +149,21 @@ func (i *KubernetesImplementer) Deployment(namespace, name string) (*apps_v1.Dep
 	return dep.Get(context.TODO(), name, meta_v1.GetOptions{})
 }
 
-// Deployments - get all deployments for namespace
+// Deployments - get all deployments for namespace that match the label selector
 func (i *KubernetesImplementer) Deployments(namespace string) (*apps_v1.DeploymentList, error) {
 	dep := i.client.AppsV1().Deployments(namespace)
-	l, err := dep.List(context.TODO(), meta_v1.ListOptions{})
+	l, err := dep.List(context.TODO(), meta_v1.ListOptions{LabelSelector: i.labelSelector})
 	return l, err
 }
 
-// Update converts generic resource into specific kubernetes type and updates it
+// ReplicaSets - get replicasets for namespace matching label selector
+func (i *KubernetesImplementer) ReplicaSets(namespace, labelSelector string) (*apps_v1.ReplicaSetList, error) {
+	return i.client.AppsV1().ReplicaSets(namespace).List(context.TODO(), meta_v1.ListOptions{LabelSelector: labelSelector})
+}
+
+// Update converts generic resource into specific kubernetes type and updates it,
+// the resource is replaced with the stored object so it carries the new
+// resource version
 func (i *KubernetesImplementer) Update(obj *k8s.GenericResource) error {
 	// retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 	// 	// Retrieve the latest version of Deployment before attempting update
@@ -124,33 +175,68 @@ func (i *KubernetesImplementer) Update(obj *k8s.GenericResource) error {
 
 	switch resource := obj.GetResource().(type) {
 	case *apps_v1.Deployment:
-		_, err := i.client.AppsV1().Deployments(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
+		updated, err := i.client.AppsV1().Deployments(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
+		*resource = *updated
 	case *apps_v1.StatefulSet:
-		_, err := i.client.AppsV1().StatefulSets(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
+		// only the pod template is changed, rollout itself is left to the
+		// statefulset controller so updateStrategy (partition, OnDelete) is respected
+		updated, err := i.client.AppsV1().StatefulSets(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
+		*resource = *updated
 	case *apps_v1.DaemonSet:
-		_, err := i.client.AppsV1().DaemonSets(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
+		updated, err := i.client.AppsV1().DaemonSets(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
+		*resource = *updated
 	case *batch_v1.CronJob:
-		_, err := i.client.BatchV1().CronJobs(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
+		updated, err := i.client.BatchV1().CronJobs(resource.Namespace).Update(context.TODO(), resource, meta_v1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		*resource = *updated
+	case *unstructured.Unstructured:
+		// OpenShift DeploymentConfig, rolled out by its ConfigChange trigger
+		// or explicitly when it has none
+		client := i.dynamic.Resource(k8s.DeploymentConfigResource).Namespace(resource.GetNamespace())
+		updated, err := client.Update(context.TODO(), resource, meta_v1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
+		resource.Object = updated.Object
+		if req := k8s.DeploymentConfigRolloutRequest(resource); req != nil {
+			_, err = client.Create(context.TODO(), req, meta_v1.CreateOptions{}, "instantiate")
+			if err != nil {
+				return err
+			}
+		}
 	default:
 		return fmt.Errorf("unsupported object type")
 	}
 	return nil
 }
 
+// SetSecretLister - serve secrets from the informer cache, the API server is
+// only queried until the informer has synced
+func (i *KubernetesImplementer) SetSecretLister(lister corelisters.SecretLister, synced cache.InformerSynced) {
+	i.secrets = lister
+	i.secretsSynced = synced
+}
+
 // Secret - get secret
 func (i *KubernetesImplementer) Secret(namespace, name string) (*v1.Secret, error) {
+	if i.secrets != nil && i.secretsSynced() {
+		secret, err := i.secrets.Secrets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return secret.DeepCopy(), nil
+	}
 	return i.client.CoreV1().Secrets(namespace).Get(context.TODO(), name, meta_v1.GetOptions{})
 }
 
@@ -0,0 +1,232 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TestKubernetesImplementerAppsV1 - implementer talks to apps/v1 endpoints
+func TestKubernetesImplementerAppsV1(t *testing.T) {
+	dep := apps_v1.Deployment{
+		TypeMeta:   meta_v1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: meta_v1.ObjectMeta{Name: "wd", Namespace: "default"},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "wd", Image: "karolisr/webhook-demo:0.0.8"}},
+				},
+			},
+		},
+	}
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		mu.Unlock()
+
+		resp.Header().Set("Content-Type", "application/json")
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/apis/apps/v1/namespaces/default/deployments":
+			json.NewEncoder(resp).Encode(apps_v1.DeploymentList{
+				TypeMeta: meta_v1.TypeMeta{APIVersion: "apps/v1", Kind: "DeploymentList"},
+				Items:    []apps_v1.Deployment{dep},
+			})
+		case req.Method == "PUT" && req.URL.Path == "/apis/apps/v1/namespaces/default/deployments/wd":
+			var updated apps_v1.Deployment
+			json.NewDecoder(req.Body).Decode(&updated)
+			json.NewEncoder(resp).Encode(updated)
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cfg := &rest.Config{Host: ts.URL}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	implementer := &KubernetesImplementer{client: client, cfg: cfg}
+
+	deps, err := implementer.Deployments("default")
+	if err != nil {
+		t.Fatalf("failed to list deployments: %s", err)
+	}
+	if len(deps.Items) != 1 || deps.Items[0].Name != "wd" {
+		t.Fatalf("unexpected deployments: %+v", deps.Items)
+	}
+
+	gr, err := k8s.NewGenericResource(&deps.Items[0])
+	if err != nil {
+		t.Fatalf("failed to create generic resource: %s", err)
+	}
+	gr.UpdateContainer(0, "karolisr/webhook-demo:0.0.9")
+	if err := implementer.Update(gr); err != nil {
+		t.Fatalf("failed to update deployment: %s", err)
+	}
+
+	expected := []string{
+		"GET /apis/apps/v1/namespaces/default/deployments",
+		"PUT /apis/apps/v1/namespaces/default/deployments/wd",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != len(expected) {
+		t.Fatalf("unexpected requests: %v", requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("expected request %s, got: %s", expected[i], requests[i])
+		}
+	}
+}
+
+// TestKubernetesImplementerDeploymentConfigRollout - DeploymentConfigs without
+// a ConfigChange trigger are instantiated after the update
+func TestKubernetesImplementerDeploymentConfigRollout(t *testing.T) {
+	tests := []struct {
+		name     string
+		triggers []interface{}
+		expected []string
+	}{
+		{
+			name:     "config change trigger",
+			triggers: []interface{}{map[string]interface{}{"type": "ConfigChange"}},
+			expected: []string{
+				"PUT /apis/apps.openshift.io/v1/namespaces/default/deploymentconfigs/dc-1",
+			},
+		},
+		{
+			name: "manual image change trigger only",
+			triggers: []interface{}{
+				map[string]interface{}{
+					"type": "ImageChange",
+					"imageChangeParams": map[string]interface{}{
+						"automatic":      false,
+						"containerNames": []interface{}{"app"},
+						"from":           map[string]interface{}{"kind": "ImageStreamTag", "name": "app:latest"},
+					},
+				},
+			},
+			expected: []string{
+				"PUT /apis/apps.openshift.io/v1/namespaces/default/deploymentconfigs/dc-1",
+				"POST /apis/apps.openshift.io/v1/namespaces/default/deploymentconfigs/dc-1/instantiate",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests []string
+				request  map[string]interface{}
+			)
+			ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				var body map[string]interface{}
+				json.NewDecoder(req.Body).Decode(&body)
+
+				mu.Lock()
+				requests = append(requests, req.Method+" "+req.URL.Path)
+				if req.Method == "POST" {
+					request = body
+				}
+				mu.Unlock()
+
+				resp.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(resp).Encode(body)
+			}))
+			defer ts.Close()
+
+			client, err := dynamic.NewForConfig(&rest.Config{Host: ts.URL})
+			if err != nil {
+				t.Fatalf("failed to create client: %s", err)
+			}
+			implementer := &KubernetesImplementer{dynamic: client}
+
+			gr, err := k8s.NewGenericResource(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps.openshift.io/v1",
+				"kind":       "DeploymentConfig",
+				"metadata":   map[string]interface{}{"name": "dc-1", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"triggers": tt.triggers,
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{"name": "app", "image": "myrepo/app:1.0.0"},
+							},
+						},
+					},
+				},
+			}})
+			if err != nil {
+				t.Fatalf("failed to create generic resource: %s", err)
+			}
+			gr.UpdateContainer(0, "myrepo/app:1.1.0")
+			if err := implementer.Update(gr); err != nil {
+				t.Fatalf("failed to update deploymentconfig: %s", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(requests, tt.expected) {
+				t.Fatalf("expected requests %v, got: %v", tt.expected, requests)
+			}
+			if request != nil && (request["kind"] != "DeploymentRequest" || request["name"] != "dc-1" || request["latest"] != true) {
+				t.Errorf("unexpected instantiate request: %v", request)
+			}
+		})
+	}
+}
+
+// TestKubernetesImplementerSecretLister - secrets are served from the informer
+// cache once it has synced
+func TestKubernetesImplementerSecretLister(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	indexer.Add(&v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "webhook", Namespace: "default"},
+		Data:       map[string][]byte{"secret": []byte("s3cr3t")},
+	})
+
+	// no client, API server must not be queried
+	implementer := &KubernetesImplementer{}
+	implementer.SetSecretLister(corelisters.NewSecretLister(indexer), func() bool { return true })
+
+	secret, err := implementer.Secret("default", "webhook")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(secret.Data["secret"]) != "s3cr3t" {
+		t.Errorf("unexpected secret: %v", secret.Data)
+	}
+
+	// returned secret is a copy, the cache is left untouched
+	secret.Data["secret"] = []byte("changed")
+	cached, _ := implementer.Secret("default", "webhook")
+	if string(cached.Data["secret"]) != "s3cr3t" {
+		t.Errorf("cached secret got modified: %v", cached.Data)
+	}
+
+	if _, err := implementer.Secret("default", "missing"); err == nil {
+		t.Errorf("expected error for missing secret")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Masterminds/semver"
@@ -17,6 +19,8 @@ import (
 	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/internal/k8s"
 	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
 	"github.com/keel-hq/keel/util/policies"
@@ -50,12 +54,25 @@ const ProviderName = "kubernetes"
 
 var versionreg = regexp.MustCompile(`:[^:]*$`)
 
+// heartbeatInterval - how often the event loop reports that it's alive while idle
+const heartbeatInterval = 10 * time.Second
+
+// livenessTimeout - event loop is considered stuck if it hasn't reported for
+// this long, processing a single event should never take anywhere near it
+const livenessTimeout = 5 * time.Minute
+
 // GenericResourceCache an interface for generic resource cache.
 type GenericResourceCache interface {
 	// Values returns a copy of the contents of the cache.
 	// The slice and its contents should be treated as read-only.
 	Values() []*k8s.GenericResource
 
+	// Get returns a copy of the entry with the given identifier.
+	Get(identifier string) (*k8s.GenericResource, bool)
+
+	// Add adds entries to the cache, replacing ones with the same identifier.
+	Add(...*k8s.GenericResource)
+
 	// Register registers ch to receive a value when Notify is called.
 	Register(chan int, int)
 }
@@ -63,13 +80,26 @@ type GenericResourceCache interface {
 // UpdatePlan - deployment update plan
 type UpdatePlan struct {
 	// Updated deployment version
-	// Deployment v1beta1.Deployment
+	// Deployment apps_v1.Deployment
 	Resource *k8s.GenericResource
 
 	// Current (last seen cluster version)
 	CurrentVersion string
 	// New version that's already in the deployment
 	NewVersion string
+
+	// PreviousImages - images before the update, recorded in audit log
+	PreviousImages []string
+	// Trigger - name of the trigger that submitted the event
+	Trigger string
+	// ResolvedVersion - semver tag sharing the digest when NewVersion is a
+	// floating tag, only set when the trigger resolved it
+	ResolvedVersion string
+	// Approval - fulfilled approval the update is waiting for, if any
+	Approval *types.Approval
+	// Original - resource before images were changed, only set for annotate-only
+	// resources which are annotated instead of updated
+	Original *k8s.GenericResource
 }
 
 func (p *UpdatePlan) String() string {
@@ -89,8 +119,73 @@ type Provider struct {
 
 	cache GenericResourceCache
 
+	// registryClient - resolves digests for containers pinned to a digest
+	registryClient registry.Client
+
+	// namespaceFilter - optional filter, resources in excluded namespaces are
+	// neither tracked nor updated
+	namespaceFilter *NamespaceFilter
+
+	// dryRun - updates are only logged and notified, resources are not modified
+	dryRun bool
+
+	// annotateOnly - default for resources without keel.sh/annotateOnly, available
+	// versions are written to keel.sh/available and availableWebhook is called
+	annotateOnly     bool
+	availableWebhook string
+
+	// waitForRollouts - success notifications are sent once deployment rollout
+	// completes, rolloutTimeout is used when resource doesn't set keel.sh/rolloutTimeout
+	waitForRollouts bool
+	rolloutTimeout  time.Duration
+
+	// matchMode - default for resources without keel.sh/matchMode
+	matchMode string
+
+	// defaultSchedule - poll schedule of resources without a valid
+	// keel.sh/pollSchedule
+	defaultSchedule string
+
+	// cluster - optional cluster name when multiple clusters are managed
+	cluster string
+
 	events chan *types.Event
 	stop   chan struct{}
+
+	// stopping - set once Stop is called, new events are rejected and
+	// inflight tracks the event that is currently being processed
+	stopping bool
+	inflight sync.WaitGroup
+
+	// pending - submitted events that are not processed yet, see Drain
+	pending sync.WaitGroup
+
+	// rollouts - rollouts of updated resources that are still being watched,
+	// Drain waits for them too
+	rollouts sync.WaitGroup
+
+	// lastUpdated - time of the last successful update, keyed by resource identifier
+	lastUpdated map[string]time.Time
+
+	// failedRollouts - versions that were rolled back, keyed by resource identifier
+	failedRollouts map[string]string
+
+	// frozenNotified - last version skipped due to freeze, keyed by resource identifier
+	frozenNotified map[string]string
+
+	// heartbeat - unix nano timestamp of the last event loop iteration
+	heartbeat int64
+
+	// queues - per resource update queues, keyed by resource identifier
+	queues   map[string]*resourceQueue
+	queuesMu sync.Mutex
+
+	// updateSlots - limits how many resource updates are applied at once,
+	// unlimited when nil
+	updateSlots chan struct{}
+
+	mu      sync.Mutex
+	failure error
 }
 
 // NewProvider - create new kubernetes based provider
@@ -98,21 +193,101 @@ func NewProvider(implementer Implementer, sender notification.Sender, approvalMa
 	return &Provider{
 		implementer:     implementer,
 		cache:           cache,
+		registryClient:  registry.New(),
 		approvalManager: approvalManager,
 		events:          make(chan *types.Event, 100),
 		stop:            make(chan struct{}),
 		sender:          sender,
+		lastUpdated:     make(map[string]time.Time),
+		failedRollouts:  make(map[string]string),
+		frozenNotified:  make(map[string]string),
+		queues:          make(map[string]*resourceQueue),
+		updateSlots:     make(chan struct{}, DefaultUpdateConcurrency),
+		matchMode:       types.MatchModeStrict,
+		defaultSchedule: types.KeelPollDefaultSchedule,
 	}, nil
 }
 
+// SetNamespaceFilter - restricts provider to namespaces allowed by the filter,
+// should be called before the provider is started
+func (p *Provider) SetNamespaceFilter(filter *NamespaceFilter) {
+	p.namespaceFilter = filter
+}
+
+// SetDryRun - when enabled, provider detects updates and sends notifications
+// but doesn't modify any resources
+func (p *Provider) SetDryRun(dryRun bool) {
+	p.dryRun = dryRun
+}
+
+// SetAnnotateOnly - when enabled, resources are annotated with available versions
+// instead of being updated unless they opt out with keel.sh/annotateOnly, webhook
+// is optional and called for every annotated resource
+func (p *Provider) SetAnnotateOnly(annotateOnly bool, webhook string) {
+	p.annotateOnly = annotateOnly
+	p.availableWebhook = webhook
+}
+
+// SetWaitForRollout - when enabled, success notifications for deployments are
+// only sent after the rollout completes, a failure is reported if it doesn't
+// complete within timeout
+func (p *Provider) SetWaitForRollout(wait bool, timeout time.Duration) {
+	p.waitForRollouts = wait
+	p.rolloutTimeout = timeout
+}
+
+// SetUpdateConcurrency - how many resources are updated in parallel, the
+// rest wait in their queues. 0 removes the limit, should be called before the
+// provider is started
+func (p *Provider) SetUpdateConcurrency(limit int) {
+	if limit <= 0 {
+		p.updateSlots = nil
+		return
+	}
+	p.updateSlots = make(chan struct{}, limit)
+}
+
+// SetMatchMode - how event images are matched against resource images unless
+// the resource sets keel.sh/matchMode, see types.KeelMatchModeAnnotation
+func (p *Provider) SetMatchMode(mode string) {
+	p.matchMode = mode
+}
+
+// SetDefaultPollSchedule - poll schedule of resources that don't set a valid
+// keel.sh/pollSchedule, defaults to types.KeelPollDefaultSchedule
+func (p *Provider) SetDefaultPollSchedule(schedule string) {
+	p.defaultSchedule = schedule
+}
+
 // Submit - submit event to provider
 func (p *Provider) Submit(event types.Event) error {
-	p.events <- &event
-	return nil
+	if p.isStopping() {
+		return provider.ErrStopping
+	}
+	p.pending.Add(1)
+	event.Results.Add(1)
+	select {
+	case p.events <- &event:
+		return nil
+	case <-p.stop:
+		p.pending.Done()
+		event.Results.Done()
+		return provider.ErrStopping
+	}
 }
 
-// GetName - get provider name
+// Drain - waits until events submitted so far are processed, or discarded
+// by Stop, and rollouts of the updated resources are watched
+func (p *Provider) Drain() {
+	p.pending.Wait()
+	p.rollouts.Wait()
+}
+
+// GetName - get provider name, includes cluster name when set
 func (p *Provider) GetName() string {
+	if p.cluster != "" {
+		return ProviderName + "/" + p.cluster
+	}
 	return ProviderName
 }
 
@@ -121,9 +296,36 @@ func (p *Provider) Start() error {
 	return p.startInternal()
 }
 
-// Stop - stops kubernetes provider
+// Stop - stops accepting new events and waits for updates that are
+// currently in progress to finish, queued events and updates are discarded
 func (p *Provider) Stop() {
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return
+	}
+	p.stopping = true
+	p.mu.Unlock()
+
 	close(p.stop)
+	p.inflight.Wait()
+}
+
+func (p *Provider) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+// begin - registers event as in-flight, returns false once provider is stopping
+func (p *Provider) begin() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopping {
+		return false
+	}
+	p.inflight.Add(1)
+	return true
 }
 
 func getImagePullSecretFromMeta(labels map[string]string, annotations map[string]string) string {
@@ -148,19 +350,29 @@ func getImagePullSecretFromMeta(labels map[string]string, annotations map[string
 // TrackedImages returns a list of tracked images.
 func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 	var trackedImages []*types.TrackedImage
+	tracked := 0
 
 	for _, gr := range p.cache.Values() {
+		if !p.namespaceFilter.Allowed(gr.Namespace) {
+			continue
+		}
+
 		labels := gr.GetLabels()
 		annotations := gr.GetAnnotations()
 
 		// ignoring unlabelled deployments
 		plc := policy.GetPolicyFromLabelsOrAnnotations(labels, annotations)
-		if plc.Type() == policy.PolicyTypeNone {
+		if plc.Type() == policy.PolicyTypeNone && !policy.HasContainerPolicies(labels, annotations) {
 			continue
 		}
+		tracked++
 
 		schedule, ok := annotations[types.KeelPollScheduleAnnotation]
 		if ok {
+			// plain Go durations such as "5m" are accepted as a shorthand
+			if d, err := time.ParseDuration(schedule); err == nil && d > 0 {
+				schedule = "@every " + d.String()
+			}
 			_, err := cron.Parse(schedule)
 			if err != nil {
 				log.WithFields(log.Fields{
@@ -169,10 +381,10 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 					"name":      gr.Name,
 					"namespace": gr.Namespace,
 				}).Error("provider.kubernetes: failed to parse poll schedule, setting default schedule")
-				schedule = types.KeelPollDefaultSchedule
+				schedule = p.defaultSchedule
 			}
 		} else {
-			schedule = types.KeelPollDefaultSchedule
+			schedule = p.defaultSchedule
 		}
 
 		// trigger type, we only care for "poll" type triggers
@@ -186,13 +398,21 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 		}
 		secrets = append(secrets, gr.GetImagePullSecrets()...)
 
-		images := gr.GetImages()
-		for _, img := range images {
-			ref, err := image.Parse(img)
+		containers := gr.Containers()
+		if policies.ShouldTrackInitContainers(labels, annotations) {
+			containers = append(containers, gr.InitContainers()...)
+		}
+		for _, c := range containers {
+			containerPlc := containerPolicy(plc, c.Name, labels, annotations)
+			if containerPlc.Type() == policy.PolicyTypeNone {
+				continue
+			}
+
+			ref, err := image.Parse(c.Image)
 			if err != nil {
 				log.WithFields(log.Fields{
 					"error":     err,
-					"image":     img,
+					"image":     c.Image,
 					"namespace": gr.Namespace,
 					"name":      gr.Name,
 				}).Error("provider.kubernetes: failed to parse image")
@@ -211,57 +431,173 @@ func (p *Provider) TrackedImages() ([]*types.TrackedImage, error) {
 				Image:        ref,
 				PollSchedule: schedule,
 				Trigger:      trigger,
-				Provider:     ProviderName,
+				Provider:     p.GetName(),
+				Cluster:      p.cluster,
 				Namespace:    gr.Namespace,
 				Secrets:      secrets,
 				Meta:         make(map[string]string),
-				Policy:       plc,
+				Policy:       containerPlc,
+				ExcludeTags:  policies.GetExcludeTags(annotations),
+				Kind:         gr.Kind(),
+				Name:         gr.Name,
+				Identifier:   gr.Identifier,
+				Paused:       policies.IsPaused(annotations),
+				LastUpdated:  p.getLastUpdated(gr.Identifier),
 			})
 		}
 	}
 
+	provider.TrackedResourcesGauge.With(prometheus.Labels{"provider": p.GetName()}).Set(float64(tracked))
+
 	return trackedImages, nil
 }
 
+func (p *Provider) getLastUpdated(identifier string) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastUpdated[identifier]
+}
+
+func (p *Provider) setLastUpdated(identifier string, t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastUpdated[identifier] = t
+}
+
+// cacheUpdated - stores the resource as returned by the update so queued
+// updates of the same resource are planned from its new resource version
+// before the watcher catches up
+func (p *Provider) cacheUpdated(resource *k8s.GenericResource) {
+	p.cache.Add(resource.DeepCopy())
+}
+
 func (p *Provider) startInternal() error {
+	defer p.recoverPanic("event loop")
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	p.beat()
 	for {
 		select {
 		case event := <-p.events:
-			_, err := p.processEvent(event)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"error": err,
-					"image": event.Repository.Name,
-					"tag":   event.Repository.Tag,
-				}).Error("provider.kubernetes: failed to process event")
+			if p.isStopping() {
+				log.WithField("queued", len(p.events)).Info("provider.kubernetes: shutting down, discarding queued events")
+				p.discardEvent(event)
+				p.discardEvents()
+				return nil
 			}
+			p.dispatch(event)
+			p.pending.Done()
+			p.beat()
+		case <-ticker.C:
+			p.beat()
 		case <-p.stop:
 			log.Info("provider.kubernetes: got shutdown signal, stopping...")
+			p.discardEvents()
 			return nil
 		}
 	}
 }
 
+// discardEvents - marks events left in the queue on shutdown as done
+func (p *Provider) discardEvents() {
+	for {
+		select {
+		case event := <-p.events:
+			p.discardEvent(event)
+		default:
+			return
+		}
+	}
+}
+
+func (p *Provider) discardEvent(event *types.Event) {
+	p.pending.Done()
+	event.Results.Done()
+}
+
+func (p *Provider) beat() {
+	atomic.StoreInt64(&p.heartbeat, time.Now().UnixNano())
+}
+
+// Healthy - returns an error if the event loop has panicked or hasn't
+// made progress within the liveness timeout
+func (p *Provider) Healthy() error {
+	p.mu.Lock()
+	failure := p.failure
+	p.mu.Unlock()
+	if failure != nil {
+		return failure
+	}
+
+	last := atomic.LoadInt64(&p.heartbeat)
+	if last == 0 {
+		// not started yet
+		return nil
+	}
+
+	since := time.Since(time.Unix(0, last))
+	if since > livenessTimeout {
+		return fmt.Errorf("event loop hasn't responded for %s", since.Round(time.Second))
+	}
+	return nil
+}
+
 func (p *Provider) processEvent(event *types.Event) (updated []*k8s.GenericResource, err error) {
+	return p.applyPlans(event, p.eventPlans(event))
+}
+
+// eventPlans - update plans of resources impacted by the event
+func (p *Provider) eventPlans(event *types.Event) []*UpdatePlan {
 	plans, err := p.createUpdatePlans(&event.Repository)
 	if err != nil {
-		return nil, err
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": event.Repository.Name,
+			"tag":   event.Repository.Tag,
+		}).Error("provider.kubernetes: failed to create update plans")
+		event.Results.Report(types.ResourceUpdate{
+			Provider: p.GetName(),
+			Status:   types.UpdateStatusError,
+			Reason:   err.Error(),
+		})
+		return nil
 	}
+	plans = filterByTrigger(plans, event.TriggerName)
+	plans = p.filterByWebhookSecret(plans, event)
 
 	if len(plans) == 0 {
 		log.WithFields(log.Fields{
 			"image": event.Repository.Name,
 			"tag":   event.Repository.Tag,
 		}).Debug("provider.kubernetes: no plans for deployment updates found for this event")
+		return nil
+	}
+
+	for _, plan := range plans {
+		plan.Trigger = event.TriggerName
+		plan.ResolvedVersion = event.Repository.Version
+	}
+	return plans
+}
+
+// applyPlans - updates resources once their plans are approved
+func (p *Provider) applyPlans(event *types.Event, plans []*UpdatePlan) (updated []*k8s.GenericResource, err error) {
+	if len(plans) == 0 {
 		return
 	}
 
 	approvedPlans := p.checkForApprovals(event, plans)
 
-	return p.updateDeployments(approvedPlans)
+	updated, err = p.updateDeployments(event, approvedPlans)
+	if len(updated) > 0 {
+		provider.UpdatesCounter.With(prometheus.Labels{"provider": p.GetName(), "trigger": event.TriggerName}).Add(float64(len(updated)))
+	}
+	return updated, err
 }
 
-func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.GenericResource, err error) {
+func (p *Provider) updateDeployments(event *types.Event, plans []*UpdatePlan) (updated []*k8s.GenericResource, err error) {
 	for _, plan := range plans {
 		resource := plan.Resource
 
@@ -269,10 +605,33 @@ func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.Generi
 
 		notificationChannels := types.ParseEventNotificationChannels(annotations)
 
+		if p.isFailedRollout(resource.Identifier, plan.NewVersion) {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"kind":      resource.Kind(),
+				"namespace": resource.Namespace,
+				"version":   plan.NewVersion,
+			}).Info("provider.kubernetes: version was rolled back after failed rollout, skipping update")
+			p.report(event, plan, types.UpdateStatusRejected, "version was rolled back after failed rollout")
+			continue
+		}
+
+		if p.dryRun {
+			p.dryRunUpdate(plan, notificationChannels)
+			p.report(event, plan, types.UpdateStatusNoop, "dry run")
+			continue
+		}
+
+		if plan.Original != nil {
+			p.annotateUpdate(plan, notificationChannels)
+			p.report(event, plan, types.UpdateStatusNoop, "annotate only")
+			continue
+		}
+
 		p.sender.Send(types.EventNotification{
 			ResourceKind: resource.Kind(),
 			Identifier:   resource.Identifier,
-			Name:         "preparing to update resource",
+			Name:         types.EventNamePreUpdateResource,
 			Message:      fmt.Sprintf("Preparing to update %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", ")),
 			CreatedAt:    time.Now(),
 			Type:         types.NotificationPreDeploymentUpdate,
@@ -282,6 +641,8 @@ func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.Generi
 				"provider":  p.GetName(),
 				"namespace": resource.GetNamespace(),
 				"name":      resource.GetName(),
+				"version":   plan.NewVersion,
+				"images":    strings.Join(resource.GetImages(), ", "),
 			},
 		})
 
@@ -302,9 +663,10 @@ func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.Generi
 				"kind":       resource.Kind(),
 				"update":     fmt.Sprintf("%s->%s", plan.CurrentVersion, plan.NewVersion),
 			}).Error("provider.kubernetes: got error while updating resource")
+			p.report(event, plan, types.UpdateStatusError, err.Error())
 
 			p.sender.Send(types.EventNotification{
-				Name:         "update resource",
+				Name:         types.EventNameUpdateResource,
 				ResourceKind: resource.Kind(),
 				Identifier:   resource.Identifier,
 				Message:      fmt.Sprintf("%s %s/%s update %s->%s failed, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err),
@@ -316,12 +678,17 @@ func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.Generi
 					"provider":  p.GetName(),
 					"namespace": resource.GetNamespace(),
 					"name":      resource.GetName(),
+					"version":   plan.NewVersion,
+					"images":    strings.Join(resource.GetImages(), ", "),
 				},
 			})
 
 			continue
 		}
 
+		p.setLastUpdated(resource.Identifier, time.Now())
+		p.cacheUpdated(resource)
+
 		err = p.updateComplete(plan)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -332,38 +699,24 @@ func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.Generi
 			}).Warn("provider.kubernetes: got error while archiving approvals counter after successful update")
 		}
 
-		var msg string
-		releaseNotes := types.ParseReleaseNotesURL(resource.GetAnnotations())
-		if releaseNotes != "" {
-			msg = fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s). Release notes: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "), releaseNotes)
-		} else {
-			msg = fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "))
+		msg := fmt.Sprintf("Successfully updated %s %s/%s %s->%s (%s)", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(resource.GetImages(), ", "))
+		if plan.ResolvedVersion != "" {
+			msg += fmt.Sprintf(", %s is %s", plan.NewVersion, plan.ResolvedVersion)
+		}
+		if releaseNotes := types.ParseReleaseNotesURL(resource.GetAnnotations()); releaseNotes != "" {
+			msg += ". Release notes: " + releaseNotes
 		}
 
-		err = p.sender.Send(types.EventNotification{
+		success := types.EventNotification{
 			ResourceKind: resource.Kind(),
 			Identifier:   resource.Identifier,
-			Name:         "update resource",
+			Name:         types.EventNameUpdateResource,
 			Message:      msg,
 			CreatedAt:    time.Now(),
 			Type:         types.NotificationDeploymentUpdate,
 			Level:        types.LevelSuccess,
 			Channels:     notificationChannels,
-			Metadata: map[string]string{
-				"provider":  p.GetName(),
-				"namespace": resource.GetNamespace(),
-				"name":      resource.GetName(),
-			},
-		})
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":     err,
-				"name":      resource.Name,
-				"kind":      resource.Kind(),
-				"previous":  plan.CurrentVersion,
-				"new":       plan.NewVersion,
-				"namespace": resource.Namespace,
-			}).Error("provider.kubernetes: got error while sending notification")
+			Metadata:     updateMetadata(p.GetName(), plan),
 		}
 
 		log.WithFields(log.Fields{
@@ -374,11 +727,37 @@ func (p *Provider) updateDeployments(plans []*UpdatePlan) (updated []*k8s.Generi
 			"namespace": resource.Namespace,
 		}).Info("provider.kubernetes: resource updated")
 		updated = append(updated, resource)
+		p.report(event, plan, types.UpdateStatusUpdated, "")
+
+		switch {
+		case p.shouldWaitForRollout(resource):
+			p.startRolloutWatch(resource, plan, notificationChannels, &success)
+		case shouldWatchRollout(resource):
+			p.sendUpdateNotification(resource, plan, success)
+			p.startRolloutWatch(resource, plan, notificationChannels, nil)
+		default:
+			p.sendUpdateNotification(resource, plan, success)
+		}
 	}
 
 	return
 }
 
+// sendUpdateNotification - sends successful update notification
+func (p *Provider) sendUpdateNotification(resource *k8s.GenericResource, plan *UpdatePlan, event types.EventNotification) {
+	err := p.sender.Send(event)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"previous":  plan.CurrentVersion,
+			"new":       plan.NewVersion,
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: got error while sending notification")
+	}
+}
+
 func getDesiredImage(delta map[string]string, currentImage string) (string, error) {
 	currentRef, err := image.Parse(currentImage)
 	if err != nil {
@@ -401,37 +780,192 @@ func getDesiredImage(delta map[string]string, currentImage string) (string, erro
 	return "", fmt.Errorf("image %s not found in deltas", currentImage)
 }
 
+// dryRunUpdate - reports update that would have been applied
+func (p *Provider) dryRunUpdate(plan *UpdatePlan, channels []string) {
+	resource := plan.Resource
+
+	log.WithFields(log.Fields{
+		"name":      resource.Name,
+		"kind":      resource.Kind(),
+		"previous":  strings.Join(plan.PreviousImages, ", "),
+		"new":       strings.Join(resource.GetImages(), ", "),
+		"namespace": resource.Namespace,
+	}).Info("provider.kubernetes: dry run, resource not updated")
+
+	metadata := updateMetadata(p.GetName(), plan)
+	metadata["dry_run"] = "true"
+
+	err := p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "dry run update resource",
+		Message:      fmt.Sprintf("[DRY RUN] Would update %s %s/%s %s->%s (%s -> %s), no changes were made", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, strings.Join(plan.PreviousImages, ", "), strings.Join(resource.GetImages(), ", ")),
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelInfo,
+		Channels:     channels,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+		}).Error("provider.kubernetes: got error while sending dry run notification")
+	}
+}
+
+// updateMetadata - successful update details, stored by the auditor
+func updateMetadata(providerName string, plan *UpdatePlan) map[string]string {
+	resource := plan.Resource
+	metadata := map[string]string{
+		"provider":         providerName,
+		"namespace":        resource.GetNamespace(),
+		"name":             resource.GetName(),
+		"version":          plan.NewVersion,
+		"images":           strings.Join(resource.GetImages(), ", "),
+		"previous_version": plan.CurrentVersion,
+		"previous_images":  strings.Join(plan.PreviousImages, ", "),
+		"trigger":          plan.Trigger,
+	}
+	if plan.ResolvedVersion != "" {
+		metadata["resolved_version"] = plan.ResolvedVersion
+	}
+	if plan.Approval != nil {
+		metadata["approval_id"] = plan.Approval.ID
+		metadata["approved_by"] = strings.Join(plan.Approval.GetVoters(), ", ")
+	}
+	return metadata
+}
+
+// filterByTrigger - drops plans for resources that don't accept events from
+// the trigger, i.e. poll events for resources that only accept webhooks
+func filterByTrigger(plans []*UpdatePlan, triggerName string) []*UpdatePlan {
+	filtered := plans[:0]
+	for _, plan := range plans {
+		trigger := policies.GetTriggerPolicy(plan.Resource.GetLabels(), plan.Resource.GetAnnotations())
+		if !trigger.Accepts(triggerName) {
+			log.WithFields(log.Fields{
+				"name":      plan.Resource.Name,
+				"kind":      plan.Resource.Kind(),
+				"namespace": plan.Resource.Namespace,
+				"trigger":   trigger.String(),
+				"event":     triggerName,
+			}).Debug("provider.kubernetes: resource doesn't accept events from this trigger, skipping")
+			continue
+		}
+		filtered = append(filtered, plan)
+	}
+	return filtered
+}
+
 // createUpdatePlans - impacted deployments by changed repository
 func (p *Provider) createUpdatePlans(repo *types.Repository) ([]*UpdatePlan, error) {
 	impacted := []*UpdatePlan{}
 
+	// digest is resolved once, only if some resource pins the image to a digest
+	var pinnedRepo *types.Repository
+
 	for _, resource := range p.cache.Values() {
+		if plan := p.planUpdate(repo, resource, &pinnedRepo); plan != nil {
+			impacted = append(impacted, plan)
+		}
+	}
 
-		labels := resource.GetLabels()
-		annotations := resource.GetAnnotations()
+	return impacted, nil
+}
 
-		plc := policy.GetPolicyFromLabelsOrAnnotations(labels, annotations)
-		if plc.Type() == policy.PolicyTypeNone {
-			continue
-		}
+// planUpdate - update plan of the resource for the changed repository, nil
+// when the resource doesn't need updating. pinnedRepo caches the repository
+// with resolved digest between calls
+func (p *Provider) planUpdate(repo *types.Repository, resource *k8s.GenericResource, pinnedRepo **types.Repository) *UpdatePlan {
+	if !p.namespaceFilter.Allowed(resource.Namespace) {
+		return nil
+	}
 
-		updated, shouldUpdateDeployment, err := checkForUpdate(plc, repo, resource)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error":      err,
-				"deployment": resource.Name,
-				"kind":       resource.Kind(),
-				"namespace":  resource.Namespace,
-			}).Error("provider.kubernetes: got error while checking versioned resource")
-			continue
-		}
+	labels := resource.GetLabels()
+	annotations := resource.GetAnnotations()
+
+	plc := policy.GetPolicyFromLabelsOrAnnotations(labels, annotations)
+	if plc.Type() == policy.PolicyTypeNone && !policy.HasContainerPolicies(labels, annotations) {
+		return nil
+	}
+
+	if policies.IsPaused(annotations) {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+		}).Debug("provider.kubernetes: updates paused, skipping resource")
+		return nil
+	}
 
-		if shouldUpdateDeployment {
-			impacted = append(impacted, updated)
+	previousImages := resource.GetImages()
+
+	var original *k8s.GenericResource
+	annotateOnly := policies.ShouldAnnotateOnly(annotations, p.annotateOnly)
+	if annotateOnly {
+		original = resource.DeepCopy()
+	}
+
+	matchMode := policies.GetMatchMode(annotations, p.matchMode)
+	eventRepo := repo
+	if repo.Digest == "" && hasPinnedContainer(repo, resource, matchMode) {
+		if *pinnedRepo == nil {
+			*pinnedRepo = p.resolveDigest(repo, resource)
 		}
+		eventRepo = *pinnedRepo
 	}
 
-	return impacted, nil
+	updated, shouldUpdateDeployment, err := checkForUpdate(plc, eventRepo, resource, matchMode)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"deployment": resource.Name,
+			"kind":       resource.Kind(),
+			"namespace":  resource.Namespace,
+		}).Error("provider.kubernetes: got error while checking versioned resource")
+		return nil
+	}
+
+	if !shouldUpdateDeployment {
+		return nil
+	}
+	if p.isFrozen(updated) {
+		return nil
+	}
+	if annotateOnly && annotations[types.KeelAvailableAnnotation] == updated.NewVersion {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"kind":      resource.Kind(),
+			"namespace": resource.Namespace,
+			"version":   updated.NewVersion,
+		}).Debug("provider.kubernetes: available version already annotated, skipping resource")
+		return nil
+	}
+	updated.PreviousImages = previousImages
+	updated.Original = original
+	return updated
+}
+
+// refreshPlan - rebuilds the plan from the cached resource right before it's
+// applied. Earlier jobs of the same queue may have changed the resource since
+// the event was dispatched, applying the plan built back then would fail on
+// a stale resource version or undo their changes. Nil when the resource is
+// gone or doesn't need the update anymore
+func (p *Provider) refreshPlan(event *types.Event, plan *UpdatePlan) *UpdatePlan {
+	resource, ok := p.cache.Get(plan.Resource.Identifier)
+	if !ok {
+		return nil
+	}
+	var pinnedRepo *types.Repository
+	refreshed := p.planUpdate(&event.Repository, resource, &pinnedRepo)
+	if refreshed == nil {
+		return nil
+	}
+	refreshed.Trigger = plan.Trigger
+	refreshed.ResolvedVersion = plan.ResolvedVersion
+	return refreshed
 }
 
 func (p *Provider) namespaces() (*v1.NamespaceList, error) {
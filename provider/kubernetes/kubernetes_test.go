@@ -1,19 +1,28 @@
 package kubernetes
 
 import (
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/internal/k8s"
 	"github.com/keel-hq/keel/pkg/store/sql"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
 
 	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -47,6 +56,8 @@ type fakeImplementer struct {
 	deployment     *apps_v1.Deployment
 	deploymentList *apps_v1.DeploymentList
 
+	replicaSetList *apps_v1.ReplicaSetList
+
 	podList     *v1.PodList
 	deletedPods []*v1.Pod
 
@@ -68,6 +79,10 @@ func (i *fakeImplementer) Deployments(namespace string) (*apps_v1.DeploymentList
 	return i.deploymentList, nil
 }
 
+func (i *fakeImplementer) ReplicaSets(namespace, labelSelector string) (*apps_v1.ReplicaSetList, error) {
+	return i.replicaSetList, nil
+}
+
 func (i *fakeImplementer) Update(obj *k8s.GenericResource) error {
 	i.updated = obj
 	return nil
@@ -99,6 +114,9 @@ func (i *fakeImplementer) ConfigMaps(namespace string) core_v1.ConfigMapInterfac
 }
 
 type fakeSender struct {
+	// mu - updates of different resources are applied and notified
+	// concurrently
+	mu        sync.Mutex
 	sentEvent types.EventNotification
 }
 
@@ -107,6 +125,8 @@ func (s *fakeSender) Configure(cfg *notification.Config) (bool, error) {
 }
 
 func (s *fakeSender) Send(event types.EventNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.sentEvent = event
 	return nil
 }
@@ -296,6 +316,61 @@ func TestGetImpacted(t *testing.T) {
 	}
 
 }
+func TestGetImpactedPaused(t *testing.T) {
+	fp := &fakeImplementer{}
+
+	newDep := func(name string, annotations map[string]string) *apps_v1.Deployment {
+		return &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        name,
+				Namespace:   "xxxx",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: annotations,
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	grs := MustParseGRS([]*apps_v1.Deployment{
+		newDep("dep-1", nil),
+		newDep("dep-2", map[string]string{types.KeelPausedAnnotation: "true"}),
+	})
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(grs...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	plans, err := provider.createUpdatePlans(&types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	})
+	if err != nil {
+		t.Errorf("failed to get deployments: %s", err)
+	}
+
+	if len(plans) != 1 {
+		t.Fatalf("expected to find 1 deployment update plan but found %d", len(plans))
+	}
+	if plans[0].Resource.Name != "dep-1" {
+		t.Errorf("expected dep-1 to be updated, paused dep-2 skipped, got: %s", plans[0].Resource.Name)
+	}
+}
+
 func TestGetImpactedPolicyAnnotations(t *testing.T) {
 	fp := &fakeImplementer{}
 	fp.namespaces = &v1.NamespaceList{
@@ -704,6 +779,20 @@ func TestProcessEvent(t *testing.T) {
 	if fp.updated.Containers()[0].Image != repo.Name+":"+repo.Tag {
 		t.Errorf("expected to find a deployment with updated image but found: %s", fp.updated.Containers()[0].Image)
 	}
+
+	tracked, err := provider.TrackedImages()
+	if err != nil {
+		t.Fatalf("failed to get tracked images: %s", err)
+	}
+	if len(tracked) != 1 {
+		t.Fatalf("expected 1 tracked image, got: %d", len(tracked))
+	}
+	if tracked[0].Kind != "deployment" || tracked[0].Name != "deployment-1" || tracked[0].Identifier != fp.updated.Identifier {
+		t.Errorf("unexpected tracked resource: %s %s (%s)", tracked[0].Kind, tracked[0].Name, tracked[0].Identifier)
+	}
+	if tracked[0].LastUpdated.IsZero() {
+		t.Errorf("expected last update time to be recorded")
+	}
 }
 
 func TestProcessEventBuildNumber(t *testing.T) {
@@ -822,7 +911,7 @@ func TestEventSent(t *testing.T) {
 		Tag:  "11.0.0",
 	}
 
-	event := &types.Event{Repository: repo}
+	event := &types.Event{Repository: repo, TriggerName: "poll"}
 	_, err = provider.processEvent(event)
 	if err != nil {
 		t.Errorf("got error while processing event: %s", err)
@@ -835,6 +924,333 @@ func TestEventSent(t *testing.T) {
 	if fs.sentEvent.Message != "Successfully updated deployment xxxx/deployment-1 10.0.0->11.0.0 (gcr.io/v2-namespace/hello-world:11.0.0)" {
 		t.Errorf("expected 'Successfully updated deployment xxxx/deployment-1 10.0.0->11.0.0 (gcr.io/v2-namespace/hello-world:11.0.0)' sent message, got: %s", fs.sentEvent.Message)
 	}
+
+	// audit details
+	meta := fs.sentEvent.Metadata
+	if meta["previous_images"] != "gcr.io/v2-namespace/hello-world:10.0.0" {
+		t.Errorf("unexpected previous images: %s", meta["previous_images"])
+	}
+	if meta["images"] != "gcr.io/v2-namespace/hello-world:11.0.0" {
+		t.Errorf("unexpected images: %s", meta["images"])
+	}
+	if meta["previous_version"] != "10.0.0" || meta["version"] != "11.0.0" {
+		t.Errorf("unexpected versions: %s->%s", meta["previous_version"], meta["version"])
+	}
+	if meta["trigger"] != "poll" {
+		t.Errorf("unexpected trigger: %s", meta["trigger"])
+	}
+	if _, ok := meta["approved_by"]; ok {
+		t.Errorf("didn't expect approval details without approval")
+	}
+}
+
+func TestEventSentDryRun(t *testing.T) {
+	fp := &fakeImplementer{}
+	deps := []*apps_v1.Deployment{
+		{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "deployment-1",
+				Namespace:   "xxxx",
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+				Annotations: map[string]string{},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:10.0.0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	grs := MustParseGRS(deps)
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(grs...)
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.SetDryRun(true)
+
+	event := &types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "11.0.0",
+	}, TriggerName: "poll"}
+	updated, err := provider.processEvent(event)
+	if err != nil {
+		t.Errorf("got error while processing event: %s", err)
+	}
+
+	if fp.updated != nil {
+		t.Errorf("didn't expect resource to be updated in dry run mode")
+	}
+	if len(updated) != 0 {
+		t.Errorf("expected no updated resources, got: %d", len(updated))
+	}
+
+	expected := "[DRY RUN] Would update deployment xxxx/deployment-1 10.0.0->11.0.0 (gcr.io/v2-namespace/hello-world:10.0.0 -> gcr.io/v2-namespace/hello-world:11.0.0), no changes were made"
+	if fs.sentEvent.Message != expected {
+		t.Errorf("expected '%s' sent message, got: %s", expected, fs.sentEvent.Message)
+	}
+	if fs.sentEvent.Metadata["dry_run"] != "true" {
+		t.Errorf("expected dry run notification to be marked in metadata")
+	}
+	if fs.sentEvent.Level != types.LevelInfo {
+		t.Errorf("unexpected notification level: %s", fs.sentEvent.Level)
+	}
+}
+
+func TestProcessEventDigestChangeForce(t *testing.T) {
+	fp := &fakeImplementer{}
+	newDep := func(name string, labels map[string]string) *apps_v1.Deployment {
+		return &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        name,
+				Namespace:   "xxxx",
+				Labels:      labels,
+				Annotations: map[string]string{},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		newDep("forced", map[string]string{types.KeelPolicyLabel: "force", types.KeelForceTagMatchLabel: "true"}),
+		newDep("versioned", map[string]string{types.KeelPolicyLabel: "minor"}),
+	})...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// poll trigger sends the same tag when its digest changes
+	updated, err := provider.processEvent(&types.Event{
+		Repository: types.Repository{
+			Name:   "gcr.io/v2-namespace/hello-world",
+			Tag:    "1.1.1",
+			Digest: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+		TriggerName: "poll",
+	})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+
+	if len(updated) != 1 {
+		t.Fatalf("expected 1 updated resource, got: %d", len(updated))
+	}
+	if updated[0].Name != "forced" {
+		t.Errorf("expected forced deployment to be restarted, got: %s", updated[0].Name)
+	}
+	if _, ok := fp.updated.GetSpecAnnotations()[types.KeelUpdateTimeAnnotation]; !ok {
+		t.Errorf("expected %s annotation to be set", types.KeelUpdateTimeAnnotation)
+	}
+	if img := fp.updated.Containers()[0].Image; img != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("expected image to stay the same, got: %s", img)
+	}
+	if digest := fp.updated.GetSpecAnnotations()[types.KeelDigestAnnotation]; digest != "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb" {
+		t.Errorf("unexpected %s annotation: %s", types.KeelDigestAnnotation, digest)
+	}
+}
+
+func TestProcessEventDigestAlreadyApplied(t *testing.T) {
+	digest := "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "forced",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "force", types.KeelForceTagMatchLabel: "true"},
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Annotations: map[string]string{types.KeelDigestAnnotation: digest},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	fp := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	event := &types.Event{
+		Repository:  types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.1", Digest: digest},
+		TriggerName: "native",
+	}
+	updated, err := provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 0 || fp.updated != nil {
+		t.Fatalf("didn't expect resource with the same digest to be updated")
+	}
+
+	event.Repository.Digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	updated, err = provider.processEvent(event)
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 1 {
+		t.Fatalf("expected resource to be updated when digest changes, got: %d", len(updated))
+	}
+	if got := fp.updated.GetSpecAnnotations()[types.KeelDigestAnnotation]; got != event.Repository.Digest {
+		t.Errorf("unexpected %s annotation: %s", types.KeelDigestAnnotation, got)
+	}
+}
+
+type fakeRegistryClient struct {
+	digests map[string]string // keyed by tag
+	opts    []registry.Opts
+}
+
+func (c *fakeRegistryClient) Get(opts registry.Opts) (*registry.Repository, error) {
+	return &registry.Repository{}, nil
+}
+
+func (c *fakeRegistryClient) Digest(opts registry.Opts) (string, error) {
+	c.opts = append(c.opts, opts)
+	digest, ok := c.digests[opts.Tag]
+	if !ok {
+		return "", fmt.Errorf("tag %s not found", opts.Tag)
+	}
+	return digest, nil
+}
+
+func TestProcessEventDigestPinned(t *testing.T) {
+	oldDigest := "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"
+	newDigest := "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "pinned",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "minor"},
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Image: "gcr.io/v2-namespace/hello-world:1.1.1@" + oldDigest},
+						{Image: "gcr.io/v2-namespace/sidecar:1.0.0"},
+					},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	fp := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	rc := &fakeRegistryClient{digests: map[string]string{"1.1.2": newDigest}}
+	provider.registryClient = rc
+
+	// webhook event without digest, digest is resolved from the registry
+	updated, err := provider.processEvent(&types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+	})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 1 {
+		t.Fatalf("expected pinned resource to be updated, got: %d", len(updated))
+	}
+
+	if len(rc.opts) != 1 || rc.opts[0].Registry != "https://gcr.io" || rc.opts[0].Name != "v2-namespace/hello-world" {
+		t.Errorf("unexpected registry requests: %+v", rc.opts)
+	}
+
+	containers := fp.updated.Containers()
+	if containers[0].Image != "gcr.io/v2-namespace/hello-world:1.1.2@"+newDigest {
+		t.Errorf("unexpected pinned image: %s", containers[0].Image)
+	}
+	if containers[1].Image != "gcr.io/v2-namespace/sidecar:1.0.0" {
+		t.Errorf("didn't expect sidecar to be updated: %s", containers[1].Image)
+	}
+}
+
+func TestProcessEventDigestPinnedUnresolved(t *testing.T) {
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "pinned",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Image: "gcr.io/v2-namespace/hello-world:1.1.1@sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"},
+					},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	fp := &fakeImplementer{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.registryClient = &fakeRegistryClient{}
+
+	updated, err := provider.processEvent(&types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+	})
+	if err != nil {
+		t.Fatalf("got error while processing event: %s", err)
+	}
+	if len(updated) != 0 || fp.updated != nil {
+		t.Fatalf("didn't expect pinned resource to be updated without digest")
+	}
 }
 
 func TestEventSentWithReleaseNotes(t *testing.T) {
@@ -909,6 +1325,215 @@ func TestEventSentWithReleaseNotes(t *testing.T) {
 	}
 }
 
+func TestProcessEventStatefulSet(t *testing.T) {
+	fp := &fakeImplementer{}
+	ss := &apps_v1.StatefulSet{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "sts-1",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "minor"},
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.StatefulSetSpec{
+			UpdateStrategy: apps_v1.StatefulSetUpdateStrategy{
+				Type: apps_v1.RollingUpdateStatefulSetStrategyType,
+			},
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(ss))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// major bump should be ignored by the minor policy
+	_, err = provider.processEvent(&types.Event{Repository: types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "2.0.0",
+	}})
+	if err != nil {
+		t.Errorf("got error while processing event: %s", err)
+	}
+	if fp.updated != nil {
+		t.Fatalf("didn't expect statefulset to be updated, but got: %s", fp.updated.Identifier)
+	}
+
+	repo := types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.2.0",
+	}
+	_, err = provider.processEvent(&types.Event{Repository: repo})
+	if err != nil {
+		t.Errorf("got error while processing event: %s", err)
+	}
+
+	if fp.updated == nil {
+		t.Fatalf("statefulset was not updated")
+	}
+
+	if fp.updated.Kind() != "statefulset" {
+		t.Errorf("expected statefulset to be updated, got: %s", fp.updated.Kind())
+	}
+
+	if fp.updated.Containers()[0].Image != repo.Name+":"+repo.Tag {
+		t.Errorf("expected to find a statefulset with updated image but found: %s", fp.updated.Containers()[0].Image)
+	}
+
+	updated := fp.updated.GetResource().(*apps_v1.StatefulSet)
+	if updated.Spec.UpdateStrategy.Type != apps_v1.RollingUpdateStatefulSetStrategyType {
+		t.Errorf("expected update strategy to be preserved, got: %s", updated.Spec.UpdateStrategy.Type)
+	}
+
+	if fs.sentEvent.Message != "Successfully updated statefulset xxxx/sts-1 1.1.1->1.2.0 (gcr.io/v2-namespace/hello-world:1.2.0)" {
+		t.Errorf("unexpected sent message: %s", fs.sentEvent.Message)
+	}
+	if fs.sentEvent.Type != types.NotificationDeploymentUpdate {
+		t.Errorf("unexpected notification type: %s", fs.sentEvent.Type)
+	}
+}
+
+func TestProcessEventDaemonSet(t *testing.T) {
+	fp := &fakeImplementer{}
+	ds := &apps_v1.DaemonSet{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "ds-1",
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.DaemonSetSpec{
+			UpdateStrategy: apps_v1.DaemonSetUpdateStrategy{
+				Type: apps_v1.RollingUpdateDaemonSetStrategyType,
+			},
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/log-shipper:1.1.1",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(ds))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	repo := types.Repository{
+		Name: "gcr.io/v2-namespace/log-shipper",
+		Tag:  "1.2.0",
+	}
+	_, err = provider.processEvent(&types.Event{Repository: repo})
+	if err != nil {
+		t.Errorf("got error while processing event: %s", err)
+	}
+
+	if fp.updated == nil {
+		t.Fatalf("daemonset was not updated")
+	}
+
+	if fp.updated.Kind() != "daemonset" {
+		t.Errorf("expected daemonset to be updated, got: %s", fp.updated.Kind())
+	}
+
+	if fp.updated.Containers()[0].Image != repo.Name+":"+repo.Tag {
+		t.Errorf("expected to find a daemonset with updated image but found: %s", fp.updated.Containers()[0].Image)
+	}
+
+	if fs.sentEvent.Message != "Successfully updated daemonset xxxx/ds-1 1.1.1->1.2.0 (gcr.io/v2-namespace/log-shipper:1.2.0)" {
+		t.Errorf("unexpected sent message: %s", fs.sentEvent.Message)
+	}
+}
+
+func TestProcessEventCronJob(t *testing.T) {
+	fp := &fakeImplementer{}
+	cj := &batch_v1.CronJob{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "cron-1",
+			Namespace:   "xxxx",
+			Annotations: map[string]string{types.KeelPolicyLabel: "patch"},
+		},
+		Spec: batch_v1.CronJobSpec{
+			Schedule: "*/5 * * * *",
+			JobTemplate: batch_v1.JobTemplateSpec{
+				Spec: batch_v1.JobSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Image: "gcr.io/v2-namespace/batch:1.0.0",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGR(cj))
+
+	fs := &fakeSender{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	repo := types.Repository{
+		Name: "gcr.io/v2-namespace/batch",
+		Tag:  "1.0.1",
+	}
+	_, err = provider.processEvent(&types.Event{Repository: repo})
+	if err != nil {
+		t.Errorf("got error while processing event: %s", err)
+	}
+
+	if fp.updated == nil {
+		t.Fatalf("cronjob was not updated")
+	}
+
+	updated, ok := fp.updated.GetResource().(*batch_v1.CronJob)
+	if !ok {
+		t.Fatalf("expected cronjob to be updated, got: %s", fp.updated.Kind())
+	}
+
+	image := updated.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image
+	if image != repo.Name+":"+repo.Tag {
+		t.Errorf("expected to find a cronjob with updated image but found: %s", image)
+	}
+
+	if fs.sentEvent.Message != "Successfully updated cronjob xxxx/cron-1 1.0.0->1.0.1 (gcr.io/v2-namespace/batch:1.0.1)" {
+		t.Errorf("unexpected sent message: %s", fs.sentEvent.Message)
+	}
+}
+
 // Test to check how many deployments are "impacted" if we have sidecar container
 func TestGetImpactedTwoContainersInSameDeployment(t *testing.T) {
 	fp := &fakeImplementer{}
@@ -1010,6 +1635,88 @@ func TestGetImpactedTwoContainersInSameDeployment(t *testing.T) {
 
 }
 
+func TestPerContainerPolicies(t *testing.T) {
+	fp := &fakeImplementer{}
+	deps := []*apps_v1.Deployment{
+		{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "dep-1",
+				Namespace: "xxxx",
+				Annotations: map[string]string{
+					types.KeelContainerPolicyPrefix + "web":    "minor",
+					types.KeelContainerPolicyPrefix + "worker": "patch",
+				},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Name:  "web",
+								Image: "gcr.io/v2-namespace/web:1.1.1",
+							},
+							{
+								Name:  "worker",
+								Image: "gcr.io/v2-namespace/worker:1.1.1",
+							},
+							{
+								Name:  "sidecar",
+								Image: "gcr.io/v2-namespace/sidecar:1.1.1",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deps)...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	imgs, err := provider.TrackedImages()
+	if err != nil {
+		t.Fatalf("failed to get tracked images: %s", err)
+	}
+	policies := map[string]string{}
+	for _, img := range imgs {
+		policies[img.Image.ShortName()] = img.Policy.Name()
+	}
+	expected := map[string]string{
+		"v2-namespace/web":    "minor",
+		"v2-namespace/worker": "patch",
+	}
+	if !reflect.DeepEqual(policies, expected) {
+		t.Errorf("unexpected tracked images: %v", policies)
+	}
+
+	tests := []struct {
+		repo string
+		tag  string
+		want bool
+	}{
+		{"gcr.io/v2-namespace/web", "1.2.0", true},
+		{"gcr.io/v2-namespace/worker", "1.2.0", false},
+		{"gcr.io/v2-namespace/worker", "1.1.2", true},
+		{"gcr.io/v2-namespace/sidecar", "1.1.2", false},
+	}
+	for _, tt := range tests {
+		plans, err := provider.createUpdatePlans(&types.Repository{Name: tt.repo, Tag: tt.tag})
+		if err != nil {
+			t.Fatalf("failed to get update plans: %s", err)
+		}
+		if got := len(plans) == 1; got != tt.want {
+			t.Errorf("%s:%s expected update %v, got %d plans", tt.repo, tt.tag, tt.want, len(plans))
+		}
+	}
+}
+
 func TestGetImpactedTwoSameContainersInSameDeployment(t *testing.T) {
 
 	fp := &fakeImplementer{}
@@ -1445,3 +2152,292 @@ func TestTrackedImagesWithSecrets(t *testing.T) {
 		t.Errorf("expected very-secret, got: %s", imgs[0].Secrets[1])
 	}
 }
+
+func TestTrackedImagesPollSchedule(t *testing.T) {
+	fp := &fakeImplementer{}
+	tests := []struct {
+		name            string
+		annotations     map[string]string
+		defaultSchedule string
+		want            string
+	}{
+		{"default", nil, "", types.KeelPollDefaultSchedule},
+		{"cron", map[string]string{types.KeelPollScheduleAnnotation: "@every 2h"}, "", "@every 2h"},
+		{"duration", map[string]string{types.KeelPollScheduleAnnotation: "5m"}, "", "@every 5m0s"},
+		{"invalid", map[string]string{types.KeelPollScheduleAnnotation: "whenever"}, "", types.KeelPollDefaultSchedule},
+		{"configured default", nil, "@every 10m", "@every 10m"},
+		{"invalid with configured default", map[string]string{types.KeelPollScheduleAnnotation: "whenever"}, "@every 10m", "@every 10m"},
+		{"annotation over configured default", map[string]string{types.KeelPollScheduleAnnotation: "@every 2h"}, "@every 10m", "@every 2h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := []*apps_v1.Deployment{
+				{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:        "dep-1",
+						Namespace:   "xxxx",
+						Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+						Annotations: tt.annotations,
+					},
+					Spec: apps_v1.DeploymentSpec{
+						Template: v1.PodTemplateSpec{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "gcr.io/v2-namespace/hello-world:1.1",
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			grc := &k8s.GenericResourceCache{}
+			grc.Add(MustParseGRS(deps)...)
+
+			approver, teardown := approver()
+			defer teardown()
+			provider, err := NewProvider(fp, &fakeSender{}, approver, grc)
+			if err != nil {
+				t.Fatalf("failed to get provider: %s", err)
+			}
+			if tt.defaultSchedule != "" {
+				provider.SetDefaultPollSchedule(tt.defaultSchedule)
+			}
+
+			imgs, err := provider.TrackedImages()
+			if err != nil {
+				t.Fatalf("failed to get images: %s", err)
+			}
+			if len(imgs) != 1 {
+				t.Fatalf("expected to find 1 image, got: %d", len(imgs))
+			}
+			if imgs[0].PollSchedule != tt.want {
+				t.Errorf("expected schedule %s, got: %s", tt.want, imgs[0].PollSchedule)
+			}
+		})
+	}
+}
+
+func TestProviderHealthy(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	if err := provider.Healthy(); err != nil {
+		t.Errorf("expected provider to be healthy before start, got: %s", err)
+	}
+
+	provider.heartbeat = time.Now().Add(-2 * livenessTimeout).UnixNano()
+	if err := provider.Healthy(); err == nil {
+		t.Errorf("expected stale heartbeat to fail liveness")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		provider.Start()
+		close(done)
+	}()
+	// nil event makes the event loop panic
+	provider.events <- nil
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event loop didn't stop after panic")
+	}
+
+	err = provider.Healthy()
+	if err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("expected panic to fail liveness, got: %v", err)
+	}
+}
+
+func TestProviderStopWaitsForInflightUpdate(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// simulate update that is being processed
+	if !p.begin() {
+		t.Fatalf("expected provider to accept events before stop")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatalf("expected Stop to wait for in-flight update")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := p.Submit(types.Event{}); err != provider.ErrStopping {
+		t.Errorf("expected new events to be rejected, got: %v", err)
+	}
+	if p.begin() {
+		t.Errorf("didn't expect queued events to be processed after stop")
+	}
+
+	p.inflight.Done()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Stop didn't return after in-flight update finished")
+	}
+
+	// stopping twice is a no-op
+	p.Stop()
+}
+
+func TestProcessEventTriggerFilter(t *testing.T) {
+	newDep := func(name, trigger string) *apps_v1.Deployment {
+		annotations := map[string]string{types.KeelPolicyLabel: "all"}
+		if trigger != "" {
+			annotations[types.KeelTriggerLabel] = trigger
+		}
+		return &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        name,
+				Namespace:   "xxxx",
+				Annotations: annotations,
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		triggerName string
+		want        []string
+	}{
+		{triggerName: "poll", want: []string{"both", "default", "poll"}},
+		{triggerName: "dockerhub", want: []string{"both", "default", "poll", "push"}},
+		// poll resources were always updated by webhooks as well
+		{triggerName: "native", want: []string{"both", "default", "poll", "push"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.triggerName, func(t *testing.T) {
+			grc := &k8s.GenericResourceCache{}
+			grc.Add(MustParseGRS([]*apps_v1.Deployment{
+				newDep("default", ""),
+				newDep("poll", "poll"),
+				newDep("push", "push"),
+				newDep("both", "both"),
+			})...)
+
+			approver, teardown := approver()
+			defer teardown()
+			p, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, grc)
+			if err != nil {
+				t.Fatalf("failed to get provider: %s", err)
+			}
+
+			updated, err := p.processEvent(&types.Event{
+				Repository:  types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+				TriggerName: tt.triggerName,
+			})
+			if err != nil {
+				t.Fatalf("failed to process event: %s", err)
+			}
+
+			var names []string
+			for _, r := range updated {
+				names = append(names, r.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("expected %v to be updated, got: %v", tt.want, names)
+			}
+		})
+	}
+}
+
+func TestProcessEventWebhookSecret(t *testing.T) {
+	newDep := func(name string, annotations map[string]string) *apps_v1.Deployment {
+		annotations[types.KeelPolicyLabel] = "all"
+		return &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        name,
+				Namespace:   "xxxx",
+				Annotations: annotations,
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		signed map[string]bool // secrets the request is signed with, "" for the global one
+		want   []string
+	}{
+		{name: "global", signed: map[string]bool{"": true}, want: []string{"global"}},
+		{name: "team a", signed: map[string]bool{"team-a": true}, want: []string{"team-a"}},
+		{name: "team b ref", signed: map[string]bool{"team-b": true}, want: []string{"team-b"}},
+		{name: "unsigned", signed: map[string]bool{}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grc := &k8s.GenericResourceCache{}
+			grc.Add(MustParseGRS([]*apps_v1.Deployment{
+				newDep("global", map[string]string{}),
+				newDep("team-a", map[string]string{types.KeelWebhookSecretAnnotation: "team-a"}),
+				newDep("team-b", map[string]string{types.KeelWebhookSecretRefAnnotation: "webhook/secret"}),
+				newDep("broken-ref", map[string]string{types.KeelWebhookSecretRefAnnotation: "webhook"}),
+			})...)
+
+			approver, teardown := approver()
+			defer teardown()
+			fi := &fakeImplementer{
+				availableSecret: &v1.Secret{Data: map[string][]byte{"secret": []byte("team-b")}},
+			}
+			p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+			if err != nil {
+				t.Fatalf("failed to get provider: %s", err)
+			}
+
+			updated, err := p.processEvent(&types.Event{
+				Repository:  types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+				TriggerName: "native",
+				Authorize:   func(secret string) bool { return tt.signed[secret] },
+			})
+			if err != nil {
+				t.Fatalf("failed to process event: %s", err)
+			}
+
+			var names []string
+			for _, r := range updated {
+				names = append(names, r.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("expected %v to be updated, got: %v", tt.want, names)
+			}
+		})
+	}
+}
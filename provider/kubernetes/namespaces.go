@@ -0,0 +1,45 @@
+package kubernetes
+
+import (
+	"strings"
+)
+
+// NamespaceFilter - limits namespaces that provider acts on. Denied namespaces
+// take precedence over allowed ones, empty allow list allows all namespaces.
+type NamespaceFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewNamespaceFilter - creates filter from comma separated namespace lists
+func NewNamespaceFilter(allowlist, denylist string) *NamespaceFilter {
+	return &NamespaceFilter{
+		allow: parseNamespaces(allowlist),
+		deny:  parseNamespaces(denylist),
+	}
+}
+
+// Allowed - checks whether resources in the namespace should be processed
+func (f *NamespaceFilter) Allowed(namespace string) bool {
+	if f == nil {
+		return true
+	}
+	if f.deny[namespace] {
+		return false
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	return f.allow[namespace]
+}
+
+func parseNamespaces(list string) map[string]bool {
+	namespaces := make(map[string]bool)
+	for _, ns := range strings.Split(list, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			namespaces[ns] = true
+		}
+	}
+	return namespaces
+}
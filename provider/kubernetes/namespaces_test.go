@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist string
+		denylist  string
+		namespace string
+		want      bool
+	}{
+		{"no lists", "", "", "default", true},
+		{"allowed", "default, staging", "", "staging", true},
+		{"not in allowlist", "default,staging", "", "production", false},
+		{"denied", "", "kube-system", "kube-system", false},
+		{"not in denylist", "", "kube-system", "default", true},
+		{"deny takes precedence", "default,staging", "staging", "staging", false},
+		{"allowed with denylist", "default,staging", "staging", "default", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewNamespaceFilter(tt.allowlist, tt.denylist)
+			if got := f.Allowed(tt.namespace); got != tt.want {
+				t.Errorf("Allowed(%s) = %v, want %v", tt.namespace, got, tt.want)
+			}
+		})
+	}
+
+	var f *NamespaceFilter
+	if !f.Allowed("default") {
+		t.Errorf("nil filter should allow all namespaces")
+	}
+}
+
+func TestProviderNamespaceFilter(t *testing.T) {
+	newDep := func(namespace string) *apps_v1.Deployment {
+		return &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "dep-1",
+				Namespace: namespace,
+				Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.1.1",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		newDep("default"),
+		newDep("staging"),
+		newDep("kube-system"),
+	})...)
+
+	approver, teardown := approver()
+	defer teardown()
+	provider, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	provider.SetNamespaceFilter(NewNamespaceFilter("default,staging,kube-system", "kube-system"))
+
+	imgs, err := provider.TrackedImages()
+	if err != nil {
+		t.Fatalf("failed to get tracked images: %s", err)
+	}
+	tracked := []string{}
+	for _, img := range imgs {
+		tracked = append(tracked, img.Namespace)
+	}
+	sort.Strings(tracked)
+	if len(tracked) != 2 || tracked[0] != "default" || tracked[1] != "staging" {
+		t.Errorf("unexpected tracked namespaces: %v", tracked)
+	}
+
+	provider.SetNamespaceFilter(NewNamespaceFilter("staging", ""))
+
+	plans, err := provider.createUpdatePlans(&types.Repository{
+		Name: "gcr.io/v2-namespace/hello-world",
+		Tag:  "1.1.2",
+	})
+	if err != nil {
+		t.Fatalf("failed to get update plans: %s", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("expected to find 1 update plan but found %d", len(plans))
+	}
+	if plans[0].Resource.Namespace != "staging" {
+		t.Errorf("expected staging deployment to be updated, got: %s", plans[0].Resource.Namespace)
+	}
+}
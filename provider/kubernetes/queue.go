@@ -0,0 +1,187 @@
+package kubernetes
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// resourceQueueSize - how many updates can wait for the same resource before
+// the event loop blocks, which in turn blocks Submit
+const resourceQueueSize = 16
+
+// DefaultUpdateConcurrency - how many resources are updated in parallel,
+// updates of other resources wait in their queues
+const DefaultUpdateConcurrency = 10
+
+// updateJob - approved or pending update of a single resource
+type updateJob struct {
+	event *types.Event
+	plan  *UpdatePlan
+}
+
+// resourceQueue - updates of a single resource, applied in order by one worker.
+// jobs is closed once nothing is pending, which ends the worker
+type resourceQueue struct {
+	jobs    chan *updateJob
+	pending int // jobs sent or about to be sent, guarded by Provider.queuesMu
+}
+
+// dispatch - queues update plans of the event per resource so updates of the
+// same resource are serialized while different resources are updated in
+// parallel. Blocks while the resource queue is full so triggers are slowed
+// down instead of events being dropped.
+func (p *Provider) dispatch(event *types.Event) {
+	defer event.Results.Done()
+	for _, plan := range p.eventPlans(event) {
+		event.Results.Add(1)
+		if !p.enqueue(plan.Resource.Identifier, &updateJob{event: event, plan: plan}) {
+			event.Results.Done()
+			return
+		}
+	}
+}
+
+// enqueue - adds job to the resource queue, starting its worker if needed,
+// returns false if provider was stopped while waiting for space in the queue
+func (p *Provider) enqueue(key string, job *updateJob) bool {
+	p.queuesMu.Lock()
+	q, ok := p.queues[key]
+	if !ok {
+		q = &resourceQueue{jobs: make(chan *updateJob, resourceQueueSize)}
+		p.queues[key] = q
+		go p.runQueue(key, q)
+	}
+	q.pending++
+	p.queuesMu.Unlock()
+
+	// job is pending until applied, so draining also waits for updates
+	// dispatched by the event
+	p.pending.Add(1)
+	select {
+	case q.jobs <- job:
+		return true
+	case <-p.stop:
+		p.pending.Done()
+		p.jobFinished(key, q)
+		return false
+	}
+}
+
+// jobFinished - the job was applied, discarded or never sent. Once nothing is
+// pending the queue is removed and closed, no one else can send to it then
+// as senders register under the same lock
+func (p *Provider) jobFinished(key string, q *resourceQueue) {
+	p.queuesMu.Lock()
+	defer p.queuesMu.Unlock()
+	q.pending--
+	if q.pending == 0 {
+		if p.queues[key] == q {
+			delete(p.queues, key)
+		}
+		close(q.jobs)
+	}
+}
+
+// runQueue - applies queued updates of the resource one by one, worker exits
+// once there is nothing left to do. Once provider is stopping remaining jobs
+// are discarded so Drain, Stop and triggers waiting for results return
+func (p *Provider) runQueue(key string, q *resourceQueue) {
+	defer p.recoverPanic("resource worker")
+
+	for job := range q.jobs {
+		p.runJob(job)
+		p.jobFinished(key, q)
+	}
+}
+
+// runJob - applies the job once an update slot is free
+func (p *Provider) runJob(job *updateJob) {
+	if !p.acquireUpdateSlot() {
+		p.discardJob(job)
+		return
+	}
+	defer p.releaseUpdateSlot()
+	if !p.begin() {
+		p.discardJob(job)
+		return
+	}
+	p.processJob(job)
+}
+
+// discardJob - marks job that won't be applied as done
+func (p *Provider) discardJob(job *updateJob) {
+	p.pending.Done()
+	job.event.Results.Done()
+}
+
+// acquireUpdateSlot - waits until fewer than the allowed number of updates
+// are in progress, returns false if provider was stopped while waiting
+func (p *Provider) acquireUpdateSlot() bool {
+	if p.updateSlots == nil {
+		return true
+	}
+	select {
+	case p.updateSlots <- struct{}{}:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+func (p *Provider) releaseUpdateSlot() {
+	if p.updateSlots != nil {
+		<-p.updateSlots
+	}
+}
+
+// processJob - applies single update, marking it as done so Stop can return.
+// The plan is rebuilt from the current cache first, earlier jobs may have
+// updated the resource. A panic only fails this update, the worker keeps
+// serving the queue
+func (p *Provider) processJob(job *updateJob) {
+	defer p.inflight.Done()
+	defer p.pending.Done()
+	defer job.event.Results.Done()
+	defer p.recoverPanic("resource update")
+
+	plan := p.refreshPlan(job.event, job.plan)
+	if plan == nil {
+		log.WithFields(log.Fields{
+			"image":     job.event.Repository.Name,
+			"tag":       job.event.Repository.Tag,
+			"namespace": job.plan.Resource.Namespace,
+			"name":      job.plan.Resource.Name,
+		}).Debug("provider.kubernetes: resource no longer needs the update, skipping")
+		p.report(job.event, job.plan, types.UpdateStatusNoop, "resource no longer needs the update")
+		return
+	}
+	job.plan = plan
+
+	_, err := p.applyPlans(job.event, []*UpdatePlan{job.plan})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"image":     job.event.Repository.Name,
+			"tag":       job.event.Repository.Tag,
+			"namespace": job.plan.Resource.Namespace,
+			"name":      job.plan.Resource.Name,
+		}).Error("provider.kubernetes: failed to update resource")
+	}
+}
+
+// recoverPanic - marks provider as failed so liveness check restarts Keel
+func (p *Provider) recoverPanic(component string) {
+	if r := recover(); r != nil {
+		log.WithFields(log.Fields{
+			"error": r,
+			"stack": string(debug.Stack()),
+		}).Errorf("provider.kubernetes: %s panicked", component)
+		p.mu.Lock()
+		p.failure = fmt.Errorf("%s panicked: %v", component, r)
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,604 @@
+package kubernetes
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// blockingImplementer - records updated images, updates of blocked resources
+// wait until they are released
+type blockingImplementer struct {
+	*fakeImplementer
+
+	mu      sync.Mutex
+	images  map[string][]string
+	blocked map[string]chan struct{}
+	updates chan string
+}
+
+func (i *blockingImplementer) Update(obj *k8s.GenericResource) error {
+	i.mu.Lock()
+	wait := i.blocked[obj.Name]
+	i.mu.Unlock()
+	if wait != nil {
+		<-wait
+	}
+
+	i.mu.Lock()
+	i.images[obj.Name] = append(i.images[obj.Name], obj.Containers()[0].Image)
+	i.mu.Unlock()
+	i.updates <- obj.Name
+	return nil
+}
+
+func queueTestDeployment(name, image string) *apps_v1.Deployment {
+	return &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Namespace:   "xxxx",
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func TestProviderQueueSerializesResourceUpdates(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		queueTestDeployment("first", "gcr.io/v2-namespace/hello-world:1.1.1"),
+		queueTestDeployment("second", "gcr.io/v2-namespace/other:1.1.1"),
+	})...)
+
+	release := make(chan struct{})
+	fi := &blockingImplementer{
+		fakeImplementer: &fakeImplementer{},
+		images:          make(map[string][]string),
+		blocked:         map[string]chan struct{}{"first": release},
+		updates:         make(chan string, 10),
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	go p.Start()
+	defer p.Stop()
+
+	for _, event := range []types.Event{
+		{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"}},
+		{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.3"}},
+		{Repository: types.Repository{Name: "gcr.io/v2-namespace/other", Tag: "1.1.2"}},
+	} {
+		if err := p.Submit(event); err != nil {
+			t.Fatalf("failed to submit event: %s", err)
+		}
+	}
+
+	// second resource is updated while the first one is still blocked
+	select {
+	case name := <-fi.updates:
+		if name != "second" {
+			t.Fatalf("expected second resource to be updated first, got: %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("second resource wasn't updated while first was blocked")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-fi.updates:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("first resource wasn't updated")
+		}
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	want := []string{"gcr.io/v2-namespace/hello-world:1.1.2", "gcr.io/v2-namespace/hello-world:1.1.3"}
+	got := fi.images["first"]
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("unexpected update order: %v", got)
+	}
+}
+
+func TestProviderQueueBackpressure(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		queueTestDeployment("first", "gcr.io/v2-namespace/hello-world:1.1.1"),
+	})...)
+
+	release := make(chan struct{})
+	fi := &blockingImplementer{
+		fakeImplementer: &fakeImplementer{},
+		images:          make(map[string][]string),
+		blocked:         map[string]chan struct{}{"first": release},
+		updates:         make(chan string, 1000),
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	go p.Start()
+
+	// update in progress, full resource queue, event waiting in the event
+	// loop and full event buffer, the last one has nowhere to go
+	total := 1 + resourceQueueSize + 1 + cap(p.events) + 1
+	submitted := make(chan struct{})
+	go func() {
+		for i := 0; i < total; i++ {
+			p.Submit(types.Event{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"}})
+		}
+		close(submitted)
+	}()
+
+	select {
+	case <-submitted:
+		t.Fatalf("expected Submit to block while queues are full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-submitted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Submit didn't unblock once updates were applied")
+	}
+	p.Stop()
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if len(fi.images["first"]) == 0 {
+		t.Errorf("expected queued updates to be applied")
+	}
+}
+
+func TestProviderDrainWaitsForQueuedUpdates(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		queueTestDeployment("first", "gcr.io/v2-namespace/hello-world:1.1.1"),
+	})...)
+
+	release := make(chan struct{})
+	fi := &blockingImplementer{
+		fakeImplementer: &fakeImplementer{},
+		images:          make(map[string][]string),
+		blocked:         map[string]chan struct{}{"first": release},
+		updates:         make(chan string, 10),
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	go p.Start()
+	defer p.Stop()
+
+	for _, tag := range []string{"1.1.2", "1.1.3"} {
+		if err := p.Submit(types.Event{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: tag}}); err != nil {
+			t.Fatalf("failed to submit event: %s", err)
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		p.Drain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatalf("drain returned while updates were still queued")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("drain didn't return after updates were applied")
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if len(fi.images["first"]) != 2 {
+		t.Errorf("expected both updates to be applied before drain returned, got: %v", fi.images["first"])
+	}
+}
+
+// countingImplementer - records how many updates run at the same time
+type countingImplementer struct {
+	*fakeImplementer
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	updated   int
+}
+
+func (i *countingImplementer) Update(obj *k8s.GenericResource) error {
+	i.mu.Lock()
+	i.active++
+	if i.active > i.maxActive {
+		i.maxActive = i.active
+	}
+	i.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	i.mu.Lock()
+	i.active--
+	i.updated++
+	i.mu.Unlock()
+	return nil
+}
+
+func TestProviderUpdateConcurrency(t *testing.T) {
+	var deployments []*apps_v1.Deployment
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		deployments = append(deployments, queueTestDeployment(name, "gcr.io/v2-namespace/hello-world:1.1.1"))
+	}
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS(deployments)...)
+
+	fi := &countingImplementer{fakeImplementer: &fakeImplementer{}}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	p.SetUpdateConcurrency(2)
+	go p.Start()
+	defer p.Stop()
+
+	if err := p.Submit(types.Event{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"}}); err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+	p.Drain()
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.updated != len(deployments) {
+		t.Errorf("expected %d updates, got: %d", len(deployments), fi.updated)
+	}
+	if fi.maxActive > 2 {
+		t.Errorf("expected at most 2 updates at once, got: %d", fi.maxActive)
+	}
+}
+
+// panickingImplementer - first update panics, later ones are recorded
+type panickingImplementer struct {
+	*fakeImplementer
+
+	mu      sync.Mutex
+	calls   int
+	updated []string
+}
+
+func (i *panickingImplementer) Update(obj *k8s.GenericResource) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.calls++
+	if i.calls == 1 {
+		panic("update failed")
+	}
+	i.updated = append(i.updated, obj.Containers()[0].Image)
+	return nil
+}
+
+func TestProviderQueueSurvivesPanic(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		queueTestDeployment("first", "gcr.io/v2-namespace/hello-world:1.1.1"),
+	})...)
+
+	fi := &panickingImplementer{fakeImplementer: &fakeImplementer{}}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	go p.Start()
+	defer p.Stop()
+
+	for _, tag := range []string{"1.1.2", "1.1.3"} {
+		if err := p.Submit(types.Event{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: tag}}); err != nil {
+			t.Fatalf("failed to submit event: %s", err)
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		p.Drain()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("drain didn't return after update panicked")
+	}
+
+	if p.Healthy() == nil {
+		t.Errorf("expected panic to be reported by health check")
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if len(fi.updated) != 1 || fi.updated[0] != "gcr.io/v2-namespace/hello-world:1.1.3" {
+		t.Errorf("expected update after the panic to be applied, got: %v", fi.updated)
+	}
+
+	p.queuesMu.Lock()
+	defer p.queuesMu.Unlock()
+	if len(p.queues) != 0 {
+		t.Errorf("expected resource queue to be removed, got: %d", len(p.queues))
+	}
+}
+
+func TestProviderStopWhileWaitingForUpdateSlot(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		queueTestDeployment("a", "gcr.io/v2-namespace/hello-world:1.1.1"),
+		queueTestDeployment("b", "gcr.io/v2-namespace/hello-world:1.1.1"),
+		queueTestDeployment("c", "gcr.io/v2-namespace/hello-world:1.1.1"),
+	})...)
+
+	release := make(chan struct{})
+	blocked := make(map[string]chan struct{})
+	for _, name := range []string{"a", "b", "c"} {
+		blocked[name] = release
+	}
+	fi := &blockingImplementer{
+		fakeImplementer: &fakeImplementer{},
+		images:          make(map[string][]string),
+		blocked:         blocked,
+		updates:         make(chan string, 10),
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	p.SetUpdateConcurrency(1)
+	go p.Start()
+
+	// same as native webhook with ?wait=true
+	results := types.NewEventResults()
+	err = p.Submit(types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+		Results:    results,
+	})
+	if err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+
+	// one update holds the only slot, the others wait for it
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.queuesMu.Lock()
+		queued := len(p.queues)
+		p.queuesMu.Unlock()
+		if queued == 3 && len(p.updateSlots) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("updates weren't queued, queues: %d", queued)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stop didn't return")
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		p.Drain()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("drain didn't return after stop")
+	}
+
+	if !results.Wait(5 * time.Second) {
+		t.Fatalf("event results weren't completed after stop")
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	updated := len(fi.images["a"]) + len(fi.images["b"]) + len(fi.images["c"])
+	if updated != 1 {
+		t.Errorf("expected only the update holding the slot to be applied, got: %d", updated)
+	}
+
+	p.queuesMu.Lock()
+	defer p.queuesMu.Unlock()
+	if len(p.queues) != 0 {
+		t.Errorf("expected resource queues to be removed, got: %d", len(p.queues))
+	}
+}
+
+func TestProviderStopCompletesQueuedEvents(t *testing.T) {
+	grc := &k8s.GenericResourceCache{}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(&fakeImplementer{}, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	// event loop isn't running, event stays in the buffer
+	results := types.NewEventResults()
+	err = p.Submit(types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+		Results:    results,
+	})
+	if err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+
+	p.Stop()
+	if err := p.Start(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !results.Wait(5 * time.Second) {
+		t.Fatalf("discarded event results weren't completed")
+	}
+	p.Drain()
+}
+
+// versionedImplementer - rejects updates of a stale resource version the same
+// way the API server does, first update waits until it's released. The cache
+// isn't updated, as if the watcher lagged behind
+type versionedImplementer struct {
+	*fakeImplementer
+
+	started chan struct{}
+	release chan struct{}
+
+	mu       sync.Mutex
+	versions map[string]int
+	images   []string
+}
+
+func (i *versionedImplementer) Update(obj *k8s.GenericResource) error {
+	dep := obj.GetResource().(*apps_v1.Deployment)
+
+	i.mu.Lock()
+	first := len(i.images) == 0
+	i.mu.Unlock()
+	if first {
+		close(i.started)
+		<-i.release
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	current := strconv.Itoa(i.versions[obj.Identifier])
+	if dep.ResourceVersion != current {
+		return errors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, dep.Name, fmt.Errorf("stale resource version %s, current %s", dep.ResourceVersion, current))
+	}
+	i.versions[obj.Identifier]++
+	dep.ResourceVersion = strconv.Itoa(i.versions[obj.Identifier])
+	i.images = append(i.images, dep.Spec.Template.Spec.Containers[0].Image)
+	return nil
+}
+
+func TestProviderQueueRefreshesStalePlans(t *testing.T) {
+	dep := queueTestDeployment("first", "gcr.io/v2-namespace/hello-world:1.1.1")
+	dep.ResourceVersion = "0"
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	fi := &versionedImplementer{
+		fakeImplementer: &fakeImplementer{},
+		started:         make(chan struct{}),
+		release:         make(chan struct{}),
+		versions:        make(map[string]int),
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	go p.Start()
+	defer p.Stop()
+
+	if err := p.Submit(types.Event{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"}}); err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+	select {
+	case <-fi.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("first update didn't start")
+	}
+
+	// second event is dispatched while the first update is in progress,
+	// its plan is built from the resource version the first one replaces
+	results := types.NewEventResults()
+	err = p.Submit(types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.3"},
+		Results:    results,
+	})
+	if err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.queuesMu.Lock()
+		q := p.queues[MustParseGR(dep).Identifier]
+		pending := q != nil && q.pending == 2
+		p.queuesMu.Unlock()
+		if pending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("second update wasn't queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(fi.release)
+	if !results.Wait(5 * time.Second) {
+		t.Fatalf("second update didn't complete")
+	}
+
+	if status := results.Status(); status != types.UpdateStatusUpdated {
+		t.Errorf("expected second update to be applied, got: %s %+v", status, results.Resources())
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	want := []string{"gcr.io/v2-namespace/hello-world:1.1.2", "gcr.io/v2-namespace/hello-world:1.1.3"}
+	if !reflect.DeepEqual(fi.images, want) {
+		t.Errorf("expected images %v, got %v", want, fi.images)
+	}
+}
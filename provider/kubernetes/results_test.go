@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+)
+
+func TestProviderReportsEventResults(t *testing.T) {
+	needsApproval := queueTestDeployment("approved", "gcr.io/v2-namespace/hello-world:1.1.1")
+	needsApproval.Annotations[types.KeelMinimumApprovalsLabel] = "1"
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{
+		queueTestDeployment("updated", "gcr.io/v2-namespace/hello-world:1.1.1"),
+		needsApproval,
+		queueTestDeployment("other", "gcr.io/v2-namespace/other:1.1.1"),
+	})...)
+
+	fi := &blockingImplementer{
+		fakeImplementer: &fakeImplementer{},
+		images:          make(map[string][]string),
+		updates:         make(chan string, 10),
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	p, err := NewProvider(fi, &fakeSender{}, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	go p.Start()
+	defer p.Stop()
+
+	results := types.NewEventResults()
+	err = p.Submit(types.Event{
+		Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"},
+		Results:    results,
+	})
+	if err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+	if !results.Wait(5 * time.Second) {
+		t.Fatalf("event wasn't processed in time")
+	}
+
+	resources := results.Resources()
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+	if len(resources) != 2 {
+		t.Fatalf("expected 2 resources, got: %+v", resources)
+	}
+	if resources[0].Name != "approved" || resources[0].Status != types.UpdateStatusPending {
+		t.Errorf("expected approved to be pending, got: %+v", resources[0])
+	}
+	if resources[1].Name != "updated" || resources[1].Status != types.UpdateStatusUpdated || resources[1].NewVersion != "1.1.2" {
+		t.Errorf("expected updated to be updated, got: %+v", resources[1])
+	}
+	if results.Status() != types.UpdateStatusUpdated {
+		t.Errorf("expected overall status updated, got: %s", results.Status())
+	}
+}
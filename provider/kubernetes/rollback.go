@@ -0,0 +1,88 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// revisionAnnotation - set by the deployment controller on deployments and
+// their replicasets, replicasets with older revisions form rollout history
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// Rollback - rolls deployment back to its previous revision by restoring pod
+// template from the replicaset of that revision, same as "kubectl rollout undo".
+// Returns the revision deployment was rolled back to.
+func Rollback(implementer Implementer, deployment *apps_v1.Deployment) (int64, error) {
+	current, err := revision(deployment.GetAnnotations())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current revision of %s/%s: %s", deployment.Namespace, deployment.Name, err)
+	}
+
+	selector, err := meta_v1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return 0, fmt.Errorf("invalid selector: %s", err)
+	}
+
+	replicaSets, err := implementer.ReplicaSets(deployment.Namespace, selector.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rollout history: %s", err)
+	}
+
+	var previous *apps_v1.ReplicaSet
+	var previousRevision int64
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !meta_v1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		rev, err := revision(rs.GetAnnotations())
+		if err != nil || rev >= current {
+			continue
+		}
+		if rev > previousRevision {
+			previous, previousRevision = rs, rev
+		}
+	}
+	if previous == nil {
+		return 0, fmt.Errorf("no previous revision found for %s/%s", deployment.Namespace, deployment.Name)
+	}
+
+	updated := deployment.DeepCopy()
+	updated.Spec.Template = *previous.Spec.Template.DeepCopy()
+	// pod template hash is added by the controller to replicaset pods only
+	delete(updated.Spec.Template.Labels, apps_v1.DefaultDeploymentUniqueLabelKey)
+
+	resource, err := k8s.NewGenericResource(updated)
+	if err != nil {
+		return 0, err
+	}
+
+	annotations := resource.GetAnnotations()
+	annotations["kubernetes.io/change-cause"] = fmt.Sprintf("keel rollback to revision %d [%s]", previousRevision, time.Now().Format(time.RFC3339))
+	resource.SetAnnotations(annotations)
+
+	if err := implementer.Update(resource); err != nil {
+		return 0, err
+	}
+
+	log.WithFields(log.Fields{
+		"namespace":  deployment.Namespace,
+		"deployment": deployment.Name,
+		"from":       current,
+		"to":         previousRevision,
+	}).Info("provider.kubernetes: deployment rolled back")
+
+	return previousRevision, nil
+}
+
+func revision(annotations map[string]string) (int64, error) {
+	return strconv.ParseInt(annotations[revisionAnnotation], 10, 64)
+}
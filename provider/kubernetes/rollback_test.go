@@ -0,0 +1,114 @@
+package kubernetes
+
+import (
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newRolloutReplicaSet(owner *apps_v1.Deployment, revision, image string) apps_v1.ReplicaSet {
+	controller := true
+	return apps_v1.ReplicaSet{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        owner.Name + "-" + revision,
+			Namespace:   owner.Namespace,
+			Annotations: map[string]string{revisionAnnotation: revision},
+			OwnerReferences: []meta_v1.OwnerReference{
+				{Kind: "Deployment", Name: owner.Name, UID: owner.UID, Controller: &controller},
+			},
+		},
+		Spec: apps_v1.ReplicaSetSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Labels: map[string]string{"app": "wd", apps_v1.DefaultDeploymentUniqueLabelKey: "hash-" + revision},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "wd", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func TestRollback(t *testing.T) {
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "dep-1",
+			Namespace:   "xxxx",
+			UID:         "dep-1-uid",
+			Annotations: map[string]string{revisionAnnotation: "3"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "wd"}},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"app": "wd"}},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "wd", Image: "gcr.io/v2-namespace/hello-world:1.1.2"}},
+				},
+			},
+		},
+	}
+
+	other := newRolloutReplicaSet(dep, "2", "gcr.io/v2-namespace/other:9.9.9")
+	other.OwnerReferences[0].UID = "other-uid"
+
+	fi := &fakeImplementer{
+		replicaSetList: &apps_v1.ReplicaSetList{
+			Items: []apps_v1.ReplicaSet{
+				newRolloutReplicaSet(dep, "1", "gcr.io/v2-namespace/hello-world:1.1.0"),
+				newRolloutReplicaSet(dep, "2", "gcr.io/v2-namespace/hello-world:1.1.1"),
+				newRolloutReplicaSet(dep, "3", "gcr.io/v2-namespace/hello-world:1.1.2"),
+				other,
+			},
+		},
+	}
+
+	rev, err := Rollback(fi, dep)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rev != 2 {
+		t.Errorf("expected rollback to revision 2, got: %d", rev)
+	}
+
+	if fi.updated == nil {
+		t.Fatalf("deployment not updated")
+	}
+	if img := fi.updated.Containers()[0].Image; img != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("unexpected image: %s", img)
+	}
+	if _, ok := fi.updated.GetResource().(*apps_v1.Deployment).Spec.Template.Labels[apps_v1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Errorf("pod template hash label should be removed")
+	}
+	if dep.Spec.Template.Spec.Containers[0].Image != "gcr.io/v2-namespace/hello-world:1.1.2" {
+		t.Errorf("original deployment should not be modified")
+	}
+}
+
+func TestRollbackNoHistory(t *testing.T) {
+	dep := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "dep-1",
+			Namespace:   "xxxx",
+			Annotations: map[string]string{revisionAnnotation: "1"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "wd"}},
+		},
+	}
+
+	fi := &fakeImplementer{
+		replicaSetList: &apps_v1.ReplicaSetList{
+			Items: []apps_v1.ReplicaSet{newRolloutReplicaSet(dep, "1", "gcr.io/v2-namespace/hello-world:1.1.0")},
+		},
+	}
+
+	if _, err := Rollback(fi, dep); err == nil {
+		t.Errorf("expected error without previous revision")
+	}
+	if fi.updated != nil {
+		t.Errorf("deployment should not be updated")
+	}
+}
@@ -0,0 +1,283 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/policies"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultRolloutTimeout - how long rollout is watched when resource doesn't
+// set keel.sh/rolloutTimeout
+const DefaultRolloutTimeout = 5 * time.Minute
+
+// rolloutCheckInterval - how often rollout status is checked
+var rolloutCheckInterval = 5 * time.Second
+
+// errRolloutAborted - returned when provider is stopped while waiting
+var errRolloutAborted = fmt.Errorf("provider stopped while waiting for rollout")
+
+// rolloutTimeout - gets rollout timeout from resource annotations, def is used
+// when the annotation is missing or invalid
+func rolloutTimeout(annotations map[string]string, def time.Duration) time.Duration {
+	if def <= 0 {
+		def = DefaultRolloutTimeout
+	}
+	value, ok := annotations[types.KeelRolloutTimeoutAnnotation]
+	if !ok {
+		return def
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		log.WithFields(log.Fields{
+			"error": err,
+			"value": value,
+		}).Warnf("provider.kubernetes: invalid rollout timeout, using default %s", def)
+		return def
+	}
+	return timeout
+}
+
+// rolloutStatus - checks deployment rollout the same way "kubectl rollout status"
+// does, returns done once all replicas run the new template and are available.
+// Failure reason is set when deployment controller gave up on the rollout.
+func rolloutStatus(deployment *apps_v1.Deployment) (done bool, failure string) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, ""
+	}
+
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == apps_v1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return false, c.Message
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return false, ""
+	case status.Replicas > status.UpdatedReplicas:
+		// old replicas are still being terminated
+		return false, ""
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return false, ""
+	}
+	return true, ""
+}
+
+// waitForRollout - waits until deployment rollout completes, returns an error
+// with the failure reason when it fails or doesn't complete within timeout
+func (p *Provider) waitForRollout(namespace, name string, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(rolloutCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return errRolloutAborted
+		case <-deadline.C:
+			return fmt.Errorf("rollout didn't complete within %s%s", timeout, p.podFailureReason(namespace, name))
+		case <-ticker.C:
+		}
+
+		deployment, err := p.implementer.Deployment(namespace, name)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":      err,
+				"namespace":  namespace,
+				"deployment": name,
+			}).Warn("provider.kubernetes: failed to get deployment while waiting for rollout")
+			continue
+		}
+
+		done, failure := rolloutStatus(deployment)
+		if done {
+			return nil
+		}
+		if failure != "" {
+			return fmt.Errorf("rollout failed: %s%s", failure, p.podFailureReason(namespace, name))
+		}
+	}
+}
+
+// podFailureReason - describes why pods of the deployment are not ready, for
+// example crash looping containers or image pull errors
+func (p *Provider) podFailureReason(namespace, name string) string {
+	deployment, err := p.implementer.Deployment(namespace, name)
+	if err != nil {
+		return ""
+	}
+	selector, err := meta_v1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return ""
+	}
+	pods, err := p.implementer.Pods(namespace, selector.String())
+	if err != nil {
+		return ""
+	}
+
+	seen := make(map[string]bool)
+	var reasons []string
+	for _, pod := range pods.Items {
+		for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			reason := containerFailureReason(cs)
+			if reason == "" || seen[reason] {
+				continue
+			}
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(reasons, ", ") + ")"
+}
+
+func containerFailureReason(cs v1.ContainerStatus) string {
+	if cs.Ready {
+		return ""
+	}
+	if w := cs.State.Waiting; w != nil && w.Reason != "" && w.Reason != "ContainerCreating" && w.Reason != "PodInitializing" {
+		if w.Message != "" {
+			return fmt.Sprintf("container %s: %s: %s", cs.Name, w.Reason, w.Message)
+		}
+		return fmt.Sprintf("container %s: %s", cs.Name, w.Reason)
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil && t.Reason != "" {
+		return fmt.Sprintf("container %s: last terminated with %s (exit code %d)", cs.Name, t.Reason, t.ExitCode)
+	}
+	return ""
+}
+
+// startRolloutWatch - watches the rollout in the background, tracked so Drain
+// waits for it
+func (p *Provider) startRolloutWatch(resource *k8s.GenericResource, plan *UpdatePlan, channels []string, success *types.EventNotification) {
+	p.rollouts.Add(1)
+	go func() {
+		defer p.rollouts.Done()
+		p.watchRollout(resource, plan, channels, success)
+	}()
+}
+
+// watchRollout - waits for rollout of the updated deployment. Failed rollouts are
+// rolled back when the deployment opts in, failed version is not applied again.
+// When success is set it's only sent once the rollout completes, otherwise a
+// failure notification is sent instead.
+func (p *Provider) watchRollout(resource *k8s.GenericResource, plan *UpdatePlan, channels []string, success *types.EventNotification) {
+	timeout := rolloutTimeout(resource.GetAnnotations(), p.rolloutTimeout)
+	err := p.waitForRollout(resource.Namespace, resource.Name, timeout)
+	if err == errRolloutAborted {
+		return
+	}
+	if err == nil {
+		if success != nil {
+			p.sendUpdateNotification(resource, plan, *success)
+		}
+		return
+	}
+
+	if !policies.ShouldRollbackOnFailure(resource.GetAnnotations()) {
+		log.WithFields(log.Fields{
+			"error":     err,
+			"namespace": resource.Namespace,
+			"name":      resource.Name,
+			"version":   plan.NewVersion,
+		}).Warn("provider.kubernetes: rollout failed")
+
+		p.sender.Send(types.EventNotification{
+			ResourceKind: resource.Kind(),
+			Identifier:   resource.Identifier,
+			Name:         types.EventNameUpdateResource,
+			Message:      fmt.Sprintf("%s %s/%s update %s->%s failed: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err),
+			CreatedAt:    time.Now(),
+			Type:         types.NotificationDeploymentUpdate,
+			Level:        types.LevelError,
+			Channels:     channels,
+			Metadata:     updateMetadata(p.GetName(), plan),
+		})
+		return
+	}
+
+	p.setFailedRollout(resource.Identifier, plan.NewVersion)
+
+	log.WithFields(log.Fields{
+		"error":     err,
+		"namespace": resource.Namespace,
+		"name":      resource.Name,
+		"version":   plan.NewVersion,
+	}).Warn("provider.kubernetes: rollout failed, rolling back")
+
+	metadata := updateMetadata(p.GetName(), plan)
+	metadata["rollback"] = "true"
+
+	var msg string
+	deployment, getErr := p.implementer.Deployment(resource.Namespace, resource.Name)
+	if getErr != nil {
+		msg = fmt.Sprintf("%s %s/%s update %s->%s failed: %s. Rollback failed, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err, getErr)
+	} else if revision, rbErr := Rollback(p.implementer, deployment); rbErr != nil {
+		msg = fmt.Sprintf("%s %s/%s update %s->%s failed: %s. Rollback failed, error: %s", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err, rbErr)
+	} else {
+		msg = fmt.Sprintf("%s %s/%s update %s->%s failed: %s. Rolled back to revision %d", resource.Kind(), resource.Namespace, resource.Name, plan.CurrentVersion, plan.NewVersion, err, revision)
+	}
+
+	p.sender.Send(types.EventNotification{
+		ResourceKind: resource.Kind(),
+		Identifier:   resource.Identifier,
+		Name:         "rollback resource",
+		Message:      msg,
+		CreatedAt:    time.Now(),
+		Type:         types.NotificationDeploymentUpdate,
+		Level:        types.LevelError,
+		Channels:     channels,
+		Metadata:     metadata,
+	})
+}
+
+// shouldWatchRollout - rollback is only supported for deployments
+func shouldWatchRollout(resource *k8s.GenericResource) bool {
+	if !isDeployment(resource) {
+		return false
+	}
+	return policies.ShouldRollbackOnFailure(resource.GetAnnotations())
+}
+
+// shouldWaitForRollout - success notification is sent after rollout completes,
+// only deployments report rollout status
+func (p *Provider) shouldWaitForRollout(resource *k8s.GenericResource) bool {
+	return p.waitForRollouts && isDeployment(resource)
+}
+
+func isDeployment(resource *k8s.GenericResource) bool {
+	_, ok := resource.GetResource().(*apps_v1.Deployment)
+	return ok
+}
+
+func (p *Provider) setFailedRollout(identifier, version string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failedRollouts[identifier] = version
+}
+
+// isFailedRollout - checks whether version was already rolled back for the resource
+func (p *Provider) isFailedRollout(identifier, version string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failedRollouts[identifier] == version
+}
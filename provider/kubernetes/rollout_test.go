@@ -0,0 +1,383 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRolloutStatus(t *testing.T) {
+	replicas := int32(2)
+
+	tests := []struct {
+		name        string
+		status      apps_v1.DeploymentStatus
+		generation  int64
+		wantDone    bool
+		wantFailure string
+	}{
+		{
+			name:     "complete",
+			status:   apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			wantDone: true,
+		},
+		{
+			name:       "not observed yet",
+			generation: 3,
+			status:     apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:   "updating replicas",
+			status: apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2},
+		},
+		{
+			name:   "old replicas terminating",
+			status: apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:   "new replicas not available",
+			status: apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+		},
+		{
+			name: "progress deadline exceeded",
+			status: apps_v1.DeploymentStatus{
+				ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2,
+				Conditions: []apps_v1.DeploymentCondition{
+					{Type: apps_v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded", Message: `ReplicaSet "wd-2" has timed out progressing.`},
+				},
+			},
+			wantFailure: `ReplicaSet "wd-2" has timed out progressing.`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generation := tt.generation
+			if generation == 0 {
+				generation = 2
+			}
+			dep := &apps_v1.Deployment{
+				ObjectMeta: meta_v1.ObjectMeta{Generation: generation},
+				Spec:       apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status:     tt.status,
+			}
+			done, failure := rolloutStatus(dep)
+			if done != tt.wantDone || failure != tt.wantFailure {
+				t.Errorf("rolloutStatus() = %v, %q, want %v, %q", done, failure, tt.wantDone, tt.wantFailure)
+			}
+		})
+	}
+}
+
+func TestRolloutTimeout(t *testing.T) {
+	if d := rolloutTimeout(map[string]string{}, 0); d != DefaultRolloutTimeout {
+		t.Errorf("expected default timeout, got: %s", d)
+	}
+	if d := rolloutTimeout(map[string]string{types.KeelRolloutTimeoutAnnotation: "90s"}, 0); d != 90*time.Second {
+		t.Errorf("unexpected timeout: %s", d)
+	}
+	if d := rolloutTimeout(map[string]string{types.KeelRolloutTimeoutAnnotation: "soon"}, 0); d != DefaultRolloutTimeout {
+		t.Errorf("expected default timeout for invalid value, got: %s", d)
+	}
+	if d := rolloutTimeout(map[string]string{}, time.Minute); d != time.Minute {
+		t.Errorf("expected configured default timeout, got: %s", d)
+	}
+}
+
+func TestWatchRolloutRollsBackFailedUpdate(t *testing.T) {
+	defer func(interval time.Duration) { rolloutCheckInterval = interval }(rolloutCheckInterval)
+	rolloutCheckInterval = time.Millisecond
+
+	replicas := int32(1)
+	dep := &apps_v1.Deployment{
+		TypeMeta: meta_v1.TypeMeta{Kind: "Deployment"},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "wd",
+			Namespace: "xxxx",
+			UID:       "wd-uid",
+			Annotations: map[string]string{
+				revisionAnnotation:                    "2",
+				types.KeelRollbackOnFailureAnnotation: "true",
+				types.KeelRolloutTimeoutAnnotation:    "1s",
+			},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "wd"}},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"app": "wd"}},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "wd", Image: "gcr.io/v2-namespace/hello-world:1.1.2"}},
+				},
+			},
+		},
+		Status: apps_v1.DeploymentStatus{
+			Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1,
+			Conditions: []apps_v1.DeploymentCondition{
+				{Type: apps_v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded", Message: `ReplicaSet "wd-2" has timed out progressing.`},
+			},
+		},
+	}
+
+	fi := &fakeImplementer{
+		deployment: dep,
+		replicaSetList: &apps_v1.ReplicaSetList{
+			Items: []apps_v1.ReplicaSet{
+				newRolloutReplicaSet(dep, "1", "gcr.io/v2-namespace/hello-world:1.1.1"),
+				newRolloutReplicaSet(dep, "2", "gcr.io/v2-namespace/hello-world:1.1.2"),
+			},
+		},
+		podList: &v1.PodList{
+			Items: []v1.Pod{
+				{
+					Status: v1.PodStatus{
+						ContainerStatuses: []v1.ContainerStatus{
+							{
+								Name:  "wd",
+								State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s restarting failed container"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resource, err := k8s.NewGenericResource(dep.DeepCopy())
+	if err != nil {
+		t.Fatalf("failed to create resource: %s", err)
+	}
+	if !shouldWatchRollout(resource) {
+		t.Fatalf("expected rollout to be watched")
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	fs := &fakeSender{}
+	p, err := NewProvider(fi, fs, approver, &k8s.GenericResourceCache{})
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	plan := &UpdatePlan{Resource: resource, CurrentVersion: "1.1.1", NewVersion: "1.1.2"}
+	p.watchRollout(resource, plan, []string{"deployments"}, nil)
+
+	if fi.updated == nil {
+		t.Fatalf("expected deployment to be rolled back")
+	}
+	if img := fi.updated.Containers()[0].Image; img != "gcr.io/v2-namespace/hello-world:1.1.1" {
+		t.Errorf("unexpected image after rollback: %s", img)
+	}
+
+	if fs.sentEvent.Level != types.LevelError {
+		t.Errorf("unexpected notification level: %s", fs.sentEvent.Level)
+	}
+	for _, want := range []string{"timed out progressing", "CrashLoopBackOff", "Rolled back to revision 1"} {
+		if !strings.Contains(fs.sentEvent.Message, want) {
+			t.Errorf("expected notification message to contain %q, got: %s", want, fs.sentEvent.Message)
+		}
+	}
+	if fs.sentEvent.Metadata["rollback"] != "true" {
+		t.Errorf("expected rollback metadata, got: %v", fs.sentEvent.Metadata)
+	}
+	if len(fs.sentEvent.Channels) != 1 || fs.sentEvent.Channels[0] != "deployments" {
+		t.Errorf("unexpected channels: %v", fs.sentEvent.Channels)
+	}
+
+	if !p.isFailedRollout(resource.Identifier, "1.1.2") {
+		t.Errorf("expected failed version to be remembered")
+	}
+}
+
+func TestWatchRolloutSuccess(t *testing.T) {
+	defer func(interval time.Duration) { rolloutCheckInterval = interval }(rolloutCheckInterval)
+	rolloutCheckInterval = time.Millisecond
+
+	replicas := int32(1)
+	dep := &apps_v1.Deployment{
+		TypeMeta: meta_v1.TypeMeta{Kind: "Deployment"},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "wd",
+			Namespace:   "xxxx",
+			Annotations: map[string]string{types.KeelRollbackOnFailureAnnotation: "true"},
+		},
+		Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+		Status: apps_v1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	fi := &fakeImplementer{deployment: dep}
+
+	resource, err := k8s.NewGenericResource(dep.DeepCopy())
+	if err != nil {
+		t.Fatalf("failed to create resource: %s", err)
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	fs := &fakeSender{}
+	p, err := NewProvider(fi, fs, approver, &k8s.GenericResourceCache{})
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+
+	p.watchRollout(resource, &UpdatePlan{Resource: resource, CurrentVersion: "1.1.1", NewVersion: "1.1.2"}, nil, nil)
+
+	if fi.updated != nil {
+		t.Errorf("didn't expect deployment to be rolled back")
+	}
+	if fs.sentEvent.Message != "" {
+		t.Errorf("didn't expect notification, got: %s", fs.sentEvent.Message)
+	}
+	if p.isFailedRollout(resource.Identifier, "1.1.2") {
+		t.Errorf("didn't expect version to be marked as failed")
+	}
+}
+
+func TestWatchRolloutWaitsBeforeSuccessNotification(t *testing.T) {
+	defer func(interval time.Duration) { rolloutCheckInterval = interval }(rolloutCheckInterval)
+	rolloutCheckInterval = time.Millisecond
+
+	replicas := int32(1)
+	dep := &apps_v1.Deployment{
+		TypeMeta:   meta_v1.TypeMeta{Kind: "Deployment"},
+		ObjectMeta: meta_v1.ObjectMeta{Name: "wd", Namespace: "xxxx"},
+		Spec:       apps_v1.DeploymentSpec{Replicas: &replicas},
+		Status:     apps_v1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	fi := &fakeImplementer{deployment: dep}
+
+	resource, err := k8s.NewGenericResource(dep.DeepCopy())
+	if err != nil {
+		t.Fatalf("failed to create resource: %s", err)
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	fs := &fakeSender{}
+	p, err := NewProvider(fi, fs, approver, &k8s.GenericResourceCache{})
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	p.SetWaitForRollout(true, time.Second)
+	if !p.shouldWaitForRollout(resource) {
+		t.Fatalf("expected provider to wait for rollout")
+	}
+
+	success := types.EventNotification{Message: "Successfully updated", Level: types.LevelSuccess}
+	p.watchRollout(resource, &UpdatePlan{Resource: resource, CurrentVersion: "1.1.1", NewVersion: "1.1.2"}, nil, &success)
+
+	if fs.sentEvent.Message != "Successfully updated" || fs.sentEvent.Level != types.LevelSuccess {
+		t.Errorf("expected success notification, got: %s %s", fs.sentEvent.Level, fs.sentEvent.Message)
+	}
+}
+
+func TestWatchRolloutTimeoutNotification(t *testing.T) {
+	defer func(interval time.Duration) { rolloutCheckInterval = interval }(rolloutCheckInterval)
+	rolloutCheckInterval = time.Millisecond
+
+	replicas := int32(1)
+	dep := &apps_v1.Deployment{
+		TypeMeta: meta_v1.TypeMeta{Kind: "Deployment"},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "wd",
+			Namespace:   "xxxx",
+			Annotations: map[string]string{types.KeelRolloutTimeoutAnnotation: "20ms"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "wd"}},
+		},
+		Status: apps_v1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	fi := &fakeImplementer{deployment: dep, podList: &v1.PodList{}}
+
+	resource, err := k8s.NewGenericResource(dep.DeepCopy())
+	if err != nil {
+		t.Fatalf("failed to create resource: %s", err)
+	}
+
+	approver, teardown := approver()
+	defer teardown()
+	fs := &fakeSender{}
+	p, err := NewProvider(fi, fs, approver, &k8s.GenericResourceCache{})
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	p.SetWaitForRollout(true, time.Minute)
+
+	success := types.EventNotification{Message: "Successfully updated", Level: types.LevelSuccess}
+	p.watchRollout(resource, &UpdatePlan{Resource: resource, CurrentVersion: "1.1.1", NewVersion: "1.1.2"}, []string{"deployments"}, &success)
+
+	if fs.sentEvent.Level != types.LevelError {
+		t.Errorf("unexpected notification level: %s", fs.sentEvent.Level)
+	}
+	if !strings.Contains(fs.sentEvent.Message, "rollout didn't complete within 20ms") {
+		t.Errorf("unexpected notification message: %s", fs.sentEvent.Message)
+	}
+	if fi.updated != nil {
+		t.Errorf("didn't expect deployment to be rolled back")
+	}
+	if p.isFailedRollout(resource.Identifier, "1.1.2") {
+		t.Errorf("didn't expect version to be marked as failed")
+	}
+}
+
+func TestDrainWaitsForRollout(t *testing.T) {
+	defer func(interval time.Duration) { rolloutCheckInterval = interval }(rolloutCheckInterval)
+	rolloutCheckInterval = time.Millisecond
+
+	replicas := int32(1)
+	dep := &apps_v1.Deployment{
+		TypeMeta: meta_v1.TypeMeta{Kind: "Deployment"},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "wd",
+			Namespace: "xxxx",
+			Labels:    map[string]string{types.KeelPolicyLabel: "all"},
+			Annotations: map[string]string{
+				types.KeelRolloutTimeoutAnnotation: "100ms",
+			},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "wd"}},
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Image: "gcr.io/v2-namespace/hello-world:1.1.1"}},
+				},
+			},
+		},
+		Status: apps_v1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	fi := &fakeImplementer{deployment: dep.DeepCopy(), podList: &v1.PodList{}}
+
+	grc := &k8s.GenericResourceCache{}
+	grc.Add(MustParseGRS([]*apps_v1.Deployment{dep})...)
+
+	approver, teardown := approver()
+	defer teardown()
+	fs := &fakeSender{}
+	p, err := NewProvider(fi, fs, approver, grc)
+	if err != nil {
+		t.Fatalf("failed to get provider: %s", err)
+	}
+	p.SetWaitForRollout(true, time.Minute)
+	go p.Start()
+	defer p.Stop()
+
+	if err := p.Submit(types.Event{Repository: types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.1.2"}}); err != nil {
+		t.Fatalf("failed to submit event: %s", err)
+	}
+	p.Drain()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if !strings.Contains(fs.sentEvent.Message, "rollout didn't complete within 100ms") {
+		t.Errorf("expected drain to wait for rollout result, got: %s", fs.sentEvent.Message)
+	}
+}
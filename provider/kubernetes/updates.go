@@ -8,11 +8,14 @@ import (
 	"github.com/keel-hq/keel/internal/policy"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
+	"github.com/keel-hq/keel/util/policies"
+
+	v1 "k8s.io/api/core/v1"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.GenericResource) (updatePlan *UpdatePlan, shouldUpdateDeployment bool, err error) {
+func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.GenericResource, matchMode string) (updatePlan *UpdatePlan, shouldUpdateDeployment bool, err error) {
 	updatePlan = &UpdatePlan{}
 
 	eventRepoRef, err := image.Parse(repo.String())
@@ -20,14 +23,45 @@ func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.Gen
 		return
 	}
 
+	if policies.IsTagExcluded(policies.GetExcludeTags(resource.GetAnnotations()), repo.Tag) {
+		log.WithFields(log.Fields{
+			"name":      resource.Name,
+			"namespace": resource.Namespace,
+			"kind":      resource.Kind(),
+			"tag":       repo.Tag,
+		}).Debug("provider.kubernetes: tag is excluded, ignoring")
+		return updatePlan, false, nil
+	}
+
 	log.WithFields(log.Fields{
 		"name":      resource.Name,
 		"namespace": resource.Namespace,
 		"kind":      resource.Kind(),
 		"policy":    plc.Name(),
 	}).Debug("provider.kubernetes.checkVersionedDeployment: keel policy found, checking resource...")
-	shouldUpdateDeployment = false
-	for idx, c := range resource.Containers() {
+	strict := matchMode != types.MatchModeLoose
+	shouldUpdateDeployment = checkContainers(plc, repo, eventRepoRef, strict, resource, resource.Containers(), resource.UpdateContainer, updatePlan)
+
+	if policies.ShouldTrackInitContainers(resource.GetLabels(), resource.GetAnnotations()) {
+		if checkContainers(plc, repo, eventRepoRef, strict, resource, resource.InitContainers(), resource.UpdateInitContainer, updatePlan) {
+			shouldUpdateDeployment = true
+		}
+	}
+
+	// recreate strategy restarts all pods, not just the ones running
+	// updated containers
+	if shouldUpdateDeployment && policies.GetUpdateStrategy(resource.GetAnnotations()) == types.UpdateStrategyRecreate {
+		setRestartTime(resource)
+	}
+
+	return updatePlan, shouldUpdateDeployment, nil
+}
+
+// checkContainers - checks containers against the event and updates matching
+// ones through the update func, returns true if any container was updated.
+// Registry host of the images is only compared when strict is set
+func checkContainers(plc policy.Policy, repo *types.Repository, eventRepoRef *image.Reference, strict bool, resource *k8s.GenericResource, containers []v1.Container, update func(index int, image string), updatePlan *UpdatePlan) (updated bool) {
+	for idx, c := range containers {
 		containerImageRef, err := image.Parse(c.Image)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -37,6 +71,8 @@ func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.Gen
 			continue
 		}
 
+		containerPlc := containerPolicy(plc, c.Name, resource.GetLabels(), resource.GetAnnotations())
+
 		log.WithFields(log.Fields{
 			"name":              resource.Name,
 			"namespace":         resource.Namespace,
@@ -44,11 +80,11 @@ func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.Gen
 			"parsed_image_name": containerImageRef.Remote(),
 			"target_image_name": repo.Name,
 			"target_tag":        repo.Tag,
-			"policy":            plc.Name(),
+			"policy":            containerPlc.Name(),
 			"image":             c.Image,
 		}).Debug("provider.kubernetes: checking image")
 
-		if containerImageRef.Repository() != eventRepoRef.Repository() {
+		if !image.SameRepository(containerImageRef, eventRepoRef, strict) {
 			log.WithFields(log.Fields{
 				"parsed_image_name": containerImageRef.Remote(),
 				"target_image_name": repo.Name,
@@ -56,13 +92,25 @@ func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.Gen
 			continue
 		}
 
-		shouldUpdateContainer, err := plc.ShouldUpdate(containerImageRef.Tag(), eventRepoRef.Tag())
+		// image stream triggers own the image, changing the template
+		// would be reverted by the trigger controller
+		if resource.ImageTriggered(c.Name) {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"kind":      resource.Kind(),
+				"container": c.Name,
+			}).Info("provider.kubernetes: container image is managed by an automatic ImageChange trigger, ignoring")
+			continue
+		}
+
+		shouldUpdateContainer, err := containerPlc.ShouldUpdate(containerImageRef.Tag(), eventRepoRef.Tag())
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error":             err,
 				"parsed_image_name": containerImageRef.Remote(),
 				"target_image_name": repo.Name,
-				"policy":            plc.Name(),
+				"policy":            containerPlc.Name(),
 			}).Error("provider.kubernetes: failed to check whether container should be updated")
 			continue
 		}
@@ -71,24 +119,63 @@ func checkForUpdate(plc policy.Policy, repo *types.Repository, resource *k8s.Gen
 			continue
 		}
 
+		// same tag with a digest that was already applied, nothing to restart
+		if repo.Digest != "" && containerImageRef.Tag() == repo.Tag && resource.GetSpecAnnotations()[types.KeelDigestAnnotation] == repo.Digest {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"image":     c.Image,
+				"digest":    repo.Digest,
+			}).Debug("provider.kubernetes: digest already applied, ignoring")
+			continue
+		}
+
+		// pinned images are patched to the digest of the new tag
+		pinned := containerImageRef.Digest() != ""
+		if pinned && repo.Digest == "" {
+			log.WithFields(log.Fields{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"image":     c.Image,
+			}).Warn("provider.kubernetes: image is pinned to a digest but event digest is unknown, ignoring")
+			continue
+		}
+		if pinned && containerImageRef.Tag() == repo.Tag && containerImageRef.Digest() == repo.Digest {
+			continue
+		}
+
 		// updating spec template annotations
 		setUpdateTime(resource)
+		if repo.Digest != "" {
+			setDigest(resource, repo.Digest)
+		}
 
 		// updating image
+		newImage := fmt.Sprintf("%s:%s", containerImageRef.Repository(), repo.Tag)
 		if containerImageRef.Registry() == image.DefaultRegistryHostname {
-			resource.UpdateContainer(idx, fmt.Sprintf("%s:%s", containerImageRef.ShortName(), repo.Tag))
-		} else {
-			resource.UpdateContainer(idx, fmt.Sprintf("%s:%s", containerImageRef.Repository(), repo.Tag))
+			newImage = fmt.Sprintf("%s:%s", containerImageRef.ShortName(), repo.Tag)
+		}
+		if pinned {
+			newImage += "@" + repo.Digest
 		}
+		update(idx, newImage)
 
-		shouldUpdateDeployment = true
+		updated = true
 
 		updatePlan.CurrentVersion = containerImageRef.Tag()
 		updatePlan.NewVersion = repo.Tag
 		updatePlan.Resource = resource
 	}
 
-	return updatePlan, shouldUpdateDeployment, nil
+	return updated
+}
+
+// containerPolicy - container specific policy if set, resource policy otherwise
+func containerPolicy(plc policy.Policy, container string, labels, annotations map[string]string) policy.Policy {
+	if containerPlc, ok := policy.GetContainerPolicy(container, labels, annotations); ok {
+		return containerPlc
+	}
+	return plc
 }
 
 func setUpdateTime(resource *k8s.GenericResource) {
@@ -96,3 +183,19 @@ func setUpdateTime(resource *k8s.GenericResource) {
 	specAnnotations[types.KeelUpdateTimeAnnotation] = time.Now().String()
 	resource.SetSpecAnnotations(specAnnotations)
 }
+
+// setDigest - records the digest resource was last updated to so the same
+// digest doesn't restart it again
+func setDigest(resource *k8s.GenericResource, digest string) {
+	specAnnotations := resource.GetSpecAnnotations()
+	specAnnotations[types.KeelDigestAnnotation] = digest
+	resource.SetSpecAnnotations(specAnnotations)
+}
+
+// setRestartTime - sets the same annotation as "kubectl rollout restart" so all
+// pods of the resource are recreated
+func setRestartTime(resource *k8s.GenericResource) {
+	specAnnotations := resource.GetSpecAnnotations()
+	specAnnotations[types.KubernetesRestartedAtAnnotation] = time.Now().Format(time.RFC3339)
+	resource.SetSpecAnnotations(specAnnotations)
+}
@@ -13,6 +13,7 @@ import (
 	apps_v1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func mustParseGlob(str string) policy.Policy {
@@ -630,7 +631,7 @@ func TestProvider_checkForUpdate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUpdatePlan, gotShouldUpdateDeployment, err := checkForUpdate(tt.args.policy, tt.args.repo, tt.args.resource)
+			gotUpdatePlan, gotShouldUpdateDeployment, err := checkForUpdate(tt.args.policy, tt.args.repo, tt.args.resource, types.MatchModeStrict)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Provider.checkUnversionedDeployment() error = %#v, wantErr %#v", err, tt.wantErr)
 				return
@@ -1115,7 +1116,7 @@ func TestProvider_checkForUpdateSemver(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUpdatePlan, gotShouldUpdateDeployment, err := checkForUpdate(tt.args.policy, tt.args.repo, tt.args.resource)
+			gotUpdatePlan, gotShouldUpdateDeployment, err := checkForUpdate(tt.args.policy, tt.args.repo, tt.args.resource, types.MatchModeStrict)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Provider.checkVersionedDeployment() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1141,3 +1142,332 @@ func TestProvider_checkForUpdateSemver(t *testing.T) {
 		})
 	}
 }
+
+func TestProvider_checkForUpdateInitContainers(t *testing.T) {
+	newDeployment := func(annotations map[string]string) *k8s.GenericResource {
+		return MustParseGR(&apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "dep-1",
+				Namespace:   "xxxx",
+				Annotations: annotations,
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/migrations:1.0.0",
+							},
+						},
+						Containers: []v1.Container{
+							{
+								Image: "gcr.io/v2-namespace/hello-world:1.0.0",
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+	tracked := map[string]string{types.KeelTrackInitContainersAnnotation: "true"}
+
+	tests := []struct {
+		name           string
+		repo           *types.Repository
+		resource       *k8s.GenericResource
+		wantUpdate     bool
+		wantInitImage  string
+		wantImage      string
+		wantNewVersion string
+	}{
+		{
+			name:          "init container not tracked by default",
+			repo:          &types.Repository{Name: "gcr.io/v2-namespace/migrations", Tag: "1.1.0"},
+			resource:      newDeployment(map[string]string{}),
+			wantUpdate:    false,
+			wantInitImage: "gcr.io/v2-namespace/migrations:1.0.0",
+			wantImage:     "gcr.io/v2-namespace/hello-world:1.0.0",
+		},
+		{
+			name:           "tracked init container updated",
+			repo:           &types.Repository{Name: "gcr.io/v2-namespace/migrations", Tag: "1.1.0"},
+			resource:       newDeployment(tracked),
+			wantUpdate:     true,
+			wantInitImage:  "gcr.io/v2-namespace/migrations:1.1.0",
+			wantImage:      "gcr.io/v2-namespace/hello-world:1.0.0",
+			wantNewVersion: "1.1.0",
+		},
+		{
+			name:           "main container updated, init container untouched",
+			repo:           &types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.2.0"},
+			resource:       newDeployment(tracked),
+			wantUpdate:     true,
+			wantInitImage:  "gcr.io/v2-namespace/migrations:1.0.0",
+			wantImage:      "gcr.io/v2-namespace/hello-world:1.2.0",
+			wantNewVersion: "1.2.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plc := policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+			plan, shouldUpdate, err := checkForUpdate(plc, tt.repo, tt.resource, types.MatchModeStrict)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if shouldUpdate != tt.wantUpdate {
+				t.Fatalf("expected update %t, got %t", tt.wantUpdate, shouldUpdate)
+			}
+			if got := tt.resource.InitContainers()[0].Image; got != tt.wantInitImage {
+				t.Errorf("expected init container image %s, got %s", tt.wantInitImage, got)
+			}
+			if got := tt.resource.Containers()[0].Image; got != tt.wantImage {
+				t.Errorf("expected container image %s, got %s", tt.wantImage, got)
+			}
+			if plan.NewVersion != tt.wantNewVersion {
+				t.Errorf("expected new version %s, got %s", tt.wantNewVersion, plan.NewVersion)
+			}
+		})
+	}
+}
+
+func TestProvider_checkForUpdateExcludeTags(t *testing.T) {
+	resource := MustParseGR(&apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "dep-1",
+			Namespace:   "xxxx",
+			Annotations: map[string]string{types.KeelExcludeTagsAnnotation: `*-debug, regexp:-nightly\.\d{1,3}$, regexp:-rc[0-9,]+$`},
+			Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Image: "gcr.io/v2-namespace/hello-world:1.0.0",
+						},
+					},
+				},
+			},
+		},
+	})
+
+	plc := policy.NewSemverPolicy(policy.SemverPolicyTypeAll, false)
+	for _, tag := range []string{"1.2.0-debug", "1.2.0-nightly.3", "1.2.0-rc1"} {
+		_, shouldUpdate, err := checkForUpdate(plc, &types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: tag}, resource, types.MatchModeStrict)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if shouldUpdate {
+			t.Errorf("expected excluded tag %s to be ignored", tag)
+		}
+	}
+
+	_, shouldUpdate, err := checkForUpdate(plc, &types.Repository{Name: "gcr.io/v2-namespace/hello-world", Tag: "1.2.0"}, resource, types.MatchModeStrict)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !shouldUpdate {
+		t.Fatalf("expected update to 1.2.0")
+	}
+	if got := resource.Containers()[0].Image; got != "gcr.io/v2-namespace/hello-world:1.2.0" {
+		t.Errorf("unexpected image: %s", got)
+	}
+}
+
+func TestProvider_checkForUpdateMultipleContainers(t *testing.T) {
+	newDeployment := func(images ...string) *k8s.GenericResource {
+		var containers []v1.Container
+		for _, img := range images {
+			containers = append(containers, v1.Container{Image: img})
+		}
+		return MustParseGR(&apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "dep-1",
+				Namespace:   "xxxx",
+				Annotations: map[string]string{},
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: containers,
+					},
+				},
+			},
+		})
+	}
+
+	tests := []struct {
+		name       string
+		repo       *types.Repository
+		resource   *k8s.GenericResource
+		matchMode  string
+		wantImages []string
+	}{
+		{
+			name:       "sidecar sharing image base is not updated",
+			repo:       &types.Repository{Name: "myrepo/app", Tag: "1.1.0"},
+			resource:   newDeployment("myrepo/app:1.0.0", "myrepo/app-sidecar:1.0.0"),
+			wantImages: []string{"myrepo/app:1.1.0", "myrepo/app-sidecar:1.0.0"},
+		},
+		{
+			name:       "only sidecar updated",
+			repo:       &types.Repository{Name: "myrepo/app-sidecar", Tag: "1.1.0"},
+			resource:   newDeployment("myrepo/app:1.0.0", "myrepo/app-sidecar:1.0.0"),
+			wantImages: []string{"myrepo/app:1.0.0", "myrepo/app-sidecar:1.1.0"},
+		},
+		{
+			name:       "same repository on a different registry is not updated",
+			repo:       &types.Repository{Name: "gcr.io/myrepo/app", Tag: "1.1.0"},
+			resource:   newDeployment("myrepo/app:1.0.0", "gcr.io/myrepo/app:1.0.0"),
+			wantImages: []string{"myrepo/app:1.0.0", "gcr.io/myrepo/app:1.1.0"},
+		},
+		{
+			name:       "all containers using the image updated",
+			repo:       &types.Repository{Name: "myrepo/app", Tag: "1.1.0"},
+			resource:   newDeployment("myrepo/app:1.0.0", "myrepo/app-sidecar:1.0.0", "myrepo/app:1.0.0"),
+			wantImages: []string{"myrepo/app:1.1.0", "myrepo/app-sidecar:1.0.0", "myrepo/app:1.1.0"},
+		},
+		{
+			name:       "loose match ignores registry, images keep their registry",
+			repo:       &types.Repository{Name: "myrepo/app", Tag: "1.1.0"},
+			resource:   newDeployment("mirror.example.com/myrepo/app:1.0.0", "gcr.io/myrepo/app-sidecar:1.0.0"),
+			matchMode:  types.MatchModeLoose,
+			wantImages: []string{"mirror.example.com/myrepo/app:1.1.0", "gcr.io/myrepo/app-sidecar:1.0.0"},
+		},
+		{
+			name:       "loose match of official image",
+			repo:       &types.Repository{Name: "registry.example.com/library/nginx", Tag: "1.1.0"},
+			resource:   newDeployment("nginx:1.0.0"),
+			matchMode:  types.MatchModeLoose,
+			wantImages: []string{"library/nginx:1.1.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plc := policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+			matchMode := tt.matchMode
+			if matchMode == "" {
+				matchMode = types.MatchModeStrict
+			}
+			_, shouldUpdate, err := checkForUpdate(plc, tt.repo, tt.resource, matchMode)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !shouldUpdate {
+				t.Fatalf("expected resource to be updated")
+			}
+			if !reflect.DeepEqual(tt.resource.GetImages(), tt.wantImages) {
+				t.Errorf("expected images %v, got %v", tt.wantImages, tt.resource.GetImages())
+			}
+		})
+	}
+}
+
+func TestProvider_checkForUpdateStrategy(t *testing.T) {
+	newDeployment := func(strategy string) *k8s.GenericResource {
+		annotations := map[string]string{}
+		if strategy != "" {
+			annotations[types.KeelUpdateStrategyAnnotation] = strategy
+		}
+		return MustParseGR(&apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "dep-1",
+				Namespace:   "xxxx",
+				Annotations: annotations,
+				Labels:      map[string]string{types.KeelPolicyLabel: "all"},
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{Image: "myrepo/app:1.0.0"}},
+					},
+				},
+			},
+		})
+	}
+
+	tests := []struct {
+		name        string
+		strategy    string
+		repo        *types.Repository
+		wantUpdate  bool
+		wantRestart bool
+	}{
+		{name: "default", repo: &types.Repository{Name: "myrepo/app", Tag: "1.1.0"}, wantUpdate: true},
+		{name: "rolling", strategy: "rolling", repo: &types.Repository{Name: "myrepo/app", Tag: "1.1.0"}, wantUpdate: true},
+		{name: "recreate", strategy: "recreate", repo: &types.Repository{Name: "myrepo/app", Tag: "1.1.0"}, wantUpdate: true, wantRestart: true},
+		{name: "unknown", strategy: "bluegreen", repo: &types.Repository{Name: "myrepo/app", Tag: "1.1.0"}, wantUpdate: true},
+		{name: "recreate without update", strategy: "recreate", repo: &types.Repository{Name: "myrepo/app", Tag: "0.9.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := newDeployment(tt.strategy)
+			plc := policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+			_, shouldUpdate, err := checkForUpdate(plc, tt.repo, resource, types.MatchModeStrict)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if shouldUpdate != tt.wantUpdate {
+				t.Fatalf("expected update %v, got %v", tt.wantUpdate, shouldUpdate)
+			}
+			_, restarted := resource.GetSpecAnnotations()[types.KubernetesRestartedAtAnnotation]
+			if restarted != tt.wantRestart {
+				t.Errorf("expected restart annotation %v, got %v", tt.wantRestart, restarted)
+			}
+			if tt.wantUpdate && resource.GetImages()[0] != "myrepo/app:"+tt.repo.Tag {
+				t.Errorf("unexpected image: %s", resource.GetImages()[0])
+			}
+		})
+	}
+}
+
+func TestProvider_checkForUpdateDeploymentConfigImageTrigger(t *testing.T) {
+	dc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.openshift.io/v1",
+		"kind":       "DeploymentConfig",
+		"metadata": map[string]interface{}{
+			"name":      "dc-1",
+			"namespace": "xxxx",
+			"labels":    map[string]interface{}{types.KeelPolicyLabel: "all"},
+		},
+		"spec": map[string]interface{}{
+			"triggers": []interface{}{
+				map[string]interface{}{"type": "ConfigChange"},
+				map[string]interface{}{
+					"type": "ImageChange",
+					"imageChangeParams": map[string]interface{}{
+						"automatic":      true,
+						"containerNames": []interface{}{"app"},
+						"from":           map[string]interface{}{"kind": "ImageStreamTag", "name": "app:latest"},
+					},
+				},
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "myrepo/app:1.0.0"},
+						map[string]interface{}{"name": "worker", "image": "myrepo/app:1.0.0"},
+					},
+				},
+			},
+		},
+	}}
+	resource := MustParseGR(dc)
+
+	plc := policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+	_, shouldUpdate, err := checkForUpdate(plc, &types.Repository{Name: "myrepo/app", Tag: "1.1.0"}, resource, types.MatchModeStrict)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !shouldUpdate {
+		t.Fatalf("expected resource to be updated")
+	}
+
+	// container set by the image stream trigger is left alone
+	wantImages := []string{"myrepo/app:1.0.0", "myrepo/app:1.1.0"}
+	if !reflect.DeepEqual(resource.GetImages(), wantImages) {
+		t.Errorf("expected images %v, got %v", wantImages, resource.GetImages())
+	}
+}
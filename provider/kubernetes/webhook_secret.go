@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keel-hq/keel/internal/k8s"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WebhookSecret - returns webhook secret of the resource, set either directly
+// with keel.sh/webhookSecret or through keel.sh/webhookSecretRef. Empty when
+// resource uses the global secret
+func WebhookSecret(implementer Implementer, resource *k8s.GenericResource) (string, error) {
+	annotations := resource.GetAnnotations()
+	if secret := annotations[types.KeelWebhookSecretAnnotation]; secret != "" {
+		return secret, nil
+	}
+
+	ref := annotations[types.KeelWebhookSecretRefAnnotation]
+	if ref == "" {
+		return "", nil
+	}
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid %s '%s', expected <name>/<key>", types.KeelWebhookSecretRefAnnotation, ref)
+	}
+
+	secret, err := implementer.Secret(resource.Namespace, parts[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to get webhook secret %s/%s: %s", resource.Namespace, parts[0], err)
+	}
+	value, ok := secret.Data[parts[1]]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("key '%s' not found in webhook secret %s/%s", parts[1], resource.Namespace, parts[0])
+	}
+	return string(value), nil
+}
+
+// filterByWebhookSecret - drops plans for resources that didn't authorize the
+// event, resources with a webhook secret only accept requests signed with it
+func (p *Provider) filterByWebhookSecret(plans []*UpdatePlan, event *types.Event) []*UpdatePlan {
+	if event.Authorize == nil {
+		return plans
+	}
+
+	filtered := plans[:0]
+	for _, plan := range plans {
+		secret, err := WebhookSecret(p.implementer, plan.Resource)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":     err,
+				"name":      plan.Resource.Name,
+				"kind":      plan.Resource.Kind(),
+				"namespace": plan.Resource.Namespace,
+			}).Error("provider.kubernetes: failed to get resource webhook secret, skipping")
+			p.report(event, plan, types.UpdateStatusError, err.Error())
+			continue
+		}
+		if !event.Authorize(secret) {
+			log.WithFields(log.Fields{
+				"name":      plan.Resource.Name,
+				"kind":      plan.Resource.Kind(),
+				"namespace": plan.Resource.Namespace,
+				"trigger":   event.TriggerName,
+			}).Warn("provider.kubernetes: webhook isn't signed with resource's secret, skipping")
+			p.report(event, plan, types.UpdateStatusRejected, "webhook isn't signed with resource's secret")
+			continue
+		}
+		filtered = append(filtered, plan)
+	}
+	return filtered
+}
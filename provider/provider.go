@@ -2,13 +2,45 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"sync"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/types"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	log "github.com/sirupsen/logrus"
 )
 
+// UpdatesCounter - successful updates, providers increment it after
+// applying an update
+var UpdatesCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "keel_updates_total",
+		Help: "How many updates were applied, partitioned by provider and trigger.",
+	},
+	[]string{"provider", "trigger"},
+)
+
+// TrackedResourcesGauge - resources (deployments, releases, etc.) that have
+// keel policy set, providers refresh it when listing tracked images
+var TrackedResourcesGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "keel_tracked_resources",
+		Help: "How many resources are tracked, partitioned by provider.",
+	},
+	[]string{"provider"},
+)
+
+func init() {
+	prometheus.MustRegister(UpdatesCounter)
+	prometheus.MustRegister(TrackedResourcesGauge)
+}
+
+// ErrStopping - returned by Submit once provider is shutting down
+var ErrStopping = errors.New("provider is shutting down")
+
 // Provider - generic provider interface
 type Provider interface {
 	Submit(event types.Event) error
@@ -17,6 +49,13 @@ type Provider interface {
 	Stop()
 }
 
+// Drainer - implemented by providers that process submitted events
+// asynchronously
+type Drainer interface {
+	// Drain - waits until submitted events are processed
+	Drain()
+}
+
 // Providers - available providers
 type Providers interface {
 	Submit(event types.Event) error
@@ -121,9 +160,25 @@ func (p *DefaultProviders) List() []string {
 	return list
 }
 
-// Stop - stop all providers
+// Drain - waits until providers process events submitted so far, should
+// only be called once triggers stopped submitting new events
+func (p *DefaultProviders) Drain() {
+	for _, provider := range p.providers {
+		if d, ok := provider.(Drainer); ok {
+			d.Drain()
+		}
+	}
+}
+
+// Stop - stop all providers, waits for in-flight updates to finish
 func (p *DefaultProviders) Stop() {
+	var wg sync.WaitGroup
 	for _, provider := range p.providers {
-		provider.Stop()
+		wg.Add(1)
+		go func(provider Provider) {
+			defer wg.Done()
+			provider.Stop()
+		}(provider)
 	}
+	wg.Wait()
 }
@@ -0,0 +1,234 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// EnvConfigFile - optional path to registries configuration file, see Config
+const EnvConfigFile = "REGISTRY_CONFIG_FILE"
+
+// configReloadInterval - how often configuration file is checked for changes
+const configReloadInterval = 10 * time.Second
+
+// Auth types available in registry configuration
+const (
+	// AuthTypeDefault - configured credentials if set, otherwise credentials
+	// found by credentials helpers (image pull secrets, ECR, GCR, etc.)
+	AuthTypeDefault = ""
+	// AuthTypeBasic - only configured credentials are used
+	AuthTypeBasic = "basic"
+	// AuthTypeAnonymous - registry is always queried without credentials
+	AuthTypeAnonymous = "anonymous"
+)
+
+// Config - registries configuration file, i.e.
+//
+//	registries:
+//	  registry.example.com:
+//	    username: keel
+//	    password: secret
+//	  docker.io:
+//	    mirror: https://mirror.example.com
+//	  registry.local:5000:
+//	    insecure: true
+//	    auth: anonymous
+//	  registry.internal:
+//	    caFile: /etc/keel/internal-ca.pem
+//	  ghcr.io:
+//	    token: ghp_...
+type Config struct {
+	Registries map[string]HostConfig `json:"registries"`
+}
+
+// HostConfig - configuration of a single registry host
+type HostConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Token - access token sent as a bearer token instead of username and
+	// password, i.e. GitHub personal access token or GITHUB_TOKEN for ghcr.io
+	Token string `json:"token"`
+	// Auth - one of AuthTypeDefault, AuthTypeBasic or AuthTypeAnonymous
+	Auth string `json:"auth"`
+	// Insecure - same as INSECURE_REGISTRY, but only for this host
+	Insecure bool `json:"insecure"`
+	// InsecureSkipVerify - skips TLS certificate verification, i.e. for
+	// self-signed certificates, unlike Insecure doesn't fall back to HTTP
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// CAFile - PEM encoded CA bundle registry certificates are verified
+	// with, in addition to system CAs
+	CAFile string `json:"caFile"`
+	// Mirror - registry URL requests are sent to instead, i.e. pull-through cache
+	Mirror string `json:"mirror"`
+}
+
+// ParseConfig - parses YAML (or JSON) registries configuration
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string]HostConfig, len(cfg.Registries))
+	for host, hc := range cfg.Registries {
+		switch hc.Auth {
+		case AuthTypeDefault:
+		case AuthTypeAnonymous:
+			if hc.Token != "" {
+				return nil, fmt.Errorf("registry %s: token can't be used with anonymous auth", host)
+			}
+		case AuthTypeBasic:
+			if hc.Username == "" {
+				return nil, fmt.Errorf("registry %s: username is required for basic auth", host)
+			}
+		default:
+			return nil, fmt.Errorf("registry %s: unknown auth type '%s'", host, hc.Auth)
+		}
+		hosts[normalizeHost(host)] = hc
+	}
+	cfg.Registries = hosts
+	return &cfg, nil
+}
+
+// normalizeHost - strips scheme and trailing slash, docker.io is stored
+// under the hostname images are polled from
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(host, "/")
+	if idx := strings.Index(host, "://"); idx >= 0 {
+		host = host[idx+3:]
+	}
+	if host == "docker.io" {
+		host = "index.docker.io"
+	}
+	return host
+}
+
+// configFile - registries configuration loaded from file, reloaded when the
+// file changes so rotated credentials are picked up without a restart
+type configFile struct {
+	path string
+
+	mu      sync.RWMutex
+	config  *Config
+	modTime time.Time
+	checked time.Time
+}
+
+func newConfigFile(path string) (*configFile, error) {
+	f := &configFile{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// host - configuration for the registry address, reloads file if needed
+func (f *configFile) host(registryAddress string) (HostConfig, bool) {
+	f.reload()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	hc, ok := f.config.Registries[normalizeHost(registryAddress)]
+	return hc, ok
+}
+
+func (f *configFile) reload() {
+	f.mu.RLock()
+	checked := f.checked
+	f.mu.RUnlock()
+	if time.Since(checked) < configReloadInterval {
+		return
+	}
+
+	f.mu.Lock()
+	f.checked = time.Now()
+	current := f.modTime
+	f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"file":  f.path,
+		}).Warn("registry.configFile: failed to check configuration file, using current configuration")
+		return
+	}
+	if !info.ModTime().After(current) {
+		return
+	}
+
+	if err := f.load(info.ModTime()); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"file":  f.path,
+		}).Error("registry.configFile: failed to reload configuration, using current configuration")
+		return
+	}
+	log.WithFields(log.Fields{
+		"file": f.path,
+	}).Info("registry.configFile: configuration reloaded")
+}
+
+func (f *configFile) load(modTime time.Time) error {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %s", f.path, err)
+	}
+
+	f.mu.Lock()
+	f.config = cfg
+	f.modTime = modTime
+	f.checked = time.Now()
+	f.mu.Unlock()
+	return nil
+}
+
+// applyConfig - applies configuration of the registry host to request options
+func (c *DefaultClient) applyConfig(opts Opts) Opts {
+	opts.insecure = c.insecure
+	if c.config == nil {
+		return opts
+	}
+	hc, ok := c.config.host(opts.Registry)
+	if !ok {
+		return opts
+	}
+
+	switch {
+	case hc.Auth == AuthTypeAnonymous:
+		opts.Username, opts.Password = "", ""
+	case hc.Auth == AuthTypeBasic || hc.Username != "":
+		opts.Username, opts.Password = hc.Username, hc.Password
+	case hc.Token != "":
+		opts.Username, opts.Password = "", ""
+		opts.token = hc.Token
+	}
+	if hc.Insecure {
+		opts.insecure = true
+	}
+	opts.skipVerify = hc.InsecureSkipVerify
+	opts.caFile = hc.CAFile
+	if hc.Mirror != "" {
+		mirror := strings.TrimSuffix(hc.Mirror, "/")
+		if !strings.Contains(mirror, "://") {
+			mirror = "https://" + mirror
+		}
+		opts.Registry = mirror
+	}
+	return opts
+}
@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+registries:
+  https://registry.example.com/:
+    username: keel
+    password: secret
+  docker.io:
+    mirror: mirror.example.com
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.Registries["registry.example.com"].Username != "keel" {
+		t.Errorf("unexpected config: %+v", cfg.Registries)
+	}
+	if cfg.Registries["index.docker.io"].Mirror != "mirror.example.com" {
+		t.Errorf("unexpected config: %+v", cfg.Registries)
+	}
+
+	for _, data := range []string{
+		"registries:\n  registry.example.com:\n    auth: oauth\n",
+		"registries:\n  registry.example.com:\n    auth: basic\n",
+		"registries:\n  ghcr.io:\n    auth: anonymous\n    token: ghp_secret\n",
+	} {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("expected error for config: %s", data)
+		}
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	client := New()
+	client.config = &configFile{checked: time.Now(), config: &Config{Registries: map[string]HostConfig{
+		"registry.example.com": {Username: "keel", Password: "secret"},
+		"public.example.com":   {Auth: AuthTypeAnonymous, Insecure: true},
+		"index.docker.io":      {Mirror: "mirror.example.com"},
+		"ghcr.io":              {Token: "ghp_secret"},
+	}}}
+
+	tests := []struct {
+		opts Opts
+		want Opts
+	}{
+		{
+			opts: Opts{Registry: "https://registry.example.com", Username: "helper", Password: "helper"},
+			want: Opts{Registry: "https://registry.example.com", Username: "keel", Password: "secret"},
+		},
+		{
+			opts: Opts{Registry: "https://public.example.com", Username: "helper", Password: "helper"},
+			want: Opts{Registry: "https://public.example.com", insecure: true},
+		},
+		{
+			opts: Opts{Registry: "https://index.docker.io", Username: "helper", Password: "helper"},
+			want: Opts{Registry: "https://mirror.example.com", Username: "helper", Password: "helper"},
+		},
+		{
+			opts: Opts{Registry: "https://ghcr.io", Username: "helper", Password: "helper"},
+			want: Opts{Registry: "https://ghcr.io", token: "ghp_secret"},
+		},
+		{
+			opts: Opts{Registry: "https://other.example.com", Username: "helper", Password: "helper"},
+			want: Opts{Registry: "https://other.example.com", Username: "helper", Password: "helper"},
+		},
+	}
+	for _, tt := range tests {
+		if got := client.applyConfig(tt.opts); got != tt.want {
+			t.Errorf("applyConfig(%+v) = %+v, want %+v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFileReload(t *testing.T) {
+	var authorized int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "keel" || password != "rotated" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		authorized++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "karolisr/keel", "tags": ["0.1.0"]}`)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "registryconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "registries.yaml")
+
+	write := func(password string, modTime time.Time) {
+		data := fmt.Sprintf("registries:\n  registry.example.com:\n    username: keel\n    password: %s\n    mirror: %s\n", password, ts.URL)
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+	write("old", time.Now().Add(-time.Minute))
+
+	os.Setenv(EnvConfigFile, path)
+	defer os.Unsetenv(EnvConfigFile)
+	os.Setenv(EnvCacheTTL, "0")
+	defer os.Unsetenv(EnvCacheTTL)
+
+	client := New()
+	opts := Opts{Registry: "https://registry.example.com", Name: "karolisr/keel"}
+	if _, err := client.Get(opts); err == nil {
+		t.Fatalf("expected error with old credentials")
+	}
+
+	write("rotated", time.Now())
+	// forcing the check instead of waiting for the reload interval
+	client.config.mu.Lock()
+	client.config.checked = time.Time{}
+	client.config.mu.Unlock()
+
+	repo, err := client.Get(opts)
+	if err != nil {
+		t.Fatalf("unexpected error with rotated credentials: %s", err)
+	}
+	if len(repo.Tags) != 1 || authorized != 1 {
+		t.Errorf("unexpected tags: %v", repo.Tags)
+	}
+}
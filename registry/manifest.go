@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rusenask/docker-registry-client/registry"
+)
+
+// EnvDigestPlatform - platform ("os/arch" or "os/arch/variant", i.e.
+// "linux/arm64") multi-arch image digests are resolved for. When not set the
+// manifest list digest is used, which is also what kubelet reports for
+// multi-arch images
+const EnvDigestPlatform = "REGISTRY_DIGEST_PLATFORM"
+
+// manifest media types
+const (
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// manifestList - manifest list (or OCI image index) fields needed to pick
+// platform specific manifest
+type manifestList struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// manifestDigest - gets manifest digest, unlike registry.ManifestDigest it
+// accepts manifest lists so multi-arch images get the list digest instead of
+// the digest of whatever single manifest the registry converts the list to.
+// When platform is set, digest of the platform specific manifest is returned.
+// Digest is taken from a HEAD request, which doesn't count against Docker Hub
+// pull rate limits, manifest body is only fetched for the platform specific
+// digest or when the registry doesn't send Docker-Content-Digest
+func manifestDigest(hub *registry.Registry, repository, reference, platform string) (digest.Digest, error) {
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", hub.URL, repository, reference)
+
+	hub.Logf("registry.manifest.head url=%s repository=%s reference=%s", url, repository, reference)
+	resp, err := manifestRequest(hub, "HEAD", url)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	hdr := resp.Header.Get("Docker-Content-Digest")
+	if hdr != "" && (platform == "" || !isManifestListType(resp.Header.Get("Content-Type"))) {
+		return digest.Parse(hdr)
+	}
+
+	hub.Logf("registry.manifest.get url=%s repository=%s reference=%s", url, repository, reference)
+	resp, err = manifestRequest(hub, "GET", url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if platform != "" && isManifestList(resp.Header.Get("Content-Type"), body) {
+		return platformDigest(body, platform)
+	}
+
+	if hdr := resp.Header.Get("Docker-Content-Digest"); hdr != "" {
+		return digest.Parse(hdr)
+	}
+
+	// digest of the body should be equal to what would be presented in
+	// Docker-Content-Digest
+	return digest.FromBytes(body), nil
+}
+
+// manifestRequest - requests manifest accepting manifest lists and OCI indexes
+func manifestRequest(hub *registry.Registry, method, url string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join([]string{mediaTypeManifestList, mediaTypeOCIIndex, mediaTypeManifest, mediaTypeOCIManifest}, ", "))
+	return hub.Client.Do(req)
+}
+
+// isManifestListType - checks whether content type is a manifest list or
+// OCI image index
+func isManifestListType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == mediaTypeManifestList || mediaType == mediaTypeOCIIndex
+}
+
+// isManifestList - checks content type first, falls back to media type in
+// the body as some registries serve manifests as application/json
+func isManifestList(contentType string, body []byte) bool {
+	if isManifestListType(contentType) {
+		return true
+	}
+	var list manifestList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return false
+	}
+	return isManifestListType(list.MediaType)
+}
+
+// platformDigest - finds platform specific manifest digest in manifest list
+func platformDigest(body []byte, platform string) (digest.Digest, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", fmt.Errorf("invalid platform '%s', expected os/arch[/variant]", platform)
+	}
+
+	var list manifestList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return "", fmt.Errorf("failed to decode manifest list: %s", err)
+	}
+
+	for _, m := range list.Manifests {
+		if m.Platform.OS != parts[0] || m.Platform.Architecture != parts[1] {
+			continue
+		}
+		if len(parts) == 3 && m.Platform.Variant != parts[2] {
+			continue
+		}
+		return digest.Parse(m.Digest)
+	}
+
+	return "", fmt.Errorf("manifest list has no manifest for platform '%s'", platform)
+}
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var manifestListResp = `{
+	"schemaVersion": 2,
+	"mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+	"manifests": [
+		{
+			"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+			"size": 528,
+			"digest": "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			"platform": {"architecture": "amd64", "os": "linux"}
+		},
+		{
+			"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+			"size": 528,
+			"digest": "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+			"platform": {"architecture": "arm", "os": "linux", "variant": "v7"}
+		},
+		{
+			"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+			"size": 528,
+			"digest": "sha256:3333333333333333333333333333333333333333333333333333333333333333",
+			"platform": {"architecture": "arm64", "os": "linux", "variant": "v8"}
+		}
+	]
+}`
+
+const manifestListDigest = "sha256:9999999999999999999999999999999999999999999999999999999999999999"
+
+func TestDigestManifestList(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "application/vnd.docker.distribution.manifest.list.v2+json") {
+			// registries convert lists to a single manifest when client
+			// doesn't accept them
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:1111111111111111111111111111111111111111111111111111111111111111")
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.list.v2+json")
+		w.Header().Set("Docker-Content-Digest", manifestListDigest)
+		fmt.Fprint(w, manifestListResp)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		platform string
+		want     string
+		wantErr  bool
+	}{
+		{platform: "", want: manifestListDigest},
+		{platform: "linux/amd64", want: "sha256:1111111111111111111111111111111111111111111111111111111111111111"},
+		{platform: "linux/arm/v7", want: "sha256:2222222222222222222222222222222222222222222222222222222222222222"},
+		{platform: "linux/arm64", want: "sha256:3333333333333333333333333333333333333333333333333333333333333333"},
+		{platform: "windows/amd64", wantErr: true},
+		{platform: "linux", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			client := New()
+			client.cacheTTL = 0
+			client.platform = tt.platform
+
+			digest, err := client.Digest(Opts{
+				Registry: ts.URL,
+				Name:     "keelhq/keel",
+				Tag:      "0.16.0",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if digest != tt.want {
+				t.Errorf("unexpected digest: %s, want: %s", digest, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestUsesHead(t *testing.T) {
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.list.v2+json")
+		w.Header().Set("Docker-Content-Digest", manifestListDigest)
+		fmt.Fprint(w, manifestListResp)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		platform string
+		want     []string
+	}{
+		{platform: "", want: []string{"HEAD"}},
+		{platform: "linux/amd64", want: []string{"HEAD", "GET"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			methods = nil
+			client := New()
+			client.cacheTTL = 0
+			client.platform = tt.platform
+
+			_, err := client.Digest(Opts{
+				Registry: ts.URL,
+				Name:     "keelhq/keel",
+				Tag:      "0.16.0",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if strings.Join(methods, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("unexpected requests: %v, want: %v", methods, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsManifestList(t *testing.T) {
+	if !isManifestList("application/vnd.docker.distribution.manifest.list.v2+json", nil) {
+		t.Errorf("expected manifest list content type to be detected")
+	}
+	if !isManifestList("application/vnd.oci.image.index.v1+json; charset=utf-8", nil) {
+		t.Errorf("expected OCI index content type to be detected")
+	}
+	if !isManifestList("application/json", []byte(manifestListResp)) {
+		t.Errorf("expected manifest list media type in body to be detected")
+	}
+	if isManifestList("application/vnd.docker.distribution.manifest.v2+json", []byte(`{"mediaType": "application/vnd.docker.distribution.manifest.v2+json"}`)) {
+		t.Errorf("didn't expect single manifest to be detected as a list")
+	}
+}
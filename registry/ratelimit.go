@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rusenask/docker-registry-client/registry"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// backoff bounds used when registry doesn't send Retry-After header
+const (
+	minRateLimitBackoff = 30 * time.Second
+	maxRateLimitBackoff = 10 * time.Minute
+)
+
+// ErrRateLimited - returned without querying the registry while it's throttling us
+var ErrRateLimited = errors.New("registry rate limit exceeded")
+
+var registryRateLimitedGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "keel_registry_rate_limited",
+		Help: "Whether requests to the registry are paused because it responded with 429 Too Many Requests.",
+	},
+	[]string{"registry"},
+)
+
+func init() {
+	prometheus.MustRegister(registryRateLimitedGauge)
+}
+
+type rateLimit struct {
+	until   time.Time
+	backoff time.Duration
+}
+
+// rateLimiter - tracks 429 responses per registry and pauses requests to it,
+// honoring Retry-After or backing off exponentially when it's not set
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits map[string]*rateLimit
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		limits: make(map[string]*rateLimit),
+	}
+}
+
+// check - returns an error if requests to the registry are paused
+func (l *rateLimiter) check(registry string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.limits[registry]
+	if !ok {
+		return nil
+	}
+	wait := time.Until(limit.until)
+	if wait > 0 {
+		return fmt.Errorf("%w, retrying in %s", ErrRateLimited, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// observe - updates registry state based on the request result
+func (l *rateLimiter) observe(registryAddress string, err error) {
+	var statusErr *registry.HttpStatusError
+	if err == nil || !errors.As(err, &statusErr) || statusErr.Response.StatusCode != http.StatusTooManyRequests {
+		if err == nil {
+			l.reset(registryAddress)
+		}
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.limits[registryAddress]
+	if !ok {
+		limit = &rateLimit{}
+		l.limits[registryAddress] = limit
+	}
+
+	limit.backoff *= 2
+	if limit.backoff < minRateLimitBackoff {
+		limit.backoff = minRateLimitBackoff
+	}
+	if limit.backoff > maxRateLimitBackoff {
+		limit.backoff = maxRateLimitBackoff
+	}
+
+	wait := limit.backoff
+	if retryAfter, ok := parseRetryAfter(statusErr.Response.Header.Get("Retry-After")); ok {
+		wait = retryAfter
+	}
+	limit.until = time.Now().Add(wait)
+
+	registryRateLimitedGauge.With(prometheus.Labels{"registry": registryAddress}).Set(1)
+
+	log.WithFields(log.Fields{
+		"registry": registryAddress,
+		"wait":     wait.String(),
+	}).Warn("registry: rate limited, pausing requests")
+}
+
+func (l *rateLimiter) reset(registryAddress string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.limits[registryAddress]; !ok {
+		return
+	}
+	delete(l.limits, registryAddress)
+	registryRateLimitedGauge.With(prometheus.Labels{"registry": registryAddress}).Set(0)
+
+	log.WithFields(log.Fields{
+		"registry": registryAddress,
+	}).Info("registry: rate limit lifted, resuming requests")
+}
+
+// parseRetryAfter - Retry-After is either delay in seconds or HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rusenask/docker-registry-client/registry"
+)
+
+func TestRateLimited(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client := New()
+	opts := Opts{
+		Registry: ts.URL,
+		Name:     "jetstack/cert-manager-controller",
+		Tag:      "v0.1.0",
+	}
+
+	_, err := client.Get(opts)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	_, err = client.Digest(opts)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected rate limited error, got: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected requests to be paused after 429, got: %d requests", requests)
+	}
+
+	wait := time.Until(client.rateLimits.limits[ts.URL].until)
+	if wait < 110*time.Second || wait > 120*time.Second {
+		t.Errorf("expected Retry-After to be honored, got wait: %s", wait)
+	}
+}
+
+func TestRateLimitBackoff(t *testing.T) {
+	l := newRateLimiter()
+	tooManyRequests := &registry.HttpStatusError{
+		Response: &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}},
+	}
+
+	for _, want := range []time.Duration{minRateLimitBackoff, 2 * minRateLimitBackoff, 4 * minRateLimitBackoff} {
+		l.observe("https://index.docker.io", tooManyRequests)
+		if l.limits["https://index.docker.io"].backoff != want {
+			t.Errorf("expected backoff %s, got: %s", want, l.limits["https://index.docker.io"].backoff)
+		}
+	}
+
+	l.limits["https://index.docker.io"].backoff = maxRateLimitBackoff
+	l.observe("https://index.docker.io", tooManyRequests)
+	if l.limits["https://index.docker.io"].backoff != maxRateLimitBackoff {
+		t.Errorf("expected backoff to be capped, got: %s", l.limits["https://index.docker.io"].backoff)
+	}
+
+	l.observe("https://index.docker.io", nil)
+	if _, ok := l.limits["https://index.docker.io"]; ok {
+		t.Errorf("expected successful request to reset backoff")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "30", want: 30 * time.Second, wantOK: true},
+		{value: "soon", wantOK: false},
+		{value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), want: 0, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseRetryAfter() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("parseRetryAfter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
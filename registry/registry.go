@@ -2,11 +2,15 @@ package registry
 
 import (
 	"errors"
+	"fmt"
 	"hash/fnv"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rusenask/docker-registry-client/registry"
 
 	log "github.com/sirupsen/logrus"
@@ -15,11 +19,48 @@ import (
 // EnvInsecure - uses insecure registry client to skip cert verification
 const EnvInsecure = "INSECURE_REGISTRY"
 
+// EnvCacheTTL - how long tag lists and digests are reused before querying
+// the registry again, i.e. "5s", set to "0" to disable caching. Keep it
+// below the shortest poll schedule
+const EnvCacheTTL = "REGISTRY_CACHE_TTL"
+
+// DefaultCacheTTL - default cache TTL, short enough to only dedupe requests
+// made within the same poll cycle
+const DefaultCacheTTL = 10 * time.Second
+
 // errors
 var (
 	ErrTagNotSupplied = errors.New("tag not supplied")
+	// ErrRepositoryNotFound - registry doesn't know the repository, unlike
+	// an existing repository without tags which returns an empty tag list
+	ErrRepositoryNotFound = errors.New("repository not found")
+	// ErrTagNotFound - registry has no manifest for the tag
+	ErrTagNotFound = errors.New("tag not found")
 )
 
+var registryRequestsCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "keel_registry_requests_total",
+		Help: "How many registry requests were made, partitioned by registry, request type and result.",
+	},
+	[]string{"registry", "request", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(registryRequestsCounter)
+}
+
+func countRequest(registryAddress, request string, err error) {
+	result := "success"
+	var statusErr *registry.HttpStatusError
+	if errors.As(err, &statusErr) && statusErr.Response.StatusCode == http.StatusTooManyRequests {
+		result = "rate_limited"
+	} else if err != nil {
+		result = "error"
+	}
+	registryRequestsCounter.With(prometheus.Labels{"registry": registryAddress, "request": request, "result": result}).Inc()
+}
+
 // Repository - holds repository related info
 type Repository struct {
 	Name string
@@ -38,10 +79,41 @@ func New() *DefaultClient {
 	if os.Getenv(EnvInsecure) == "true" {
 		insecure = true
 	}
+
+	cacheTTL := DefaultCacheTTL
+	if os.Getenv(EnvCacheTTL) != "" {
+		ttl, err := time.ParseDuration(os.Getenv(EnvCacheTTL))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Errorf("registry.New: failed to parse %s, defaulting to %s", EnvCacheTTL, DefaultCacheTTL)
+		} else {
+			cacheTTL = ttl
+		}
+	}
+
+	var config *configFile
+	if os.Getenv(EnvConfigFile) != "" {
+		cf, err := newConfigFile(os.Getenv(EnvConfigFile))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"file":  os.Getenv(EnvConfigFile),
+			}).Error("registry.New: failed to load registries configuration file, ignoring")
+		} else {
+			config = cf
+		}
+	}
+
 	return &DefaultClient{
 		mu:         &sync.Mutex{},
 		registries: make(map[uint32]*registry.Registry),
 		insecure:   insecure,
+		cacheTTL:   cacheTTL,
+		cache:      make(map[string]*cacheEntry),
+		rateLimits: newRateLimiter(),
+		platform:   os.Getenv(EnvDigestPlatform),
+		config:     config,
 	}
 }
 
@@ -51,6 +123,30 @@ type DefaultClient struct {
 	mu         *sync.Mutex
 	registries map[uint32]*registry.Registry
 	insecure   bool
+
+	// responses cache, keyed by request type, repository and credentials
+	cacheTTL time.Duration
+	cacheMu  sync.Mutex
+	cache    map[string]*cacheEntry
+
+	rateLimits *rateLimiter
+
+	// platform multi-arch image digests are resolved for, manifest list
+	// digest is used when empty
+	platform string
+
+	// config - optional per registry host configuration
+	config *configFile
+}
+
+// cacheEntry - cached registry response, the entry is locked while it's
+// being fetched so concurrent requests for the same repository wait for
+// the first one instead of querying the registry again
+type cacheEntry struct {
+	mu      sync.Mutex
+	fetched time.Time
+	tags    []string
+	digest  string
 }
 
 // Opts - registry client opts. If username & password are not supplied
@@ -58,6 +154,13 @@ type DefaultClient struct {
 type Opts struct {
 	Registry, Name, Tag string
 	Username, Password  string // if "" - anonymous
+
+	// insecure, skipVerify, caFile and token - set from client and registry
+	// host configuration
+	insecure   bool
+	skipVerify bool
+	caFile     string
+	token      string
 }
 
 // LogFormatter - formatter callback passed into registry client
@@ -71,23 +174,39 @@ func hash(s string) uint32 {
 	return h.Sum32()
 }
 
-func (c *DefaultClient) getRegistryClient(registryAddress, username, password string) (*registry.Registry, error) {
+func (c *DefaultClient) getRegistryClient(opts Opts) (*registry.Registry, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	var r *registry.Registry
 
-	h := hash(registryAddress + username + password)
+	h := hash(fmt.Sprintf("%s%s%s%t%t%s%s", opts.Registry, opts.Username, opts.Password, opts.insecure, opts.skipVerify, opts.caFile, opts.token))
 	r, ok := c.registries[h]
 	if ok {
 		return r, nil
 	}
 
-	url := strings.TrimSuffix(registryAddress, "/")
-	if os.Getenv(EnvInsecure) == "true" {
-		r = registry.NewInsecure(url, username, password)
-	} else {
-		r = registry.New(url, username, password)
+	url := strings.TrimSuffix(opts.Registry, "/")
+	switch {
+	case opts.token != "":
+		var err error
+		r, err = newTokenRegistry(url, opts.token, opts.insecure || opts.skipVerify, opts.caFile)
+		if err != nil {
+			return nil, err
+		}
+	case opts.insecure:
+		log.WithFields(log.Fields{
+			"registry": url,
+		}).Warn("registry: insecure registry, TLS certificate verification is skipped")
+		r = registry.NewInsecure(url, opts.Username, opts.Password)
+	case opts.skipVerify || opts.caFile != "":
+		var err error
+		r, err = newTLSRegistry(url, opts.Username, opts.Password, opts.skipVerify, opts.caFile)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		r = registry.New(url, opts.Username, opts.Password)
 	}
 
 	r.Logf = LogFormatter
@@ -97,22 +216,92 @@ func (c *DefaultClient) getRegistryClient(registryAddress, username, password st
 	return r, nil
 }
 
-// Get - get repository
+// lookup - returns cache entry of the request, creating it when missing
+func (c *DefaultClient) lookup(request string, opts Opts) *cacheEntry {
+	key := fmt.Sprintf("%s:%s/%s:%s:%d", request, opts.Registry, opts.Name, opts.Tag, hash(opts.Username+opts.Password))
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	// dropping expired entries so removed repositories don't pile up
+	for k, entry := range c.cache {
+		if k != key && entry.expired(c.cacheTTL) {
+			delete(c.cache, k)
+		}
+	}
+
+	entry, ok := c.cache[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.cache[key] = entry
+	}
+	return entry
+}
+
+// expired - should only be checked when not holding the entry lock for
+// the fetch, zero fetched time means the entry is being populated
+func (e *cacheEntry) expired(ttl time.Duration) bool {
+	if !e.mu.TryLock() {
+		return false
+	}
+	defer e.mu.Unlock()
+	return !e.fetched.IsZero() && time.Since(e.fetched) > ttl
+}
+
+// Get - get repository, tags are cached for the configured TTL
 func (c *DefaultClient) Get(opts Opts) (*Repository, error) {
+	opts = c.applyConfig(opts)
+	if c.cacheTTL <= 0 {
+		return c.get(opts)
+	}
+
+	// tag list doesn't depend on the tag
+	entry := c.lookup("tags", Opts{
+		Registry: opts.Registry,
+		Name:     opts.Name,
+		Username: opts.Username,
+		Password: opts.Password,
+		insecure: opts.insecure,
+	})
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !entry.fetched.IsZero() && time.Since(entry.fetched) < c.cacheTTL {
+		return &Repository{Tags: entry.tags}, nil
+	}
+
+	repo, err := c.get(opts)
+	if err != nil {
+		return nil, err
+	}
+	entry.tags = repo.Tags
+	entry.fetched = time.Now()
+	return repo, nil
+}
+
+func (c *DefaultClient) get(opts Opts) (*Repository, error) {
+	if err := c.rateLimits.check(opts.Registry); err != nil {
+		return nil, err
+	}
 
 	// fallback to HTTP if the registry doesn't speak HTTPS https://github.com/keel-hq/keel/issues/331
 INIT_CLIENT:
-	hub, err := c.getRegistryClient(opts.Registry, opts.Username, opts.Password)
+	hub, err := c.getRegistryClient(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	tags, err := hub.Tags(opts.Name)
+	tags, err := listTags(hub, opts.Name)
+	countRequest(opts.Registry, "tags", err)
+	c.rateLimits.observe(opts.Registry, err)
 	if err != nil {
-		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.insecure {
+		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && opts.insecure {
 			opts.Registry = strings.Replace(opts.Registry, "https://", "http://", 1)
 			goto INIT_CLIENT
 		}
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", ErrRepositoryNotFound, opts.Name)
+		}
 		return nil, err
 	}
 	repo := &Repository{
@@ -122,27 +311,71 @@ INIT_CLIENT:
 	return repo, nil
 }
 
-// Digest - get digest for repo
+// Digest - get digest for repo, digests are cached for the configured TTL
 func (c *DefaultClient) Digest(opts Opts) (string, error) {
 	if opts.Tag == "" {
 		return "", ErrTagNotSupplied
 	}
+	opts = c.applyConfig(opts)
+
+	if c.cacheTTL <= 0 {
+		return c.digest(opts)
+	}
+
+	entry := c.lookup("digest", opts)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !entry.fetched.IsZero() && time.Since(entry.fetched) < c.cacheTTL {
+		return entry.digest, nil
+	}
+
+	digest, err := c.digest(opts)
+	if err != nil {
+		return "", err
+	}
+	entry.digest = digest
+	entry.fetched = time.Now()
+	return digest, nil
+}
+
+func (c *DefaultClient) digest(opts Opts) (string, error) {
+	if err := c.rateLimits.check(opts.Registry); err != nil {
+		return "", err
+	}
 
 	// fallback to HTTP if the registry doesn't speak HTTPS https://github.com/keel-hq/keel/issues/331
 INIT_CLIENT:
-	hub, err := c.getRegistryClient(opts.Registry, opts.Username, opts.Password)
+	hub, err := c.getRegistryClient(opts)
 	if err != nil {
 		return "", err
 	}
 
-	manifestDigest, err := hub.ManifestDigest(opts.Name, opts.Tag)
+	manifestDigest, err := manifestDigest(hub, opts.Name, opts.Tag, c.platform)
+	countRequest(opts.Registry, "digest", err)
+	c.rateLimits.observe(opts.Registry, err)
 	if err != nil {
-		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && c.insecure {
+		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") && strings.HasPrefix(opts.Registry, "https://") && opts.insecure {
 			opts.Registry = strings.Replace(opts.Registry, "https://", "http://", 1)
 			goto INIT_CLIENT
 		}
+		if isNotFound(err) {
+			return "", fmt.Errorf("%w: %s:%s", ErrTagNotFound, opts.Name, opts.Tag)
+		}
 		return "", err
 	}
 
 	return manifestDigest.String(), nil
 }
+
+// IsUnauthorized - registry rejected the credentials, i.e. an expired ECR
+// token
+func IsUnauthorized(err error) bool {
+	var statusErr *registry.HttpStatusError
+	return errors.As(err, &statusErr) && statusErr.Response.StatusCode == http.StatusUnauthorized
+}
+
+func isNotFound(err error) bool {
+	var statusErr *registry.HttpStatusError
+	return errors.As(err, &statusErr) && statusErr.Response.StatusCode == http.StatusNotFound
+}
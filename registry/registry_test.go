@@ -313,3 +313,47 @@ func TestGetDockerHubManyTags(t *testing.T) {
 	}
 	fmt.Println(tags)
 }
+
+func TestGetCached(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/tags/list") {
+			requests++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, tagsResp)
+	}))
+	defer ts.Close()
+
+	client := New()
+
+	for _, tag := range []string{"v0.1.0", "v0.2.0", ""} {
+		repo, err := client.Get(Opts{
+			Registry: ts.URL,
+			Name:     "jetstack/cert-manager-controller",
+			Tag:      tag,
+		})
+		if err != nil {
+			t.Fatalf("error while getting tags: %s", err)
+		}
+		if len(repo.Tags) != 50 {
+			t.Errorf("unexpected tags: %d", len(repo.Tags))
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected tags to be fetched once, got: %d", requests)
+	}
+
+	client.cacheTTL = 0
+	_, err := client.Get(Opts{
+		Registry: ts.URL,
+		Name:     "jetstack/cert-manager-controller",
+	})
+	if err != nil {
+		t.Fatalf("error while getting tags: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected tags to be fetched again with cache disabled, got: %d", requests)
+	}
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rusenask/docker-registry-client/registry"
+)
+
+// tagsPage - single page of /v2/<name>/tags/list response
+type tagsPage struct {
+	Tags []string `json:"tags"`
+}
+
+// listTags - gets all repository tags following Link header pagination.
+// Unlike registry.Tags each page is decoded separately and relative as well
+// as absolute next page links (on a different host too) are supported.
+func listTags(hub *registry.Registry, repository string) ([]string, error) {
+	next := fmt.Sprintf("%s/v2/%s/tags/list", hub.URL, repository)
+	visited := make(map[string]bool)
+
+	var tags []string
+	for next != "" {
+		if visited[next] {
+			return nil, fmt.Errorf("registry returned already visited tags page %s", next)
+		}
+		visited[next] = true
+
+		hub.Logf("registry.tags url=%s repository=%s", next, repository)
+		page, nextURL, err := getTagsPage(hub, next)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+		next = nextURL
+	}
+	return tags, nil
+}
+
+func getTagsPage(hub *registry.Registry, url string) (*tagsPage, string, error) {
+	resp, err := hub.Client.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var page tagsPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", err
+	}
+
+	next, err := nextPageURL(resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return &page, next, nil
+}
+
+// nextPageURL - gets URL of the next page from RFC 5988 Link header, i.e.
+// `</v2/app/tags/list?last=1.2.3&n=100>; rel="next"`, relative URLs are
+// resolved against the request URL. Empty when there are no more pages.
+func nextPageURL(resp *http.Response) (string, error) {
+	for _, header := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			if len(parts) < 2 {
+				continue
+			}
+
+			next := false
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "rel") && strings.EqualFold(strings.Trim(kv[1], `"`), "next") {
+					next = true
+				}
+			}
+			if !next {
+				continue
+			}
+
+			// some registries (i.e. quay.io) don't wrap the URL in angle brackets
+			target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			if resp.Request == nil || resp.Request.URL == nil {
+				return target, nil
+			}
+			u, err := resp.Request.URL.Parse(target)
+			if err != nil {
+				return "", fmt.Errorf("invalid next page link '%s': %s", target, err)
+			}
+			return u.String(), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetPaginatedTags(t *testing.T) {
+	var requests []string
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("last") {
+		case "":
+			// relative link
+			w.Header().Set("Link", `</v2/karolisr/keel/tags/list?last=0.0.2&n=2>; rel="next"`)
+			fmt.Fprint(w, `{"name": "karolisr/keel", "tags": ["0.0.1", "0.0.2"]}`)
+		case "0.0.2":
+			// absolute link without angle brackets
+			w.Header().Set("Link", ts.URL+`/v2/karolisr/keel/tags/list?last=0.0.4&n=2; rel=next`)
+			fmt.Fprint(w, `{"name": "karolisr/keel", "tags": ["0.0.3", "0.0.4"]}`)
+		case "0.0.4":
+			fmt.Fprint(w, `{"name": "karolisr/keel", "tags": ["0.1.0"]}`)
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+	}))
+	defer ts.Close()
+
+	client := New()
+	repo, err := client.Get(Opts{
+		Registry: ts.URL,
+		Name:     "karolisr/keel",
+	})
+	if err != nil {
+		t.Fatalf("error while getting tags: %s", err)
+	}
+
+	want := []string{"0.0.1", "0.0.2", "0.0.3", "0.0.4", "0.1.0"}
+	if !reflect.DeepEqual(repo.Tags, want) {
+		t.Errorf("unexpected tags: %v", repo.Tags)
+	}
+	if len(requests) != 3 {
+		t.Errorf("expected 3 page requests, got: %v", requests)
+	}
+}
+
+func TestGetPaginatedTagsLoop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `</v2/karolisr/keel/tags/list?last=0.0.1>; rel="next"`)
+		fmt.Fprint(w, `{"name": "karolisr/keel", "tags": ["0.0.1"]}`)
+	}))
+	defer ts.Close()
+
+	client := New()
+	if _, err := client.Get(Opts{Registry: ts.URL, Name: "karolisr/keel"}); err == nil {
+		t.Errorf("expected error when registry keeps returning the same page")
+	}
+}
+
+func TestGetEmptyRepository(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "karolisr/keel", "tags": null}`)
+	}))
+	defer ts.Close()
+
+	client := New()
+	repo, err := client.Get(Opts{Registry: ts.URL, Name: "karolisr/keel"})
+	if err != nil {
+		t.Fatalf("unexpected error for repository without tags: %s", err)
+	}
+	if len(repo.Tags) != 0 {
+		t.Errorf("expected no tags, got: %v", repo.Tags)
+	}
+}
+
+func TestGetRepositoryNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors": [{"code": "NAME_UNKNOWN", "message": "repository name not known to registry"}]}`)
+	}))
+	defer ts.Close()
+
+	client := New()
+	_, err := client.Get(Opts{Registry: ts.URL, Name: "karolisr/keel"})
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Errorf("expected ErrRepositoryNotFound, got: %v", err)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://registry.example.com/v2/app/tags/list", nil)
+
+	tests := []struct {
+		link string
+		want string
+	}{
+		{link: "", want: ""},
+		{link: `</v2/app/tags/list?last=b&n=2>; rel="next"`, want: "https://registry.example.com/v2/app/tags/list?last=b&n=2"},
+		{link: `<https://cdn.example.com/v2/app/tags/list?last=b>; type="application/json"; rel="next"`, want: "https://cdn.example.com/v2/app/tags/list?last=b"},
+		{link: `</v2/app/tags/list?last=a>; rel="prev", </v2/app/tags/list?last=c>; rel="next"`, want: "https://registry.example.com/v2/app/tags/list?last=c"},
+		{link: `</v2/app/tags/list?last=a>; rel="prev"`, want: ""},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}, Request: req}
+		if tt.link != "" {
+			resp.Header.Set("Link", tt.link)
+		}
+		got, err := nextPageURL(resp)
+		if err != nil {
+			t.Errorf("nextPageURL(%q) error: %s", tt.link, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
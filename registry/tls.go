@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rusenask/docker-registry-client/registry"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// newTLSRegistry - registry client with custom TLS configuration, either
+// trusting CAs from caFile or skipping certificate verification entirely
+func newTLSRegistry(url, username, password string, skipVerify bool, caFile string) (*registry.Registry, error) {
+	tlsConfig, err := newTLSConfig(url, skipVerify, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &registry.Registry{
+		URL: url,
+		Client: &http.Client{
+			Transport: registry.WrapTransport(newTransport(tlsConfig), url, username, password),
+		},
+		Logf: LogFormatter,
+	}, nil
+}
+
+func newTLSConfig(url string, skipVerify bool, caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if skipVerify {
+		log.WithFields(log.Fields{
+			"registry": url,
+		}).Warn("registry: insecureSkipVerify is set, TLS certificate verification is skipped")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	return tlsConfig, nil
+}
+
+// newTransport - same transport settings as registry.New
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
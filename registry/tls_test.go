@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedRegistry(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "karolisr/keel", "tags": ["0.1.0"]}`)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "registryca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	err = ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	tests := []struct {
+		name    string
+		host    HostConfig
+		wantErr bool
+	}{
+		{name: "not configured", wantErr: true},
+		{name: "custom CA", host: HostConfig{CAFile: caFile}},
+		{name: "skip verify", host: HostConfig{InsecureSkipVerify: true}},
+		{name: "missing CA file", host: HostConfig{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(EnvCacheTTL, "0")
+			defer os.Unsetenv(EnvCacheTTL)
+			os.Setenv(EnvInsecure, "false")
+			defer os.Unsetenv(EnvInsecure)
+
+			client := New()
+			client.config = &configFile{checked: time.Now(), config: &Config{Registries: map[string]HostConfig{host: tt.host}}}
+
+			_, err := client.Get(Opts{Registry: ts.URL, Name: "karolisr/keel"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rusenask/docker-registry-client/registry"
+)
+
+// newTokenRegistry - registry client authenticating with an access token
+// instead of username and password, i.e. GitHub personal access token or
+// GITHUB_TOKEN for ghcr.io
+func newTokenRegistry(url, token string, skipVerify bool, caFile string) (*registry.Registry, error) {
+	tlsConfig, err := newTLSConfig(url, skipVerify, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := newTransport(tlsConfig)
+	return &registry.Registry{
+		URL: url,
+		Client: &http.Client{
+			Transport: &registry.ErrorTransport{
+				Transport: &bearerTransport{
+					transport: transport,
+					token:     token,
+					tokens:    make(map[string]string),
+				},
+			},
+		},
+		Logf: LogFormatter,
+	}, nil
+}
+
+// bearerTransport - answers registry bearer challenges by exchanging the
+// access token for a registry token at the realm (https://ghcr.io/token for
+// GitHub), the access token is sent as a bearer token. When the realm doesn't
+// issue a token the access token itself is used, which GitHub also accepts
+type bearerTransport struct {
+	transport http.RoundTripper
+	token     string
+
+	// tokens - registry tokens, keyed by request path
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(t.authorize(req, t.cached(req.URL.Path)))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err := t.exchange(challenge)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.tokens[req.URL.Path] = token
+	t.mu.Unlock()
+
+	return t.transport.RoundTrip(t.authorize(req, token))
+}
+
+func (t *bearerTransport) cached(path string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tokens[path]
+}
+
+// authorize - request copy with the bearer token, original request is
+// returned unchanged when there is no token yet
+func (t *bearerTransport) authorize(req *http.Request, token string) *http.Request {
+	if token == "" {
+		return req
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+// exchange - gets registry token for the challenge, falls back to the
+// encoded access token when the realm doesn't issue one
+func (t *bearerTransport) exchange(challenge bearerChallenge) (string, error) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(t.token))
+	if challenge.realm == "" {
+		return encoded, nil
+	}
+
+	u, err := url.Parse(challenge.realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm '%s': %s", challenge.realm, err)
+	}
+	q := u.Query()
+	if challenge.service != "" {
+		q.Set("service", challenge.service)
+	}
+	if challenge.scope != "" {
+		q.Set("scope", challenge.scope)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+encoded)
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return encoded, nil
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %s", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return encoded, nil
+}
+
+type bearerChallenge struct {
+	realm, service, scope string
+}
+
+// parseBearerChallenge - parses WWW-Authenticate header, i.e.
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/app:pull"
+func parseBearerChallenge(header string) (bearerChallenge, bool) {
+	var c bearerChallenge
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return c, false
+	}
+
+	for _, param := range splitParams(parts[1]) {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "realm":
+			c.realm = value
+		case "service":
+			c.service = value
+		case "scope":
+			c.scope = value
+		}
+	}
+	return c, true
+}
+
+// splitParams - splits on commas outside of quotes, scopes can contain
+// commas (repository:org/app:pull,push)
+func splitParams(s string) []string {
+	var (
+		params []string
+		quoted bool
+		start  int
+	)
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			params = append(params, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, s[start:])
+}
@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGHCR - registry issuing tokens the same way ghcr.io does, tokens are
+// requested from /token with the access token as a bearer token
+type fakeGHCR struct {
+	token string
+	// issueTokens - when false token requests are rejected and the encoded
+	// access token has to be used directly
+	issueTokens bool
+	exchanges   int
+}
+
+func (g *fakeGHCR) handler(url func() string) http.Handler {
+	encoded := "Bearer " + base64.StdEncoding.EncodeToString([]byte(g.token))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			g.exchanges++
+			if !g.issueTokens || r.Header.Get("Authorization") != encoded {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:keel-hq/keel:pull" || r.URL.Query().Get("service") != "ghcr.io" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "registry-token"}`)
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		if (g.issueTokens && auth != "Bearer registry-token") || (!g.issueTokens && auth != encoded) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="ghcr.io",scope="repository:keel-hq/keel:pull"`, url()))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "keel-hq/keel", "tags": ["0.1.0", "0.2.0"]}`)
+	})
+}
+
+func TestTokenRegistry(t *testing.T) {
+	for _, issueTokens := range []bool{true, false} {
+		t.Run(fmt.Sprintf("issue tokens %t", issueTokens), func(t *testing.T) {
+			os.Setenv(EnvCacheTTL, "0")
+			defer os.Unsetenv(EnvCacheTTL)
+			os.Setenv(EnvInsecure, "false")
+			defer os.Unsetenv(EnvInsecure)
+
+			ghcr := &fakeGHCR{token: "ghp_secret", issueTokens: issueTokens}
+			var ts *httptest.Server
+			ts = httptest.NewServer(ghcr.handler(func() string { return ts.URL }))
+			defer ts.Close()
+
+			client := New()
+			host := strings.TrimPrefix(ts.URL, "http://")
+			client.config = &configFile{checked: time.Now(), config: &Config{Registries: map[string]HostConfig{
+				host: {Token: "ghp_secret"},
+			}}}
+
+			for i := 0; i < 2; i++ {
+				repo, err := client.Get(Opts{Registry: ts.URL, Name: "keel-hq/keel"})
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if len(repo.Tags) != 2 {
+					t.Errorf("unexpected tags: %v", repo.Tags)
+				}
+			}
+			// registry token is reused
+			if ghcr.exchanges != 1 {
+				t.Errorf("expected a single token exchange, got: %d", ghcr.exchanges)
+			}
+		})
+	}
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	c, ok := parseBearerChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/app:pull,push"`)
+	if !ok {
+		t.Fatalf("expected challenge to be parsed")
+	}
+	if c.realm != "https://ghcr.io/token" || c.service != "ghcr.io" || c.scope != "repository:org/app:pull,push" {
+		t.Errorf("unexpected challenge: %+v", c)
+	}
+
+	if _, ok := parseBearerChallenge(`Basic realm="registry"`); ok {
+		t.Errorf("didn't expect basic challenge to be parsed")
+	}
+}
@@ -36,6 +36,10 @@ type Getter interface {
 type DefaultGetter struct {
 	kubernetesImplementer kubernetes.Implementer
 	defaultDockerConfig   DockerCfg // default configuration supplied by optional environment variable
+
+	// clusters - implementers of additional clusters, secrets of images
+	// tracked in those clusters are read from the cluster they belong to
+	clusters map[string]kubernetes.Implementer
 }
 
 // NewGetter - create new default getter
@@ -49,7 +53,21 @@ func NewGetter(implementer kubernetes.Implementer, defaultDockerConfig DockerCfg
 	return &DefaultGetter{
 		kubernetesImplementer: implementer,
 		defaultDockerConfig:   defaultDockerConfig,
+		clusters:              make(map[string]kubernetes.Implementer),
+	}
+}
+
+// AddCluster - registers implementer of an additional cluster
+func (g *DefaultGetter) AddCluster(name string, implementer kubernetes.Implementer) {
+	g.clusters[name] = implementer
+}
+
+// implementer - returns implementer of the cluster image is tracked in
+func (g *DefaultGetter) implementer(image *types.TrackedImage) kubernetes.Implementer {
+	if implementer, ok := g.clusters[image.Cluster]; ok && image.Cluster != "" {
+		return implementer
 	}
+	return g.kubernetesImplementer
 }
 
 // Get - get secret for tracked image
@@ -84,7 +102,7 @@ func (g *DefaultGetter) lookupSecrets(image *types.TrackedImage) ([]string, erro
 		return secrets, nil
 	}
 
-	podList, err := g.kubernetesImplementer.Pods(image.Namespace, selector)
+	podList, err := g.implementer(image).Pods(image.Namespace, selector)
 	if err != nil {
 		return secrets, err
 	}
@@ -130,7 +148,7 @@ func (g *DefaultGetter) getCredentialsFromSecret(image *types.TrackedImage) (*ty
 	secretFound := false
 
 	for _, secretRef := range image.Secrets {
-		secret, err := g.kubernetesImplementer.Secret(image.Namespace, secretRef)
+		secret, err := g.implementer(image).Secret(image.Namespace, secretRef)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"image":      image.Image.Repository(),
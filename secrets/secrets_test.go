@@ -206,6 +206,43 @@ func TestGetSecretNotFound(t *testing.T) {
 	}
 }
 
+func TestGetSecretFromCluster(t *testing.T) {
+	imgRef, _ := image.Parse("karolisr/webhook-demo:0.0.11")
+
+	local := &testutil.FakeK8sImplementer{
+		Error: fmt.Errorf("secret not found"),
+	}
+	remote := &testutil.FakeK8sImplementer{
+		AvailableSecret: map[string]*v1.Secret{
+			"myregistrysecret": {
+				Data: map[string][]byte{
+					dockerConfigKey: []byte(secretDataPayload),
+				},
+				Type: v1.SecretTypeDockercfg,
+			},
+		},
+	}
+
+	getter := NewGetter(local, nil)
+	getter.AddCluster("remote", remote)
+
+	trackedImage := &types.TrackedImage{
+		Image:     imgRef,
+		Namespace: "default",
+		Secrets:   []string{"myregistrysecret"},
+		Cluster:   "remote",
+	}
+
+	creds, err := getter.Get(trackedImage)
+	if err != nil {
+		t.Fatalf("failed to get creds: %s", err)
+	}
+
+	if creds.Username != "user-x" {
+		t.Errorf("unexpected username: %s", creds.Username)
+	}
+}
+
 var secretDataPayloadEncoded = `{"https://index.docker.io/v1/":{"auth": "%s"}}`
 
 func TestLookupHelmSecret(t *testing.T) {
@@ -2,7 +2,11 @@ package poll
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/keel-hq/keel/provider"
@@ -25,6 +29,9 @@ type DefaultManager struct {
 
 	// root context
 	ctx context.Context
+
+	// running - set once the initial scan is done, cleared when Start returns
+	running int32
 }
 
 // NewPollManager - new default poller
@@ -52,6 +59,9 @@ func (s *DefaultManager) Start(ctx context.Context) error {
 		}).Error("trigger.poll.manager: scan failed")
 	}
 
+	atomic.StoreInt32(&s.running, 1)
+	defer atomic.StoreInt32(&s.running, 0)
+
 	ticker := time.NewTicker(time.Duration(s.scanTick) * time.Second)
 	defer ticker.Stop()
 
@@ -85,3 +95,48 @@ func (s *DefaultManager) scan(ctx context.Context) error {
 
 	return nil
 }
+
+// RunOnce - checks every tracked image once instead of polling them on
+// schedule, returns an error if registry checks failed. Watcher must not be
+// started, otherwise images would be checked again on their schedule
+func (s *DefaultManager) RunOnce(ctx context.Context) error {
+	s.ctx = ctx
+
+	trackedImages, err := s.providers.TrackedImages()
+	if err != nil {
+		return err
+	}
+
+	// new images are checked straight away while being added
+	var errs []string
+	err = s.watcher.Watch(trackedImages...)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	failures := s.watcher.Failures()
+	keys := make([]string, 0, len(failures))
+	for key := range failures {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		errs = append(errs, fmt.Sprintf("%s: %s", key, failures[key]))
+	}
+
+	log.WithFields(log.Fields{
+		"tracked_images": len(trackedImages),
+		"errors":         len(errs),
+	}).Info("trigger.poll.manager: single poll run finished")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("registry checks failed: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Running - reports whether the manager has completed its initial scan and is
+// still running
+func (s *DefaultManager) Running() bool {
+	return atomic.LoadInt32(&s.running) == 1
+}
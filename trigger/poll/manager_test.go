@@ -2,10 +2,12 @@ package poll
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/keel-hq/keel/approvals"
 	"github.com/keel-hq/keel/pkg/store/sql"
@@ -130,6 +132,56 @@ func TestCheckDeployment(t *testing.T) {
 	}
 }
 
+func TestRunOnce(t *testing.T) {
+	imgA, _ := image.Parse("gcr.io/v2-namespace/hello-world:latest")
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{
+				Image:        imgA,
+				Trigger:      types.TriggerTypePoll,
+				Provider:     "fp",
+				PollSchedule: types.KeelPollDefaultSchedule,
+			},
+		},
+	}
+
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+	}
+	watcher := NewRepositoryWatcher(providers, frc)
+	pm := NewPollManager(providers, watcher)
+
+	if err := pm.RunOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(watcher.watched) != 1 {
+		t.Errorf("expected image to be checked, watched: %d", len(watcher.watched))
+	}
+
+	// registry failing for already checked image
+	frc.digestErrToReturn = errors.New("registry unavailable")
+	for _, details := range watcher.watched {
+		NewWatchTagJob(providers, frc, details).Run()
+	}
+	if err := pm.RunOnce(context.Background()); err == nil || !strings.Contains(err.Error(), "registry unavailable") {
+		t.Errorf("expected registry error, got: %v", err)
+	}
+
+	// registry failing for new image
+	failing := NewRepositoryWatcher(providers, frc)
+	if err := NewPollManager(providers, failing).RunOnce(context.Background()); err == nil {
+		t.Errorf("expected registry error")
+	}
+}
+
 // To run this test, set AWS env variables
 // export AWS_ACCESS_KEY_ID=AKIA.........
 // export AWS_ACCESS_KEY=3v..............
@@ -1,14 +1,18 @@
 package poll
 
 import (
+	"errors"
+	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/Masterminds/semver"
-	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/internal/policy"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/policies"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -20,6 +24,7 @@ type WatchRepositoryTagsJob struct {
 	providers      provider.Providers
 	registryClient registry.Client
 	details        *watchDetails
+	noUpdateSender notification.Sender
 
 	// latests map[string]string // a map of prerelease tags and their corresponding latest versions
 }
@@ -50,22 +55,41 @@ func (j *WatchRepositoryTagsJob) Run() {
 		Tag:      j.details.latest,
 	}
 
-	creds, err := credentialshelper.GetCredentials(j.details.trackedImage)
-	if err == nil {
-		registryOpts.Username = creds.Username
-		registryOpts.Password = creds.Password
-	}
-
-	repository, err := j.registryClient.Get(registryOpts)
+	var repository *registry.Repository
+	err := withCredentials(j.details.trackedImage, &registryOpts, func(opts registry.Opts) (err error) {
+		repository, err = j.registryClient.Get(opts)
+		return err
+	})
 
 	if err != nil {
-		log.WithFields(log.Fields{
+		fields := log.Fields{
 			"error":        err,
 			"registry_url": reg,
 			"image":        j.details.trackedImage.Image.String(),
-		}).Error("trigger.poll.WatchRepositoryTagsJob: failed to get repository")
+		}
+		if errors.Is(err, registry.ErrRepositoryNotFound) {
+			log.WithFields(fields).Warn("trigger.poll.WatchRepositoryTagsJob: repository not found")
+		} else {
+			log.WithFields(fields).Error("trigger.poll.WatchRepositoryTagsJob: failed to get repository")
+		}
+		j.details.setLastError(err)
 		return
 	}
+	// repository exists but nothing is pushed yet, watch is kept and tags
+	// are checked again on the next run
+	if len(repository.Tags) == 0 {
+		log.WithFields(log.Fields{
+			"registry_url": reg,
+			"image":        j.details.trackedImage.Image.String(),
+		}).Debug("trigger.poll.WatchRepositoryTagsJob: repository has no tags yet")
+		j.details.setChecked("", j.details.digest)
+		return
+	}
+	var newest string
+	if versions := semverSort(repository.Tags); len(versions) > 0 {
+		newest = versions[0].Original()
+	}
+	j.details.setChecked(newest, j.details.digest)
 
 	registriesScannedCounter.With(prometheus.Labels{"registry": j.details.trackedImage.Image.Registry(), "image": j.details.trackedImage.Image.Repository()}).Inc()
 
@@ -98,10 +122,30 @@ func (j *WatchRepositoryTagsJob) computeEvents(tags []string) ([]types.Event, er
 	versions := semverSort(tags)
 
 	for _, trackedImage := range getRelatedTrackedImages(j.details.trackedImage, trackedImages) {
+		candidates, candidateVersions := tags, versions
+		if len(trackedImage.ExcludeTags) > 0 {
+			candidates = excludeTags(trackedImage.ExcludeTags, tags)
+			candidateVersions = semverSort(candidates)
+		}
+
+		if isSortablePolicy(trackedImage.Policy) {
+			tag, ok := greatestMatchingTag(trackedImage, candidates)
+			if ok && !exists(tag, events) {
+				events = append(events, types.Event{
+					Repository: types.Repository{
+						Name: j.details.trackedImage.Image.Repository(),
+						Tag:  tag,
+					},
+					TriggerName: types.TriggerTypePoll.String(),
+				})
+			}
+			continue
+		}
+
 		// Current version tag might not be a valid semver one
 		currentVersion, invalidCurrentVersion := semver.NewVersion(trackedImage.Image.Tag())
 		// matches, going through tags
-		for _, version := range versions {
+		for _, version := range candidateVersions {
 			if invalidCurrentVersion == nil && (currentVersion.GreaterThan(version) || currentVersion.Equal(version)) {
 				// Current tag is a valid semver, and is bigger than currently tested one
 				// -> we can stop now, nothing will be worth upgrading in the rest of the sorted list
@@ -139,6 +183,39 @@ func (j *WatchRepositoryTagsJob) computeEvents(tags []string) ([]types.Event, er
 	return events, nil
 }
 
+// isSortablePolicy - regexp policies with a capture group order tags by
+// the captured component instead of semver
+func isSortablePolicy(p types.Policy) bool {
+	rp, ok := p.(*policy.RegexpPolicy)
+	return ok && rp.Sortable()
+}
+
+// greatestMatchingTag - goes through all tags (they don't have to be semver)
+// and returns the one policy considers the newest
+func greatestMatchingTag(trackedImage *types.TrackedImage, tags []string) (string, bool) {
+	current := trackedImage.Image.Tag()
+	best := current
+	for _, tag := range tags {
+		update, err := trackedImage.Policy.ShouldUpdate(best, tag)
+		if err != nil || !update {
+			continue
+		}
+		best = tag
+	}
+	return best, best != current
+}
+
+// excludeTags - drops tags matching exclude patterns of the tracked image
+func excludeTags(patterns []string, tags []string) []string {
+	var kept []string
+	for _, tag := range tags {
+		if !policies.IsTagExcluded(patterns, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
 func exists(tag string, events []types.Event) bool {
 	for _, e := range events {
 		if tag == e.Repository.Tag {
@@ -185,6 +262,9 @@ func (j *WatchRepositoryTagsJob) processTags(tags []string) error {
 	if err != nil {
 		return err
 	}
+	if len(events) == 0 {
+		reportNoUpdate(j.noUpdateSender, j.details.trackedImage, fmt.Sprintf("Polled %s, no tag is newer than %s", j.details.trackedImage.Image.Repository(), j.details.trackedImage.Image.Tag()))
+	}
 	for _, e := range events {
 		err = j.providers.Submit(e)
 		if err != nil {
@@ -193,6 +193,59 @@ func TestWatchAllTagsMixedPolicyAll(t *testing.T) {
 	testRunHelper(testCases, availableTags, t)
 }
 
+func mustRegexpPolicy(pattern string) policy.Policy {
+	p, err := policy.NewRegexpPolicy("regexp:" + pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestWatchAllTagsRegexpSortable(t *testing.T) {
+	availableTags := []string{"release-2023.12", "release-2024.01", "release-2024.10", "release-2024.9", "latest", "1.0.0"}
+	testRunHelper([]runTestCase{{"release-2023.12", "release-2024.10", mustRegexpPolicy(`^release-(\d{4}\.\d+)$`)}}, availableTags, t)
+	// current tag doesn't follow the pattern
+	testRunHelper([]runTestCase{{"latest", "release-2024.10", mustRegexpPolicy(`^release-(\d{4}\.\d+)$`)}}, availableTags, t)
+}
+
+func TestWatchAllTagsExcludeTags(t *testing.T) {
+	availableTags := []string{"1.2.3", "1.2.4-debug", "1.3.0-debug", "1.2.5-dev"}
+	for _, tt := range []struct {
+		excludeTags []string
+		expectedTag string
+	}{
+		{nil, "1.3.0-debug"},
+		{[]string{"*-debug"}, "1.2.5-dev"},
+		{[]string{"*-debug", "*-dev"}, "1.2.3"},
+		{[]string{"regexp:-(debug|dev)$"}, "1.2.3"},
+	} {
+		reference, _ := image.Parse("foo/bar:1.2.0")
+		fp := &fakeProvider{
+			images: []*types.TrackedImage{{
+				Image:       reference,
+				Policy:      policy.NewSemverPolicy(policy.SemverPolicyTypeAll, false),
+				ExcludeTags: tt.excludeTags,
+			}},
+		}
+		store, teardown := newTestingUtils()
+		am := approvals.New(&approvals.Opts{
+			Store: store,
+		})
+		providers := provider.New([]provider.Provider{fp}, am)
+
+		job := NewWatchRepositoryTagsJob(providers, &fakeRegistryClient{tagsToReturn: availableTags}, &watchDetails{trackedImage: fp.images[0]})
+		job.Run()
+		teardown()
+
+		if len(fp.submitted) != 1 {
+			t.Fatalf("exclude %v: expected 1 event, got: %d", tt.excludeTags, len(fp.submitted))
+		}
+		if fp.submitted[0].Repository.Tag != tt.expectedTag {
+			t.Errorf("exclude %v: expected tag %s, got: %s", tt.excludeTags, tt.expectedTag, fp.submitted[0].Repository.Tag)
+		}
+	}
+}
+
 func Test_semverSort(t *testing.T) {
 	tags := []string{"1.3.0", "aa1.0.0", "zzz", "1.3.0-dev", "1.5.0", "2.0.0-alpha", "1.3.0-dev1", "1.8.0-alpha", "1.3.1-dev", "123", "1.2.3-rc.1.2+meta"}
 	expectedTags := []string{"2.0.0-alpha", "1.8.0-alpha", "1.5.0", "1.3.1-dev", "1.3.0", "1.3.0-dev1", "1.3.0-dev", "1.2.3-rc.1.2+meta"}
@@ -0,0 +1,50 @@
+package poll
+
+import (
+	"time"
+
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var pollNoUpdateCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "keel_poll_no_update_total",
+		Help: "How many polls found nothing to update, partitioned by image.",
+	},
+	[]string{"image"},
+)
+
+func init() {
+	prometheus.MustRegister(pollNoUpdateCounter)
+}
+
+// SetNoUpdateSender - enables debug level notifications sent when a poll
+// finds nothing to update, helps to tell apart Keel not running from images
+// that are already up to date. Should be called before the watcher is started.
+func (w *RepositoryWatcher) SetNoUpdateSender(sender notification.Sender) {
+	w.noUpdateSender = sender
+}
+
+// reportNoUpdate - counts poll that found nothing to update and notifies about
+// it when sender is set
+func reportNoUpdate(sender notification.Sender, trackedImage *types.TrackedImage, message string) {
+	pollNoUpdateCounter.With(prometheus.Labels{"image": trackedImage.Image.Repository()}).Inc()
+
+	if sender == nil {
+		return
+	}
+	sender.Send(types.EventNotification{
+		Name:      "poll found no update",
+		Message:   message,
+		CreatedAt: time.Now(),
+		Type:      types.NotificationSystemEvent,
+		Level:     types.LevelDebug,
+		Metadata: map[string]string{
+			"image":   trackedImage.Image.Remote(),
+			"trigger": types.TriggerTypePoll.String(),
+		},
+	})
+}
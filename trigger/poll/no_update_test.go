@@ -0,0 +1,112 @@
+package poll
+
+import (
+	"testing"
+
+	"github.com/keel-hq/keel/approvals"
+	"github.com/keel-hq/keel/extension/notification"
+	"github.com/keel-hq/keel/internal/policy"
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+	"github.com/keel-hq/keel/util/image"
+)
+
+type fakeSender struct {
+	sent []types.EventNotification
+}
+
+func (s *fakeSender) Configure(*notification.Config) (bool, error) {
+	return true, nil
+}
+
+func (s *fakeSender) Send(event types.EventNotification) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func TestWatchTagJobNoUpdate(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:123123123",
+	}
+
+	reference, _ := image.Parse("foo/bar:1.1")
+
+	details := &watchDetails{
+		trackedImage: &types.TrackedImage{
+			Image: reference,
+		},
+		digest: "sha256:123123123",
+	}
+
+	job := NewWatchTagJob(providers, frc, details)
+	// sender not set, nothing should be sent
+	job.Run()
+
+	fs := &fakeSender{}
+	job.noUpdateSender = fs
+	job.Run()
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("didn't expect any events, got: %d", len(fp.submitted))
+	}
+	if len(fs.sent) != 1 {
+		t.Fatalf("expected 1 notification, got: %d", len(fs.sent))
+	}
+	if fs.sent[0].Level != types.LevelDebug {
+		t.Errorf("unexpected notification level: %s", fs.sent[0].Level)
+	}
+	if fs.sent[0].Metadata["image"] != "index.docker.io/foo/bar:1.1" {
+		t.Errorf("unexpected image: %s", fs.sent[0].Metadata["image"])
+	}
+}
+
+func TestWatchAllTagsJobNoUpdate(t *testing.T) {
+	reference, _ := image.Parse("foo/bar:1.1.3")
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{
+				Image:  reference,
+				Policy: policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true),
+			},
+		},
+	}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		tagsToReturn: []string{"1.1.2", "1.1.3", "0.9.1"},
+	}
+
+	details := &watchDetails{
+		trackedImage: fp.images[0],
+	}
+
+	fs := &fakeSender{}
+	job := NewWatchRepositoryTagsJob(providers, frc, details)
+	job.noUpdateSender = fs
+	job.Run()
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("didn't expect any events, got: %d", len(fp.submitted))
+	}
+	if len(fs.sent) != 1 {
+		t.Fatalf("expected 1 notification, got: %d", len(fs.sent))
+	}
+	if fs.sent[0].Name != "poll found no update" {
+		t.Errorf("unexpected notification: %s", fs.sent[0].Name)
+	}
+}
@@ -1,7 +1,9 @@
 package poll
 
 import (
-	"github.com/keel-hq/keel/extension/credentialshelper"
+	"fmt"
+
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
@@ -14,8 +16,16 @@ type WatchTagJob struct {
 	providers      provider.Providers
 	registryClient registry.Client
 	details        *watchDetails
+	noUpdateSender notification.Sender
+
+	// resolveVersion - look up semver tag sharing the new digest
+	resolveVersion bool
 }
 
+// maxVersionLookups - how many of the newest semver tags are checked when
+// resolving version of a floating tag
+const maxVersionLookups = 10
+
 // NewWatchTagJob - new watch tag job monitors specific tag by checking digest based on specified
 // cron style schedule
 func NewWatchTagJob(providers provider.Providers, registryClient registry.Client, details *watchDetails) *WatchTagJob {
@@ -28,65 +38,122 @@ func NewWatchTagJob(providers provider.Providers, registryClient registry.Client
 
 // Run - main function to check schedule
 func (j *WatchTagJob) Run() {
-	reg := j.details.trackedImage.Image.Scheme() + "://" + j.details.trackedImage.Image.Registry()
+	// tracked image can be replaced by the watcher while the job is running
+	j.details.mu.RLock()
+	trackedImage := j.details.trackedImage
+	lastDigest := j.details.digest
+	j.details.mu.RUnlock()
+
+	reg := trackedImage.Image.Scheme() + "://" + trackedImage.Image.Registry()
 	registryOpts := registry.Opts{
 		Registry: reg,
-		Name:     j.details.trackedImage.Image.ShortName(),
-		Tag:      j.details.trackedImage.Image.Tag(),
-	}
-
-	creds, err := credentialshelper.GetCredentials(j.details.trackedImage)
-	if err == nil {
-		registryOpts.Username = creds.Username
-		registryOpts.Password = creds.Password
+		Name:     trackedImage.Image.ShortName(),
+		Tag:      trackedImage.Image.Tag(),
 	}
 
-	currentDigest, err := j.registryClient.Digest(registryOpts)
+	var currentDigest string
+	err := withCredentials(trackedImage, &registryOpts, func(opts registry.Opts) (err error) {
+		currentDigest, err = j.registryClient.Digest(opts)
+		return err
+	})
 
-	registriesScannedCounter.With(prometheus.Labels{"registry": j.details.trackedImage.Image.Registry(), "image": j.details.trackedImage.Image.Repository()}).Inc()
+	registriesScannedCounter.With(prometheus.Labels{"registry": trackedImage.Image.Registry(), "image": trackedImage.Image.Repository()}).Inc()
 
 	if err != nil {
-		log.WithFields(log.Fields{
+		fields := log.Fields{
 			"error": err,
-			"image": j.details.trackedImage.Image.String(),
-		}).Error("trigger.poll.WatchTagJob: failed to check digest")
+			"image": trackedImage.Image.String(),
+		}
+		if lastDigest == "" {
+			// image was added while its repository had no tags, tag isn't
+			// pushed yet
+			log.WithFields(fields).Debug("trigger.poll.WatchTagJob: tag not found yet")
+		} else {
+			log.WithFields(fields).Error("trigger.poll.WatchTagJob: failed to check digest")
+		}
+		j.details.setLastError(err)
 		return
 	}
+	j.details.setChecked(trackedImage.Image.Tag(), currentDigest)
 
 	log.WithFields(log.Fields{
-		"current_digest": j.details.digest,
+		"current_digest": lastDigest,
 		"new_digest":     currentDigest,
 		"registry_url":   reg,
-		"image":          j.details.trackedImage.Image.String(),
+		"image":          trackedImage.Image.String(),
 	}).Debug("trigger.poll.WatchTagJob: checking digest")
 
-	// checking whether image digest has changed
-	if j.details.digest != currentDigest {
+	// checking whether image digest has changed, tag stays the same so
+	// providers restart workloads that follow it (force policy)
+	if lastDigest != currentDigest {
 		// updating digest
+		j.details.mu.Lock()
 		j.details.digest = currentDigest
+		j.details.mu.Unlock()
 
 		event := types.Event{
 			Repository: types.Repository{
-				Name:   j.details.trackedImage.Image.Repository(),
-				Tag:    j.details.trackedImage.Image.Tag(),
+				Name:   trackedImage.Image.Repository(),
+				Tag:    trackedImage.Image.Tag(),
 				Digest: currentDigest,
 			},
 			TriggerName: types.TriggerTypePoll.String(),
 		}
+		if j.resolveVersion {
+			event.Repository.Version = j.findVersion(registryOpts, currentDigest)
+		}
 		log.WithFields(log.Fields{
-			"image":      j.details.trackedImage.Image.String(),
+			"image":      trackedImage.Image.String(),
 			"new_digest": currentDigest,
+			"version":    event.Repository.Version,
 		}).Info("trigger.poll.WatchTagJob: digest change detected, submiting event to providers")
 
 		// j.providers.Submit(event)
 		err := j.providers.Submit(event)
 		if err != nil {
 			log.WithFields(log.Fields{
-				"repository": j.details.trackedImage.Image.Repository(),
+				"repository": trackedImage.Image.Repository(),
 				"digest":     currentDigest,
 				"error":      err,
 			}).Error("trigger.poll.WatchRepositoryTagsJob: error while submitting an event")
 		}
+		return
+	}
 
+	reportNoUpdate(j.noUpdateSender, trackedImage, fmt.Sprintf("Polled %s, digest is unchanged (%s)", trackedImage.Image.Remote(), currentDigest))
+}
+
+// findVersion - returns the newest semver tag pointing to the digest, empty if
+// none of the newest tags do
+func (j *WatchTagJob) findVersion(opts registry.Opts, digest string) string {
+	repository, err := j.registryClient.Get(opts)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"image": opts.Name,
+		}).Warn("trigger.poll.WatchTagJob: failed to list tags to resolve version")
+		return ""
+	}
+
+	versions := semverSort(repository.Tags)
+	if len(versions) > maxVersionLookups {
+		versions = versions[:maxVersionLookups]
+	}
+	for _, v := range versions {
+		tagOpts := opts
+		tagOpts.Tag = v.Original()
+		tagDigest, err := j.registryClient.Digest(tagOpts)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"image": opts.Name,
+				"tag":   tagOpts.Tag,
+			}).Debug("trigger.poll.WatchTagJob: failed to get tag digest while resolving version")
+			continue
+		}
+		if tagDigest == digest {
+			return tagOpts.Tag
+		}
 	}
+	return ""
 }
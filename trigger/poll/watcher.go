@@ -2,11 +2,15 @@ package poll
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/keel-hq/keel/extension/credentialshelper"
+	"github.com/keel-hq/keel/extension/notification"
 	"github.com/keel-hq/keel/provider"
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
@@ -43,6 +47,11 @@ func init() {
 type Watcher interface {
 	Watch(image ...*types.TrackedImage) error
 	Unwatch(image string) error
+	// Failures - registry errors of the last check, keyed by watched image
+	Failures() map[string]error
+	// Status - result of the last registry check of the image, false if
+	// image isn't watched
+	Status(image *types.TrackedImage) (ImageStatus, bool)
 }
 
 type watchDetails struct {
@@ -52,8 +61,61 @@ type watchDetails struct {
 	schedule     string
 
 	mu sync.RWMutex
+
+	// status - result of the last registry check, guarded by statusMu as
+	// jobs hold mu while checking
+	status   ImageStatus
+	statusMu sync.Mutex
+}
+
+// ImageStatus - result of the last registry check of a watched image
+type ImageStatus struct {
+	// Tag - last tag seen, for images watched for all tags it's the newest
+	// semver tag in the repository
+	Tag string
+	// Digest - last digest seen, images watched for all tags only check it
+	// when the watch is added
+	Digest string
+	// CheckedAt - time of the last successful check
+	CheckedAt time.Time
+	// Error - error of the last check, cleared once a check succeeds
+	Error error
+}
+
+// setChecked - records successful registry check
+func (d *watchDetails) setChecked(tag, digest string) {
+	d.statusMu.Lock()
+	d.status = ImageStatus{Tag: tag, Digest: digest, CheckedAt: time.Now()}
+	d.statusMu.Unlock()
+}
+
+func (d *watchDetails) setLastError(err error) {
+	d.statusMu.Lock()
+	d.status.Error = err
+	d.statusMu.Unlock()
+}
+
+func (d *watchDetails) lastError() error {
+	return d.lastStatus().Error
+}
+
+func (d *watchDetails) lastStatus() ImageStatus {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	return d.status
 }
 
+// DefaultConcurrency - default number of registry checks that can run at the same time
+const DefaultConcurrency = 10
+
+// DefaultJitter - default fraction of the poll interval that job runs are
+// spread across
+const DefaultJitter = 0.5
+
+// maxJitterOffset - upper bound of the job run delay so jobs with long
+// intervals aren't postponed by hours
+const maxJitterOffset = 5 * time.Minute
+
 // RepositoryWatcher - repository watcher cron
 type RepositoryWatcher struct {
 	providers provider.Providers
@@ -64,6 +126,19 @@ type RepositoryWatcher struct {
 	// internal map of internal watches
 	// map[registry/name]=image.Reference
 	watched map[string]*watchDetails
+	mu      sync.Mutex
+
+	// workers - semaphore bounding concurrent registry checks
+	workers chan struct{}
+
+	// jitter - fraction of the interval job runs are delayed by at most
+	jitter float64
+
+	// noUpdateSender - optional, notified when poll finds nothing to update
+	noUpdateSender notification.Sender
+
+	// resolveVersions - look up semver tags sharing digest of floating tags
+	resolveVersions bool
 
 	cron *cron.Cron
 }
@@ -76,10 +151,52 @@ func NewRepositoryWatcher(providers provider.Providers, registryClient registry.
 		providers:      providers,
 		registryClient: registryClient,
 		watched:        make(map[string]*watchDetails),
+		workers:        make(chan struct{}, DefaultConcurrency),
+		jitter:         DefaultJitter,
 		cron:           c,
 	}
 }
 
+// SetConcurrency - sets how many registry checks can run at the same time,
+// should be called before the watcher is started
+func (w *RepositoryWatcher) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	w.workers = make(chan struct{}, concurrency)
+}
+
+// SetJitter - sets fraction (0-1) of the poll interval that job runs are spread
+// across, 0 disables it. Should be called before the watcher is started
+func (w *RepositoryWatcher) SetJitter(jitter float64) {
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	w.jitter = jitter
+}
+
+// SetResolveVersions - when enabled, digest changes of floating tags (i.e.
+// latest) are reported with the semver tag that shares the new digest. Costs
+// extra registry requests, should be called before the watcher is started
+func (w *RepositoryWatcher) SetResolveVersions(resolve bool) {
+	w.resolveVersions = resolve
+}
+
+// ParseSchedule - validates poll schedule, plain Go durations such as "5m" are
+// accepted as a shorthand for "@every 5m"
+func ParseSchedule(schedule string) (string, error) {
+	if d, err := time.ParseDuration(schedule); err == nil && d > 0 {
+		schedule = "@every " + d.String()
+	}
+	if _, err := cron.Parse(schedule); err != nil {
+		return "", err
+	}
+	return schedule, nil
+}
+
 // Start - starts repository watcher
 func (w *RepositoryWatcher) Start(ctx context.Context) {
 	// starting cron job
@@ -111,6 +228,8 @@ func (w *RepositoryWatcher) Unwatch(imageName string) error {
 		return err
 	}
 	key := getImageIdentifier(imageRef, false)
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	_, ok := w.watched[key]
 	if ok {
 		w.cron.DeleteJob(key)
@@ -124,20 +243,50 @@ func (w *RepositoryWatcher) Unwatch(imageName string) error {
 // if details changed - updates details
 func (w *RepositoryWatcher) Watch(images ...*types.TrackedImage) error {
 
-	var errs []string
-	tracked := map[string]bool{}
-
+	// images sharing a watch key are handled by the same worker, in order,
+	// so only the first one of them adds the job
+	var keys []string
+	grouped := map[string][]*types.TrackedImage{}
 	for _, image := range images {
-		if image.Trigger != types.TriggerTypePoll {
+		if !image.Trigger.Polls() {
 			continue
 		}
-		identifier, err := w.watch(image)
-		if err != nil {
-			errs = append(errs, err.Error())
-			continue
+		keepTag := image.Policy != nil && image.Policy.Name() == "force"
+		key := getImageIdentifier(image.Image, keepTag)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
 		}
-		tracked[identifier] = true
+		grouped[key] = append(grouped[key], image)
+	}
+
+	var (
+		errs    []string
+		tracked = map[string]bool{}
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	for _, key := range keys {
+		wg.Add(1)
+		go func(images []*types.TrackedImage) {
+			defer wg.Done()
+
+			w.workers <- struct{}{}
+			defer func() { <-w.workers }()
+
+			for _, image := range images {
+				identifier, err := w.watch(image)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err.Error())
+				} else {
+					tracked[identifier] = true
+				}
+				mu.Unlock()
+			}
+		}(grouped[key])
 	}
+	wg.Wait()
 
 	pollTriggerTrackedImages.Set(float64(len(tracked)))
 
@@ -153,14 +302,47 @@ func (w *RepositoryWatcher) Watch(images ...*types.TrackedImage) error {
 	return nil
 }
 
+// Failures - returns images which registry check failed the last time they
+// were checked
+func (w *RepositoryWatcher) Failures() map[string]error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	failures := make(map[string]error)
+	for key, details := range w.watched {
+		if err := details.lastError(); err != nil {
+			failures[key] = err
+		}
+	}
+	return failures
+}
+
+// Status - returns result of the last registry check of the image, false if
+// image isn't watched (i.e. it's not tracked by poll trigger)
+func (w *RepositoryWatcher) Status(image *types.TrackedImage) (ImageStatus, bool) {
+	keepTag := image.Policy != nil && image.Policy.Name() == "force"
+	key := getImageIdentifier(image.Image, keepTag)
+
+	w.mu.Lock()
+	details, ok := w.watched[key]
+	w.mu.Unlock()
+	if !ok {
+		return ImageStatus{}, false
+	}
+	return details.lastStatus(), true
+}
+
 func (w *RepositoryWatcher) unwatch(tracked map[string]bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	for key, details := range w.watched {
 		if !tracked[key] {
+			details.mu.RLock()
 			log.WithFields(log.Fields{
 				"job_name": key,
 				"image":    details.trackedImage.String(),
 				"schedule": details.schedule,
 			}).Info("trigger.poll.RepositoryWatcher: image no tracked anymore, removing watcher")
+			details.mu.RUnlock()
 			w.cron.DeleteJob(key)
 			delete(w.watched, key)
 		}
@@ -187,7 +369,9 @@ func (w *RepositoryWatcher) watch(image *types.TrackedImage) (string, error) {
 	key := getImageIdentifier(image.Image, keepTag)
 
 	// checking whether it's already being watched
+	w.mu.Lock()
 	details, ok := w.watched[key]
+	w.mu.Unlock()
 	if !ok {
 		// err = w.addJob(imageRef, registryUsername, registryPassword, schedule)
 		err = w.addJob(image, image.PollSchedule)
@@ -202,13 +386,22 @@ func (w *RepositoryWatcher) watch(image *types.TrackedImage) (string, error) {
 	}
 
 	// checking schedule
-	if details.schedule != image.PollSchedule {
+	details.mu.RLock()
+	schedule := details.schedule
+	details.mu.RUnlock()
+	if schedule != image.PollSchedule {
+		w.mu.Lock()
 		err := w.cron.UpdateJob(key, image.PollSchedule)
+		w.mu.Unlock()
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
 				"image": image.String(),
 			}).Error("trigger.poll.RepositoryWatcher.Watch: failed to update image watch job")
+		} else {
+			details.mu.Lock()
+			details.schedule = image.PollSchedule
+			details.mu.Unlock()
 		}
 	}
 
@@ -232,21 +425,26 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 		Tag:      ti.Image.Tag(),
 	}
 
-	creds, err := credentialshelper.GetCredentials(ti)
-	if err == nil {
-		registryOpts.Username = creds.Username
-		registryOpts.Password = creds.Password
-	}
-
-	digest, err := w.registryClient.Digest(registryOpts)
+	var digest string
+	err := withCredentials(ti, &registryOpts, func(opts registry.Opts) (err error) {
+		digest, err = w.registryClient.Digest(opts)
+		return err
+	})
 	if err != nil {
+		if !errors.Is(err, registry.ErrTagNotFound) || !w.emptyRepository(registryOpts) {
+			log.WithFields(log.Fields{
+				"error":    err,
+				"image":    ti.Image.String(),
+				"username": registryOpts.Username,
+				"password": strings.Repeat("*", len(registryOpts.Password)),
+			}).Error("trigger.poll.RepositoryWatcher.addJob: failed to get image digest")
+			return err
+		}
+		// watching with an empty digest, the first pushed tag is picked up
+		// by the job
 		log.WithFields(log.Fields{
-			"error":    err,
-			"image":    ti.Image.String(),
-			"username": registryOpts.Username,
-			"password": strings.Repeat("*", len(registryOpts.Password)),
-		}).Error("trigger.poll.RepositoryWatcher.addJob: failed to get image digest")
-		return err
+			"image": ti.Image.String(),
+		}).Debug("trigger.poll.RepositoryWatcher.addJob: repository has no tags yet")
 	}
 
 	keepTag := ti.Policy != nil && ti.Policy.Name() == "force"
@@ -259,7 +457,9 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 	}
 
 	// adding job to internal map
+	w.mu.Lock()
 	w.watched[key] = details
+	w.mu.Unlock()
 
 	// checking tag type:
 	//  - for versioned (semver) tags:
@@ -268,10 +468,13 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 	//      setup, which checks digest
 	//  - for non-semver types we create a single tag watcher which
 	// checks digest
+	//  - for regexp policies with a capture group we always watch all tags
 	_, err = version.GetVersion(ti.Image.Tag())
-	if err != nil || keepTag == true {
+	if (err != nil && !isSortablePolicy(ti.Policy)) || keepTag == true {
 		// adding new job
 		job := NewWatchTagJob(w.providers, w.registryClient, details)
+		job.noUpdateSender = w.noUpdateSender
+		job.resolveVersion = w.resolveVersions
 		log.WithFields(log.Fields{
 			"job_name": key,
 			"image":    ti.Image.String(),
@@ -282,11 +485,12 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 		// running it now
 		job.Run()
 
-		return w.cron.AddJob(key, schedule, job)
+		return w.addCronJob(key, details, job)
 	}
 
 	// adding new job
 	job := NewWatchRepositoryTagsJob(w.providers, w.registryClient, details)
+	job.noUpdateSender = w.noUpdateSender
 	log.WithFields(log.Fields{
 		"job_name": key,
 		"image":    ti.Image.String(),
@@ -297,6 +501,100 @@ func (w *RepositoryWatcher) addJob(ti *types.TrackedImage, schedule string) erro
 	// running it now
 	job.Run()
 
-	return w.cron.AddJob(key, schedule, job)
+	return w.addCronJob(key, details, job)
+}
+
+// withCredentials - runs the registry request with credentials from the
+// credentials helpers. When the registry rejects them, cached credentials
+// are dropped and the request is retried once, ECR tokens expire
+func withCredentials(ti *types.TrackedImage, opts *registry.Opts, request func(opts registry.Opts) error) error {
+	setCredentials(ti, opts)
+	err := request(*opts)
+	if !registry.IsUnauthorized(err) {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"image": ti.Image.String(),
+	}).Debug("trigger.poll: registry rejected credentials, refreshing them")
+	credentialshelper.InvalidateCredentials(ti)
+	setCredentials(ti, opts)
+	return request(*opts)
+}
+
+func setCredentials(ti *types.TrackedImage, opts *registry.Opts) {
+	creds, err := credentialshelper.GetCredentials(ti)
+	if err != nil {
+		return
+	}
+	opts.Username = creds.Username
+	opts.Password = creds.Password
+}
+
+// emptyRepository - checks whether the repository exists but has no tags,
+// i.e. it was just created. Missing repositories aren't considered empty
+func (w *RepositoryWatcher) emptyRepository(opts registry.Opts) bool {
+	repository, err := w.registryClient.Get(opts)
+	return err == nil && len(repository.Tags) == 0
+}
+
+// addCronJob - schedules the job, cron isn't safe for concurrent use
+// before it's started so access is serialised
+func (w *RepositoryWatcher) addCronJob(key string, details *watchDetails, job cron.Job) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cron.AddJob(key, details.schedule, &boundedJob{
+		key:     key,
+		details: details,
+		workers: w.workers,
+		jitter:  w.jitter,
+		job:     job,
+	})
+}
+
+// boundedJob - waits for a free worker before running the job so scheduled
+// checks share the concurrency limit with the initial ones
+type boundedJob struct {
+	key     string
+	details *watchDetails
+	workers chan struct{}
+	jitter  float64
+	job     cron.Job
+}
+
+// Run - runs the job once a worker is available. Runs are delayed by a stable
+// per job offset so jobs sharing a schedule don't all hit registries at the
+// start of the interval. The delay happens before taking a worker so waiting
+// jobs don't hold back others
+func (j *boundedJob) Run() {
+	time.Sleep(j.offset())
+
+	j.workers <- struct{}{}
+	defer func() { <-j.workers }()
+	j.job.Run()
+}
+
+// offset - position of the job within the jitter fraction of its interval,
+// derived from the job key so it's stable and evenly spread across jobs
+func (j *boundedJob) offset() time.Duration {
+	j.details.mu.RLock()
+	schedule := j.details.schedule
+	j.details.mu.RUnlock()
+
+	sched, err := cron.Parse(schedule)
+	if err != nil {
+		return 0
+	}
+	next := sched.Next(time.Now())
+	window := time.Duration(float64(sched.Next(next).Sub(next)) * j.jitter)
+	if window > maxJitterOffset {
+		window = maxJitterOffset
+	}
+	if window <= 0 {
+		return 0
+	}
 
+	h := fnv.New64a()
+	h.Write([]byte(j.key))
+	return time.Duration(h.Sum64() % uint64(window))
 }
@@ -3,8 +3,13 @@ package poll
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/keel-hq/keel/approvals"
 	// "github.com/keel-hq/keel/cache/memory"
@@ -14,6 +19,8 @@ import (
 	"github.com/keel-hq/keel/registry"
 	"github.com/keel-hq/keel/types"
 	"github.com/keel-hq/keel/util/image"
+
+	dockerregistry "github.com/rusenask/docker-registry-client/registry"
 )
 
 func mustParse(img string, schedule string) *types.TrackedImage {
@@ -37,10 +44,15 @@ type fakeRegistryClient struct {
 	digestErrToReturn error
 
 	tagsToReturn []string
+
+	getErrToReturn error
 }
 
 func (c *fakeRegistryClient) Get(opts registry.Opts) (*registry.Repository, error) {
 	c.opts = opts
+	if c.getErrToReturn != nil {
+		return nil, c.getErrToReturn
+	}
 	return &registry.Repository{
 		Name: opts.Name,
 		Tags: c.tagsToReturn,
@@ -168,6 +180,162 @@ func TestWatchTagJobForce(t *testing.T) {
 	}
 }
 
+// tagDigestsRegistryClient - returns digest per tag
+type tagDigestsRegistryClient struct {
+	digests map[string]string
+}
+
+func (c *tagDigestsRegistryClient) Get(opts registry.Opts) (*registry.Repository, error) {
+	repo := &registry.Repository{Name: opts.Name}
+	for tag := range c.digests {
+		repo.Tags = append(repo.Tags, tag)
+	}
+	return repo, nil
+}
+
+func (c *tagDigestsRegistryClient) Digest(opts registry.Opts) (string, error) {
+	return c.digests[opts.Tag], nil
+}
+
+func TestWatchTagJobResolveVersion(t *testing.T) {
+	newDigest := "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"
+	tests := []struct {
+		name    string
+		resolve bool
+		digests map[string]string
+		want    string
+	}{
+		{
+			name:    "disabled",
+			digests: map[string]string{"latest": newDigest, "1.2.0": newDigest},
+		},
+		{
+			name:    "matching tag",
+			resolve: true,
+			digests: map[string]string{"latest": newDigest, "1.1.0": "sha256:111", "1.2.0": newDigest, "1.3.0-rc": "sha256:333"},
+			want:    "1.2.0",
+		},
+		{
+			name:    "no matching tag",
+			resolve: true,
+			digests: map[string]string{"latest": newDigest, "1.1.0": "sha256:111"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			store, teardown := newTestingUtils()
+			defer teardown()
+			am := approvals.New(&approvals.Opts{
+				Store: store,
+			})
+			providers := provider.New([]provider.Provider{fp}, am)
+
+			reference, _ := image.Parse("foo/bar:latest")
+			job := NewWatchTagJob(providers, &tagDigestsRegistryClient{digests: tt.digests}, &watchDetails{
+				trackedImage: &types.TrackedImage{Image: reference},
+				digest:       "sha256:123123123",
+			})
+			job.resolveVersion = tt.resolve
+			job.Run()
+
+			if len(fp.submitted) != 1 {
+				t.Fatalf("expected event to be submitted, got: %d", len(fp.submitted))
+			}
+			if fp.submitted[0].Repository.Version != tt.want {
+				t.Errorf("expected version %q, got: %q", tt.want, fp.submitted[0].Repository.Version)
+			}
+		})
+	}
+}
+
+func TestWatchTagJobDigestUnchanged(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+	}
+
+	reference, _ := image.Parse("foo/bar:1.1")
+	job := NewWatchTagJob(providers, frc, &watchDetails{
+		trackedImage: &types.TrackedImage{
+			Image: reference,
+		},
+		digest: frc.digestToReturn,
+	})
+
+	job.Run()
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("didn't expect events without digest change, got: %d", len(fp.submitted))
+	}
+
+	frc.digestToReturn = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	job.Run()
+	job.Run()
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected single event after digest change, got: %d", len(fp.submitted))
+	}
+	if fp.submitted[0].Repository.Tag != "1.1" || fp.submitted[0].Repository.Digest != frc.digestToReturn {
+		t.Errorf("unexpected event: %+v", fp.submitted[0].Repository)
+	}
+}
+
+func TestWatchForceAndSemverSeparately(t *testing.T) {
+	forced, _ := image.Parse("gcr.io/v2-namespace/hello-world:1.1.1")
+	versioned, _ := image.Parse("gcr.io/v2-namespace/hello-world:1.1.1")
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			{
+				Image:        forced,
+				Trigger:      types.TriggerTypePoll,
+				Provider:     "fp",
+				PollSchedule: types.KeelPollDefaultSchedule,
+				Policy:       policy.NewForcePolicy(true),
+			},
+			{
+				Image:        versioned,
+				Trigger:      types.TriggerTypePoll,
+				Provider:     "fp",
+				PollSchedule: types.KeelPollDefaultSchedule,
+				Policy:       policy.NewSemverPolicy(policy.SemverPolicyTypeMinor, true),
+			},
+		},
+	}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"1.1.1"},
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+	if err := watcher.Watch(fp.images...); err != nil {
+		t.Fatalf("failed to watch images: %s", err)
+	}
+
+	if _, ok := watcher.watched["gcr.io/v2-namespace/hello-world:1.1.1"]; !ok {
+		t.Errorf("digest watcher for forced tag not found")
+	}
+	if _, ok := watcher.watched["gcr.io/v2-namespace/hello-world"]; !ok {
+		t.Errorf("tags watcher for semver policy not found")
+	}
+}
+
 func TestWatchTagJobLatest(t *testing.T) {
 
 	fp := &fakeProvider{}
@@ -463,6 +631,86 @@ func TestWatchTagJobCheckCredentials(t *testing.T) {
 	}
 }
 
+// expiringCredentialsHelper - caches a token that the registry rejects
+// until it's invalidated
+type expiringCredentialsHelper struct {
+	invalidated int
+}
+
+func (h *expiringCredentialsHelper) GetCredentials(image *types.TrackedImage) (*types.Credentials, error) {
+	if h.invalidated > 0 {
+		return &types.Credentials{Username: "AWS", Password: "fresh"}, nil
+	}
+	return &types.Credentials{Username: "AWS", Password: "expired"}, nil
+}
+
+func (h *expiringCredentialsHelper) IsEnabled() bool { return true }
+
+func (h *expiringCredentialsHelper) InvalidateCredentials(image *types.TrackedImage) {
+	h.invalidated++
+}
+
+// authRegistryClient - rejects requests with expired credentials
+type authRegistryClient struct {
+	fakeRegistryClient
+	requests int
+}
+
+func (c *authRegistryClient) Digest(opts registry.Opts) (string, error) {
+	c.requests++
+	c.opts = opts
+	if opts.Password == "expired" {
+		return "", &dockerregistry.HttpStatusError{Response: &http.Response{StatusCode: http.StatusUnauthorized}}
+	}
+	return c.digestToReturn, nil
+}
+
+func TestWatchTagJobRefreshesRejectedCredentials(t *testing.T) {
+	helper := &expiringCredentialsHelper{}
+	credentialshelper.RegisterCredentialsHelper("expiring", helper)
+	defer credentialshelper.UnregisterCredentialsHelper("expiring")
+
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &authRegistryClient{
+		fakeRegistryClient: fakeRegistryClient{
+			digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		},
+	}
+
+	reference, _ := image.Parse("528670773427.dkr.ecr.us-east-2.amazonaws.com/foo/bar:1.1")
+	details := &watchDetails{
+		trackedImage: &types.TrackedImage{
+			Image: reference,
+		},
+		digest: "sha256:123123123",
+	}
+
+	NewWatchTagJob(providers, frc, details).Run()
+
+	if helper.invalidated != 1 {
+		t.Errorf("expected credentials to be invalidated once, got: %d", helper.invalidated)
+	}
+	if frc.requests != 2 {
+		t.Errorf("expected request to be retried once, got %d requests", frc.requests)
+	}
+	if frc.opts.Password != "fresh" {
+		t.Errorf("expected retry with refreshed credentials, got: %s", frc.opts.Password)
+	}
+	if details.lastError() != nil {
+		t.Errorf("unexpected error: %s", details.lastError())
+	}
+	if details.digest != frc.digestToReturn {
+		t.Errorf("expected digest to be updated, got: %s", details.digest)
+	}
+}
+
 func TestWatchWithAuthenticationError(t *testing.T) {
 
 	fakeHelper := &fakeCredentialsHelper{
@@ -628,3 +876,377 @@ func TestUnwatchAfterNotTrackedAnymore(t *testing.T) {
 		t.Errorf("expected to find watching 3 entries, found: %d", len(watcher.watched))
 	}
 }
+
+func TestWatchScheduleUpdated(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"5.0.0"},
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.Start(ctx)
+
+	watcher.Watch(mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 10m"))
+
+	det, ok := watcher.watched["gcr.io/v2-namespace/hello-world"]
+	if !ok {
+		t.Fatalf("watcher not found")
+	}
+	if det.schedule != "@every 10m" {
+		t.Errorf("unexpected schedule: %s", det.schedule)
+	}
+
+	watcher.Watch(mustParse("gcr.io/v2-namespace/hello-world:1.1.1", "@every 1h"))
+
+	if len(watcher.watched) != 1 {
+		t.Errorf("expected to find watching 1 entry, found: %d", len(watcher.watched))
+	}
+	if det.schedule != "@every 1h" {
+		t.Errorf("expected schedule to be updated, got: %s", det.schedule)
+	}
+}
+
+// slowRegistryClient - tracks how many digest requests are in flight at once
+type slowRegistryClient struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *slowRegistryClient) Get(opts registry.Opts) (*registry.Repository, error) {
+	return &registry.Repository{Name: opts.Name}, nil
+}
+
+func (c *slowRegistryClient) Digest(opts registry.Opts) (string, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	if strings.Contains(opts.Name, "broken") {
+		return "", errors.New("registry unavailable")
+	}
+	return "sha256:aaa", nil
+}
+
+func TestWatchConcurrency(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	src := &slowRegistryClient{}
+	watcher := NewRepositoryWatcher(providers, src)
+	watcher.SetConcurrency(2)
+
+	tracked := []*types.TrackedImage{
+		mustParse("gcr.io/v2-namespace/app-a:latest", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/app-b:latest", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/broken:latest", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/app-c:latest", "@every 10m"),
+		mustParse("gcr.io/v2-namespace/app-d:latest", "@every 10m"),
+	}
+
+	err := watcher.Watch(tracked...)
+	if err == nil {
+		t.Errorf("expected error from broken image")
+	}
+
+	if src.maxInFlight != 2 {
+		t.Errorf("expected 2 concurrent registry checks, got: %d", src.maxInFlight)
+	}
+
+	if len(watcher.watched) != 4 {
+		t.Errorf("expected 4 watched images, got: %d", len(watcher.watched))
+	}
+}
+
+func TestBoundedJobOffset(t *testing.T) {
+	details := &watchDetails{schedule: "@every 10m"}
+
+	a := &boundedJob{key: "index.docker.io/karolisr/keel", details: details, jitter: DefaultJitter}
+	b := &boundedJob{key: "index.docker.io/karolisr/webhook-demo", details: details, jitter: DefaultJitter}
+
+	for _, job := range []*boundedJob{a, b} {
+		offset := job.offset()
+		if offset < 0 || offset >= 5*time.Minute {
+			t.Errorf("expected offset within first half of the interval, got: %s", offset)
+		}
+		if offset != job.offset() {
+			t.Errorf("expected offset to be stable")
+		}
+	}
+
+	if a.offset() == b.offset() {
+		t.Errorf("expected different jobs to be spread")
+	}
+
+	details.schedule = "@every 24h"
+	if offset := a.offset(); offset < 0 || offset >= maxJitterOffset {
+		t.Errorf("expected offset to be capped, got: %s", offset)
+	}
+
+	details.schedule = "invalid"
+	if a.offset() != 0 {
+		t.Errorf("expected no offset for invalid schedule")
+	}
+}
+
+func TestBoundedJobJitter(t *testing.T) {
+	details := &watchDetails{schedule: "@every 10m"}
+
+	job := &boundedJob{key: "index.docker.io/karolisr/keel", details: details, jitter: 0.1}
+	if offset := job.offset(); offset < 0 || offset >= time.Minute {
+		t.Errorf("expected offset within 10%% of the interval, got: %s", offset)
+	}
+
+	job.jitter = 0
+	if offset := job.offset(); offset != 0 {
+		t.Errorf("expected no offset with jitter disabled, got: %s", offset)
+	}
+}
+
+func TestSetJitter(t *testing.T) {
+	watcher := NewRepositoryWatcher(nil, &fakeRegistryClient{})
+	if watcher.jitter != DefaultJitter {
+		t.Errorf("unexpected default jitter: %f", watcher.jitter)
+	}
+
+	for in, want := range map[float64]float64{-1: 0, 0.25: 0.25, 2: 1} {
+		watcher.SetJitter(in)
+		if watcher.jitter != want {
+			t.Errorf("SetJitter(%f): expected %f, got: %f", in, want, watcher.jitter)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		want     string
+		wantErr  bool
+	}{
+		{"@every 5m", "@every 5m", false},
+		{"5m", "@every 5m0s", false},
+		{"0 */10 * * * *", "0 */10 * * * *", false},
+		{"invalid", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSchedule(tt.schedule)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSchedule(%s) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseSchedule(%s) = %s, want %s", tt.schedule, got, tt.want)
+		}
+	}
+}
+
+func TestWatchOnlyPolledTriggers(t *testing.T) {
+	newImage := func(name string, trigger types.TriggerType) *types.TrackedImage {
+		ref, _ := image.Parse(name)
+		return &types.TrackedImage{
+			Image:        ref,
+			Trigger:      trigger,
+			Provider:     "fp",
+			PollSchedule: types.KeelPollDefaultSchedule,
+			Policy:       policy.NewSemverPolicy(policy.SemverPolicyTypeMinor, true),
+		}
+	}
+	fp := &fakeProvider{
+		images: []*types.TrackedImage{
+			newImage("gcr.io/v2-namespace/poll:1.1.1", types.TriggerTypePoll),
+			newImage("gcr.io/v2-namespace/both:1.1.1", types.TriggerTypeBoth),
+			newImage("gcr.io/v2-namespace/push:1.1.1", types.TriggerTypePush),
+			newImage("gcr.io/v2-namespace/default:1.1.1", types.TriggerTypeDefault),
+		},
+	}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+	frc := &fakeRegistryClient{tagsToReturn: []string{"1.1.1"}}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+	if err := watcher.Watch(fp.images...); err != nil {
+		t.Fatalf("failed to watch images: %s", err)
+	}
+
+	for name, want := range map[string]bool{
+		"gcr.io/v2-namespace/poll":    true,
+		"gcr.io/v2-namespace/both":    true,
+		"gcr.io/v2-namespace/push":    false,
+		"gcr.io/v2-namespace/default": false,
+	} {
+		if _, ok := watcher.watched[name]; ok != want {
+			t.Errorf("%s: expected watched %v, got %v", name, want, ok)
+		}
+	}
+}
+
+func TestWatcherStatus(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	frc := &fakeRegistryClient{
+		digestToReturn: "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb",
+		tagsToReturn:   []string{"1.1.0", "1.2.0", "latest"},
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+
+	tagged := mustParse("gcr.io/v2-namespace/hello-world:1.1.0", "@every 10m")
+	tagged.Policy = policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+	floating := mustParse("gcr.io/v2-namespace/greetings:latest", "@every 10m")
+
+	before := time.Now()
+	if err := watcher.Watch(tagged, floating); err != nil {
+		t.Fatalf("failed to watch: %s", err)
+	}
+
+	status, ok := watcher.Status(tagged)
+	if !ok {
+		t.Fatalf("expected image to be watched")
+	}
+	if status.Tag != "1.2.0" || status.Digest != frc.digestToReturn || status.Error != nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if status.CheckedAt.Before(before) {
+		t.Errorf("unexpected check time: %s", status.CheckedAt)
+	}
+
+	// registry failing, last successful check is kept
+	frc.digestErrToReturn = errors.New("registry unavailable")
+	key := getImageIdentifier(floating.Image, false)
+	NewWatchTagJob(providers, frc, watcher.watched[key]).Run()
+
+	status, ok = watcher.Status(floating)
+	if !ok {
+		t.Fatalf("expected image to be watched")
+	}
+	if status.Tag != "latest" || status.Digest != frc.digestToReturn || status.CheckedAt.IsZero() {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if status.Error == nil {
+		t.Errorf("expected registry error")
+	}
+
+	if _, ok := watcher.Status(mustParse("gcr.io/v2-namespace/other:1.0.0", "@every 10m")); ok {
+		t.Errorf("didn't expect image that isn't watched to have status")
+	}
+}
+
+func TestWatchEmptyRepository(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	// repository was just created, nothing is pushed yet
+	frc := &fakeRegistryClient{
+		digestErrToReturn: fmt.Errorf("%w: v2-namespace/hello-world:1.1.0", registry.ErrTagNotFound),
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+
+	tracked := mustParse("gcr.io/v2-namespace/hello-world:1.1.0", "@every 10m")
+	tracked.Policy = policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+	fp.images = []*types.TrackedImage{tracked}
+
+	if err := watcher.Watch(tracked); err != nil {
+		t.Fatalf("expected empty repository to be watched, got: %s", err)
+	}
+
+	key := getImageIdentifier(tracked.Image, false)
+	details, ok := watcher.watched[key]
+	if !ok {
+		t.Fatalf("expected image to be watched")
+	}
+	if len(fp.submitted) != 0 {
+		t.Errorf("didn't expect any events, got: %v", fp.submitted)
+	}
+
+	// tags pushed, next run picks them up
+	frc.tagsToReturn = []string{"1.1.0", "1.2.0"}
+	NewWatchRepositoryTagsJob(providers, frc, details).Run()
+
+	if len(fp.submitted) != 1 || fp.submitted[0].Repository.Tag != "1.2.0" {
+		t.Errorf("expected event for 1.2.0, got: %v", fp.submitted)
+	}
+}
+
+func TestWatchRepositoryNotFound(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+
+	providers := provider.New([]provider.Provider{fp}, am)
+
+	notFound := fmt.Errorf("%w: v2-namespace/hello-world", registry.ErrRepositoryNotFound)
+	frc := &fakeRegistryClient{
+		digestErrToReturn: fmt.Errorf("%w: v2-namespace/hello-world:1.1.0", registry.ErrTagNotFound),
+		getErrToReturn:    notFound,
+	}
+
+	watcher := NewRepositoryWatcher(providers, frc)
+
+	tracked := mustParse("gcr.io/v2-namespace/hello-world:1.1.0", "@every 10m")
+	tracked.Policy = policy.NewSemverPolicy(policy.SemverPolicyTypeAll, true)
+
+	if err := watcher.Watch(tracked); err == nil {
+		t.Errorf("expected error when repository doesn't exist")
+	}
+	if _, ok := watcher.Status(tracked); ok {
+		t.Errorf("didn't expect missing repository to be watched")
+	}
+
+	// repository removed after the watch was added
+	details := &watchDetails{trackedImage: tracked}
+	NewWatchRepositoryTagsJob(providers, frc, details).Run()
+
+	if err := details.lastError(); !errors.Is(err, registry.ErrRepositoryNotFound) {
+		t.Errorf("expected ErrRepositoryNotFound, got: %v", err)
+	}
+	if len(fp.submitted) != 0 {
+		t.Errorf("didn't expect any events, got: %v", fp.submitted)
+	}
+}
@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/context"
@@ -35,6 +36,9 @@ type DefaultManager struct {
 
 	// root context
 	ctx context.Context
+
+	// running - set once the initial scan is done, cleared when Start returns
+	running int32
 }
 
 // Subscriber - subscribe is responsible to listen for repository events and
@@ -69,6 +73,9 @@ func (s *DefaultManager) Start(ctx context.Context) error {
 		}).Error("trigger.pubsub.manager: scan failed")
 	}
 
+	atomic.StoreInt32(&s.running, 1)
+	defer atomic.StoreInt32(&s.running, 0)
+
 	ticker := time.NewTicker(time.Duration(s.scanTick) * time.Second)
 	defer ticker.Stop()
 
@@ -146,3 +153,9 @@ func (s *DefaultManager) removeSubscription(gcrURI string) {
 	defer s.mu.Unlock()
 	delete(s.subscribers, gcrURI)
 }
+
+// Running - reports whether the manager has completed its initial scan and is
+// still running
+func (s *DefaultManager) Running() bool {
+	return atomic.LoadInt32(&s.running) == 1
+}
@@ -27,6 +27,10 @@ type PubsubSubscriber struct {
 	project    string
 	disableAck bool
 
+	// imageFilter - only images matching one of the patterns are processed,
+	// so multiple instances can share a topic
+	imageFilter []string
+
 	client *pubsub.Client
 }
 
@@ -40,6 +44,10 @@ type pubsubImplementer interface {
 type Opts struct {
 	ProjectID string
 	Providers provider.Providers
+
+	// ImageFilter - optional image prefixes or glob patterns, i.e.
+	// "gcr.io/my-project/prod-*", messages for other images are acked and ignored
+	ImageFilter []string
 }
 
 // WithKeepAliveDialer - required so connections aren't dropped
@@ -60,9 +68,10 @@ func NewPubsubSubscriber(opts *Opts) (*PubsubSubscriber, error) {
 	}
 
 	return &PubsubSubscriber{
-		project:   opts.ProjectID,
-		providers: opts.Providers,
-		client:    client,
+		project:     opts.ProjectID,
+		providers:   opts.Providers,
+		client:      client,
+		imageFilter: opts.ImageFilter,
 	}, nil
 }
 
@@ -178,6 +187,13 @@ func (s *PubsubSubscriber) callback(ctx context.Context, msg *pubsub.Message) {
 		return
 	}
 
+	if !matchesImageFilter(s.imageFilter, ref.Repository()) {
+		log.WithFields(log.Fields{
+			"image_name": ref.Name(),
+		}).Debug("trigger.pubsub: image doesn't match filter, ignoring message")
+		return
+	}
+
 	// sending event to the providers
 	log.WithFields(log.Fields{
 		"action":     decoded.Action,
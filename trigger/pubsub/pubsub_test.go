@@ -109,3 +109,26 @@ func TestCallbackNoTag(t *testing.T) {
 		t.Errorf("expected repo tag %s but got %s", "latest", fp.submitted[0].Repository.Tag)
 	}
 }
+
+func TestCallbackImageFilter(t *testing.T) {
+	fp := &fakeProvider{}
+	store, teardown := newTestingUtils()
+	defer teardown()
+	am := approvals.New(&approvals.Opts{
+		Store: store,
+	})
+	providers := provider.New([]provider.Provider{fp}, am)
+	sub := &PubsubSubscriber{disableAck: true, providers: providers, imageFilter: []string{"gcr.io/v2-namespace/prod-*"}}
+
+	for _, tag := range []string{"gcr.io/v2-namespace/staging-app:1.1.1", "gcr.io/v2-namespace/prod-app:1.1.1"} {
+		data, _ := json.Marshal(&Message{Action: "INSERT", Tag: tag})
+		sub.callback(context.Background(), &pubsub.Message{Data: data})
+	}
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected 1 event, got: %d", len(fp.submitted))
+	}
+	if fp.submitted[0].Repository.Name != "gcr.io/v2-namespace/prod-app" {
+		t.Errorf("unexpected repo name: %s", fp.submitted[0].Repository.Name)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strings"
+
+	"github.com/ryanuber/go-glob"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -65,3 +68,35 @@ func isGoogleArtifactRegistry(registry string) bool {
 	}
 	return matched
 }
+
+// ParseImageFilter - parses comma separated list of image filters
+func ParseImageFilter(filter string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(filter, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matchesImageFilter - checks repository against filters, patterns with "*" are
+// matched as globs, others as prefixes. Empty filter matches everything.
+func matchesImageFilter(filter []string, repository string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, pattern := range filter {
+		if strings.Contains(pattern, "*") {
+			if glob.Glob(pattern, repository) {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(repository, pattern) {
+			return true
+		}
+	}
+	return false
+}
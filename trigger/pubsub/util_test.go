@@ -95,3 +95,28 @@ func TestGetContainerRegistryURIWithClusterNameSet(t *testing.T) {
 		t.Errorf("unexpected topic name: %s", name)
 	}
 }
+
+func Test_matchesImageFilter(t *testing.T) {
+	filter := ParseImageFilter("gcr.io/project/prod-, europe-docker.pkg.dev/*/prod/*")
+
+	tests := []struct {
+		repository string
+		want       bool
+	}{
+		{repository: "gcr.io/project/prod-api", want: true},
+		{repository: "gcr.io/project/staging-api", want: false},
+		{repository: "europe-docker.pkg.dev/project/prod/api", want: true},
+		{repository: "europe-docker.pkg.dev/project/dev/api", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			if got := matchesImageFilter(filter, tt.repository); got != tt.want {
+				t.Errorf("matchesImageFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !matchesImageFilter(ParseImageFilter(""), "gcr.io/project/anything") {
+		t.Errorf("expected empty filter to match everything")
+	}
+}
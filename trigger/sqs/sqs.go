@@ -0,0 +1,251 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/keel-hq/keel/provider"
+	"github.com/keel-hq/keel/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TriggerName - name of the trigger, set on events
+const TriggerName = "ecr"
+
+// long polling settings, see
+// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-short-and-long-polling.html
+const (
+	waitTimeSeconds     = 20
+	maxNumberOfMessages = 10
+)
+
+// Opts - subscriber options
+type Opts struct {
+	QueueURL  string
+	Region    string
+	Providers provider.Providers
+}
+
+// sqsImplementer - subset of the SQS API used by the subscriber
+type sqsImplementer interface {
+	ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error)
+}
+
+// Subscriber - consumes ECR push events delivered to an SQS queue
+// by EventBridge and submits them to providers
+type Subscriber struct {
+	providers provider.Providers
+	queueURL  string
+
+	client sqsImplementer
+}
+
+// NewSubscriber - create new SQS subscriber. Credentials are resolved through
+// the default AWS credentials chain (env, shared config, instance role)
+func NewSubscriber(opts *Opts) (*Subscriber, error) {
+	if opts.QueueURL == "" {
+		return nil, fmt.Errorf("queue URL not specified")
+	}
+
+	cfg := aws.NewConfig()
+	if opts.Region != "" {
+		cfg = cfg.WithRegion(opts.Region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subscriber{
+		providers: opts.Providers,
+		queueURL:  opts.QueueURL,
+		client:    sqs.New(sess),
+	}, nil
+}
+
+// Start - starts receiving messages, blocks until context is cancelled
+func (s *Subscriber) Start(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"queue": s.queueURL,
+	}).Info("trigger.sqs: subscribing for events...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		out, err := s.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.queueURL),
+			MaxNumberOfMessages: aws.Int64(maxNumberOfMessages),
+			WaitTimeSeconds:     aws.Int64(waitTimeSeconds),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.WithFields(log.Fields{
+				"error": err,
+				"queue": s.queueURL,
+			}).Error("trigger.sqs: failed to receive messages")
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, msg := range out.Messages {
+			s.handle(ctx, msg)
+		}
+	}
+}
+
+// handle - processes single message, message is only deleted once the event
+// is dispatched so failed submissions are redelivered after the visibility timeout
+func (s *Subscriber) handle(ctx context.Context, msg *sqs.Message) {
+	event, ok, err := decodeMessage(aws.StringValue(msg.Body))
+	if err != nil {
+		// malformed messages would never succeed, removing them from the queue
+		log.WithFields(log.Fields{
+			"error":      err,
+			"message_id": aws.StringValue(msg.MessageId),
+		}).Error("trigger.sqs: failed to decode message")
+		s.delete(ctx, msg)
+		return
+	}
+
+	if ok {
+		log.WithFields(log.Fields{
+			"image": event.Repository.Name,
+			"tag":   event.Repository.Tag,
+		}).Debug("trigger.sqs: got message")
+
+		err = s.providers.Submit(*event)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":      err,
+				"message_id": aws.StringValue(msg.MessageId),
+				"image":      event.Repository.Name,
+			}).Error("trigger.sqs: failed to submit event, message will be retried")
+			return
+		}
+	}
+
+	s.delete(ctx, msg)
+}
+
+func (s *Subscriber) delete(ctx context.Context, msg *sqs.Message) {
+	_, err := s.client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(s.queueURL),
+		ReceiptHandle: msg.ReceiptHandle,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"message_id": aws.StringValue(msg.MessageId),
+		}).Error("trigger.sqs: failed to delete message")
+	}
+}
+
+// Message - EventBridge event envelope, handles both "ECR Image Action" events
+// and "AWS API Call via CloudTrail" PutImage events
+type Message struct {
+	DetailType string `json:"detail-type"`
+	Source     string `json:"source"`
+	Account    string `json:"account"`
+	Region     string `json:"region"`
+	Detail     struct {
+		// ECR Image Action
+		Result         string `json:"result"`
+		ActionType     string `json:"action-type"`
+		RepositoryName string `json:"repository-name"`
+		ImageTag       string `json:"image-tag"`
+		ImageDigest    string `json:"image-digest"`
+
+		// CloudTrail
+		EventName         string `json:"eventName"`
+		ErrorCode         string `json:"errorCode"`
+		RequestParameters struct {
+			RegistryID     string `json:"registryId"`
+			RepositoryName string `json:"repositoryName"`
+			ImageTag       string `json:"imageTag"`
+		} `json:"requestParameters"`
+		ResponseElements struct {
+			Image struct {
+				ImageID struct {
+					ImageDigest string `json:"imageDigest"`
+				} `json:"imageId"`
+			} `json:"image"`
+		} `json:"responseElements"`
+	} `json:"detail"`
+}
+
+// decodeMessage - decodes message body, returns false if the message
+// is not an ECR push event
+func decodeMessage(body string) (*types.Event, bool, error) {
+	var msg Message
+	err := json.Unmarshal([]byte(body), &msg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if msg.Source != "aws.ecr" {
+		return nil, false, nil
+	}
+
+	var (
+		registryID, repository, tag, digest string
+	)
+
+	switch {
+	case msg.Detail.ActionType == "PUSH" && msg.Detail.Result == "SUCCESS":
+		registryID = msg.Account
+		repository = msg.Detail.RepositoryName
+		tag = msg.Detail.ImageTag
+		digest = msg.Detail.ImageDigest
+	case msg.Detail.EventName == "PutImage" && msg.Detail.ErrorCode == "":
+		registryID = msg.Detail.RequestParameters.RegistryID
+		if registryID == "" {
+			registryID = msg.Account
+		}
+		repository = msg.Detail.RequestParameters.RepositoryName
+		tag = msg.Detail.RequestParameters.ImageTag
+		digest = msg.Detail.ResponseElements.Image.ImageID.ImageDigest
+	default:
+		return nil, false, nil
+	}
+
+	if repository == "" || tag == "" {
+		return nil, false, nil
+	}
+
+	if registryID == "" || msg.Region == "" {
+		return nil, false, fmt.Errorf("registry ID or region missing in event for repository %s", repository)
+	}
+
+	return &types.Event{
+		Repository: types.Repository{
+			Name:   fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s", registryID, msg.Region, repository),
+			Tag:    tag,
+			Digest: digest,
+		},
+		CreatedAt:   time.Now(),
+		TriggerName: TriggerName,
+	}, true, nil
+}
@@ -0,0 +1,224 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/keel-hq/keel/types"
+)
+
+var fakeImageActionEvent = `{
+  "version": "0",
+  "id": "13cde686-328b-6117-af20-0e5566167482",
+  "detail-type": "ECR Image Action",
+  "source": "aws.ecr",
+  "account": "123456789012",
+  "time": "2019-11-16T01:54:34Z",
+  "region": "us-west-2",
+  "resources": [],
+  "detail": {
+    "result": "SUCCESS",
+    "repository-name": "my-repository-name",
+    "image-digest": "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234",
+    "action-type": "PUSH",
+    "image-tag": "1.2.3"
+  }
+}`
+
+var fakeCloudTrailEvent = `{
+  "version": "0",
+  "id": "0c5c5f8c-2a5b-b3c6-a8ec-bd9b3b1c3d08",
+  "detail-type": "AWS API Call via CloudTrail",
+  "source": "aws.ecr",
+  "account": "123456789012",
+  "region": "eu-west-1",
+  "detail": {
+    "eventSource": "ecr.amazonaws.com",
+    "eventName": "PutImage",
+    "requestParameters": {
+      "registryId": "210987654321",
+      "repositoryName": "team/app",
+      "imageTag": "latest"
+    },
+    "responseElements": {
+      "image": {
+        "imageId": {
+          "imageDigest": "sha256:aaaa",
+          "imageTag": "latest"
+        }
+      }
+    }
+  }
+}`
+
+var fakeDeleteEvent = `{
+  "detail-type": "ECR Image Action",
+  "source": "aws.ecr",
+  "account": "123456789012",
+  "region": "us-west-2",
+  "detail": {
+    "result": "SUCCESS",
+    "repository-name": "my-repository-name",
+    "action-type": "DELETE",
+    "image-tag": "1.2.3"
+  }
+}`
+
+type fakeProviders struct {
+	submitted []types.Event
+	err       error
+}
+
+func (p *fakeProviders) Submit(event types.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.submitted = append(p.submitted, event)
+	return nil
+}
+
+func (p *fakeProviders) TrackedImages() ([]*types.TrackedImage, error) {
+	return nil, nil
+}
+
+func (p *fakeProviders) List() []string {
+	return []string{"fakeprovider"}
+}
+
+func (p *fakeProviders) Stop() {}
+
+type fakeClient struct {
+	deleted []string
+}
+
+func (c *fakeClient) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	return &sqs.ReceiveMessageOutput{}, nil
+}
+
+func (c *fakeClient) DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	c.deleted = append(c.deleted, aws.StringValue(input.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantOK     bool
+		wantErr    bool
+		wantName   string
+		wantTag    string
+		wantDigest string
+	}{
+		{
+			name:       "image action push",
+			body:       fakeImageActionEvent,
+			wantOK:     true,
+			wantName:   "123456789012.dkr.ecr.us-west-2.amazonaws.com/my-repository-name",
+			wantTag:    "1.2.3",
+			wantDigest: "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234",
+		},
+		{
+			name:       "cloudtrail put image",
+			body:       fakeCloudTrailEvent,
+			wantOK:     true,
+			wantName:   "210987654321.dkr.ecr.eu-west-1.amazonaws.com/team/app",
+			wantTag:    "latest",
+			wantDigest: "sha256:aaaa",
+		},
+		{
+			name:   "image action delete",
+			body:   fakeDeleteEvent,
+			wantOK: false,
+		},
+		{
+			name:    "malformed",
+			body:    `{"source": `,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, ok, err := decodeMessage(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("decodeMessage() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if event.Repository.Name != tt.wantName {
+				t.Errorf("expected name %s, got %s", tt.wantName, event.Repository.Name)
+			}
+			if event.Repository.Tag != tt.wantTag {
+				t.Errorf("expected tag %s, got %s", tt.wantTag, event.Repository.Tag)
+			}
+			if event.Repository.Digest != tt.wantDigest {
+				t.Errorf("expected digest %s, got %s", tt.wantDigest, event.Repository.Digest)
+			}
+			if event.TriggerName != TriggerName {
+				t.Errorf("expected trigger name %s, got %s", TriggerName, event.TriggerName)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	fp := &fakeProviders{}
+	fc := &fakeClient{}
+	sub := &Subscriber{providers: fp, client: fc, queueURL: "https://sqs.us-west-2.amazonaws.com/123456789012/keel"}
+
+	sub.handle(context.Background(), &sqs.Message{
+		Body:          aws.String(fakeImageActionEvent),
+		ReceiptHandle: aws.String("receipt-1"),
+	})
+
+	if len(fp.submitted) != 1 {
+		t.Fatalf("expected 1 submitted event, got: %d", len(fp.submitted))
+	}
+
+	if len(fc.deleted) != 1 || fc.deleted[0] != "receipt-1" {
+		t.Errorf("expected message to be deleted, got: %v", fc.deleted)
+	}
+}
+
+func TestHandleSubmitFailed(t *testing.T) {
+	fp := &fakeProviders{err: errors.New("boom")}
+	fc := &fakeClient{}
+	sub := &Subscriber{providers: fp, client: fc, queueURL: "https://sqs.us-west-2.amazonaws.com/123456789012/keel"}
+
+	sub.handle(context.Background(), &sqs.Message{
+		Body:          aws.String(fakeImageActionEvent),
+		ReceiptHandle: aws.String("receipt-1"),
+	})
+
+	if len(fc.deleted) != 0 {
+		t.Errorf("expected message to be kept for retry, got deleted: %v", fc.deleted)
+	}
+}
+
+func TestHandleIgnoredEvent(t *testing.T) {
+	fp := &fakeProviders{}
+	fc := &fakeClient{}
+	sub := &Subscriber{providers: fp, client: fc, queueURL: "https://sqs.us-west-2.amazonaws.com/123456789012/keel"}
+
+	sub.handle(context.Background(), &sqs.Message{
+		Body:          aws.String(fakeDeleteEvent),
+		ReceiptHandle: aws.String("receipt-2"),
+	})
+
+	if len(fp.submitted) != 0 {
+		t.Errorf("expected no submitted events, got: %d", len(fp.submitted))
+	}
+
+	if len(fc.deleted) != 1 {
+		t.Errorf("expected ignored message to be deleted, got: %v", fc.deleted)
+	}
+}
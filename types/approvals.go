@@ -67,6 +67,16 @@ type Approval struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// SameTarget - checks whether event is for the image build approval was
+// requested for. Re-triggers for the same target reuse the approval and its
+// votes, events or approvals without digest match any build.
+func (a *Approval) SameTarget(event *Event) bool {
+	if a.Digest == "" || event == nil || event.Repository.Digest == "" {
+		return true
+	}
+	return a.Digest == event.Repository.Digest
+}
+
 func (a *Approval) GetVoters() []string {
 	// meta := make(map[string]string)
 	var voters []string
@@ -18,6 +18,7 @@ var (
 		"NotificationSystemEvent":         NotificationSystemEvent,
 		"NotificationUpdateApproved":      NotificationUpdateApproved,
 		"NotificationUpdateRejected":      NotificationUpdateRejected,
+		"NotificationUpdateExpired":       NotificationUpdateExpired,
 	}
 
 	_NotificationValueToName = map[Notification]string{
@@ -30,6 +31,7 @@ var (
 		NotificationSystemEvent:         "NotificationSystemEvent",
 		NotificationUpdateApproved:      "NotificationUpdateApproved",
 		NotificationUpdateRejected:      "NotificationUpdateRejected",
+		NotificationUpdateExpired:       "NotificationUpdateExpired",
 	}
 )
 
@@ -46,6 +48,7 @@ func init() {
 			interface{}(NotificationSystemEvent).(fmt.Stringer).String():         NotificationSystemEvent,
 			interface{}(NotificationUpdateApproved).(fmt.Stringer).String():      NotificationUpdateApproved,
 			interface{}(NotificationUpdateRejected).(fmt.Stringer).String():      NotificationUpdateRejected,
+			interface{}(NotificationUpdateExpired).(fmt.Stringer).String():       NotificationUpdateExpired,
 		}
 	}
 }
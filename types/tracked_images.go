@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/keel-hq/keel/util/image"
 )
@@ -17,6 +18,7 @@ type TrackedImage struct {
 	Trigger      TriggerType       `json:"trigger"`
 	PollSchedule string            `json:"pollSchedule"`
 	Provider     string            `json:"provider"`
+	Cluster      string            `json:"cluster,omitempty"` // set when multiple clusters are managed
 	Namespace    string            `json:"namespace"`
 	Secrets      []string          `json:"secrets"`
 	Meta         map[string]string `json:"meta"` // metadata supplied by providers
@@ -25,6 +27,16 @@ type TrackedImage struct {
 	// combined semver tags
 	Tags   []string `json:"tags"`
 	Policy Policy   `json:"policy"`
+	// tag patterns that are never deployed, see KeelExcludeTagsAnnotation
+	ExcludeTags []string `json:"excludeTags,omitempty"`
+
+	// resource the image belongs to, set by providers so the
+	// tracked state can be inspected through the API
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Identifier  string    `json:"identifier"`
+	Paused      bool      `json:"paused"`
+	LastUpdated time.Time `json:"lastUpdated"` // zero if not updated since keel started
 }
 
 type Policy interface {
@@ -11,11 +11,15 @@ var (
 	_TriggerTypeNameToValue = map[string]TriggerType{
 		"TriggerTypeDefault": TriggerTypeDefault,
 		"TriggerTypePoll":    TriggerTypePoll,
+		"TriggerTypePush":    TriggerTypePush,
+		"TriggerTypeBoth":    TriggerTypeBoth,
 	}
 
 	_TriggerTypeValueToName = map[TriggerType]string{
 		TriggerTypeDefault: "TriggerTypeDefault",
 		TriggerTypePoll:    "TriggerTypePoll",
+		TriggerTypePush:    "TriggerTypePush",
+		TriggerTypeBoth:    "TriggerTypeBoth",
 	}
 )
 
@@ -25,6 +29,8 @@ func init() {
 		_TriggerTypeNameToValue = map[string]TriggerType{
 			interface{}(TriggerTypeDefault).(fmt.Stringer).String(): TriggerTypeDefault,
 			interface{}(TriggerTypePoll).(fmt.Stringer).String():    TriggerTypePoll,
+			interface{}(TriggerTypePush).(fmt.Stringer).String():    TriggerTypePush,
+			interface{}(TriggerTypeBoth).(fmt.Stringer).String():    TriggerTypeBoth,
 		}
 	}
 }
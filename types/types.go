@@ -21,6 +21,10 @@ const KeelDefaultPort = 9300
 // KeelPolicyLabel - keel update policies (version checking)
 const KeelPolicyLabel = "keel.sh/policy"
 
+// KeelContainerPolicyPrefix - per container update policy, container name is
+// appended to the prefix, for example keel.sh/policy.web=minor
+const KeelContainerPolicyPrefix = KeelPolicyLabel + "."
+
 const KeelImagePullSecretAnnotation = "keel.sh/imagePullSecret"
 
 // KeelTriggerLabel - trigger label is used to specify custom trigger types
@@ -35,10 +39,70 @@ const KeelForceTagMatchLabel = "keel.sh/matchTag"
 // KeelMatchPreReleaseAnnotation - label or annotation to set pre-release matching for SemVer, defaults to true for backward compatibility
 const KeelMatchPreReleaseAnnotation = "keel.sh/matchPreRelease"
 
-// KeelPollScheduleAnnotation - optional variable to setup custom schedule for polling, defaults to @every 10m
+// KeelTrackInitContainersAnnotation - label or annotation to also track and
+// update init container images, defaults to false
+const KeelTrackInitContainersAnnotation = "keel.sh/trackInitContainers"
+
+// KeelPausedAnnotation - set to "true" to temporarily stop updating the resource
+// while keeping its policy, managed by bot "pause" and "resume" commands
+const KeelPausedAnnotation = "keel.sh/paused"
+
+// KeelFreezeAnnotation - set to "true" to skip all updates of the resource, or
+// to a tag to only allow updates to that tag, i.e. during a release freeze
+const KeelFreezeAnnotation = "keel.sh/freeze"
+
+// KeelAnnotateOnlyAnnotation - set to "true" to record available updates in the
+// keel.sh/available annotation instead of changing images, i.e. for resources
+// managed by GitOps tools, "false" opts out when annotate-only is the default
+const KeelAnnotateOnlyAnnotation = "keel.sh/annotateOnly"
+
+// KeelAvailableAnnotation - newest version found for annotate-only resources
+const KeelAvailableAnnotation = "keel.sh/available"
+
+// KeelRollbackOnFailureAnnotation - set to "true" to roll deployment back to its
+// previous revision when rollout after an update doesn't become ready in time
+const KeelRollbackOnFailureAnnotation = "keel.sh/rollbackOnFailure"
+
+// KeelRolloutTimeoutAnnotation - how long to wait for the rollout to become
+// ready (Go duration such as "10m"), defaults to 5m
+const KeelRolloutTimeoutAnnotation = "keel.sh/rolloutTimeout"
+
+// KeelUpdateStrategyAnnotation - how images are updated, "rolling" (default)
+// only patches the image while "recreate" also restarts all pods
+const KeelUpdateStrategyAnnotation = "keel.sh/updateStrategy"
+
+// Update strategies set through KeelUpdateStrategyAnnotation
+const (
+	UpdateStrategyRolling  = "rolling"
+	UpdateStrategyRecreate = "recreate"
+)
+
+// KeelMatchModeAnnotation - how event images are matched against images of the
+// resource, "strict" (default) compares registry host and name while "loose"
+// only compares the name
+const KeelMatchModeAnnotation = "keel.sh/matchMode"
+
+// Match modes set through KeelMatchModeAnnotation
+const (
+	MatchModeStrict = "strict"
+	MatchModeLoose  = "loose"
+)
+
+// KeelExcludeTagsAnnotation - tags that are never deployed, comma separated
+// globs or "regexp:" prefixed expressions, i.e. "*-debug,*-dev", see
+// policies.GetExcludeTags for commas inside expressions
+const KeelExcludeTagsAnnotation = "keel.sh/excludeTags"
+
+// KubernetesRestartedAtAnnotation - pod template annotation set by
+// "kubectl rollout restart", changing it cycles all pods
+const KubernetesRestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// KeelPollScheduleAnnotation - optional variable to setup custom schedule for polling (cron
+// expression or Go duration such as "30m"), defaults to @every 1m
 const KeelPollScheduleAnnotation = "keel.sh/pollSchedule"
 
-// KeelPollDefaultSchedule - defaul polling schedule
+// KeelPollDefaultSchedule - default polling schedule, providers can be configured
+// with a different one on startup, see POLL_DEFAULT_SCHEDULE
 const KeelPollDefaultSchedule = "@every 1m"
 
 // KeelDigestAnnotation - digest annotation
@@ -48,13 +112,27 @@ const KeelDigestAnnotation = "keel.sh/digest"
 // default notification channel(-s) per deployment/chart
 const KeelNotificationChanAnnotation = "keel.sh/notify"
 
+// KeelNotificationChannelAnnotation - same as KeelNotificationChanAnnotation,
+// channels from both annotations are used when both are set
+const KeelNotificationChannelAnnotation = "keel.sh/notificationChannel"
+
+// KeelWebhookSecretAnnotation - optional secret native webhook requests
+// updating the resource have to be signed with, used instead of the global
+// webhook secret
+const KeelWebhookSecretAnnotation = "keel.sh/webhookSecret"
+
+// KeelWebhookSecretRefAnnotation - same as KeelWebhookSecretAnnotation but
+// references a key of a secret in the resource namespace, "<name>/<key>"
+const KeelWebhookSecretRefAnnotation = "keel.sh/webhookSecretRef"
+
 // KeelMinimumApprovalsLabel - min approvals
 const KeelMinimumApprovalsLabel = "keel.sh/approvals"
 
 // KeelUpdateTimeAnnotation - update time
 const KeelUpdateTimeAnnotation = "keel.sh/update-time"
 
-// KeelApprovalDeadlineLabel - approval deadline
+// KeelApprovalDeadlineLabel - approval deadline, either hours (i.e. "24") or
+// a duration (i.e. "30m")
 const KeelApprovalDeadlineLabel = "keel.sh/approvalDeadline"
 
 // KeelApprovalDeadlineDefault - default deadline in hours
@@ -70,6 +148,9 @@ type Repository struct {
 	Name   string `json:"name"`
 	Tag    string `json:"tag"`
 	Digest string `json:"digest"` // optional digest field
+	// Version - optional semver tag sharing the digest of a floating tag
+	// (i.e. latest), only set when version resolution is enabled
+	Version string `json:"version,omitempty"`
 }
 
 // String gives you [host/]team/repo[:tag] identifier
@@ -92,6 +173,17 @@ type Event struct {
 	CreatedAt  time.Time  `json:"createdAt,omitempty"`
 	// optional field to identify trigger
 	TriggerName string `json:"triggerName,omitempty"`
+	// Authorize - optional, set by webhooks that support resource scoped
+	// secrets, reports whether a resource with the secret (empty when it
+	// doesn't set one) accepts the event
+	Authorize func(secret string) bool `json:"-"`
+	// Signature - optional, webhook signature the event was sent with. Events
+	// signed differently are authorized for different resources, so they
+	// aren't deduplicated against each other
+	Signature string `json:"-"`
+	// Results - optional, set by triggers that wait for the outcome of the
+	// event, providers report what happened to each resource
+	Results *EventResults `json:"-"`
 }
 
 func (e *Event) Value() (driver.Value, error) {
@@ -152,6 +244,8 @@ const (
 	TriggerTypeDefault  TriggerType = iota // default policy is to wait for external triggers
 	TriggerTypePoll                        // poll policy sets up watchers for the affected repositories
 	TriggerTypeApproval                    // fulfilled approval requests trigger events
+	TriggerTypePush                        // push policy only accepts webhook (and other external) events
+	TriggerTypeBoth                        // both policy sets up watchers and accepts webhook events
 )
 
 func (t TriggerType) String() string {
@@ -162,6 +256,10 @@ func (t TriggerType) String() string {
 		return "poll"
 	case TriggerTypeApproval:
 		return "approval"
+	case TriggerTypePush:
+		return "push"
+	case TriggerTypeBoth:
+		return "both"
 	default:
 		return "default"
 	}
@@ -172,10 +270,30 @@ func ParseTrigger(trigger string) TriggerType {
 	switch trigger {
 	case "poll":
 		return TriggerTypePoll
+	case "push":
+		return TriggerTypePush
+	case "both":
+		return TriggerTypeBoth
 	}
 	return TriggerTypeDefault
 }
 
+// Polls - whether repositories should be polled for the trigger type
+func (t TriggerType) Polls() bool {
+	return t == TriggerTypePoll || t == TriggerTypeBoth
+}
+
+// Accepts - whether events from the named trigger should update resources
+// with this trigger type. Push only resources ignore poll events, all other
+// trigger types accept events from any source, poll resources have always
+// been updated by webhooks too.
+func (t TriggerType) Accepts(triggerName string) bool {
+	if triggerName == TriggerTypePoll.String() {
+		return t != TriggerTypePush
+	}
+	return true
+}
+
 // EventNotification notification used for sending
 type EventNotification struct {
 	Name         string       `json:"name"`
@@ -199,11 +317,19 @@ func ParseEventNotificationChannels(annotations map[string]string) []string {
 	if annotations == nil {
 		return channels
 	}
-	chanStr, ok := annotations[KeelNotificationChanAnnotation]
-	if ok {
-		chans := strings.Split(chanStr, ",")
-		for _, c := range chans {
-			channels = append(channels, strings.TrimSpace(c))
+	seen := map[string]bool{}
+	for _, key := range []string{KeelNotificationChanAnnotation, KeelNotificationChannelAnnotation} {
+		chanStr, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		for _, c := range strings.Split(chanStr, ",") {
+			c = strings.TrimSpace(c)
+			if c == "" || seen[c] {
+				continue
+			}
+			seen[c] = true
+			channels = append(channels, c)
 		}
 	}
 
@@ -218,6 +344,15 @@ func ParseReleaseNotesURL(annotations map[string]string) string {
 	return annotations[KeelReleaseNotesURL]
 }
 
+// Names of notifications sent for the update itself. Dry runs, annotate-only
+// resources, frozen and rolled back updates share the update notification
+// types under other names
+const (
+	EventNamePreUpdateResource = "preparing to update resource"
+	EventNameUpdateResource    = "update resource"
+	EventNameUpdateRelease     = "update release"
+)
+
 // Notification - notification types used by notifier
 type Notification int
 
@@ -238,6 +373,7 @@ const (
 
 	NotificationUpdateApproved
 	NotificationUpdateRejected
+	NotificationUpdateExpired
 )
 
 func (n Notification) String() string {
@@ -260,6 +396,8 @@ func (n Notification) String() string {
 		return "update approved"
 	case NotificationUpdateRejected:
 		return "update rejected "
+	case NotificationUpdateExpired:
+		return "update approval expired"
 	default:
 		return "unknown"
 	}
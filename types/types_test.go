@@ -108,6 +108,16 @@ func TestParseEventNotificationChannels(t *testing.T) {
 			args: args{map[string]string{KeelNotificationChanAnnotation: "verychan,corp"}},
 			want: []string{"verychan", "corp"},
 		},
+		{
+			name: "notification channel annotation",
+			args: args{map[string]string{KeelNotificationChannelAnnotation: "deploys"}},
+			want: []string{"deploys"},
+		},
+		{
+			name: "both annotations, duplicates and empty entries skipped",
+			args: args{map[string]string{KeelNotificationChanAnnotation: "verychan,", KeelNotificationChannelAnnotation: "deploys, verychan"}},
+			want: []string{"verychan", "deploys"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -157,3 +167,51 @@ func TestParseReleaseNotesURL(t *testing.T) {
 		})
 	}
 }
+
+func TestTriggerTypeAccepts(t *testing.T) {
+	tests := []struct {
+		trigger TriggerType
+		event   string
+		want    bool
+	}{
+		{TriggerTypeDefault, "poll", true},
+		{TriggerTypeDefault, "dockerhub", true},
+		{TriggerTypePoll, "poll", true},
+		{TriggerTypePoll, "dockerhub", true},
+		{TriggerTypePoll, "native", true},
+		{TriggerTypePoll, "approval", true},
+		{TriggerTypePush, "poll", false},
+		{TriggerTypePush, "native", true},
+		{TriggerTypePush, "approval", true},
+		{TriggerTypeBoth, "poll", true},
+		{TriggerTypeBoth, "native", true},
+	}
+	for _, tt := range tests {
+		if got := tt.trigger.Accepts(tt.event); got != tt.want {
+			t.Errorf("%s.Accepts(%q) = %v, want %v", tt.trigger, tt.event, got, tt.want)
+		}
+	}
+
+	for _, trigger := range []string{"poll", "push", "both"} {
+		if got := ParseTrigger(trigger).String(); got != trigger {
+			t.Errorf("ParseTrigger(%q) = %s", trigger, got)
+		}
+	}
+}
+
+func TestNilEventResults(t *testing.T) {
+	var results *EventResults
+	results.Add(1)
+	results.Report(ResourceUpdate{Status: UpdateStatusUpdated})
+	results.Done()
+
+	if !results.Wait(time.Millisecond) {
+		t.Errorf("expected nil results not to block")
+	}
+	if resources := results.Resources(); len(resources) != 0 {
+		t.Errorf("unexpected resources: %v", resources)
+	}
+	if status := results.Status(); status != UpdateStatusNoop {
+		t.Errorf("unexpected status: %s", status)
+	}
+}
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// Update statuses reported to triggers waiting for the outcome of an event
+const (
+	UpdateStatusUpdated  = "updated"
+	UpdateStatusNoop     = "noop"     // nothing to update, dry run or annotate only mode
+	UpdateStatusPending  = "pending"  // waiting for approvals
+	UpdateStatusRejected = "rejected" // approval rejected, event not authorized or version rolled back
+	UpdateStatusError    = "error"
+)
+
+// ResourceUpdate - outcome of an event for a single resource
+type ResourceUpdate struct {
+	Provider       string `json:"provider"`
+	Identifier     string `json:"identifier,omitempty"`
+	Kind           string `json:"kind,omitempty"`
+	Namespace      string `json:"namespace,omitempty"`
+	Name           string `json:"name,omitempty"`
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	NewVersion     string `json:"newVersion,omitempty"`
+	Status         string `json:"status"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// EventResults - collects outcome of an event, set on the event by triggers
+// that wait for it. Providers call Add for every piece of work they take on
+// and Done once it's finished, Report is called for every resource. All
+// methods are safe to call on nil results
+type EventResults struct {
+	wg sync.WaitGroup
+
+	mu        sync.Mutex
+	resources []ResourceUpdate
+}
+
+// NewEventResults - results to wait on, providers have to accept the event
+// before Wait is called
+func NewEventResults() *EventResults {
+	return &EventResults{}
+}
+
+// Add - registers work that has to finish before results are complete
+func (r *EventResults) Add(delta int) {
+	if r == nil {
+		return
+	}
+	r.wg.Add(delta)
+}
+
+// Done - marks registered work as finished
+func (r *EventResults) Done() {
+	if r == nil {
+		return
+	}
+	r.wg.Done()
+}
+
+// Report - records outcome for a resource
+func (r *EventResults) Report(update ResourceUpdate) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.resources = append(r.resources, update)
+	r.mu.Unlock()
+}
+
+// Wait - waits until providers finish processing the event, returns false
+// when timeout expires first. Nil results have nothing to wait for
+func (r *EventResults) Wait(timeout time.Duration) bool {
+	if r == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Resources - outcomes reported so far
+func (r *EventResults) Resources() []ResourceUpdate {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resources := make([]ResourceUpdate, len(r.resources))
+	copy(resources, r.resources)
+	return resources
+}
+
+// Status - overall outcome, the first of error, updated, pending and rejected
+// reported for any resource, noop otherwise
+func (r *EventResults) Status() string {
+	found := make(map[string]bool)
+	for _, resource := range r.Resources() {
+		found[resource.Status] = true
+	}
+	for _, status := range []string{UpdateStatusError, UpdateStatusUpdated, UpdateStatusPending, UpdateStatusRejected} {
+		if found[status] {
+			return status
+		}
+	}
+	return UpdateStatusNoop
+}
@@ -8,6 +8,7 @@ import (
 type Reference struct {
 	named  Named  `json:"named"`
 	tag    string `json:"tag"`
+	digest string // set when reference is pinned to a digest
 	scheme string `json:"scheme"` // registry scheme, i.e. http, https
 }
 
@@ -17,7 +18,16 @@ func (r Reference) String() string {
 
 // Name returns the image's name. (ie: debian[:8.2])
 func (r Reference) Name() string {
-	return r.named.RemoteName() + r.tag
+	return r.named.RemoteName() + r.suffix()
+}
+
+// suffix - tag and digest, digest is only appended separately when the
+// reference has both
+func (r Reference) suffix() string {
+	if r.digest != "" && strings.HasPrefix(r.tag, ":") {
+		return r.tag + "@" + r.digest
+	}
+	return r.tag
 }
 
 // ShortName returns the image's name (ie: debian)
@@ -25,6 +35,12 @@ func (r Reference) ShortName() string {
 	return r.named.RemoteName()
 }
 
+// Path returns the image's name without docker hub's library/ prefix (ie:
+// debian for index.docker.io/library/debian)
+func (r Reference) Path() string {
+	return strings.TrimPrefix(r.named.RemoteName(), DefaultRepoPrefix)
+}
+
 // Tag returns the image's tag (or digest).
 func (r Reference) Tag() string {
 	if len(r.tag) > 1 {
@@ -33,6 +49,11 @@ func (r Reference) Tag() string {
 	return ""
 }
 
+// Digest returns the digest image is pinned to, empty if it's only tagged.
+func (r Reference) Digest() string {
+	return r.digest
+}
+
 // Registry returns the image's registry. (ie: host[:port])
 func (r Reference) Registry() string {
 	return r.named.Hostname()
@@ -48,9 +69,18 @@ func (r Reference) Repository() string {
 	return r.named.FullName()
 }
 
-// Remote returns the image's remote identifier. (ie: registry/name[:tag])
+// Remote returns the image's remote identifier. (ie: registry/name[:tag][@digest])
 func (r Reference) Remote() string {
-	return r.named.FullName() + r.tag
+	return r.named.FullName() + r.suffix()
+}
+
+// SameRepository - checks whether both references point to the same
+// repository, registry host is only compared when strict is set
+func SameRepository(a, b *Reference, strict bool) bool {
+	if strict {
+		return a.Repository() == b.Repository()
+	}
+	return a.Path() == b.Path()
 }
 
 func clean(url string) (cleaned string, scheme string) {
@@ -73,6 +103,14 @@ func clean(url string) (cleaned string, scheme string) {
 }
 
 // Parse returns a Reference from analyzing the given remote identifier.
+// Registry host may include a port, repository may have any number of path
+// components and reference may be pinned to a digest, with or without a tag:
+//
+//	registry.internal:5000/team/app:1.2.3
+//	localhost:5000/team/sub/app@sha256:...
+//	registry.internal:5000/team/app:1.2.3@sha256:...
+//
+// Tag of references pinned only to a digest is the digest.
 func Parse(remote string) (*Reference, error) {
 
 	cleaned, scheme := clean(remote)
@@ -84,41 +122,28 @@ func Parse(remote string) (*Reference, error) {
 
 	n = WithDefaultTag(n)
 
-	var t string
-	switch x := n.(type) {
-	case Canonical:
-		t = "@" + x.Digest().String()
-	case NamedTagged:
-		t = ":" + x.Tag()
+	ref := &Reference{named: n, scheme: scheme}
+	if x, ok := n.(NamedTagged); ok {
+		ref.tag = ":" + x.Tag()
+	}
+	if x, ok := n.(Canonical); ok {
+		ref.digest = x.Digest().String()
+		if ref.tag == "" {
+			ref.tag = "@" + ref.digest
+		}
 	}
 
-	return &Reference{named: n, tag: t, scheme: scheme}, nil
+	return ref, nil
 }
 
 // ParseRepo - parses remote
 // pretty much the same as Parse but better for testing
 func ParseRepo(remote string) (*Repository, error) {
-
-	cleaned, scheme := clean(remote)
-
-	n, err := ParseNamed(cleaned)
-
+	ref, err := Parse(remote)
 	if err != nil {
 		return nil, err
 	}
 
-	n = WithDefaultTag(n)
-
-	var t string
-	switch x := n.(type) {
-	case Canonical:
-		t = "@" + x.Digest().String()
-	case NamedTagged:
-		t = ":" + x.Tag()
-	}
-
-	ref := &Reference{named: n, tag: t, scheme: scheme}
-
 	return &Repository{
 		Name:       ref.Name(),
 		Repository: ref.Repository(),
@@ -126,6 +151,7 @@ func ParseRepo(remote string) (*Repository, error) {
 		Remote:     ref.Remote(),
 		ShortName:  ref.ShortName(),
 		Tag:        ref.Tag(),
+		Digest:     ref.Digest(),
 		Scheme:     ref.scheme,
 	}, nil
 }
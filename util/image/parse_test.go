@@ -142,3 +142,175 @@ func TestParseRepo(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCustomRegistries(t *testing.T) {
+	const digest = "sha256:0604af35299dd37ff23937d115d103532948b568a9dd8197d14c256a8ab8b0bb"
+
+	tests := []struct {
+		remote  string
+		want    *Repository
+		wantErr bool
+	}{
+		{
+			remote: "registry.internal:5000/team/app:1.2.3",
+			want: &Repository{
+				Name:       "team/app:1.2.3",
+				Repository: "registry.internal:5000/team/app",
+				Remote:     "registry.internal:5000/team/app:1.2.3",
+				Registry:   "registry.internal:5000",
+				ShortName:  "team/app",
+				Tag:        "1.2.3",
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "http://registry.internal:5000/team/sub/app:1.2.3",
+			want: &Repository{
+				Name:       "team/sub/app:1.2.3",
+				Repository: "registry.internal:5000/team/sub/app",
+				Remote:     "registry.internal:5000/team/sub/app:1.2.3",
+				Registry:   "registry.internal:5000",
+				ShortName:  "team/sub/app",
+				Tag:        "1.2.3",
+				Scheme:     "http",
+			},
+		},
+		{
+			remote: "localhost/app:1.2.3",
+			want: &Repository{
+				Name:       "app:1.2.3",
+				Repository: "localhost/app",
+				Remote:     "localhost/app:1.2.3",
+				Registry:   "localhost",
+				ShortName:  "app",
+				Tag:        "1.2.3",
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "localhost:5000/team/app",
+			want: &Repository{
+				Name:       "team/app:latest",
+				Repository: "localhost:5000/team/app",
+				Remote:     "localhost:5000/team/app:latest",
+				Registry:   "localhost:5000",
+				ShortName:  "team/app",
+				Tag:        "latest",
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "registry:5000/app:1.0",
+			want: &Repository{
+				Name:       "app:1.0",
+				Repository: "registry:5000/app",
+				Remote:     "registry:5000/app:1.0",
+				Registry:   "registry:5000",
+				ShortName:  "app",
+				Tag:        "1.0",
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "10.0.0.1:5000/team/app:1.0",
+			want: &Repository{
+				Name:       "team/app:1.0",
+				Repository: "10.0.0.1:5000/team/app",
+				Remote:     "10.0.0.1:5000/team/app:1.0",
+				Registry:   "10.0.0.1:5000",
+				ShortName:  "team/app",
+				Tag:        "1.0",
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "registry.internal:5000/team/app@" + digest,
+			want: &Repository{
+				Name:       "team/app@" + digest,
+				Repository: "registry.internal:5000/team/app",
+				Remote:     "registry.internal:5000/team/app@" + digest,
+				Registry:   "registry.internal:5000",
+				ShortName:  "team/app",
+				Tag:        digest,
+				Digest:     digest,
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "registry.internal:5000/team/app:1.2.3@" + digest,
+			want: &Repository{
+				Name:       "team/app:1.2.3@" + digest,
+				Repository: "registry.internal:5000/team/app",
+				Remote:     "registry.internal:5000/team/app:1.2.3@" + digest,
+				Registry:   "registry.internal:5000",
+				ShortName:  "team/app",
+				Tag:        "1.2.3",
+				Digest:     digest,
+				Scheme:     "https",
+			},
+		},
+		{
+			remote: "team/app:1.2.3@" + digest,
+			want: &Repository{
+				Name:       "team/app:1.2.3@" + digest,
+				Repository: "index.docker.io/team/app",
+				Remote:     "index.docker.io/team/app:1.2.3@" + digest,
+				Registry:   DefaultRegistryHostname,
+				ShortName:  "team/app",
+				Tag:        "1.2.3",
+				Digest:     digest,
+				Scheme:     "https",
+			},
+		},
+		{
+			remote:  "registry.internal:5000/Team/app:1.2.3",
+			wantErr: true,
+		},
+		{
+			remote:  "registry.internal:5000/team/app@sha256:xxx",
+			wantErr: true,
+		},
+		{
+			remote:  "registry.internal:port/team/app:1.2.3",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			got, err := ParseRepo(tt.remote)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRepo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRepo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameRepository(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		strict      bool
+		wantMatches bool
+	}{
+		{"karolisr/keel:0.1.0", "index.docker.io/karolisr/keel:0.2.0", true, true},
+		{"karolisr/keel:0.1.0", "mirror.example.com/karolisr/keel:0.2.0", true, false},
+		{"karolisr/keel:0.1.0", "mirror.example.com/karolisr/keel:0.2.0", false, true},
+		{"nginx:1.0.0", "mirror.example.com/library/nginx:1.1.0", false, true},
+		{"karolisr/keel:0.1.0", "karolisr/keel-sidecar:0.1.0", false, false},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.a)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %s", tt.a, err)
+		}
+		b, err := Parse(tt.b)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %s", tt.b, err)
+		}
+		if got := SameRepository(a, b, tt.strict); got != tt.wantMatches {
+			t.Errorf("SameRepository(%s, %s, %t) = %t, want %t", tt.a, tt.b, tt.strict, got, tt.wantMatches)
+		}
+	}
+}
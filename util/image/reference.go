@@ -34,6 +34,7 @@ type Repository struct {
 	ShortName  string // ShortName returns the image's name (ie: debian)
 	Remote     string // Remote returns the image's remote identifier. (ie: registry/name[:tag])
 	Tag        string // Tag returns the image's tag (or digest).
+	Digest     string // Digest the image is pinned to, if any. (ie: sha256:...)
 }
 
 // Named is an object with a full name
@@ -63,6 +64,13 @@ type Canonical interface {
 	Digest() digest.Digest
 }
 
+// TaggedCanonical reference is pinned to a digest and also includes the tag,
+// i.e. "name:tag@digest"
+type TaggedCanonical interface {
+	NamedTagged
+	Digest() digest.Digest
+}
+
 // ParseNamed parses s and returns a syntactically valid reference implementing
 // the Named interface. The reference must have a name, otherwise an error is
 // returned.
@@ -78,11 +86,15 @@ func ParseNamed(s string) (Named, error) {
 	if err != nil {
 		return nil, err
 	}
-	if canonical, isCanonical := named.(reference.Canonical); isCanonical {
+	canonical, isCanonical := named.(reference.Canonical)
+	tagged, isTagged := named.(reference.NamedTagged)
+	switch {
+	case isCanonical && isTagged:
+		// "name:tag@digest", tag is kept so it can still be tracked
+		return WithTagAndDigest(r, tagged.Tag(), canonical.Digest())
+	case isCanonical:
 		return WithDigest(r, canonical.Digest())
-	}
-
-	if tagged, isTagged := named.(reference.NamedTagged); isTagged {
+	case isTagged:
 		return WithTag(r, tagged.Tag())
 	}
 	return r, nil
@@ -125,6 +137,20 @@ func WithDigest(name Named, digest digest.Digest) (Canonical, error) {
 	return &canonicalRef{namedRef{r}}, nil
 }
 
+// WithTagAndDigest combines the name, tag and digest to form a reference
+// pinned to the digest that still carries the tag
+func WithTagAndDigest(name Named, tag string, digest digest.Digest) (TaggedCanonical, error) {
+	tagged, err := reference.WithTag(name, tag)
+	if err != nil {
+		return nil, err
+	}
+	r, err := reference.WithDigest(tagged, digest)
+	if err != nil {
+		return nil, err
+	}
+	return &taggedCanonicalRef{namedRef{r}}, nil
+}
+
 type namedRef struct {
 	reference.Named
 }
@@ -134,6 +160,9 @@ type taggedRef struct {
 type canonicalRef struct {
 	namedRef
 }
+type taggedCanonicalRef struct {
+	namedRef
+}
 
 func (r *namedRef) FullName() string {
 	hostname, remoteName := splitHostname(r.Name())
@@ -153,6 +182,12 @@ func (r *taggedRef) Tag() string {
 func (r *canonicalRef) Digest() digest.Digest {
 	return r.namedRef.Named.(reference.Canonical).Digest()
 }
+func (r *taggedCanonicalRef) Tag() string {
+	return r.namedRef.Named.(reference.NamedTagged).Tag()
+}
+func (r *taggedCanonicalRef) Digest() digest.Digest {
+	return r.namedRef.Named.(reference.Canonical).Digest()
+}
 
 // WithDefaultTag adds a default tag to a reference if it only has a repo name.
 func WithDefaultTag(ref Named) Named {
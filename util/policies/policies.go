@@ -1,7 +1,14 @@
 package policies
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/keel-hq/keel/types"
+	"github.com/ryanuber/go-glob"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // GetTriggerPolicy - checks for trigger label, if not set - returns
@@ -21,3 +28,177 @@ func GetTriggerPolicy(labels map[string]string, annotations map[string]string) t
 
 	return types.TriggerTypeDefault
 }
+
+// ShouldTrackInitContainers - checks whether init container images should be
+// tracked and updated alongside regular containers
+func ShouldTrackInitContainers(labels map[string]string, annotations map[string]string) bool {
+	track, ok := annotations[types.KeelTrackInitContainersAnnotation]
+	if ok {
+		return track == "true"
+	}
+
+	return labels[types.KeelTrackInitContainersAnnotation] == "true"
+}
+
+// IsPaused - checks whether updates of the resource are paused
+func IsPaused(annotations map[string]string) bool {
+	return annotations[types.KeelPausedAnnotation] == "true"
+}
+
+// ShouldAnnotateOnly - checks whether available updates should only be recorded
+// in annotations, def is used when the resource doesn't set it
+func ShouldAnnotateOnly(annotations map[string]string, def bool) bool {
+	value, ok := annotations[types.KeelAnnotateOnlyAnnotation]
+	if !ok {
+		return def
+	}
+	return value == "true"
+}
+
+// GetFreeze - checks freeze annotation, frozen resources are not updated at all
+// unless the annotation pins them to a tag, updates to that tag are still allowed
+func GetFreeze(annotations map[string]string) (frozen bool, tag string) {
+	value := strings.TrimSpace(annotations[types.KeelFreezeAnnotation])
+	switch strings.ToLower(value) {
+	case "", "false":
+		return false, ""
+	case "true":
+		return true, ""
+	}
+	return true, value
+}
+
+// ShouldRollbackOnFailure - checks whether deployment should be rolled back
+// when rollout after an update fails
+func ShouldRollbackOnFailure(annotations map[string]string) bool {
+	return annotations[types.KeelRollbackOnFailureAnnotation] == "true"
+}
+
+// GetUpdateStrategy - checks update strategy annotation, unknown strategies
+// fall back to rolling updates
+func GetUpdateStrategy(annotations map[string]string) string {
+	strategy, ok := annotations[types.KeelUpdateStrategyAnnotation]
+	if !ok {
+		return types.UpdateStrategyRolling
+	}
+
+	switch strategy {
+	case types.UpdateStrategyRolling, types.UpdateStrategyRecreate:
+		return strategy
+	}
+
+	log.WithFields(log.Fields{
+		"strategy": strategy,
+	}).Warn("policies: unknown update strategy, using rolling")
+	return types.UpdateStrategyRolling
+}
+
+// ParseMatchMode - validates image match mode, see types.KeelMatchModeAnnotation
+func ParseMatchMode(value string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case types.MatchModeStrict, types.MatchModeLoose:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown match mode '%s', expected %s or %s", value, types.MatchModeStrict, types.MatchModeLoose)
+}
+
+// GetMatchMode - checks match mode annotation, def is used when the resource
+// doesn't set it or sets an unknown mode
+func GetMatchMode(annotations map[string]string, def string) string {
+	value, ok := annotations[types.KeelMatchModeAnnotation]
+	if !ok {
+		return def
+	}
+
+	mode, err := ParseMatchMode(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warnf("policies: invalid match mode, using %s", def)
+		return def
+	}
+	return mode
+}
+
+// GetExcludeTags - tag patterns from the exclude tags annotation, comma
+// separated globs (i.e. "*-debug, *-dev") or regular expressions prefixed
+// with "regexp:". Commas inside braces, brackets or parentheses of an
+// expression don't separate patterns so "regexp:^\d{1,3}$" stays whole, any
+// other comma in an expression has to be written as \x2c. Invalid
+// expressions are dropped
+func GetExcludeTags(annotations map[string]string) []string {
+	var patterns []string
+	for _, pattern := range splitExcludeTags(annotations[types.KeelExcludeTagsAnnotation]) {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if expr := strings.TrimPrefix(pattern, "regexp:"); expr != pattern {
+			if _, err := regexp.Compile(expr); err != nil {
+				log.WithFields(log.Fields{
+					"error":   err,
+					"pattern": pattern,
+				}).Warn("policies: invalid exclude tags expression, ignoring")
+				continue
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// splitExcludeTags - splits annotation value on commas, "regexp:" patterns
+// are joined with the following parts while their braces, brackets or
+// parentheses are unbalanced
+func splitExcludeTags(value string) []string {
+	var patterns []string
+	for _, part := range strings.Split(value, ",") {
+		if n := len(patterns); n > 0 && unbalanced(patterns[n-1]) {
+			patterns[n-1] += "," + part
+			continue
+		}
+		patterns = append(patterns, part)
+	}
+	return patterns
+}
+
+// unbalanced - checks whether regexp pattern has an unclosed brace, bracket
+// or parenthesis, escaped ones and ones inside character classes are ignored
+func unbalanced(pattern string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if !strings.HasPrefix(pattern, "regexp:") {
+		return false
+	}
+	depth, class := 0, false
+	for i := len("regexp:"); i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case class:
+			class = c != ']'
+		case c == '[':
+			class = true
+		case c == '{' || c == '(':
+			depth++
+		case c == '}' || c == ')':
+			depth--
+		}
+	}
+	return class || depth > 0
+}
+
+// IsTagExcluded - checks tag against patterns returned by GetExcludeTags
+func IsTagExcluded(patterns []string, tag string) bool {
+	for _, pattern := range patterns {
+		if expr := strings.TrimPrefix(pattern, "regexp:"); expr != pattern {
+			if re, err := regexp.Compile(expr); err == nil && re.MatchString(tag) {
+				return true
+			}
+			continue
+		}
+		if glob.Glob(pattern, tag) {
+			return true
+		}
+	}
+	return false
+}
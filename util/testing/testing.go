@@ -18,6 +18,7 @@ type FakeK8sImplementer struct {
 	NamespacesList   *v1.NamespaceList
 	DeploymentSingle *apps_v1.Deployment
 	DeploymentList   *apps_v1.DeploymentList
+	ReplicaSetList   *apps_v1.ReplicaSetList
 
 	// stores value of an updated deployment
 	Updated *k8s.GenericResource
@@ -46,6 +47,11 @@ func (i *FakeK8sImplementer) Deployments(namespace string) (*apps_v1.DeploymentL
 	return i.DeploymentList, nil
 }
 
+// ReplicaSets - available replicasets
+func (i *FakeK8sImplementer) ReplicaSets(namespace, labelSelector string) (*apps_v1.ReplicaSetList, error) {
+	return i.ReplicaSetList, nil
+}
+
 // Update - update deployment
 func (i *FakeK8sImplementer) Update(obj *k8s.GenericResource) error {
 	i.Updated = obj
@@ -54,6 +54,26 @@ func GetVersion(version string) (*types.Version, error) {
 	}, nil
 }
 
+// NumericPreReleaseChannel - channel of pre-releases starting with a purely
+// numeric identifier, i.e. "1" or "20240101". No named channel can be equal
+// to it
+const NumericPreReleaseChannel = "0"
+
+// PreReleaseChannel - returns the channel of a pre-release, which is its first
+// dot separated identifier, i.e. "rc" for "rc.2" and "canary" for "canary.5".
+// Purely numeric identifiers share NumericPreReleaseChannel, stable releases
+// have no channel
+func PreReleaseChannel(preRelease string) string {
+	if preRelease == "" {
+		return ""
+	}
+	channel := strings.SplitN(preRelease, ".", 2)[0]
+	if strings.Trim(channel, "0123456789") == "" {
+		return NumericPreReleaseChannel
+	}
+	return channel
+}
+
 // GetVersionFromImageName - get version from image name
 func GetVersionFromImageName(name string) (*types.Version, error) {
 	parts := strings.Split(name, ":")
@@ -104,7 +124,7 @@ func NewAvailable(current string, tags []string, matchPreRelease bool) (newVersi
 
 		}
 
-		if matchPreRelease && currentVersion.Prerelease() != v.Prerelease() {
+		if matchPreRelease && PreReleaseChannel(currentVersion.Prerelease()) != PreReleaseChannel(v.Prerelease()) {
 			continue
 		}
 
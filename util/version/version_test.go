@@ -159,3 +159,22 @@ func TestLowest(t *testing.T) {
 		})
 	}
 }
+
+func TestPreReleaseChannel(t *testing.T) {
+	tests := map[string]string{
+		"":         "",
+		"rc.1":     "rc",
+		"rc.2":     "rc",
+		"canary":   "canary",
+		"beta.1.2": "beta",
+		"dev1":     "dev1",
+		"1":        NumericPreReleaseChannel,
+		"20240101": NumericPreReleaseChannel,
+		"1.rc":     NumericPreReleaseChannel,
+	}
+	for preRelease, want := range tests {
+		if got := PreReleaseChannel(preRelease); got != want {
+			t.Errorf("PreReleaseChannel(%q) = %q, want %q", preRelease, got, want)
+		}
+	}
+}